@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/malonaz/sgpt/internal/configuration"
 	"github.com/malonaz/sgpt/internal/debug"
 	"github.com/malonaz/sgpt/internal/file"
+	"github.com/malonaz/sgpt/internal/gitcontext"
 	gograph "github.com/malonaz/sgpt/internal/graph"
 	goignore "github.com/malonaz/sgpt/internal/ignore"
 	"github.com/malonaz/sgpt/internal/lore"
@@ -29,11 +31,11 @@ import (
 	"github.com/malonaz/sgpt/internal/store"
 	"github.com/malonaz/sgpt/internal/tool"
 	"github.com/malonaz/sgpt/internal/tool/agent"
-	"github.com/malonaz/sgpt/internal/tool/diff"
-	toolio "github.com/malonaz/sgpt/internal/tool/io"
+	"github.com/malonaz/sgpt/internal/tool/builtins"
 	"github.com/malonaz/sgpt/internal/tool/lores"
+	toolmcp "github.com/malonaz/sgpt/internal/tool/mcp"
+	"github.com/malonaz/sgpt/internal/tool/plugin"
 	"github.com/malonaz/sgpt/internal/tool/rpc"
-	"github.com/malonaz/sgpt/internal/tool/shell"
 )
 
 func NewCmd(
@@ -74,10 +76,14 @@ func NewCmd(
 		Continue      bool
 		Tools         []string
 		Debug         bool
+		AutoLores     int
 	}
 
 	loreIndex := lore.NewIndex(repoRoot, imports)
-	searchLoresTool := &lores.Tool{Index: loreIndex}
+	// One ranker per process: its index is shared by ranked searches and
+	// automatic retrieval, across every tab.
+	loreRanker := lore.NewRanker(loreIndex)
+	searchLoresTool := &lores.Tool{Index: loreIndex, Ranker: loreRanker}
 
 	cmd := &cobra.Command{
 		Use: "chat",
@@ -106,10 +112,23 @@ func NewCmd(
 					return fmt.Errorf("starting debug server: %w", err)
 				}
 			}
+			var retrieveLores func(string, []*aipb.Message) ([]string, error)
+			if opts.AutoLores > 0 {
+				retrieveLores = lores.Retriever(loreIndex, loreRanker, opts.AutoLores)
+			}
 
 			// Discoverable tool engines warrant the discovery-protocol
 			// section of the system prompt.
 			opts.Role.ToolDiscovery = forestErr == nil && len(forest.ToolSets()) > 0
+			// Required role parameters not passed via --role-arg are asked
+			// for; any other role error is reported by Parse below.
+			if missingParameters, err := opts.Role.MissingParameters(); err == nil && len(missingParameters) > 0 {
+				values, err := tui.PromptRoleParameters(ctx, opts.Role.RoleName, missingParameters)
+				cobra.CheckErr(err)
+				for name, value := range values {
+					opts.Role.SetArg(name, value)
+				}
+			}
 			parsedRole, err := opts.Role.Parse()
 			// A failed graph discovery empties the role registry, making the
 			// resulting "unknown role" misleading — surface the real cause.
@@ -127,11 +146,32 @@ func NewCmd(
 			}
 			selectedModel, err := chatStore.ResolveModel(ctx, opts.Model)
 			cobra.CheckErr(err)
+			// A role's fallback chain replaces the configured one.
+			fallbackModelNames := config.Chat.GetFallbackModels()
+			if len(parsedRole.GetFallbackModels()) > 0 {
+				fallbackModelNames = parsedRole.GetFallbackModels()
+			}
+			fallbackModels := make([]*aipb.Model, 0, len(fallbackModelNames))
+			for _, fallbackModelName := range fallbackModelNames {
+				fallbackModel, err := chatStore.ResolveModel(ctx, fallbackModelName)
+				cobra.CheckErr(err)
+				fallbackModels = append(fallbackModels, fallbackModel)
+			}
 
 			opts.FileInjection.Files = append(opts.FileInjection.Files, args...)
 			var filePaths []string
 			files, err := file.Parse(opts.FileInjection)
 			cobra.CheckErr(err)
+			watchFiles, err := file.Parse(&file.InjectionOpts{
+				Files:          opts.FileInjection.WatchFiles,
+				FileExtensions: opts.FileInjection.FileExtensions,
+			})
+			cobra.CheckErr(err)
+			watchFilePaths := make([]string, 0, len(watchFiles))
+			for _, watchFile := range watchFiles {
+				watchFilePaths = append(watchFilePaths, watchFile.Path)
+			}
+			watchFilePaths = file.Normalize(watchFilePaths)
 			// Role files are curated in config: --ext is a convenience for
 			// ad-hoc directory injection and must never drop them.
 			roleFiles, err := file.Parse(&file.InjectionOpts{Files: parsedRole.GetFiles()})
@@ -147,11 +187,33 @@ func NewCmd(
 			}
 			loreFiles, err := file.Parse(&file.InjectionOpts{Files: defaultLorePaths})
 			cobra.CheckErr(err)
+			// Over the context budget, --fit trims the --file files; role
+			// files, lores and watched files are curated and always kept.
+			var injectedFileContents map[string]string
+			if contextLimit := int(selectedModel.GetTtt().GetContextTokenLimit()); contextLimit > 0 {
+				curatedFiles := append(append(append([]*file.File(nil), roleFiles...), loreFiles...), watchFiles...)
+				files, err = fitInjectedFiles(files, curatedFiles, contextLimit, opts.FileInjection.FitStrategies)
+				cobra.CheckErr(err)
+				for _, parsedFile := range files {
+					if parsedFile.Outlined {
+						if injectedFileContents == nil {
+							injectedFileContents = map[string]string{}
+						}
+						outlinePath := file.Normalize([]string{parsedFile.Path})[0]
+						injectedFileContents[outlinePath] = fmt.Sprintf("file %s (outline): `%s`", outlinePath, parsedFile.Content)
+					}
+				}
+			}
 			realFilePaths := make([]string, 0, len(files)+len(roleFiles)+len(loreFiles))
 			for _, parsedFile := range append(append(files, roleFiles...), loreFiles...) {
 				realFilePaths = append(realFilePaths, parsedFile.Path)
 			}
 			filePaths = append(filePaths, file.Normalize(realFilePaths)...)
+			for _, watchFilePath := range watchFilePaths {
+				if !slices.Contains(filePaths, watchFilePath) {
+					filePaths = append(filePaths, watchFilePath)
+				}
+			}
 
 			// Tag the chat with the GitHub repos its files belong to.
 			var tags []string
@@ -164,22 +226,21 @@ func NewCmd(
 			for githubRepo := range githubRepoSet {
 				tags = append(tags, githubRepo)
 			}
-
-			agentTool := &agent.Tool{}
+			// Git sources inject under their canonical selector, a virtual
+			// path the session loads (and reloads) through git.
+			for _, selector := range opts.FileInjection.GitSources {
+				source, err := gitcontext.Parse(selector)
+				cobra.CheckErr(err)
+				filePaths = append(filePaths, source.String())
+			}
 
 			// The registry always carries the FULL tool surface — every
 			// builtin and every configured tool engine. Which subset is
 			// advertised to the model is a per-session selection (seeded
 			// from --tool/role, toggleable mid-chat via the tool picker).
 			registry := tool.NewRegistry()
-			registry.Register(tool.HandlerIDShell, &shell.Tool{})
-			registry.Register(tool.HandlerIDReadFiles, &toolio.ReadFilesTool{})
-			registry.Register(tool.HandlerIDDiff, &diff.Tool{})
-			registry.Register(tool.HandlerIDReplace, &toolio.ReplaceTool{})
-			// Same instance everywhere: sub-agents can spawn sub-agents.
-			registry.Register(tool.HandlerIDAgent, agentTool)
-			// search_lores searches every reachable library.
-			registry.Register(tool.HandlerIDSearchLores, searchLoresTool)
+			registry.SetDefaultExecutionPolicy(config.Chat.GetToolExecutionPolicy())
+			agentTool := builtins.Register(registry, searchLoresTool)
 
 			availableToolNames := tool.BuiltinNames()
 			for _, name := range tool.BuiltinNames() {
@@ -194,10 +255,27 @@ func NewCmd(
 			for _, toolSetConfiguration := range toolSetConfigurations {
 				availableToolNames = append(availableToolNames, toolSetConfiguration.GetName())
 			}
+			// MCP servers, likewise, are only started when first enabled;
+			// subprocess servers are terminated with the chat.
+			mcpServers := forest.McpServers()
+			mcpManager := toolmcp.NewManager(mcpServers)
+			defer mcpManager.Close()
+			registry.Register(tool.HandlerIDMCP, mcpManager)
+			for _, mcpServer := range mcpServers {
+				availableToolNames = append(availableToolNames, mcpServer.GetName())
+			}
+			plugins := forest.Plugins()
+			pluginManager := plugin.NewManager(plugins)
+			defer pluginManager.Close()
+			registry.Register(tool.HandlerIDPlugin, pluginManager)
+			for _, pluginConfiguration := range plugins {
+				availableToolNames = append(availableToolNames, pluginConfiguration.GetName())
+			}
 
 			// resolveTool maps a user-facing name to advertised tool/tool-set
-			// names, lazily initializing an engine and registering its tool
-			// sets on first use. Cached, so repeat toggles are free.
+			// names, lazily initializing an engine (MCP server, plugin) and
+			// registering its tools on first use. Cached, so repeat toggles
+			// are free.
 			var resolveMu sync.Mutex
 			resolvedToolNames := map[string][]string{}
 			resolveTool := func(ctx context.Context, name string) ([]string, error) {
@@ -210,6 +288,32 @@ func NewCmd(
 					resolvedToolNames[name] = []string{name}
 					return resolvedToolNames[name], nil
 				}
+				if mcpManager.Has(name) {
+					mcpTools, err := mcpManager.EnsureServer(ctx, name)
+					if err != nil {
+						return nil, err
+					}
+					registry.AddTools(mcpTools...)
+					mcpToolNames := make([]string, 0, len(mcpTools))
+					for _, mcpTool := range mcpTools {
+						mcpToolNames = append(mcpToolNames, mcpTool.GetName())
+					}
+					resolvedToolNames[name] = mcpToolNames
+					return mcpToolNames, nil
+				}
+				if pluginManager.Has(name) {
+					pluginTools, err := pluginManager.EnsurePlugin(ctx, name)
+					if err != nil {
+						return nil, err
+					}
+					registry.AddTools(pluginTools...)
+					pluginToolNames := make([]string, 0, len(pluginTools))
+					for _, pluginTool := range pluginTools {
+						pluginToolNames = append(pluginToolNames, pluginTool.GetName())
+					}
+					resolvedToolNames[name] = pluginToolNames
+					return pluginToolNames, nil
+				}
 				toolSets, err := toolEngineManager.EnsureEngine(ctx, name)
 				if err != nil {
 					return nil, err
@@ -258,6 +362,7 @@ func NewCmd(
 				store.SetTags(chat, tags)
 				store.SetFiles(chat, filePaths)
 				store.SetCurrentModel(chat, selectedModel.Name)
+				store.SetRole(chat, parsedRole.GetName())
 			}
 
 			// Messages are server-side resources: load the history to seed
@@ -269,22 +374,34 @@ func NewCmd(
 			}
 
 			params := session.Params{
-				Model:              selectedModel,
-				Role:               parsedRole,
-				MaxTokens:          opts.MaxTokens,
-				Temperature:        opts.Temperature,
-				Chat:               opts.Chat,
-				SystemPrompt:       parsedRole.Prompt,
-				InjectedFiles:      filePaths,
-				Tools:              toolNames,
-				AvailableToolNames: availableToolNames,
-				ResolveTool:        resolveTool,
-				LoreNameForPath:    loreIndex.NameForPath,
+				Model:                selectedModel,
+				Role:                 parsedRole,
+				MaxTokens:            opts.MaxTokens,
+				Temperature:          opts.Temperature,
+				Chat:                 opts.Chat,
+				SystemPrompt:         parsedRole.Prompt,
+				InjectedFiles:        filePaths,
+				InjectedFileContents: injectedFileContents,
+				LiveFiles:            watchFilePaths,
+				Tools:                toolNames,
+				AvailableToolNames:   availableToolNames,
+				ResolveTool:          resolveTool,
+				LoreNameForPath:      loreIndex.NameForPath,
+				RetrieveLores:        retrieveLores,
+				Budget:               config.Chat.GetBudget(),
+				FallbackModels:       fallbackModels,
+				GenerationRetry:      config.Chat.GetGenerationRetry(),
 			}
 
 			chatSession := session.New(ctx, chatStore, registry, chat, messages, params)
 			app := tui.NewApp(ctx, chatStore, registry, chatSession, params)
-			app.SetAgentSessionFactory(func(ctx context.Context, request *agent.LaunchRequest) (*session.Session, []string, error) {
+			app.SetLoreIndex(loreIndex)
+			app.SetAgentSessionFactory(func(ctx context.Context, parent *session.Session, request *agent.LaunchRequest) (*session.Session, []string, error) {
+				// A launching chat closed mid-launch still bills the tree of
+				// the chat the CLI started.
+				if parent == nil {
+					parent = chatSession
+				}
 				model := selectedModel
 				if request.Model != "" {
 					var err error
@@ -311,7 +428,8 @@ func NewCmd(
 				store.SetTags(subChat, []string{"agent"})
 				store.SetFiles(subChat, subFilePaths)
 				store.SetCurrentModel(subChat, model.Name)
-				store.SetParentChatID(subChat, chatSession.Chat().GetName())
+				store.SetRole(subChat, parsedRole.GetName())
+				store.SetParentChatID(subChat, request.ParentChat)
 				subParams := session.Params{
 					Model:              model,
 					Role:               parsedRole,
@@ -324,8 +442,15 @@ func NewCmd(
 					SystemPrompt:       parsedRole.Prompt,
 					InjectedFiles:      subFilePaths,
 					LoreNameForPath:    loreIndex.NameForPath,
+					RetrieveLores:      retrieveLores,
+					Budget:             config.Chat.GetBudget(),
+					FallbackModels:     fallbackModels,
+					GenerationRetry:    config.Chat.GetGenerationRetry(),
 				}
-				return session.New(ctx, chatStore, registry, subChat, nil, subParams), subFilePaths, nil
+				subSession := session.New(ctx, chatStore, registry, subChat, nil, subParams)
+				// Sub-agent spend counts toward the launching chat's tree cap.
+				subSession.SetParent(parent)
+				return subSession, subFilePaths, nil
 			})
 			agentTool.SetLauncher(app)
 			program := tea.NewProgram(app, tea.WithContext(ctx))
@@ -344,8 +469,9 @@ func NewCmd(
 	cmd.Flags().Float64Var(&opts.Temperature, "temperature", 0, "Temperature (0.0-2.0)")
 	cmd.Flags().StringVar(&opts.Chat, "name", "", "Chat to resume")
 	cmd.Flags().BoolVarP(&opts.Continue, "continue", "c", false, "Continue previous chat")
-	cmd.Flags().StringSliceVar(&opts.Tools, "tool", nil, "Enable a specific tool engine by name (repeatable)")
+	cmd.Flags().StringSliceVar(&opts.Tools, "tool", nil, "Enable a specific tool engine, MCP server or plugin by name (repeatable)")
 	cmd.Flags().BoolVar(&opts.Debug, "debug", false, "Start a local debug log server")
+	cmd.Flags().IntVar(&opts.AutoLores, "auto-lores", int(config.Chat.GetAutoLores()), "Inject the N lores most relevant to each turn's message (0 disables)")
 
 	cmd.RegisterFlagCompletionFunc("model", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		models, _ := chatStore.ListModels(cmd.Context(), false)
@@ -353,16 +479,27 @@ func NewCmd(
 	})
 
 	cmd.RegisterFlagCompletionFunc("tool", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		// Built-in tools complete alongside discovered tool engines;
-		// imports are only offered once the user types "@".
+		// Built-in tools complete alongside discovered tool engines, MCP
+		// servers and plugins; imports are only offered once the user types
+		// "@".
 		candidates := tool.BuiltinNames()
 		toolSetConfigurations := forest.PrimaryToolSets()
+		mcpServers := forest.PrimaryMcpServers()
+		plugins := forest.PrimaryPlugins()
 		if strings.HasPrefix(toComplete, "@") {
 			toolSetConfigurations = forest.ToolSets()
+			mcpServers = forest.McpServers()
+			plugins = forest.Plugins()
 		}
 		for _, toolSetConfiguration := range toolSetConfigurations {
 			candidates = append(candidates, toolSetConfiguration.GetName())
 		}
+		for _, mcpServer := range mcpServers {
+			candidates = append(candidates, mcpServer.GetName())
+		}
+		for _, pluginConfiguration := range plugins {
+			candidates = append(candidates, pluginConfiguration.GetName())
+		}
 		var names []string
 		for _, name := range candidates {
 			if toComplete == "" || strings.Contains(strings.ToLower(name), strings.ToLower(toComplete)) {
@@ -393,6 +530,45 @@ func NewCmd(
 	return cmd
 }
 
+// fitInjectedFiles checks the injected files against the context budget,
+// applying the fit strategies to the files when they overflow it. Overflowing
+// the budget only warns; overflowing the whole window fails, as the first
+// turn would.
+func fitInjectedFiles(files, curatedFiles []*file.File, contextLimit int, strategies []string) ([]*file.File, error) {
+	budget := int(float64(contextLimit) * file.ContextBudgetRatio)
+	allFiles := append(append([]*file.File(nil), files...), curatedFiles...)
+	if file.TotalTokens(allFiles) <= budget {
+		return files, nil
+	}
+	if len(strategies) == 0 {
+		report := file.BudgetReport(allFiles, budget, contextLimit)
+		hint := fmt.Sprintf("use --fit to trim them (strategies: %s)", strings.Join(file.FitStrategies, ", "))
+		if file.TotalTokens(allFiles) > contextLimit {
+			return nil, fmt.Errorf("injected files overflow the context window:\n%s%s", report, hint)
+		}
+		fmt.Fprintf(os.Stderr, "warning: injected files overflow the context budget:\n%s%s\n", report, hint)
+		return files, nil
+	}
+
+	keptFiles, droppedFiles, err := file.Fit(files, max(budget-file.TotalTokens(curatedFiles), 0), strategies)
+	if err != nil {
+		return nil, err
+	}
+	var outlinedFiles int
+	for _, keptFile := range keptFiles {
+		if keptFile.Outlined {
+			outlinedFiles++
+		}
+	}
+	allFiles = append(append([]*file.File(nil), keptFiles...), curatedFiles...)
+	fmt.Fprintf(os.Stderr, "fitted injected files to the context budget: %d dropped, %d outlined, ~%s tokens left\n",
+		len(droppedFiles), outlinedFiles, file.FormatTokens(file.TotalTokens(allFiles)))
+	if file.TotalTokens(allFiles) > contextLimit {
+		return nil, fmt.Errorf("injected files still overflow the context window:\n%s", file.BudgetReport(allFiles, budget, contextLimit))
+	}
+	return keptFiles, nil
+}
+
 func filterModels(models []*aipb.Model, prefix string) []string {
 	var names []string
 	for _, model := range models {
@@ -0,0 +1,392 @@
+package chats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	"github.com/malonaz/sgpt/cli/export"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	chatexport "github.com/malonaz/sgpt/internal/export"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// NewCmd manages chats outside the TUI: listing, reading, and batch edits.
+// Every subcommand takes chats by ID or full resource name.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	chatStore := store.New(config, aiClient)
+
+	cmd := &cobra.Command{
+		Use:   "chats",
+		Short: "List, show, rename, tag, favorite, archive and delete chats",
+	}
+	cmd.AddCommand(
+		newListCmd(chatStore),
+		newShowCmd(chatStore),
+		newRenameCmd(chatStore),
+		newTagCmd(chatStore, true),
+		newTagCmd(chatStore, false),
+		newLabelCmd(chatStore, "favorite", "Mark chats as favorites", store.SetFavoriteLabel),
+		newLabelCmd(chatStore, "archive", "Archive chats, hiding them from `sgpt chats list` and the menu", store.SetArchivedLabel),
+		newDeleteCmd(chatStore),
+	)
+	return cmd
+}
+
+func addFormatFlag(cmd *cobra.Command, format *string) {
+	cmd.Flags().StringVar(format, "format", formatTable, "Output format: table or json")
+}
+
+func validateFormat(format string) error {
+	if format != formatTable && format != formatJSON {
+		return fmt.Errorf("invalid --format %q: want table or json", format)
+	}
+	return nil
+}
+
+func newListCmd(chatStore *store.Store) *cobra.Command {
+	var (
+		opts   listOptions
+		limit  int
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List chats, most recent first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(format); err != nil {
+				return err
+			}
+			filter, err := opts.filter()
+			if err != nil {
+				return err
+			}
+			var chats []*aipb.Chat
+			pageToken := ""
+			for limit <= 0 || len(chats) < limit {
+				page, nextPageToken, err := chatStore.ListChats(cmd.Context(), 100, pageToken, filter)
+				if err != nil {
+					return err
+				}
+				for _, chat := range page {
+					if opts.matches(chat) {
+						chats = append(chats, chat)
+					}
+				}
+				if nextPageToken == "" {
+					break
+				}
+				pageToken = nextPageToken
+			}
+			if limit > 0 && len(chats) > limit {
+				chats = chats[:limit]
+			}
+			return writeChats(cmd.OutOrStdout(), format, chats)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.favorite, "favorite", false, "Only favorite chats")
+	cmd.Flags().BoolVar(&opts.archived, "archived", false, "Only archived chats (hidden otherwise)")
+	cmd.Flags().StringVar(&opts.parent, "parent", "", "Only sub-agent chats launched by this chat")
+	cmd.Flags().StringVar(&opts.tag, "tag", "", "Only chats with this tag")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Only chats whose current model contains this")
+	cmd.Flags().StringVar(&opts.since, "since", "", "Only chats created on or after this day (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Only chats created on or before this day (YYYY-MM-DD)")
+	cmd.Flags().IntVarP(&limit, "limit", "n", 50, "Maximum number of chats (0 for all)")
+	addFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newShowCmd(chatStore *store.Store) *cobra.Command {
+	var (
+		format         string
+		includeContext bool
+		includeSystem  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "show [chat]",
+		Short: "Print a chat's transcript (default: the latest chat)",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			var chat *aipb.Chat
+			var err error
+			if len(args) == 1 {
+				chat, err = chatStore.GetChat(ctx, chatStore.ChatName(args[0]))
+			} else {
+				chat, err = chatStore.LatestChat(ctx)
+			}
+			if err != nil {
+				return err
+			}
+			messages, err := chatStore.ListMessages(ctx, chat.GetName())
+			if err != nil {
+				return err
+			}
+			opts := &chatexport.Options{
+				IncludeContext: includeContext,
+				IncludeSystem:  includeSystem,
+				Renderer:       export.NewRendererRegistry(),
+			}
+			content, err := chatexport.Render(format, chat, messages, opts)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(content)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", chatexport.FormatMarkdown, "Transcript format: md, html or json")
+	cmd.Flags().BoolVar(&includeContext, "include-context", false, "Include injected context such as file contents")
+	cmd.Flags().BoolVar(&includeSystem, "include-system", false, "Include the system prompt")
+	return cmd
+}
+
+func newRenameCmd(chatStore *store.Store) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "rename <chat> <title>",
+		Short: "Set a chat's title",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(format); err != nil {
+				return err
+			}
+			title := strings.TrimSpace(strings.Join(args[1:], " "))
+			if title == "" {
+				return fmt.Errorf("title is empty")
+			}
+			chat, err := updateChat(cmd.Context(), chatStore, args[0], func(chat *aipb.Chat) string {
+				chat.Title = title
+				return "title"
+			})
+			if err != nil {
+				return err
+			}
+			return writeChats(cmd.OutOrStdout(), format, []*aipb.Chat{chat})
+		},
+	}
+	addFormatFlag(cmd, &format)
+	return cmd
+}
+
+// newTagCmd adds (tag) or removes (untag) tags on a chat.
+func newTagCmd(chatStore *store.Store, add bool) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "tag <chat> <tag>...",
+		Short: "Add tags to a chat",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(format); err != nil {
+				return err
+			}
+			for _, tag := range args[1:] {
+				// Tags are stored comma-joined.
+				if tag == "" || strings.Contains(tag, ",") {
+					return fmt.Errorf("invalid tag %q: must be non-empty and without commas", tag)
+				}
+			}
+			chat, err := updateChat(cmd.Context(), chatStore, args[0], func(chat *aipb.Chat) string {
+				tags := store.Tags(chat)
+				for _, tag := range args[1:] {
+					if add && !slices.Contains(tags, tag) {
+						tags = append(tags, tag)
+					}
+					if !add {
+						tags = slices.DeleteFunc(tags, func(existing string) bool { return existing == tag })
+					}
+				}
+				store.SetTags(chat, tags)
+				return "annotations"
+			})
+			if err != nil {
+				return err
+			}
+			return writeChats(cmd.OutOrStdout(), format, []*aipb.Chat{chat})
+		},
+	}
+	if !add {
+		cmd.Use = "untag <chat> <tag>..."
+		cmd.Short = "Remove tags from a chat"
+	}
+	addFormatFlag(cmd, &format)
+	return cmd
+}
+
+// newLabelCmd sets, or with --unset clears, a boolean label on chats.
+func newLabelCmd(chatStore *store.Store, name, short string, setLabel func(chat *aipb.Chat, value bool)) *cobra.Command {
+	var (
+		unset  bool
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   name + " <chat>...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(format); err != nil {
+				return err
+			}
+			chats := make([]*aipb.Chat, 0, len(args))
+			for _, reference := range args {
+				chat, err := updateChat(cmd.Context(), chatStore, reference, func(chat *aipb.Chat) string {
+					setLabel(chat, !unset)
+					return "labels"
+				})
+				if err != nil {
+					return err
+				}
+				chats = append(chats, chat)
+			}
+			return writeChats(cmd.OutOrStdout(), format, chats)
+		},
+	}
+	cmd.Flags().BoolVar(&unset, "unset", false, "Clear the label instead")
+	addFormatFlag(cmd, &format)
+	return cmd
+}
+
+func newDeleteCmd(chatStore *store.Store) *cobra.Command {
+	var (
+		olderThan        string
+		includeFavorites bool
+		dryRun           bool
+		yes              bool
+		format           string
+	)
+	cmd := &cobra.Command{
+		Use:   "delete [chat]...",
+		Short: "Delete chats, or with --older-than every chat idle for that long",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateFormat(format); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			var chatNames []string
+			switch {
+			case olderThan != "" && len(args) > 0:
+				return fmt.Errorf("pass either chats or --older-than, not both")
+			case olderThan != "":
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return err
+				}
+				if chatNames, err = idleChatNames(ctx, chatStore, time.Now().Add(-age), includeFavorites); err != nil {
+					return err
+				}
+				// A bulk delete is irreversible and its extent unseen: confirm it.
+				if !dryRun && !yes && len(chatNames) > 0 {
+					confirmed, err := confirm(cmd.InOrStdin(), cmd.ErrOrStderr(), fmt.Sprintf("Delete %d chats not updated for %s?", len(chatNames), olderThan))
+					if err != nil {
+						return err
+					}
+					if !confirmed {
+						return fmt.Errorf("aborted: no chats deleted")
+					}
+				}
+			case len(args) > 0:
+				for _, reference := range args {
+					chatNames = append(chatNames, chatStore.ChatName(reference))
+				}
+			default:
+				return fmt.Errorf("pass chats to delete, or --older-than")
+			}
+
+			deletedChatNames := []string{}
+			for _, chatName := range chatNames {
+				if !dryRun {
+					if err := chatStore.DeleteChat(ctx, chatName); err != nil {
+						return fmt.Errorf("%s: %w", chatName, err)
+					}
+				}
+				deletedChatNames = append(deletedChatNames, chatName)
+				if format == formatTable {
+					suffix := ""
+					if dryRun {
+						suffix = " (dry-run)"
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "deleted %s%s\n", chatName, suffix)
+				}
+			}
+			if format == formatJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(deletedChatNames)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "Delete every chat not updated for this long (e.g. 90d, 2w, 12h)")
+	cmd.Flags().BoolVar(&includeFavorites, "include-favorites", false, "With --older-than, delete favorite chats too")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the chats that would be deleted without deleting them")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "With --older-than, delete without asking for confirmation")
+	addFormatFlag(cmd, &format)
+	return cmd
+}
+
+// confirm asks a yes/no question on out and reads the answer from in. Only
+// an explicit yes confirms: no answer, as from a closed stdin, declines.
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("reading confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// idleChatNames lists the chats last updated before cutoff. The whole list is
+// collected before anything is deleted: deleting while paging would shift the
+// pages under the page token.
+func idleChatNames(ctx context.Context, chatStore *store.Store, cutoff time.Time, includeFavorites bool) ([]string, error) {
+	filter := store.TimeRangeFilter("update_time", time.Time{}, cutoff)
+	var chatNames []string
+	pageToken := ""
+	for {
+		chats, nextPageToken, err := chatStore.ListChats(ctx, 100, pageToken, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, chat := range chats {
+			if !includeFavorites && store.IsFavorite(chat) {
+				continue
+			}
+			chatNames = append(chatNames, chat.GetName())
+		}
+		if nextPageToken == "" {
+			return chatNames, nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
+// updateChat fetches a chat, applies mutate and persists the field it
+// returns.
+func updateChat(ctx context.Context, chatStore *store.Store, reference string, mutate func(chat *aipb.Chat) string) (*aipb.Chat, error) {
+	chat, err := chatStore.GetChat(ctx, chatStore.ChatName(reference))
+	if err != nil {
+		return nil, err
+	}
+	path := mutate(chat)
+	return chatStore.UpdateChat(ctx, chat, path)
+}
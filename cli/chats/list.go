@@ -0,0 +1,152 @@
+package chats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+const dayLayout = "2006-01-02"
+
+// listOptions selects chats. Labels and timestamps are filtered server-side;
+// tags and the model live in annotations (tags comma-joined), which AIP-160
+// cannot match exactly, so those are filtered client-side.
+type listOptions struct {
+	favorite bool
+	archived bool
+	parent   string
+	tag      string
+	model    string
+	since    string
+	until    string
+}
+
+// filter returns the server-side AIP-160 filter.
+func (o *listOptions) filter() (string, error) {
+	var start, end time.Time
+	if o.since != "" {
+		day, err := time.ParseInLocation(dayLayout, o.since, time.Local)
+		if err != nil {
+			return "", fmt.Errorf("invalid --since %q: want YYYY-MM-DD", o.since)
+		}
+		start = day
+	}
+	if o.until != "" {
+		day, err := time.ParseInLocation(dayLayout, o.until, time.Local)
+		if err != nil {
+			return "", fmt.Errorf("invalid --until %q: want YYYY-MM-DD", o.until)
+		}
+		// --until is inclusive: the range ends at the next midnight.
+		end = day.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return "", fmt.Errorf("--until %s is before --since %s", o.until, o.since)
+	}
+
+	filters := []string{store.TimeRangeFilter("create_time", start, end)}
+	if o.favorite {
+		filters = append(filters, store.FavoriteFilter)
+	}
+	if o.archived {
+		filters = append(filters, store.ArchivedFilter)
+	}
+	if o.parent != "" {
+		// The label holds the parent's ID segment, not its resource name.
+		filters = append(filters, store.ParentChatFilter(o.parent[strings.LastIndex(o.parent, "/")+1:]))
+	}
+	return store.AndFilters(filters...), nil
+}
+
+// matches applies the client-side filters. Archived chats are hidden unless
+// asked for.
+func (o *listOptions) matches(chat *aipb.Chat) bool {
+	if !o.archived && store.IsArchived(chat) {
+		return false
+	}
+	if o.tag != "" && !slices.Contains(store.Tags(chat), o.tag) {
+		return false
+	}
+	if o.model != "" && !strings.Contains(store.CurrentModel(chat), o.model) {
+		return false
+	}
+	return true
+}
+
+// parseAge parses a duration, also accepting whole days ("30d") and weeks
+// ("2w"), which time.ParseDuration does not.
+func parseAge(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age %q: want e.g. 30d, 2w or 12h", value)
+	}
+	return age, nil
+}
+
+// writeChats prints chats as a table or a JSON array of chat resources.
+func writeChats(w io.Writer, format string, chats []*aipb.Chat) error {
+	if format == formatJSON {
+		rawChats := make([]json.RawMessage, 0, len(chats))
+		for _, chat := range chats {
+			rawChat, err := pbutil.JSONMarshal(chat)
+			if err != nil {
+				return fmt.Errorf("marshaling %s: %w", chat.GetName(), err)
+			}
+			rawChats = append(rawChats, rawChat)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rawChats)
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tTITLE\tMODEL\tTAGS\tPRICE\tUPDATED\tFLAGS")
+	for _, chat := range chats {
+		var flags []string
+		if store.IsFavorite(chat) {
+			flags = append(flags, "favorite")
+		}
+		if store.IsArchived(chat) {
+			flags = append(flags, "archived")
+		}
+		if parentChatID := store.ParentChatID(chat); parentChatID != "" {
+			flags = append(flags, "agent of "+parentChatID)
+		}
+		model := store.CurrentModel(chat)
+		model = model[strings.LastIndex(model, "/")+1:]
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t$%.4f\t%s\t%s\n",
+			chatID(chat), titleOrPlaceholder(chat), model, strings.Join(store.Tags(chat), ","),
+			chat.GetPrice(), chat.GetUpdateTime().AsTime().Local().Format("2006-01-02 15:04"), strings.Join(flags, ","))
+	}
+	return writer.Flush()
+}
+
+// chatID is the last segment of a chat's name: what every subcommand accepts.
+func chatID(chat *aipb.Chat) string {
+	return chat.GetName()[strings.LastIndex(chat.GetName(), "/")+1:]
+}
+
+func titleOrPlaceholder(chat *aipb.Chat) string {
+	if chat.GetTitle() == "" {
+		return "(untitled)"
+	}
+	return chat.GetTitle()
+}
@@ -0,0 +1,80 @@
+package chats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+func TestParseAge(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	} {
+		got, err := parseAge(value)
+		if err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "d", "-3d", "soon"} {
+		if _, err := parseAge(value); err == nil {
+			t.Errorf("parseAge(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestListOptionsFilter(t *testing.T) {
+	opts := &listOptions{favorite: true, parent: "organizations/o/users/u/chats/abc", since: "2025-03-01"}
+	filter, err := opts.filter()
+	if err != nil {
+		t.Fatalf("filter: %v", err)
+	}
+	for _, want := range []string{store.FavoriteFilter, store.ParentChatFilter("abc"), "create_time >= "} {
+		if !strings.Contains(filter, want) {
+			t.Errorf("filter = %s, want it to contain %s", filter, want)
+		}
+	}
+
+	opts = &listOptions{since: "2025-03-02", until: "2025-03-01"}
+	if _, err := opts.filter(); err == nil {
+		t.Error("filter accepted --until before --since")
+	}
+}
+
+func TestListOptionsMatches(t *testing.T) {
+	chat := &aipb.Chat{}
+	store.SetTags(chat, []string{"go", "owner/repo"})
+	store.SetCurrentModel(chat, "providers/anthropic/models/claude-sonnet")
+
+	if !(&listOptions{tag: "owner/repo", model: "sonnet"}).matches(chat) {
+		t.Error("chat should match its tag and model")
+	}
+	if (&listOptions{tag: "rust"}).matches(chat) {
+		t.Error("chat should not match a tag it lacks")
+	}
+	store.SetArchivedLabel(chat, true)
+	if (&listOptions{}).matches(chat) {
+		t.Error("archived chats should be hidden by default")
+	}
+	if !(&listOptions{archived: true}).matches(chat) {
+		t.Error("archived chats should match --archived")
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	for answer, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		var out strings.Builder
+		got, err := confirm(strings.NewReader(answer), &out, "Delete 3 chats?")
+		if err != nil || got != want {
+			t.Errorf("confirm(%q) = %t, %v; want %t", answer, got, err, want)
+		}
+		if out.String() != "Delete 3 chats? [y/N] " {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
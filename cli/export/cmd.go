@@ -0,0 +1,93 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	chatexport "github.com/malonaz/sgpt/internal/export"
+	"github.com/malonaz/sgpt/internal/store"
+	"github.com/malonaz/sgpt/internal/tool"
+	"github.com/malonaz/sgpt/internal/tool/builtins"
+	"github.com/malonaz/sgpt/internal/tool/lores"
+)
+
+// NewCmd exports a chat to markdown, standalone HTML or a JSON bundle.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	chatStore := store.New(config, aiClient)
+
+	var (
+		format         string
+		output         string
+		includeContext bool
+		includeSystem  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "export [chat]",
+		Short: "Export a chat (default: the latest) to markdown, HTML or a JSON bundle",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			// An output path's extension picks the format unless given.
+			if !cmd.Flags().Changed("format") && output != "" {
+				if extension := strings.TrimPrefix(filepath.Ext(output), "."); extension != "" {
+					format = extension
+				}
+			}
+
+			var chat *aipb.Chat
+			var err error
+			if len(args) == 1 {
+				chat, err = chatStore.GetChat(ctx, chatStore.ChatName(args[0]))
+			} else {
+				chat, err = chatStore.LatestChat(ctx)
+			}
+			if err != nil {
+				return err
+			}
+			messages, err := chatStore.ListMessages(ctx, chat.GetName())
+			if err != nil {
+				return err
+			}
+
+			opts := &chatexport.Options{
+				IncludeContext: includeContext,
+				IncludeSystem:  includeSystem,
+				Renderer:       NewRendererRegistry(),
+			}
+			content, err := chatexport.Render(format, chat, messages, opts)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				_, err := cmd.OutOrStdout().Write(content)
+				return err
+			}
+			if err := os.WriteFile(output, content, 0644); err != nil {
+				return fmt.Errorf("writing export: %w", err)
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Exported %s to %s\n", chat.GetName(), output)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", chatexport.FormatMarkdown, "Export format: md, html or json (default: from --output's extension, else md)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write (default: stdout)")
+	cmd.Flags().BoolVar(&includeContext, "include-context", false, "Include injected context such as file contents (md/html)")
+	cmd.Flags().BoolVar(&includeSystem, "include-system", false, "Include the system prompt (md/html)")
+	return cmd
+}
+
+// NewRendererRegistry registers the builtin tools for their renderers only:
+// nothing is executed, so no engine is dialed and engine tools render as
+// raw JSON.
+func NewRendererRegistry() *tool.Registry {
+	registry := tool.NewRegistry()
+	builtins.Register(registry, &lores.Tool{})
+	return registry
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/export"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// Import formats.
+const (
+	formatAuto   = "auto"
+	formatBundle = "bundle"
+	formatOpenAI = "openai"
+)
+
+// NewCmd recreates a chat from an sgpt JSON bundle (`sgpt export -f json`)
+// or an OpenAI-style `messages` transcript.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	chatStore := store.New(config, aiClient)
+
+	var (
+		format string
+		title  string
+	)
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import a chat from an sgpt JSON bundle or an OpenAI-style messages transcript",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", args[0], err)
+			}
+			if format == formatAuto {
+				format = formatOpenAI
+				if export.IsBundle(data) {
+					format = formatBundle
+				}
+			}
+
+			var bundle *export.Bundle
+			switch format {
+			case formatBundle:
+				if bundle, err = export.UnmarshalBundle(data); err != nil {
+					return err
+				}
+			case formatOpenAI:
+				messages, err := export.ParseOpenAIMessages(data)
+				if err != nil {
+					return err
+				}
+				bundle = &export.Bundle{Chat: &aipb.Chat{}, Messages: messages}
+			default:
+				return fmt.Errorf("invalid --format %q: want auto, bundle or openai", format)
+			}
+			// Validated up front: nothing is written for a malformed history.
+			bundle, err = export.ForImport(bundle)
+			if err != nil {
+				return fmt.Errorf("invalid history: %w", err)
+			}
+			if title != "" {
+				bundle.Chat.Title = title
+			}
+
+			chat, err := importBundle(cmd.Context(), chatStore, bundle)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: imported %d messages\n", chat.GetName(), len(bundle.Messages))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&format, "format", "f", formatAuto, "Input format: auto, bundle or openai")
+	cmd.Flags().StringVar(&title, "title", "", "Title of the imported chat (default: the bundle's; none for transcripts, see `sgpt titles`)")
+	return cmd
+}
+
+// importBundle creates the chat, then its messages in order. A failure
+// midway deletes the chat rather than leave a truncated history behind.
+func importBundle(ctx context.Context, chatStore *store.Store, bundle *export.Bundle) (*aipb.Chat, error) {
+	chat, err := chatStore.CreateChat(ctx, bundle.Chat)
+	if err != nil {
+		return nil, err
+	}
+	for i, message := range bundle.Messages {
+		if _, err := chatStore.CreateMessage(ctx, chat.GetName(), message); err != nil {
+			if deleteErr := chatStore.DeleteChat(ctx, chat.GetName()); deleteErr != nil {
+				return nil, fmt.Errorf("message %d: %w (and cleaning up %s: %v)", i, err, chat.GetName(), deleteErr)
+			}
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+	return chat, nil
+}
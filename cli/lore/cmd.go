@@ -0,0 +1,296 @@
+package lore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/malonaz/core/go/pbutil"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	lorelibrary "github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/repo"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// NewCmd manages lore libraries outside of chats: the enclosing repo's
+// (read-write) and each import's (read-only).
+func NewCmd(config *sgptpb.Configuration) *cobra.Command {
+	// Empty outside a repo: imported libraries are still listed.
+	repoRoot, _ := gograph.FindRoot(".")
+	index := lorelibrary.NewIndex(repoRoot, repo.NewImports(config.GetImports()))
+
+	cmd := &cobra.Command{
+		Use:   "lore",
+		Short: "List, show, search, create, edit, remove and lint lores",
+	}
+	cmd.AddCommand(
+		newListCmd(index),
+		newShowCmd(index),
+		newSearchCmd(index),
+		newNewCmd(index),
+		newEditCmd(index),
+		newRmCmd(index),
+		newLintCmd(index),
+	)
+	return cmd
+}
+
+// loadAll reads every library's lores, enclosing repo first.
+func loadAll(index *lorelibrary.Index) ([]*sgptpb.Lore, error) {
+	var lores []*sgptpb.Lore
+	for _, library := range index.Libraries() {
+		libraryLores, err := library.Load()
+		if err != nil {
+			return nil, err
+		}
+		lores = append(lores, libraryLores...)
+	}
+	return lores, nil
+}
+
+// localLibrary returns the enclosing repo's library: the only one written to.
+func localLibrary(index *lorelibrary.Index) (lorelibrary.Library, error) {
+	libraries := index.Libraries()
+	if len(libraries) == 0 || libraries[0].Prefix != "" {
+		return lorelibrary.Library{}, fmt.Errorf("not inside a repo: lores are written to the enclosing repo's .sgpt/lores")
+	}
+	return libraries[0], nil
+}
+
+// localID resolves a selector to an existing lore of the enclosing repo,
+// returning its ID and path. Imported lores are read-only.
+func localID(index *lorelibrary.Index, selector string) (string, string, error) {
+	name, path, err := index.Resolve(selector)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.HasPrefix(name, repo.Prefix) {
+		return "", "", fmt.Errorf("%s is imported and read-only: change it in its own repo", name)
+	}
+	return strings.TrimPrefix(name, "lores/"), path, nil
+}
+
+func newListCmd(index *lorelibrary.Index) *cobra.Command {
+	var (
+		format string
+		labels []string
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List lores with their title, labels and description",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("invalid --format %q: want table or json", format)
+			}
+			wantLabels, err := parseLabels(labels)
+			if err != nil {
+				return err
+			}
+			lores, err := loadAll(index)
+			if err != nil {
+				return err
+			}
+			var matching []*sgptpb.Lore
+			for _, lore := range lores {
+				if hasLabels(lore, wantLabels) {
+					matching = append(matching, lore)
+				}
+			}
+			return writeLores(cmd.OutOrStdout(), format, matching)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table or json")
+	cmd.Flags().StringArrayVar(&labels, "label", nil, "Only lores with this label, as key=value (repeatable)")
+	return cmd
+}
+
+func hasLabels(lore *sgptpb.Lore, labels map[string]string) bool {
+	for key, value := range labels {
+		if lore.GetLabels()[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func writeLores(w io.Writer, format string, lores []*sgptpb.Lore) error {
+	if format == formatJSON {
+		rawLores := make([]json.RawMessage, 0, len(lores))
+		for _, lore := range lores {
+			rawLore, err := pbutil.JSONMarshal(lore)
+			if err != nil {
+				return fmt.Errorf("marshaling %s: %w", lore.GetName(), err)
+			}
+			rawLores = append(rawLores, rawLore)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rawLores)
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tTITLE\tLABELS\tDESCRIPTION")
+	for _, lore := range lores {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", lore.GetName(), lore.GetTitle(), formatLabels(lore.GetLabels()), lore.GetDescription())
+	}
+	return writer.Flush()
+}
+
+// formatLabels renders labels as sorted "key=value" pairs.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseLabels parses "key=value" flags.
+func parseLabels(pairs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid label %q: want key=value", pair)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+func newShowCmd(index *lorelibrary.Index) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <selector>",
+		Short: `Print a lore ("lores/{lore}" or "@{import}//lores/{lore}")`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name, path, err := index.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			lore, err := lorelibrary.UnmarshalMarkdown(data)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", path, err)
+			}
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "# %s\n\n", lore.GetTitle())
+			fmt.Fprintf(w, "Name:   %s\nFile:   %s\n", name, path)
+			if labels := formatLabels(lore.GetLabels()); labels != "" {
+				fmt.Fprintf(w, "Labels: %s\n", labels)
+			}
+			if lore.GetDescription() != "" {
+				fmt.Fprintf(w, "\n%s\n", lore.GetDescription())
+			}
+			fmt.Fprintf(w, "\n%s\n", strings.TrimRight(lore.GetContent(), "\n"))
+			return nil
+		},
+	}
+}
+
+func newSearchCmd(index *lorelibrary.Index) *cobra.Command {
+	var topN int
+	var ranked bool
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search lores' titles, descriptions, labels and content (case-insensitive regexp, or BM25-ranked free text)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var matches []*lorelibrary.Match
+			if ranked {
+				var err error
+				if matches, err = lorelibrary.NewRanker(index).Search(args[0], topN); err != nil {
+					return err
+				}
+			} else {
+				lores, err := loadAll(index)
+				if err != nil {
+					return err
+				}
+				if matches, err = lorelibrary.Search(lores, args[0], topN); err != nil {
+					return err
+				}
+			}
+			w := cmd.OutOrStdout()
+			if len(matches) == 0 {
+				fmt.Fprintln(w, "No matching lores")
+				return nil
+			}
+			for i, match := range matches {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "%s  %s\n", match.Lore.GetName(), match.Lore.GetTitle())
+				if match.Lore.GetDescription() != "" {
+					fmt.Fprintf(w, "  %s\n", match.Lore.GetDescription())
+				}
+				for _, snippet := range match.Snippets {
+					fmt.Fprintf(w, "  > %s\n", snippet)
+				}
+				if hidden := match.MatchCount - len(match.Snippets); hidden > 0 {
+					fmt.Fprintf(w, "  (%d more matching line(s))\n", hidden)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVarP(&topN, "top", "n", 10, "Maximum number of lores")
+	cmd.Flags().BoolVar(&ranked, "ranked", false, "Rank lores by relevance to a free-text query instead of grepping")
+	return cmd
+}
+
+func newLintCmd(index *lorelibrary.Index) *cobra.Command {
+	var sync bool
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check lores' front matter, duplicate titles and label vocabulary drift",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if sync {
+				library, err := localLibrary(index)
+				if err != nil {
+					return err
+				}
+				if err := syncLabels(library); err != nil {
+					return err
+				}
+			}
+			var problems []*lorelibrary.Problem
+			for _, library := range index.Libraries() {
+				libraryProblems, err := library.Lint()
+				if err != nil {
+					return err
+				}
+				problems = append(problems, libraryProblems...)
+			}
+			w := cmd.OutOrStdout()
+			for _, problem := range problems {
+				fmt.Fprintf(w, "%s: %s\n", problem.Path, problem.Message)
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("found %d problem(s)", len(problems))
+			}
+			fmt.Fprintf(w, "%d lore librar(ies) OK\n", len(index.Libraries()))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&sync, "sync", false, "Rewrite the enclosing repo's .sgpt/labels from its lores first")
+	return cmd
+}
@@ -0,0 +1,78 @@
+package lore
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	lorelibrary "github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/repo"
+)
+
+func TestWritesOnlyTouchTheEnclosingRepo(t *testing.T) {
+	local := lorelibrary.Library{Root: t.TempDir()}
+	imported := lorelibrary.Library{Root: t.TempDir()}
+	for _, library := range []lorelibrary.Library{local, imported} {
+		if _, err := library.Save("go/errors", &sgptpb.Lore{Title: "Go errors", Content: "Wrap them.", Labels: map[string]string{"language": "go"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index := lorelibrary.NewIndex(local.Root, repo.NewImports([]*sgptpb.Import{{Name: "core", Path: imported.Root}}))
+
+	if _, _, err := localID(index, "@core//lores/go/errors"); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("localID on an imported lore: got %v, want a read-only error", err)
+	}
+	id, _, err := localID(index, "lores/go/errors")
+	if err != nil || id != "go/errors" {
+		t.Fatalf("localID = %q, %v", id, err)
+	}
+
+	cmd := newRmCmd(index)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"lores/go/errors"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(local.Path("go/errors")); !os.IsNotExist(err) {
+		t.Errorf("local lore not deleted: %v", err)
+	}
+	if _, err := os.Stat(imported.Path("go/errors")); err != nil {
+		t.Errorf("imported lore touched: %v", err)
+	}
+	// Labels were resynced from the now-empty library.
+	problems, err := local.Lint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("lint after rm: %v", problems[0].Message)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels([]string{"language=go", "topic=errors=wrapping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels["language"] != "go" || labels["topic"] != "errors=wrapping" {
+		t.Errorf("parseLabels = %v", labels)
+	}
+	for _, pair := range []string{"language", "=go", "language="} {
+		if _, err := parseLabels([]string{pair}); err == nil {
+			t.Errorf("parseLabels(%q) succeeded, want an error", pair)
+		}
+	}
+}
+
+func TestWriteLoresTable(t *testing.T) {
+	var b bytes.Buffer
+	lores := []*sgptpb.Lore{{Name: "lores/go/errors", Title: "Go errors", Labels: map[string]string{"topic": "errors", "language": "go"}}}
+	if err := writeLores(&b, formatTable, lores); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "lores/go/errors  Go errors  language=go,topic=errors") {
+		t.Errorf("unexpected table:\n%s", b.String())
+	}
+}
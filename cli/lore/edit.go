@@ -0,0 +1,195 @@
+package lore
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/malonaz/sgpt/cli/tui/editor"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	lorelibrary "github.com/malonaz/sgpt/internal/lore"
+)
+
+func newNewCmd(index *lorelibrary.Index) *cobra.Command {
+	var (
+		lore   = &sgptpb.Lore{}
+		labels []string
+		force  bool
+	)
+	cmd := &cobra.Command{
+		Use:   "new <lore>",
+		Short: "Create a lore in the enclosing repo (opens $EDITOR unless --content is set)",
+		Long: "Create a lore in the enclosing repo's .sgpt/lores. The ID may hold \"/\" segments:\n" +
+			"  sgpt lore new go/errors --title \"Go errors\" --label language=go",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			library, err := localLibrary(index)
+			if err != nil {
+				return err
+			}
+			id := strings.TrimPrefix(args[0], "lores/")
+			if err := lorelibrary.ValidateID(id); err != nil {
+				return err
+			}
+			if _, err := os.Stat(library.Path(id)); err == nil && !force {
+				return fmt.Errorf("%s already exists (pass --force to overwrite, or `sgpt lore edit`)", library.Path(id))
+			}
+			if lore.Labels, err = parseLabels(labels); err != nil {
+				return err
+			}
+			if lore.GetContent() == "" {
+				if lore, err = editLore(lore); err != nil {
+					return err
+				}
+			}
+			if err := validateLore(lore); err != nil {
+				return err
+			}
+			path, err := save(library, id, lore)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", path)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&lore.Title, "title", "", "Title")
+	cmd.Flags().StringVar(&lore.Description, "description", "", "One-line description")
+	cmd.Flags().StringArrayVar(&labels, "label", nil, "Label as key=value (repeatable)")
+	cmd.Flags().StringVar(&lore.Content, "content", "", "Markdown content; opens $EDITOR when empty")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing lore")
+	return cmd
+}
+
+func newEditCmd(index *lorelibrary.Index) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <selector>",
+		Short: "Edit a lore of the enclosing repo in $EDITOR",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			library, err := localLibrary(index)
+			if err != nil {
+				return err
+			}
+			id, path, err := localID(index, args[0])
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			lore, err := lorelibrary.UnmarshalMarkdown(data)
+			if err != nil {
+				return fmt.Errorf("parsing %s: %w", path, err)
+			}
+			if lore, err = editLore(lore); err != nil {
+				return err
+			}
+			if err := validateLore(lore); err != nil {
+				return err
+			}
+			if _, err := save(library, id, lore); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Saved %s\n", path)
+			return nil
+		},
+	}
+}
+
+func newRmCmd(index *lorelibrary.Index) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <selector>...",
+		Short: "Delete lores of the enclosing repo",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			library, err := localLibrary(index)
+			if err != nil {
+				return err
+			}
+			for _, selector := range args {
+				id, path, err := localID(index, selector)
+				if err != nil {
+					return err
+				}
+				if err := library.Delete(id); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted %s\n", path)
+			}
+			return syncLabels(library)
+		},
+	}
+}
+
+// editLore opens a lore, rendered as markdown, in the user's editor and
+// parses it back. The file is edited in a temporary copy, so an invalid edit
+// never reaches the library.
+func editLore(lore *sgptpb.Lore) (*sgptpb.Lore, error) {
+	data, err := lorelibrary.MarshalMarkdown(lore)
+	if err != nil {
+		return nil, err
+	}
+	tmpFile, err := os.CreateTemp("", "sgpt-lore-*"+lorelibrary.Extension)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+	_, err = tmpFile.Write(data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	editorCmd := editor.Command(tmpPath)
+	if editorCmd == nil {
+		return nil, fmt.Errorf("no editor: set $EDITOR")
+	}
+	editorCmd.Stdin, editorCmd.Stdout, editorCmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return nil, fmt.Errorf("running editor: %w", err)
+	}
+	data, err = os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	edited, err := lorelibrary.UnmarshalMarkdown(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the edited lore (nothing was saved): %w", err)
+	}
+	return edited, nil
+}
+
+// validateLore rejects lores the library would lint: untitled or empty.
+func validateLore(lore *sgptpb.Lore) error {
+	if strings.TrimSpace(lore.GetTitle()) == "" {
+		return fmt.Errorf("lore has no title (nothing was saved)")
+	}
+	if strings.TrimSpace(lore.GetContent()) == "" {
+		return fmt.Errorf("lore has no content (nothing was saved)")
+	}
+	return nil
+}
+
+// save writes a lore, then refreshes the library's label vocabulary.
+func save(library lorelibrary.Library, id string, lore *sgptpb.Lore) (string, error) {
+	path, err := library.Save(id, lore)
+	if err != nil {
+		return "", err
+	}
+	return path, syncLabels(library)
+}
+
+func syncLabels(library lorelibrary.Library) error {
+	lores, err := library.Load()
+	if err != nil {
+		return err
+	}
+	return library.SyncLabels(lores)
+}
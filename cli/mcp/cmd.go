@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/lore"
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+	"github.com/malonaz/sgpt/internal/repo"
+	"github.com/malonaz/sgpt/internal/tool"
+	"github.com/malonaz/sgpt/internal/tool/agent"
+	"github.com/malonaz/sgpt/internal/tool/builtins"
+	"github.com/malonaz/sgpt/internal/tool/lores"
+	toolmcp "github.com/malonaz/sgpt/internal/tool/mcp"
+)
+
+// chatOnlyToolNameSet holds the built-ins that need a chat to run:
+// sub-agents open tabs of their own.
+var chatOnlyToolNameSet = map[string]bool{
+	agent.Definition.GetName():      true,
+	agent.BatchDefinition.GetName(): true,
+}
+
+// NewCmd integrates sgpt with other Model Context Protocol clients.
+func NewCmd(config *sgptpb.Configuration) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve sgpt's tools over the Model Context Protocol",
+	}
+	cmd.AddCommand(newServeCmd(config))
+	return cmd
+}
+
+func newServeCmd(config *sgptpb.Configuration) *cobra.Command {
+	var toolNames, autoApprovedToolNames []string
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve built-in tools to an MCP client over stdio",
+		Long: "Serve built-in tools (file reads and edits, lore search, ...) to an MCP client, e.g. an editor or another agent,\n" +
+			"over stdin/stdout. Lores are those of the enclosing repo and its imports. Calls a chat would ask the user to\n" +
+			"review (shell commands, file edits) are refused unless their tool is auto-approved.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tools, err := resolveTools(toolNames, autoApprovedToolNames)
+			if err != nil {
+				return err
+			}
+			// Empty outside a repo: imported libraries are still served.
+			repoRoot, _ := gograph.FindRoot(".")
+			loreIndex := lore.NewIndex(repoRoot, repo.NewImports(config.GetImports()))
+
+			registry := tool.NewRegistry()
+			// The agent tools are registered but never served: see chatOnlyToolNameSet.
+			builtins.Register(registry, &lores.Tool{Index: loreIndex, Ranker: lore.NewRanker(loreIndex)})
+			registry.AddTools(tools...)
+
+			handler := toolmcp.NewHandler(registry, tools, autoApprovedToolNames)
+			server := gomcp.NewServer(gomcp.Implementation{Name: "sgpt", Version: cmd.Root().Version}, config.GetTitle(), handler)
+			return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+		},
+	}
+	cmd.Flags().StringSliceVar(&toolNames, "tool", config.GetMcpServe().GetTools(), "Built-in tool to serve (repeatable; default: every tool that runs outside a chat)")
+	cmd.Flags().StringSliceVar(&autoApprovedToolNames, "approve", config.GetMcpServe().GetAutoApprovedTools(), "Served tool whose calls run without review (repeatable)")
+	return cmd
+}
+
+// resolveTools returns the definitions of the tools to serve, checking the
+// policy only names servable built-ins.
+func resolveTools(toolNames, autoApprovedToolNames []string) ([]*aipb.Tool, error) {
+	if len(toolNames) == 0 {
+		for _, name := range tool.BuiltinNames() {
+			if !chatOnlyToolNameSet[name] {
+				toolNames = append(toolNames, name)
+			}
+		}
+	}
+	servedToolNameSet := map[string]bool{}
+	var tools []*aipb.Tool
+	for _, name := range toolNames {
+		builtinTool, ok := tool.Builtin(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown built-in tool %q", name)
+		}
+		if chatOnlyToolNameSet[name] {
+			return nil, fmt.Errorf("%s needs a chat to run and cannot be served", name)
+		}
+		if servedToolNameSet[name] {
+			continue
+		}
+		servedToolNameSet[name] = true
+		tools = append(tools, builtinTool)
+	}
+	for _, name := range autoApprovedToolNames {
+		if !servedToolNameSet[name] {
+			return nil, fmt.Errorf("%s is auto-approved but not served", name)
+		}
+	}
+	return tools, nil
+}
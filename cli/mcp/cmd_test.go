@@ -0,0 +1,39 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveTools(t *testing.T) {
+	tools, err := resolveTools(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, servedTool := range tools {
+		names = append(names, servedTool.GetName())
+	}
+	got := strings.Join(names, ",")
+	if !strings.Contains(got, "read_files") || !strings.Contains(got, "search_lores") || strings.Contains(got, "agent") {
+		t.Errorf("default tools = %s, want every built-in but the agent tools", got)
+	}
+
+	tools, err = resolveTools([]string{"read_files", "replace", "read_files"}, []string{"replace"})
+	if err != nil || len(tools) != 2 {
+		t.Errorf("resolveTools() = %d tools, %v", len(tools), err)
+	}
+
+	for _, test := range []struct {
+		toolNames, autoApprovedToolNames []string
+		want                             string
+	}{
+		{[]string{"bogus"}, nil, `unknown built-in tool "bogus"`},
+		{[]string{"agent"}, nil, "needs a chat"},
+		{[]string{"read_files"}, []string{"exec_shell"}, "exec_shell is auto-approved but not served"},
+	} {
+		if _, err := resolveTools(test.toolNames, test.autoApprovedToolNames); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("resolveTools(%v, %v) = %v, want %q", test.toolNames, test.autoApprovedToolNames, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package roles
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/configuration"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/repo"
+	"github.com/malonaz/sgpt/internal/role"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// NewCmd manages the roles visible from the current directory: the
+// enclosing repo's .sgpt artifacts plus imports.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	imports := repo.NewImports(config.GetImports())
+	loadForest := func() (*gograph.Forest, error) {
+		repoRoot, _ := gograph.FindRoot(".")
+		if repoRoot == "" {
+			return gograph.NewForest(&gograph.Tree{PathToDir: map[string]*gograph.Dir{}}, imports, configuration.LoadIgnore), nil
+		}
+		tree, err := gograph.Scan(repoRoot, config.GetIgnore())
+		if err != nil {
+			return nil, fmt.Errorf("scanning repo: %w", err)
+		}
+		return gograph.NewForest(tree, imports, configuration.LoadIgnore), nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "roles",
+		Short: "List, show, explain, validate and scaffold roles",
+	}
+	cmd.AddCommand(
+		newListCmd(loadForest),
+		newShowCmd(loadForest),
+		newExplainCmd(loadForest),
+		newValidateCmd(config, store.New(config, aiClient), loadForest),
+		newNewCmd(config),
+	)
+	return cmd
+}
+
+// roleName canonicalizes a role selector ("reviewer", "//go:reviewer",
+// "@import//dir:title") to its name; what the forest cannot resolve is
+// returned as is, as it may still be an alias.
+func roleName(forest *gograph.Forest, selector string) string {
+	if resolved, err := forest.ResolveRole(selector); err == nil {
+		return resolved.GetName()
+	}
+	return selector
+}
+
+func newListCmd(loadForest func() (*gograph.Forest, error)) *cobra.Command {
+	var (
+		format      string
+		withImports bool
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List roles with their alias, model, tools and source file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("invalid --format %q: want table or json", format)
+			}
+			forest, err := loadForest()
+			if err != nil {
+				return err
+			}
+			var artifacts []*gograph.Artifact[*sgptpb.Role]
+			for _, artifact := range forest.RoleArtifacts() {
+				if withImports || artifact.Import == "" {
+					artifacts = append(artifacts, artifact)
+				}
+			}
+			return writeRoles(cmd.OutOrStdout(), format, artifacts)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table or json")
+	cmd.Flags().BoolVar(&withImports, "imports", true, "Include roles from imported repos")
+	return cmd
+}
+
+// roleEntry is a listed role, as printed by `list --format json`.
+type roleEntry struct {
+	Selector string   `json:"selector"`
+	Alias    string   `json:"alias,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Tools    []string `json:"tools,omitempty"`
+	Import   string   `json:"import,omitempty"`
+	File     string   `json:"file"`
+}
+
+// writeRoles prints roles as declared in their file, not expanded: `sgpt roles
+// explain` shows what a role inherits.
+func writeRoles(w io.Writer, format string, artifacts []*gograph.Artifact[*sgptpb.Role]) error {
+	entries := make([]*roleEntry, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		model := artifact.Message.GetModel()
+		if artifact.Message.GetOverrideModel() != "" {
+			model = artifact.Message.GetOverrideModel()
+		}
+		entries = append(entries, &roleEntry{
+			Selector: artifact.Message.GetName(),
+			Alias:    artifact.Message.GetAlias(),
+			Model:    model,
+			Tools:    artifact.Message.GetTools(),
+			Import:   artifact.Import,
+			File:     artifact.FilePath,
+		})
+	}
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SELECTOR\tALIAS\tMODEL\tTOOLS\tIMPORT\tFILE")
+	for _, entry := range entries {
+		model := entry.Model[strings.LastIndex(entry.Model, "/")+1:]
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Selector, entry.Alias, model, strings.Join(entry.Tools, ","), entry.Import, entry.File)
+	}
+	return writer.Flush()
+}
+
+func newShowCmd(loadForest func() (*gograph.Forest, error)) *cobra.Command {
+	var (
+		roleArgs     []string
+		systemPrompt bool
+	)
+	cmd := &cobra.Command{
+		Use:   "show <selector>",
+		Short: "Print a role's expanded prompt",
+		Long: "Print a role's expanded prompt: included roles merged and templates rendered, as a chat with\n" +
+			"this role would get it. --system prints the whole system prompt the role is wrapped in.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forest, err := loadForest()
+			if err != nil {
+				return err
+			}
+			opts := role.NewOpts(forest.Roles())
+			opts.RoleName = roleName(forest, args[0])
+			opts.RoleArgs = roleArgs
+			opts.ToolDiscovery = len(forest.ToolSets()) > 0
+			var expandedRole *sgptpb.Role
+			if systemPrompt {
+				expandedRole, err = opts.Parse()
+			} else {
+				expandedRole, err = opts.Expand()
+			}
+			if err != nil {
+				return err
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Role:  %s\n", expandedRole.GetName())
+			if expandedRole.GetAlias() != "" {
+				fmt.Fprintf(w, "Alias: %s\n", expandedRole.GetAlias())
+			}
+			if expandedRole.GetModel() != "" {
+				fmt.Fprintf(w, "Model: %s\n", expandedRole.GetModel())
+			}
+			if len(expandedRole.GetTools()) > 0 {
+				fmt.Fprintf(w, "Tools: %s\n", strings.Join(expandedRole.GetTools(), ", "))
+			}
+			if len(expandedRole.GetFiles()) > 0 {
+				fmt.Fprintf(w, "Files: %s\n", strings.Join(expandedRole.GetFiles(), ", "))
+			}
+			fmt.Fprintf(w, "\n%s\n", expandedRole.GetPrompt())
+			return nil
+		},
+	}
+	cmd.Flags().StringArrayVar(&roleArgs, "role-arg", nil, "set a role parameter, as name=value (repeatable)")
+	cmd.Flags().BoolVar(&systemPrompt, "system", false, "Print the full system prompt, not just the role's part")
+	return cmd
+}
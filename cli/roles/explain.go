@@ -0,0 +1,90 @@
+package roles
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/role"
+)
+
+func newExplainCmd(loadForest func() (*gograph.Forest, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <selector>",
+		Short: "Print a role fully expanded, with where each piece came from",
+		Long: "Print a role fully expanded: its included roles, model, tools, files, parameters and prompt sections,\n" +
+			"each annotated with the role it came from. Prompts are printed unrendered.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forest, err := loadForest()
+			if err != nil {
+				return err
+			}
+			composition, err := role.NewOpts(forest.Roles()).Explain(roleName(forest, args[0]))
+			if err != nil {
+				return err
+			}
+			writeComposition(cmd.OutOrStdout(), composition)
+			return nil
+		},
+	}
+}
+
+func writeComposition(w io.Writer, composition *role.Composition) {
+	fmt.Fprintf(w, "Role:     %s\n", composition.Name)
+	if composition.Alias != "" {
+		fmt.Fprintf(w, "Alias:    %s\n", composition.Alias)
+	}
+	if len(composition.Includes) > 0 {
+		fmt.Fprintf(w, "Includes: %s\n", strings.Join(composition.Includes, ", "))
+	}
+	if composition.Model.Value != "" {
+		directive := "@model"
+		if composition.ModelOverridden {
+			directive = "@override_model"
+		}
+		fmt.Fprintf(w, "Model:    %s  (%s in %s)\n", composition.Model.Value, directive, composition.Model.Source)
+	}
+
+	writePieces(w, "Fallback models", composition.FallbackModels, "from")
+	writePieces(w, "Tools", composition.Tools, "from")
+	writePieces(w, "Excluded tools", composition.ExcludedTools, "by")
+	writePieces(w, "Files", composition.Files, "from")
+	writePieces(w, "Excluded files", composition.ExcludedFiles, "by")
+
+	if len(composition.Parameters) > 0 {
+		fmt.Fprintf(w, "\nParameters:\n")
+		for _, parameter := range composition.Parameters {
+			value := "required"
+			if !parameter.GetRequired() {
+				value = fmt.Sprintf("default %q", parameter.GetDefaultValue())
+			}
+			fmt.Fprintf(w, "  %s  %s  (from %s)\n", parameter.GetName(), value, parameter.Source)
+		}
+	}
+
+	for _, section := range composition.Sections {
+		header := "prompt"
+		if section.Name != "" {
+			header = "@section " + section.Name
+		}
+		header += " from " + section.Source
+		if len(section.Replaced) > 0 {
+			header += ", replacing " + strings.Join(section.Replaced, ", ")
+		}
+		fmt.Fprintf(w, "\n── %s ──\n%s\n", header, section.Content)
+	}
+}
+
+func writePieces(w io.Writer, title string, pieces []role.Piece, relation string) {
+	if len(pieces) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, piece := range pieces {
+		fmt.Fprintf(w, "  %s  (%s %s)\n", piece.Value, relation, piece.Source)
+	}
+}
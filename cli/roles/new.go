@@ -0,0 +1,142 @@
+package roles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/repo"
+)
+
+// titlePattern keeps role titles usable in selectors and file names.
+var titlePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// newRoleOptions are the directives a scaffolded role starts with.
+type newRoleOptions struct {
+	alias string
+	model string
+	tools []string
+	roles []string
+}
+
+func newNewCmd(config *sgptpb.Configuration) *cobra.Command {
+	var (
+		opts  newRoleOptions
+		force bool
+	)
+	cmd := &cobra.Command{
+		Use:   "new <selector>",
+		Short: "Scaffold a role file in the right .sgpt directory",
+		Long: "Scaffold a role file. The selector picks the directory, relative to the repo root:\n" +
+			"  //go/server:reviewer  go/server/.sgpt/reviewer.role.md\n" +
+			"  reviewer              .sgpt/reviewer.role.md (root role)\n" +
+			"  :reviewer             .sgpt/reviewer.role.md in the current directory",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoRoot, err := gograph.FindRoot(".")
+			if err != nil {
+				return fmt.Errorf("roles live in a repo with a %s: %w", gograph.RootFileName, err)
+			}
+			cwd, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("getting current working directory: %w", err)
+			}
+			dir, title, err := parseNewSelector(args[0], repoRoot, cwd)
+			if err != nil {
+				return err
+			}
+			// A role in an ignored directory would never be discovered.
+			tree, err := gograph.Scan(repoRoot, config.GetIgnore())
+			if err != nil {
+				return fmt.Errorf("scanning repo: %w", err)
+			}
+			if _, ok := tree.PathToDir[dir]; !ok {
+				return fmt.Errorf("directory %q does not exist or is ignored", dir)
+			}
+
+			path := filepath.Join(repoRoot, dir, gograph.ArtifactDirName, title+gograph.RoleExtension)
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("%s already exists (pass --force to overwrite)", path)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, []byte(scaffoldRole(title, &opts)), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s\nEdit its prompt, then check it with `sgpt roles validate`.\n", path)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&opts.alias, "alias", "", "Short alias for --role")
+	cmd.Flags().StringVar(&opts.model, "model", "", "Default model name or alias")
+	cmd.Flags().StringArrayVar(&opts.tools, "tool", nil, "Enable a tool or tool set (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.roles, "include", nil, "Include another role (repeatable)")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite an existing role file")
+	return cmd
+}
+
+// parseNewSelector maps a new role's selector to its root-relative directory
+// and title. Besides "//dir:title" and bare root titles, ":title" targets
+// the current directory.
+func parseNewSelector(selector, repoRoot, cwd string) (string, string, error) {
+	if _, _, ok := repo.Split(selector); ok {
+		return "", "", fmt.Errorf("cannot create a role in an imported repo (%s)", selector)
+	}
+	var dir, title string
+	switch {
+	case strings.HasPrefix(selector, ":"):
+		relative, err := filepath.Rel(repoRoot, cwd)
+		if err != nil || strings.HasPrefix(relative, "..") {
+			return "", "", fmt.Errorf("current directory %s is outside the repo %s", cwd, repoRoot)
+		}
+		dir, title = filepath.ToSlash(relative), selector[1:]
+	case strings.HasPrefix(selector, "//"):
+		var ok bool
+		dir, title, ok = strings.Cut(strings.TrimPrefix(selector, "//"), ":")
+		if !ok {
+			// "//title" is a root role.
+			dir, title = ".", dir
+		}
+		dir = filepath.Clean(dir)
+	default:
+		dir, title = ".", selector
+	}
+	if !titlePattern.MatchString(title) {
+		return "", "", fmt.Errorf("invalid role title %q in %q: want letters, digits, '_', '-' or '.'", title, selector)
+	}
+	if dir == ".." || strings.HasPrefix(dir, "../") || filepath.IsAbs(dir) {
+		return "", "", fmt.Errorf("invalid directory %q in %q: want a path under the repo root", dir, selector)
+	}
+	return dir, title, nil
+}
+
+// scaffoldRole renders a new role file: its directives, then a prompt
+// skeleton split into sections roles including it can replace.
+func scaffoldRole(title string, opts *newRoleOptions) string {
+	var b strings.Builder
+	if opts.alias != "" {
+		fmt.Fprintf(&b, "@alias(%q)\n", opts.alias)
+	}
+	if opts.model != "" {
+		fmt.Fprintf(&b, "@model(%q)\n", opts.model)
+	}
+	for _, roleName := range opts.roles {
+		fmt.Fprintf(&b, "@role(%q)\n", roleName)
+	}
+	for _, toolName := range opts.tools {
+		fmt.Fprintf(&b, "@tool(%q)\n", toolName)
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "You are %s. Describe the role's purpose and expertise here.\n\n", title)
+	b.WriteString("@section(\"style\")\nExplain how answers should be written.\n")
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package roles
+
+import "testing"
+
+func TestParseNewSelector(t *testing.T) {
+	for selector, want := range map[string][2]string{
+		"reviewer":             {".", "reviewer"},
+		"//reviewer":           {".", "reviewer"},
+		"//go/server:reviewer": {"go/server", "reviewer"},
+		"//:reviewer":          {".", "reviewer"},
+		":reviewer":            {"cli/roles", "reviewer"},
+	} {
+		dir, title, err := parseNewSelector(selector, "/repo", "/repo/cli/roles")
+		if err != nil || dir != want[0] || title != want[1] {
+			t.Errorf("parseNewSelector(%q) = %q, %q, %v; want %q, %q", selector, dir, title, err, want[0], want[1])
+		}
+	}
+	for _, selector := range []string{"@lib//a:b", "//a:", "//../x:y", "a/b", "//a:b c"} {
+		if _, _, err := parseNewSelector(selector, "/repo", "/repo"); err == nil {
+			t.Errorf("parseNewSelector(%q) succeeded, want an error", selector)
+		}
+	}
+	if _, _, err := parseNewSelector(":x", "/repo", "/elsewhere"); err == nil {
+		t.Errorf("parseNewSelector outside the repo succeeded, want an error")
+	}
+}
+
+func TestScaffoldRole(t *testing.T) {
+	got := scaffoldRole("reviewer", &newRoleOptions{alias: "rev", tools: []string{"diff"}, roles: []string{"//:base"}})
+	want := "@alias(\"rev\")\n@role(\"//:base\")\n@tool(\"diff\")\n\n" +
+		"You are reviewer. Describe the role's purpose and expertise here.\n\n" +
+		"@section(\"style\")\nExplain how answers should be written.\n"
+	if got != want {
+		t.Errorf("scaffoldRole = %q, want %q", got, want)
+	}
+}
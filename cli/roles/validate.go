@@ -0,0 +1,222 @@
+package roles
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/configuration"
+	"github.com/malonaz/sgpt/internal/file"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/repo"
+	"github.com/malonaz/sgpt/internal/store"
+	"github.com/malonaz/sgpt/internal/tool"
+	// Built-in tools register themselves on import.
+	_ "github.com/malonaz/sgpt/internal/tool/agent"
+	_ "github.com/malonaz/sgpt/internal/tool/diff"
+	_ "github.com/malonaz/sgpt/internal/tool/io"
+	_ "github.com/malonaz/sgpt/internal/tool/lores"
+	_ "github.com/malonaz/sgpt/internal/tool/shell"
+)
+
+func newValidateCmd(config *sgptpb.Configuration, chatStore *store.Store, loadForest func() (*gograph.Forest, error)) *cobra.Command {
+	var skipModels bool
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check every role and tool set for broken references",
+		Long: "Check every .role.md and .toolset in the repo and its imports: unknown tools, unresolvable @role\n" +
+			"includes, unknown models, missing files and undeclared gRPC clients. Exits non-zero on any problem.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			forest, err := loadForest()
+			if err != nil {
+				return err
+			}
+			v := &validator{
+				config:     config,
+				roles:      forest.RoleArtifacts(),
+				toolSets:   forest.ToolSetArtifacts(),
+				mcpServers: forest.McpServerArtifacts(),
+				plugins:    forest.PluginArtifacts(),
+			}
+			if !skipModels {
+				v.knownModel = newModelChecker(cmd.Context(), chatStore)
+			}
+			problems := v.validate()
+			for importName, err := range forest.ImportErrors() {
+				problems = append(problems, &problem{location: repo.Prefix + importName, message: err.Error()})
+			}
+			return writeProblems(cmd.OutOrStdout(), problems, len(v.roles), len(v.toolSets))
+		},
+	}
+	cmd.Flags().BoolVar(&skipModels, "skip-models", false, "Do not check models against the AI service")
+	return cmd
+}
+
+// problem is one broken reference found by validate.
+type problem struct {
+	// location is the offending artifact's file, or an import.
+	location string
+	message  string
+}
+
+type validator struct {
+	config   *sgptpb.Configuration
+	roles    []*gograph.Artifact[*sgptpb.Role]
+	toolSets []*gograph.Artifact[*sgptpb.ToolSet]
+	// mcpServers and plugins are only checked as tool names: their files
+	// are validated on load.
+	mcpServers []*gograph.Artifact[*sgptpb.McpServer]
+	plugins    []*gograph.Artifact[*sgptpb.Plugin]
+	// knownModel reports whether a model name is served; nil skips model
+	// checks.
+	knownModel func(name string) (bool, error)
+}
+
+func (v *validator) validate() []*problem {
+	var problems []*problem
+	report := func(location, format string, args ...any) {
+		problems = append(problems, &problem{location: location, message: fmt.Sprintf(format, args...)})
+	}
+
+	// Includes resolve like the role registry does: by name or alias.
+	roleNameSet := map[string]bool{}
+	toolSetNameSet := map[string]bool{}
+	aliasToFilePath := map[string]string{}
+	for _, artifact := range v.roles {
+		roleNameSet[artifact.Message.GetName()] = true
+		if alias := artifact.Message.GetAlias(); alias != "" {
+			roleNameSet[alias] = true
+		}
+	}
+	for _, artifact := range v.toolSets {
+		toolSetNameSet[artifact.Message.GetName()] = true
+	}
+	for _, artifact := range v.mcpServers {
+		toolSetNameSet[artifact.Message.GetName()] = true
+	}
+	for _, artifact := range v.plugins {
+		toolSetNameSet[artifact.Message.GetName()] = true
+	}
+
+	for _, artifact := range v.roles {
+		role := artifact.Message
+		if alias := role.GetAlias(); alias != "" {
+			if filePath, ok := aliasToFilePath[alias]; ok {
+				report(artifact.FilePath, "alias %q is already used by %s, which wins", alias, filePath)
+			} else {
+				aliasToFilePath[alias] = artifact.FilePath
+			}
+		}
+		for _, includedName := range role.GetRoles() {
+			if !roleNameSet[includedName] {
+				report(artifact.FilePath, "@role(%q) does not resolve to a role", includedName)
+			}
+		}
+		for _, toolNames := range [][]string{role.GetTools(), role.GetExcludedTools()} {
+			for _, toolName := range toolNames {
+				if _, ok := tool.Builtin(toolName); !ok && !toolSetNameSet[toolName] {
+					report(artifact.FilePath, "unknown tool %q: neither a built-in tool, a tool set, an MCP server nor a plugin", toolName)
+				}
+			}
+		}
+		for _, filePath := range role.GetFiles() {
+			// A "dir/..." file injects the directory recursively.
+			path, recurse := strings.CutSuffix(filePath, "/...")
+			path, err := file.ExpandPath(path)
+			if err != nil {
+				report(artifact.FilePath, "@file(%q): %v", filePath, err)
+				continue
+			}
+			fileInfo, err := os.Stat(path)
+			switch {
+			case err != nil:
+				report(artifact.FilePath, "@file(%q): %v", filePath, err)
+			case recurse && !fileInfo.IsDir():
+				report(artifact.FilePath, "@file(%q): cannot recurse on a file", filePath)
+			}
+		}
+		if v.knownModel == nil {
+			continue
+		}
+		for _, model := range append([]string{role.GetModel(), role.GetOverrideModel()}, role.GetFallbackModels()...) {
+			if model == "" {
+				continue
+			}
+			modelName, err := configuration.ResolveModelAlias(v.config, model)
+			if err != nil {
+				report(artifact.FilePath, "model %q: %v", model, err)
+				continue
+			}
+			known, err := v.knownModel(modelName)
+			if err != nil {
+				report(artifact.FilePath, "model %q: listing models: %v", model, err)
+			} else if !known {
+				report(artifact.FilePath, "unknown model %q", model)
+			}
+		}
+	}
+
+	for _, artifact := range v.toolSets {
+		toolSet := artifact.Message
+		if _, err := configuration.GrpcClient(v.config, toolSet.GetEngineService()); err != nil {
+			report(artifact.FilePath, "engine_service: %v", err)
+		}
+		if len(toolSet.GetToolSets()) == 0 {
+			report(artifact.FilePath, "declares no tool_sets")
+		}
+	}
+	return problems
+}
+
+// newModelChecker looks models up in the AI service's model list, listed
+// once (cached on disk) and refreshed once on the first miss.
+func newModelChecker(ctx context.Context, chatStore *store.Store) func(string) (bool, error) {
+	var (
+		modelNameSet map[string]bool
+		refreshed    bool
+	)
+	load := func(forceRefresh bool) error {
+		models, err := chatStore.ListModels(ctx, forceRefresh)
+		if err != nil {
+			return err
+		}
+		modelNameSet = make(map[string]bool, len(models))
+		for _, model := range models {
+			modelNameSet[model.GetName()] = true
+		}
+		return nil
+	}
+	return func(name string) (bool, error) {
+		if modelNameSet == nil {
+			if err := load(false); err != nil {
+				return false, err
+			}
+		}
+		if !modelNameSet[name] && !refreshed {
+			refreshed = true
+			if err := load(true); err != nil {
+				return false, err
+			}
+		}
+		return modelNameSet[name], nil
+	}
+}
+
+func writeProblems(w io.Writer, problems []*problem, roleCount, toolSetCount int) error {
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].location < problems[j].location })
+	for _, problem := range problems {
+		fmt.Fprintf(w, "%s: %s\n", problem.location, problem.message)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s) in %d role(s) and %d tool set(s)", len(problems), roleCount, toolSetCount)
+	}
+	fmt.Fprintf(w, "%d role(s) and %d tool set(s) OK\n", roleCount, toolSetCount)
+	return nil
+}
@@ -0,0 +1,77 @@
+package roles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+)
+
+func TestValidate(t *testing.T) {
+	directory := t.TempDir()
+	existingFile := filepath.Join(directory, "exists.md")
+	if err := os.WriteFile(existingFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	v := &validator{
+		config: &sgptpb.Configuration{
+			GrpcClients: []*sgptpb.GrpcClient{{Name: "engine"}},
+			Models:      []*sgptpb.Model{{Name: "providers/a/models/m", Alias: "m"}},
+		},
+		roles: []*gograph.Artifact[*sgptpb.Role]{
+			{FilePath: "base.role.md", Message: &sgptpb.Role{Name: "//base", Alias: "b", Model: "m"}},
+			{FilePath: "good.role.md", Message: &sgptpb.Role{
+				Name:  "//a:good",
+				Roles: []string{"//base", "b"},
+				Tools: []string{"//a:engine", "//a:docs", "//a:lint"},
+				Files: []string{existingFile, directory + "/..."},
+			}},
+			{FilePath: "bad.role.md", Message: &sgptpb.Role{
+				Name:           "//a:bad",
+				Alias:          "b",
+				OverrideModel:  "providers/a/models/gone",
+				FallbackModels: []string{"m", "providers/a/models/lost"},
+				Roles:          []string{"//nope"},
+				ExcludedTools:  []string{"bogus"},
+				Files:          []string{"/does/not/exist", existingFile + "/..."},
+			}},
+		},
+		toolSets: []*gograph.Artifact[*sgptpb.ToolSet]{
+			{FilePath: "a/engine.toolset", Message: &sgptpb.ToolSet{Name: "//a:engine", EngineService: "missing"}},
+		},
+		mcpServers: []*gograph.Artifact[*sgptpb.McpServer]{
+			{FilePath: "a/docs.mcp", Message: &sgptpb.McpServer{Name: "//a:docs"}},
+		},
+		plugins: []*gograph.Artifact[*sgptpb.Plugin]{
+			{FilePath: "a/lint.plugin", Message: &sgptpb.Plugin{Name: "//a:lint", Command: "./lint.py"}},
+		},
+		knownModel: func(name string) (bool, error) { return name == "providers/a/models/m", nil },
+	}
+
+	var messages []string
+	for _, problem := range v.validate() {
+		messages = append(messages, problem.location+": "+problem.message)
+	}
+	got := strings.Join(messages, "\n")
+	for _, want := range []string{
+		`bad.role.md: alias "b" is already used by base.role.md`,
+		`bad.role.md: @role("//nope") does not resolve`,
+		`bad.role.md: unknown tool "bogus"`,
+		`bad.role.md: @file("/does/not/exist")`,
+		`bad.role.md: @file("` + existingFile + `/..."): cannot recurse on a file`,
+		`bad.role.md: unknown model "providers/a/models/gone"`,
+		`bad.role.md: unknown model "providers/a/models/lost"`,
+		`a/engine.toolset: engine_service: unknown grpc client: "missing"`,
+		`a/engine.toolset: declares no tool_sets`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems do not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "good.role.md") || strings.Contains(got, "base.role.md:") {
+		t.Errorf("valid roles reported problems:\n%s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	chatsearch "github.com/malonaz/sgpt/internal/search"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// NewCmd searches message text across all chats.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	chatStore := store.New(config, aiClient)
+
+	var (
+		limit  int
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search message text across all chats",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("invalid --format %q: want table or json", format)
+			}
+			index := chatsearch.Load()
+			// Only chats updated since the last sync are fetched.
+			var reindexedCount int
+			err := index.Sync(cmd.Context(), chatStore, func(chat *aipb.Chat) {
+				reindexedCount++
+				fmt.Fprintf(cmd.ErrOrStderr(), "\rIndexing chats... %d", reindexedCount)
+			})
+			if reindexedCount > 0 {
+				fmt.Fprintln(cmd.ErrOrStderr())
+			}
+			if err != nil {
+				return fmt.Errorf("indexing chats: %w", err)
+			}
+			if err := index.Save(); err != nil {
+				return fmt.Errorf("saving search index: %w", err)
+			}
+
+			hits := index.Search(strings.Join(args, " "), limit)
+			if format == formatJSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if hits == nil {
+					hits = []chatsearch.Hit{}
+				}
+				return encoder.Encode(hits)
+			}
+			if len(hits) == 0 {
+				fmt.Fprintln(cmd.ErrOrStderr(), "No matches")
+				return nil
+			}
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(writer, "MESSAGE\tTITLE\tSNIPPET")
+			for _, hit := range hits {
+				title := hit.Title
+				if title == "" {
+					title = "(untitled)"
+				}
+				fmt.Fprintf(writer, "%s\t%s\t%s\n", hit.Message, title, hit.Snippet)
+			}
+			return writer.Flush()
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Maximum number of matches (0 for all)")
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table or json")
+	return cmd
+}
@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/malonaz/core/go/grpc"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/configuration"
+	gograph "github.com/malonaz/sgpt/internal/graph"
+	"github.com/malonaz/sgpt/internal/repo"
+	"github.com/malonaz/sgpt/internal/tool/rpc"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// NewCmd manages the tool engines visible from the current directory: the
+// enclosing repo's .toolset artifacts plus imports.
+func NewCmd(config *sgptpb.Configuration, clientNameToGRPCConnection map[string]*grpc.Connection) *cobra.Command {
+	imports := repo.NewImports(config.GetImports())
+	// loadEngines returns the discovered engines with a manager for them.
+	loadEngines := func() ([]*sgptpb.ToolSet, *rpc.Manager, error) {
+		forest := gograph.NewForest(&gograph.Tree{PathToDir: map[string]*gograph.Dir{}}, imports, configuration.LoadIgnore)
+		if repoRoot, _ := gograph.FindRoot("."); repoRoot != "" {
+			tree, err := gograph.Scan(repoRoot, config.GetIgnore())
+			if err != nil {
+				return nil, nil, fmt.Errorf("scanning repo: %w", err)
+			}
+			forest = gograph.NewForest(tree, imports, configuration.LoadIgnore)
+		}
+		toolSets := forest.ToolSets()
+		return toolSets, rpc.NewManager(config, clientNameToGRPCConnection, toolSets), nil
+	}
+
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "List, ping and refresh tool engines",
+	}
+	cmd.AddCommand(
+		newListCmd(loadEngines),
+		newPingCmd(loadEngines),
+		newRefreshCmd(loadEngines),
+	)
+	return cmd
+}
+
+type engineLoader func() ([]*sgptpb.ToolSet, *rpc.Manager, error)
+
+// selectEngines returns the engines named by args, or every engine.
+func selectEngines(toolSets []*sgptpb.ToolSet, args []string) ([]*sgptpb.ToolSet, error) {
+	if len(args) == 0 {
+		return toolSets, nil
+	}
+	nameToToolSet := map[string]*sgptpb.ToolSet{}
+	for _, toolSet := range toolSets {
+		nameToToolSet[toolSet.GetName()] = toolSet
+	}
+	var selected []*sgptpb.ToolSet
+	for _, name := range args {
+		toolSet, ok := nameToToolSet[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown tool engine %q", name)
+		}
+		selected = append(selected, toolSet)
+	}
+	return selected, nil
+}
+
+// completeEngines completes engine names; imports are only offered once
+// the user types "@".
+func completeEngines(loadEngines engineLoader) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		toolSets, _, err := loadEngines()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, toolSet := range toolSets {
+			name := toolSet.GetName()
+			if strings.HasPrefix(name, repo.Prefix) && !strings.HasPrefix(toComplete, "@") {
+				continue
+			}
+			if strings.HasPrefix(name, toComplete) {
+				names = append(names, name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// engineEntry is a listed engine, as printed by `list --format json`.
+type engineEntry struct {
+	Name           string `json:"name"`
+	EngineService  string `json:"engine_service"`
+	ToolSets       int    `json:"tool_sets"`
+	CachedToolSets int    `json:"cached_tool_sets"`
+	Fingerprint    string `json:"fingerprint,omitempty"`
+	// SchemaAgeSeconds is how long the schema has been cached unchanged;
+	// absent when the engine was never initialized.
+	SchemaAgeSeconds int64 `json:"schema_age_seconds,omitempty"`
+}
+
+func newListCmd(loadEngines engineLoader) *cobra.Command {
+	var format string
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List tool engines with their cached tool sets and schema age, without contacting them",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != formatTable && format != formatJSON {
+				return fmt.Errorf("invalid --format %q: want table or json", format)
+			}
+			toolSets, manager, err := loadEngines()
+			if err != nil {
+				return err
+			}
+			entries := make([]*engineEntry, 0, len(toolSets))
+			for _, toolSet := range toolSets {
+				cacheStatus, err := manager.CacheStatus(toolSet.GetName())
+				if err != nil {
+					return err
+				}
+				entries = append(entries, &engineEntry{
+					Name:             toolSet.GetName(),
+					EngineService:    toolSet.GetEngineService(),
+					ToolSets:         len(toolSet.GetToolSets()),
+					CachedToolSets:   cacheStatus.CachedToolSetCount,
+					Fingerprint:      cacheStatus.Fingerprint,
+					SchemaAgeSeconds: int64(cacheStatus.SchemaAge.Seconds()),
+				})
+			}
+			return writeEngines(cmd.OutOrStdout(), format, entries)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table or json")
+	return cmd
+}
+
+func writeEngines(w io.Writer, format string, entries []*engineEntry) error {
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tSERVICE\tCACHED\tSCHEMA\tSCHEMA AGE")
+	for _, entry := range entries {
+		fingerprint, schemaAge := "-", "-"
+		if entry.Fingerprint != "" {
+			fingerprint = entry.Fingerprint
+			schemaAge = formatAge(time.Duration(entry.SchemaAgeSeconds) * time.Second)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%d/%d\t%s\t%s\n",
+			entry.Name, entry.EngineService, entry.CachedToolSets, entry.ToolSets, fingerprint, schemaAge)
+	}
+	return writer.Flush()
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+func newPingCmd(loadEngines engineLoader) *cobra.Command {
+	return &cobra.Command{
+		Use:               "ping [engine...]",
+		Short:             "Check tool engines are reachable (all of them by default)",
+		ValidArgsFunction: completeEngines(loadEngines),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			toolSets, manager, err := loadEngines()
+			if err != nil {
+				return err
+			}
+			selected, err := selectEngines(toolSets, args)
+			if err != nil {
+				return err
+			}
+			var failures int
+			writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, toolSet := range selected {
+				roundTrip, err := manager.PingEngine(cmd.Context(), toolSet.GetName())
+				if err != nil {
+					failures++
+					fmt.Fprintf(writer, "%s\tunreachable\t%v\n", toolSet.GetName(), err)
+					continue
+				}
+				fmt.Fprintf(writer, "%s\tok\t%s\n", toolSet.GetName(), roundTrip.Round(time.Millisecond))
+			}
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if failures > 0 {
+				return fmt.Errorf("%d of %d tool engine(s) unreachable", failures, len(selected))
+			}
+			return nil
+		},
+	}
+}
+
+func newRefreshCmd(loadEngines engineLoader) *cobra.Command {
+	return &cobra.Command{
+		Use:               "refresh [engine...]",
+		Short:             "Recreate the cached tool sets of tool engines (all of them by default)",
+		ValidArgsFunction: completeEngines(loadEngines),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			toolSets, manager, err := loadEngines()
+			if err != nil {
+				return err
+			}
+			selected, err := selectEngines(toolSets, args)
+			if err != nil {
+				return err
+			}
+			for _, toolSet := range selected {
+				refreshed, err := manager.RefreshEngine(cmd.Context(), toolSet.GetName())
+				if err != nil {
+					return err
+				}
+				cacheStatus, err := manager.CacheStatus(toolSet.GetName())
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%s: refreshed %d tool set(s), schema %s\n", toolSet.GetName(), len(refreshed), cacheStatus.Fingerprint)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+func TestSelectEngines(t *testing.T) {
+	toolSets := []*sgptpb.ToolSet{{Name: "//a:engine"}, {Name: "@lib//b:engine"}}
+	if selected, err := selectEngines(toolSets, nil); err != nil || len(selected) != 2 {
+		t.Errorf("selectEngines(all) = %v, %v", selected, err)
+	}
+	if selected, err := selectEngines(toolSets, []string{"@lib//b:engine"}); err != nil || len(selected) != 1 || selected[0].GetName() != "@lib//b:engine" {
+		t.Errorf("selectEngines(one) = %v, %v", selected, err)
+	}
+	if _, err := selectEngines(toolSets, []string{"//nope"}); err == nil || !strings.Contains(err.Error(), `unknown tool engine "//nope"`) {
+		t.Errorf("selectEngines(unknown) err = %v", err)
+	}
+}
+
+func TestWriteEngines(t *testing.T) {
+	entries := []*engineEntry{
+		{Name: "//a:engine", EngineService: "engine", ToolSets: 2, CachedToolSets: 1, Fingerprint: "abc123", SchemaAgeSeconds: int64((3 * time.Hour).Seconds())},
+		{Name: "//b:engine", EngineService: "other", ToolSets: 1},
+	}
+	var b bytes.Buffer
+	if err := writeEngines(&b, formatTable, entries); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("table = %q", b.String())
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "//a:engine engine 1/2 abc123 3h" {
+		t.Errorf("initialized engine row = %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); strings.Join(fields, " ") != "//b:engine other 0/1 - -" {
+		t.Errorf("uninitialized engine row = %q", lines[2])
+	}
+}
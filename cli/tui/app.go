@@ -3,7 +3,9 @@ package tui
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -15,9 +17,12 @@ import (
 
 	"github.com/malonaz/sgpt/cli/tui/keymap"
 	"github.com/malonaz/sgpt/cli/tui/screen"
+	loresscreen "github.com/malonaz/sgpt/cli/tui/screen/lores"
 	menuscreen "github.com/malonaz/sgpt/cli/tui/screen/menu"
 	"github.com/malonaz/sgpt/cli/tui/styles"
 	"github.com/malonaz/sgpt/cli/tui/widget"
+	"github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/search"
 	"github.com/malonaz/sgpt/internal/session"
 	"github.com/malonaz/sgpt/internal/store"
 	"github.com/malonaz/sgpt/internal/tool"
@@ -26,18 +31,32 @@ import (
 
 const alertDuration = 2 * time.Second
 const menuTabID = "menu"
+const loresTabID = "lores"
+
+// maxAgentTreeRows bounds the tab bar in agent tree mode; rows scroll to keep
+// the active tab in view.
+const maxAgentTreeRows = 8
 
 type alertDismissMsg struct{}
 
 type openTabMsg struct {
 	id     string
 	screen screen.Screen
+	// agent marks a sub-agent tab, tracked until it answers.
+	agent bool
+}
+
+// agentDoneMsg delivers a sub-agent's answer to its tab.
+type agentDoneMsg struct {
+	tabID  string
+	result agentResult
 }
 
 // AgentSessionFactory builds a ready-to-run session for a sub-agent launch
 // (registry, injected files, system prompt). Lives in cli/chat, which owns
 // tool/file/role resolution.
-type AgentSessionFactory func(ctx context.Context, request *agent.LaunchRequest) (chatSession *session.Session, injectedFiles []string, err error)
+// parent is the launching session; nil when it is no longer open.
+type AgentSessionFactory func(ctx context.Context, parent *session.Session, request *agent.LaunchRequest) (chatSession *session.Session, injectedFiles []string, err error)
 
 type agentResult struct {
 	text string
@@ -47,6 +66,22 @@ type agentResult struct {
 type tab struct {
 	id     string
 	screen screen.Screen
+	// agent is set on sub-agent tabs.
+	agent *agentRun
+}
+
+// agentRun tracks a sub-agent tab's launch: its first completed turn is the
+// answer returned to the parent.
+type agentRun struct {
+	done   bool
+	result agentResult
+}
+
+// pendingClose is a tab close awaiting confirmation because sub-agents it
+// launched are still running.
+type pendingClose struct {
+	tabID           string
+	runningChildren []*session.Session
 }
 
 var (
@@ -56,6 +91,7 @@ var (
 	keyPrevTab  = keymap.New("alt+j", "Previous tab")
 	keyNextTab  = keymap.New("alt+;", "Next tab")
 	keyOpenMenu = keymap.New("alt+m", "Open menu")
+	keyLores    = keymap.New("alt+l", "Open lore browser")
 	keyCopyName = keymap.New("alt+c", "Copy chat name")
 	keyHelp     = keymap.New("alt+h", "Toggle this help")
 	keyTab1     = key.NewBinding(key.WithKeys("alt+f1"))
@@ -69,6 +105,13 @@ var (
 	keyTab9     = key.NewBinding(key.WithKeys("alt+f9"))
 )
 
+// Agent tree bindings: sub-agent tabs are navigated by their hierarchy.
+var (
+	keyAgentTree   = keymap.New("alt+g", "Toggle agent tree tab bar")
+	keyParentAgent = keymap.New("alt+u", "Jump to parent agent tab")
+	keyChildAgent  = keymap.New("alt+k", "Jump to child agent tab (cycles)")
+)
+
 var tabIndexKeys = []key.Binding{keyTab1, keyTab2, keyTab3, keyTab4, keyTab5, keyTab6, keyTab7, keyTab8, keyTab9}
 
 type App struct {
@@ -77,15 +120,36 @@ type App struct {
 	registry *tool.Registry
 
 	defaultParams session.Params
+	// searchIndex is shared with the menu, which searches it; opened chats
+	// are re-indexed as their histories load.
+	searchIndex *search.Index
 
 	agentSessionFactory AgentSessionFactory
 	agentTabCounter     atomic.Int64
 	// newTabCounter uniquifies tab IDs for chats not yet persisted.
 	newTabCounter atomic.Int64
 
+	// loreIndex backs the lore browser; nil disables it.
+	loreIndex *lore.Index
+	// loreTargetTabID is the chat tab the lore browser injects into: the
+	// last chat tab it was opened from.
+	loreTargetTabID string
+
 	tabs      []*tab
 	activeTab int
 
+	// sessions holds the session of every open tab, and of sub-agents whose
+	// tab is still opening. LaunchAgent runs off the UI loop, so it resolves
+	// the launching session here rather than in tabs.
+	sessionsMu sync.Mutex
+	sessions   []*session.Session
+
+	// agentTreeMode renders the tab bar as the agent tree.
+	agentTreeMode bool
+	// parentTabIDToLastChildTabID remembers where child-tab cycling left off.
+	parentTabIDToLastChildTabID map[string]string
+	pendingClose                *pendingClose
+
 	program *tea.Program
 	width   int
 	height  int
@@ -111,9 +175,14 @@ func NewApp(
 		store:         chatStore,
 		registry:      registry,
 		defaultParams: params,
+		searchIndex:   search.Load(),
+
+		parentTabIDToLastChildTabID: map[string]string{},
 	}
+	app.trackSession(chatSession)
 
-	menuScreen := menuscreen.New(ctx, chatStore, app.makeWrap(menuTabID))
+	menuScreen := menuscreen.New(ctx, chatStore, app.searchIndex, app.makeWrap(menuTabID))
+	menuScreen.SetLiveAgentStatus(app.liveAgentStatus)
 
 	tabID := app.tabIDForChat(chatSession.Chat())
 	chatScreen := screen.NewChatScreen(app.makeWrap(tabID), app.makeSend(tabID), chatSession)
@@ -134,21 +203,27 @@ func (a *App) SetAgentSessionFactory(factory AgentSessionFactory) {
 	a.agentSessionFactory = factory
 }
 
+func (a *App) SetLoreIndex(index *lore.Index) {
+	a.loreIndex = index
+}
+
 // LaunchAgent implements agent.Launcher. Called from a session's tool-execute
 // goroutine — never the bubbletea loop — so the tab is opened via program.Send.
-func (a *App) LaunchAgent(ctx context.Context, request *agent.LaunchRequest) (string, error) {
+func (a *App) LaunchAgent(ctx context.Context, request *agent.LaunchRequest) (*agent.LaunchResult, error) {
 	if a.agentSessionFactory == nil {
-		return "", fmt.Errorf("sub-agent launching is not configured")
+		return nil, fmt.Errorf("sub-agent launching is not configured")
 	}
-	chatSession, _, err := a.agentSessionFactory(ctx, request)
+	chatSession, _, err := a.agentSessionFactory(ctx, a.sessionForChat(request.ParentChat), request)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	a.trackSession(chatSession)
 
 	resultCh := make(chan agentResult, 1)
 	chatSession.SetOnTurnComplete(func(text string, err error) {
-		// Only the first terminal turn is the sub-agent's answer; the user may
-		// keep chatting in the tab afterwards — those completions are dropped.
+		// Only the turns awaited below are the sub-agent's answer; the user
+		// may keep chatting in the tab afterwards — those completions are
+		// dropped.
 		select {
 		case resultCh <- agentResult{text: text, err: err}:
 		default:
@@ -159,22 +234,68 @@ func (a *App) LaunchAgent(ctx context.Context, request *agent.LaunchRequest) (st
 	// unique tab ID.
 	tabID := fmt.Sprintf("agent-%d", a.agentTabCounter.Add(1))
 	chatScreen := screen.NewChatScreen(a.makeWrap(tabID), a.makeSend(tabID), chatSession)
-	a.program.Send(openTabMsg{id: tabID, screen: chatScreen})
+	a.program.Send(openTabMsg{id: tabID, screen: chatScreen, agent: true})
 
 	// SendMessage blocks for the whole turn; run it off this goroutine so we
 	// can honor ctx cancellation while waiting.
 	go chatSession.SendMessage(request.Query)
 
-	select {
-	case result := <-resultCh:
-		return result.text, result.err
-	case <-ctx.Done():
-		return "", ctx.Err()
+	for attempt := 1; ; attempt++ {
+		select {
+		case result := <-resultCh:
+			launchResult := &agent.LaunchResult{Response: result.text}
+			if result.err == nil {
+				launchResult.StructuredResponse, result.err = request.ParseResponse(result.text)
+				if result.err != nil && attempt < agent.MaxResponseAttempts {
+					// Same tab, same context: the sub-agent fixes its answer.
+					go chatSession.SendMessage(agent.ResponseRetryMessage(result.err))
+					continue
+				}
+			}
+			a.program.Send(agentDoneMsg{tabID: tabID, result: result})
+			launchResult.Price = chatSession.Price()
+			return launchResult, result.err
+		case <-ctx.Done():
+			// The parent gave up (cancelled turn, closed tab): stop the
+			// sub-agent too, and settle its tab as the answer would.
+			chatSession.CancelTurn()
+			a.program.Send(agentDoneMsg{tabID: tabID, result: agentResult{err: ctx.Err()}})
+			return &agent.LaunchResult{Price: chatSession.Price()}, ctx.Err()
+		}
 	}
 }
 
 var _ agent.Launcher = (*App)(nil)
 
+func (a *App) trackSession(chatSession *session.Session) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	a.sessions = append(a.sessions, chatSession)
+}
+
+// untrackSession forgets a session whose tab closed: it can no longer
+// launch sub-agents, nor be resolved as their parent.
+func (a *App) untrackSession(chatSession *session.Session) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	a.sessions = slices.DeleteFunc(a.sessions, func(tracked *session.Session) bool { return tracked == chatSession })
+}
+
+// sessionForChat returns the session of the named chat; nil if none.
+func (a *App) sessionForChat(chatName string) *session.Session {
+	if chatName == "" {
+		return nil
+	}
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+	for _, chatSession := range a.sessions {
+		if chatSession.Chat().GetName() == chatName {
+			return chatSession
+		}
+	}
+	return nil
+}
+
 func (a *App) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	for i, t := range a.tabs {
@@ -197,16 +318,25 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case openTabMsg:
 		cmd := a.addTab(msg.id, msg.screen)
+		if msg.agent {
+			a.tabs[len(a.tabs)-1].agent = &agentRun{}
+		}
 		return a, cmd
 
+	case agentDoneMsg:
+		for _, t := range a.tabs {
+			if t.id == msg.tabID && t.agent != nil {
+				t.agent.done = true
+				t.agent.result = msg.result
+			}
+		}
+		return a, nil
+
 	case tea.WindowSizeMsg:
 		a.width = msg.Width
 		a.height = msg.Height
 		a.ready = true
-		contentHeight := a.contentHeight()
-		for _, t := range a.tabs {
-			t.screen.SetSize(a.width, contentHeight)
-		}
+		a.resizeTabs()
 		return a, nil
 
 	case screen.TabMsg:
@@ -219,6 +349,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return a, a.openChat(innerMsg)
 				case screen.CloseTabMsg:
 					return a, a.closeTab(msg.TabID)
+				case screen.InjectFilesMsg:
+					return a, a.injectFiles(innerMsg.Paths)
 				default:
 					cmd := t.screen.Update(innerMsg)
 					return a, cmd
@@ -240,6 +372,11 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, a.closeTab(msg.TabID)
 
 	case tea.KeyPressMsg:
+		// A close confirmation swallows every key: y/n answer, anything
+		// else aborts the close.
+		if a.pendingClose != nil {
+			return a, a.answerPendingClose(msg.String())
+		}
 		// The help modal swallows every key: alt+h opens, anything closes.
 		if a.helpVisible {
 			a.helpVisible = false
@@ -277,16 +414,33 @@ func (a *App) View() tea.View {
 	}
 
 	var b strings.Builder
-	if a.alertVisible && len(a.alertQueue) > 0 {
+	// Banners overlay the first line of the tab bar, so a multi-line (agent
+	// tree) bar keeps its height and the content never jumps.
+	header := a.renderTabBar()
+	var banner string
+	if a.pendingClose != nil {
+		bannerStyle := lipgloss.NewStyle().
+			Background(styles.ErrorColor).
+			Foreground(lipgloss.Color("#000000")).
+			Bold(true).
+			Padding(0, 1)
+		banner = bannerStyle.Width(a.width).Render(fmt.Sprintf(
+			"%d sub-agent(s) still running — y: cancel them and close · n: close and leave them running · any other key: keep the tab",
+			len(a.pendingClose.runningChildren)))
+	} else if a.alertVisible && len(a.alertQueue) > 0 {
 		alertStyle := lipgloss.NewStyle().
 			Background(styles.SuccessColor).
 			Foreground(lipgloss.Color("#000000")).
 			Bold(true).
 			Padding(0, 1)
-		b.WriteString(alertStyle.Width(a.width).Render(a.alertQueue[0]))
-	} else {
-		b.WriteString(a.renderTabBar())
+		banner = alertStyle.Width(a.width).Render(a.alertQueue[0])
 	}
+	if banner != "" {
+		lines := strings.Split(header, "\n")
+		lines[0] = banner
+		header = strings.Join(lines, "\n")
+	}
+	b.WriteString(header)
 	b.WriteString("\n")
 	if a.activeTab < len(a.tabs) {
 		b.WriteString(a.tabs[a.activeTab].screen.View())
@@ -305,7 +459,7 @@ func (a *App) keymaps() []keymap.Map {
 		Name: "Global",
 		Bindings: []keymap.Binding{
 			keyHelp, keyQuit, keyNewTab, keyCloseTab, keyPrevTab, keyNextTab,
-			keyOpenMenu, keyCopyName,
+			keyOpenMenu, keyLores, keyCopyName, keyAgentTree, keyParentAgent, keyChildAgent,
 		},
 	}}
 	if a.activeTab < len(a.tabs) {
@@ -336,6 +490,16 @@ func (a *App) handleGlobalKey(msg tea.KeyPressMsg) tea.Cmd {
 		return a.switchTab(a.activeTab - 1)
 	case key.Matches(msg, keyOpenMenu.Key):
 		return a.focusMenu()
+	case key.Matches(msg, keyLores.Key):
+		return a.openLores()
+	case key.Matches(msg, keyAgentTree.Key):
+		a.agentTreeMode = !a.agentTreeMode
+		a.resizeTabs()
+		return nil
+	case key.Matches(msg, keyParentAgent.Key):
+		return a.jumpToParentAgent()
+	case key.Matches(msg, keyChildAgent.Key):
+		return a.jumpToChildAgent()
 	case key.Matches(msg, keyCopyName.Key):
 		if a.activeTab < len(a.tabs) {
 			if chatScreen, ok := a.tabs[a.activeTab].screen.(*screen.ChatScreen); ok {
@@ -382,17 +546,50 @@ func (a *App) closeTab(tabID string) tea.Cmd {
 	if a.isMenuTab(removeIndex) {
 		return nil
 	}
-	nonMenuTabs := 0
+	if runningChildren := a.runningDescendants(removeIndex); len(runningChildren) > 0 {
+		a.pendingClose = &pendingClose{tabID: a.tabs[removeIndex].id, runningChildren: runningChildren}
+		return nil
+	}
+	return a.removeTab(removeIndex)
+}
+
+// answerPendingClose resolves a close confirmation: y cancels the running
+// sub-agents and closes, n closes and leaves them running.
+func (a *App) answerPendingClose(answer string) tea.Cmd {
+	pending := a.pendingClose
+	a.pendingClose = nil
+	if answer != "y" && answer != "n" {
+		return nil
+	}
+	if answer == "y" {
+		for _, child := range pending.runningChildren {
+			child.CancelTurn()
+		}
+	}
+	for i, t := range a.tabs {
+		if t.id == pending.tabID {
+			return a.removeTab(i)
+		}
+	}
+	return nil
+}
+
+func (a *App) removeTab(removeIndex int) tea.Cmd {
+	chatTabs := 0
 	for _, t := range a.tabs {
-		if t.id != menuTabID {
-			nonMenuTabs++
+		if tabChatSession(t) != nil {
+			chatTabs++
 		}
 	}
-	if nonMenuTabs <= 1 {
+	if tabChatSession(a.tabs[removeIndex]) != nil && chatTabs <= 1 {
 		a.quitting = true
 		return tea.Quit
 	}
 	a.tabs[removeIndex].screen.OnBlur()
+	if chatSession := tabChatSession(a.tabs[removeIndex]); chatSession != nil {
+		a.untrackSession(chatSession)
+	}
+	delete(a.parentTabIDToLastChildTabID, a.tabs[removeIndex].id)
 	a.tabs = append(a.tabs[:removeIndex], a.tabs[removeIndex+1:]...)
 	if a.activeTab >= len(a.tabs) {
 		a.activeTab = len(a.tabs) - 1
@@ -400,6 +597,7 @@ func (a *App) closeTab(tabID string) tea.Cmd {
 	if a.isMenuTab(a.activeTab) && a.activeTab+1 < len(a.tabs) {
 		a.activeTab++
 	}
+	a.resizeTabs()
 	return a.tabs[a.activeTab].screen.OnFocus()
 }
 
@@ -407,9 +605,11 @@ func (a *App) addTab(id string, s screen.Screen) tea.Cmd {
 	if a.activeTab < len(a.tabs) {
 		a.tabs[a.activeTab].screen.OnBlur()
 	}
-	s.SetSize(a.width, a.contentHeight())
 	a.tabs = append(a.tabs, &tab{id: id, screen: s})
 	a.activeTab = len(a.tabs) - 1
+	// Resizes every tab, not just the new one: in agent tree mode the tab
+	// bar grew a row.
+	a.resizeTabs()
 	return tea.Batch(s.Init(), s.OnFocus())
 }
 
@@ -420,6 +620,9 @@ func (a *App) openChat(msg screen.OpenChatMsg) tea.Cmd {
 			// keep their placeholder tab ID after being persisted.
 			if chatScreen, ok := t.screen.(*screen.ChatScreen); ok &&
 				chatScreen.Session().Chat().GetName() == msg.Chat.Name {
+				if msg.MessageName != "" {
+					chatScreen.FocusMessage(msg.MessageName)
+				}
 				return a.switchTab(i)
 			}
 		}
@@ -434,6 +637,7 @@ func (a *App) openChat(msg screen.OpenChatMsg) tea.Cmd {
 			// send, so an opened-and-abandoned tab leaves no empty chat behind.
 			chat = &aipb.Chat{}
 			store.SetCurrentModel(chat, a.defaultParams.Model.Name)
+			store.SetRole(chat, a.defaultParams.Role.GetName())
 		}
 
 		// Messages are server-side resources: load the history to seed the
@@ -444,6 +648,10 @@ func (a *App) openChat(msg screen.OpenChatMsg) tea.Cmd {
 			if err != nil {
 				return screen.AlertMsg{Text: fmt.Sprintf("Loading messages failed: %v", err)}
 			}
+			if a.searchIndex.Stale(chat) {
+				a.searchIndex.Update(chat, messages)
+				a.searchIndex.Save()
+			}
 		}
 
 		params := a.defaultParams
@@ -451,7 +659,11 @@ func (a *App) openChat(msg screen.OpenChatMsg) tea.Cmd {
 		tabID := a.tabIDForChat(chat)
 
 		chatSession := session.New(a.ctx, a.store, a.registry, chat, messages, params)
+		a.trackSession(chatSession)
 		chatScreen := screen.NewChatScreen(a.makeWrap(tabID), a.makeSend(tabID), chatSession)
+		if msg.MessageName != "" {
+			chatScreen.FocusMessage(msg.MessageName)
+		}
 		return openTabMsg{id: tabID, screen: chatScreen}
 	}
 }
@@ -469,6 +681,48 @@ func (a *App) focusMenu() tea.Cmd {
 	return nil
 }
 
+// openLores focuses the lore browser, opening it on first use. Opened from a
+// chat tab, it injects into that chat.
+func (a *App) openLores() tea.Cmd {
+	if a.loreIndex == nil {
+		return a.showAlert("Lores are not available")
+	}
+	if tabChatSession(a.tabs[a.activeTab]) != nil {
+		a.loreTargetTabID = a.tabs[a.activeTab].id
+	}
+	for i, t := range a.tabs {
+		if t.id == loresTabID {
+			return a.switchTab(i)
+		}
+	}
+	return a.addTab(loresTabID, loresscreen.New(a.loreIndex, a.makeWrap(loresTabID)))
+}
+
+// injectFiles adds files to the lore browser's target chat — or, once that
+// tab is gone, the last chat tab — and switches to it.
+func (a *App) injectFiles(paths []string) tea.Cmd {
+	targetIndex := -1
+	for i, t := range a.tabs {
+		if tabChatSession(t) == nil {
+			continue
+		}
+		targetIndex = i
+		if t.id == a.loreTargetTabID {
+			break
+		}
+	}
+	if targetIndex < 0 {
+		return a.showAlert("No chat to inject into")
+	}
+	chatSession := tabChatSession(a.tabs[targetIndex])
+	title := a.tabs[targetIndex].screen.ShortTitle()
+	return tea.Batch(a.switchTab(targetIndex), func() tea.Msg {
+		// SetInjectedFiles performs RPCs — off the UI loop.
+		chatSession.SetInjectedFiles(append(chatSession.InjectedFiles(), paths...))
+		return screen.AlertMsg{Text: fmt.Sprintf("Injected %s into %s", strings.Join(paths, ", "), title)}
+	})
+}
+
 func (a *App) showAlert(text string) tea.Cmd {
 	a.alertQueue = append(a.alertQueue, text)
 	if a.alertVisible {
@@ -517,7 +771,17 @@ func (a *App) contentHeight() int {
 	return a.height - lipgloss.Height(a.renderTabBar()) - 1
 }
 
+func (a *App) resizeTabs() {
+	contentHeight := a.contentHeight()
+	for _, t := range a.tabs {
+		t.screen.SetSize(a.width, contentHeight)
+	}
+}
+
 func (a *App) renderTabBar() string {
+	if a.agentTreeMode {
+		return widget.RenderAgentTree(a.agentNodes(), a.width, maxAgentTreeRows)
+	}
 	var tabs []widget.Tab
 	for i, t := range a.tabs {
 		streaming := false
@@ -533,3 +797,153 @@ func (a *App) renderTabBar() string {
 	}
 	return widget.RenderTabBar(tabs, a.width)
 }
+
+// tabChatSession returns the session behind a chat tab; nil for the menu.
+func tabChatSession(t *tab) *session.Session {
+	if chatScreen, ok := t.screen.(*screen.ChatScreen); ok {
+		return chatScreen.Session()
+	}
+	return nil
+}
+
+// tabAgentStatus derives a tab's status from its live session, falling back
+// on its launch for sub-agents between turns.
+func tabAgentStatus(t *tab, chatSession *session.Session) widget.AgentStatus {
+	switch chatSession.State() {
+	case session.StateAwaitingReview, session.StateAwaitingBudget:
+		return widget.AgentStatusAwaitingReview
+	case session.StateStreaming, session.StateExecutingTools:
+		return widget.AgentStatusRunning
+	}
+	switch {
+	case t.agent == nil:
+		return widget.AgentStatusIdle
+	case !t.agent.done:
+		return widget.AgentStatusRunning
+	case t.agent.result.err != nil:
+		return widget.AgentStatusFailed
+	default:
+		return widget.AgentStatusDone
+	}
+}
+
+// liveAgentStatus reports the status of the tab running chatName, if any.
+func (a *App) liveAgentStatus(chatName string) (widget.AgentStatus, bool) {
+	for _, t := range a.tabs {
+		if chatSession := tabChatSession(t); chatSession != nil && chatSession.Chat().GetName() == chatName {
+			return tabAgentStatus(t, chatSession), true
+		}
+	}
+	return widget.AgentStatusIdle, false
+}
+
+// agentNodes describes every tab as a node of the agent tree.
+func (a *App) agentNodes() []*widget.AgentNode {
+	nodes := make([]*widget.AgentNode, 0, len(a.tabs))
+	for i, t := range a.tabs {
+		node := &widget.AgentNode{ID: t.id, Title: t.screen.ShortTitle(), Active: i == a.activeTab}
+		if chatSession := tabChatSession(t); chatSession != nil {
+			chat := chatSession.Chat()
+			node.ChatID = chatID(chat)
+			node.ParentChatID = store.ParentChatID(chat)
+			node.Status = tabAgentStatus(t, chatSession)
+			node.Price = chatSession.Price()
+			if t.agent != nil && t.agent.done {
+				node.Result = t.agent.result.text
+				if t.agent.result.err != nil {
+					node.Result = t.agent.result.err.Error()
+				}
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// chatID is the ID segment of a chat's name; empty until persisted.
+func chatID(chat *aipb.Chat) string {
+	name := chat.GetName()
+	if name == "" {
+		return ""
+	}
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// childTabIndexes returns the tabs of the sub-agents launched by the tab at
+// index, in tab order.
+func (a *App) childTabIndexes(index int) []int {
+	parentSession := tabChatSession(a.tabs[index])
+	if parentSession == nil {
+		return nil
+	}
+	parentChatID := chatID(parentSession.Chat())
+	if parentChatID == "" {
+		return nil
+	}
+	var childIndexes []int
+	for i, t := range a.tabs {
+		if chatSession := tabChatSession(t); chatSession != nil && i != index &&
+			store.ParentChatID(chatSession.Chat()) == parentChatID {
+			childIndexes = append(childIndexes, i)
+		}
+	}
+	return childIndexes
+}
+
+// runningDescendants returns the sessions of the sub-agents, at any depth,
+// below the tab at index that still have a turn in flight.
+func (a *App) runningDescendants(index int) []*session.Session {
+	var running []*session.Session
+	visitedIndexSet := map[int]bool{index: true}
+	queue := []int{index}
+	for len(queue) > 0 {
+		for _, childIndex := range a.childTabIndexes(queue[0]) {
+			if visitedIndexSet[childIndex] {
+				continue
+			}
+			visitedIndexSet[childIndex] = true
+			queue = append(queue, childIndex)
+			if chatSession := tabChatSession(a.tabs[childIndex]); chatSession.TurnInFlight() {
+				running = append(running, chatSession)
+			}
+		}
+		queue = queue[1:]
+	}
+	return running
+}
+
+func (a *App) jumpToParentAgent() tea.Cmd {
+	chatSession := tabChatSession(a.tabs[a.activeTab])
+	if chatSession == nil {
+		return nil
+	}
+	parentChatID := store.ParentChatID(chatSession.Chat())
+	if parentChatID == "" {
+		return a.showAlert("Not a sub-agent chat")
+	}
+	for i, t := range a.tabs {
+		if parentSession := tabChatSession(t); parentSession != nil && chatID(parentSession.Chat()) == parentChatID {
+			return a.switchTab(i)
+		}
+	}
+	return a.showAlert("The parent chat is not open")
+}
+
+// jumpToChildAgent switches to the active tab's next sub-agent tab, cycling
+// through them on repeated presses from the parent.
+func (a *App) jumpToChildAgent() tea.Cmd {
+	childIndexes := a.childTabIndexes(a.activeTab)
+	if len(childIndexes) == 0 {
+		return a.showAlert("No sub-agent tabs for this chat")
+	}
+	parentTabID := a.tabs[a.activeTab].id
+	next := childIndexes[0]
+	for i, childIndex := range childIndexes {
+		if a.tabs[childIndex].id == a.parentTabIDToLastChildTabID[parentTabID] {
+			next = childIndexes[(i+1)%len(childIndexes)]
+			break
+		}
+	}
+	a.parentTabIDToLastChildTabID[parentTabID] = a.tabs[next].id
+	return a.switchTab(next)
+}
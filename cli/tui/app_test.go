@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	"github.com/malonaz/sgpt/cli/tui/screen"
+	"github.com/malonaz/sgpt/cli/tui/widget"
+	"github.com/malonaz/sgpt/internal/session"
+	"github.com/malonaz/sgpt/internal/store"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+func newChatTab(id string, chat *aipb.Chat) *tab {
+	chatSession := session.New(context.Background(), nil, tool.NewRegistry(), chat, nil, session.Params{})
+	return &tab{id: id, screen: screen.NewChatScreen(nil, nil, chatSession)}
+}
+
+func TestAgentTreeParentCancelled(t *testing.T) {
+	parentChat := &aipb.Chat{Name: "organizations/o/users/u/chats/parent"}
+	childChat := &aipb.Chat{Name: "organizations/o/users/u/chats/child"}
+	store.SetParentChatID(childChat, parentChat.GetName())
+	childTab := newChatTab("agent-1", childChat)
+	childTab.agent = &agentRun{}
+	a := &App{tabs: []*tab{newChatTab("parent", parentChat), childTab}}
+
+	childNode := func() *widget.AgentNode {
+		for _, node := range a.agentNodes() {
+			if node.ID == childTab.id {
+				return node
+			}
+		}
+		t.Fatal("no node for the sub-agent tab")
+		return nil
+	}
+	if node := childNode(); node.Status != widget.AgentStatusRunning || node.ParentChatID != "parent" {
+		t.Fatalf("sub-agent node = %+v, want running under parent", node)
+	}
+
+	// The parent's turn is cancelled mid-run: LaunchAgent settles the tab
+	// with the parent's error.
+	a.Update(agentDoneMsg{tabID: childTab.id, result: agentResult{err: context.Canceled}})
+	if node := childNode(); node.Status != widget.AgentStatusFailed || node.Result != context.Canceled.Error() {
+		t.Errorf("sub-agent node = %+v, want failed with %q", node, context.Canceled)
+	}
+}
@@ -13,7 +13,9 @@ type ClosedMsg struct {
 	Modified bool
 }
 
-func Open(content, extension string) tea.Cmd {
+// Command returns the command opening path in the user's editor: $EDITOR,
+// else $VISUAL, else vim. Nil when the setting is blank.
+func Command(path string) *exec.Cmd {
 	editor := os.Getenv("EDITOR")
 	if editor == "" {
 		editor = os.Getenv("VISUAL")
@@ -25,7 +27,10 @@ func Open(content, extension string) tea.Cmd {
 	if len(editorArgs) == 0 {
 		return nil
 	}
+	return exec.Command(editorArgs[0], append(editorArgs[1:], path)...)
+}
 
+func Open(content, extension string) tea.Cmd {
 	tmpFile, err := os.CreateTemp("", "sgpt-*."+extension)
 	if err != nil {
 		return nil
@@ -39,8 +44,11 @@ func Open(content, extension string) tea.Cmd {
 	info, _ := os.Stat(tmpPath)
 	modTimeBefore := info.ModTime()
 
-	args := append(editorArgs[1:], tmpPath)
-	c := exec.Command(editorArgs[0], args...)
+	c := Command(tmpPath)
+	if c == nil {
+		os.Remove(tmpPath)
+		return nil
+	}
 	return tea.ExecProcess(c, func(err error) tea.Msg {
 		defer os.Remove(tmpPath)
 		info, statErr := os.Stat(tmpPath)
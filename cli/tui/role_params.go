@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/textarea"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/malonaz/sgpt/cli/tui/styles"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// roleParamsModel asks for role parameters one at a time, before the chat
+// starts: the role's prompt cannot be rendered without them.
+type roleParamsModel struct {
+	roleName   string
+	parameters []*sgptpb.Role_Parameter
+	values     map[string]string
+	index      int
+	input      textarea.Model
+	cancelled  bool
+}
+
+func (m *roleParamsModel) Init() tea.Cmd {
+	return textarea.Blink
+}
+
+func (m *roleParamsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.input.SetWidth(max(20, msg.Width-4))
+		return m, nil
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.cancelled = true
+			return m, tea.Quit
+		case "enter":
+			value := strings.TrimSpace(m.input.Value())
+			if value == "" {
+				return m, nil
+			}
+			m.values[m.parameters[m.index].GetName()] = value
+			m.index++
+			if m.index == len(m.parameters) {
+				return m, tea.Quit
+			}
+			m.input.Reset()
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *roleParamsModel) View() tea.View {
+	if m.index == len(m.parameters) || m.cancelled {
+		return tea.NewView("")
+	}
+	var b strings.Builder
+	b.WriteString(styles.TitleStyle.Render(fmt.Sprintf(" Role %s needs parameters (%d/%d) ", m.roleName, m.index+1, len(m.parameters))))
+	b.WriteString("\n\n")
+	b.WriteString(styles.ToolLabelStyle.Render(m.parameters[m.index].GetName()))
+	b.WriteString("\n")
+	b.WriteString(m.input.View())
+	b.WriteString("\n")
+	b.WriteString(styles.HelpStyle.Render("Enter: confirm │ Esc: cancel"))
+	return tea.NewView(b.String())
+}
+
+// PromptRoleParameters asks the user for the given role parameters and
+// returns their values by name.
+func PromptRoleParameters(ctx context.Context, roleName string, parameters []*sgptpb.Role_Parameter) (map[string]string, error) {
+	input := textarea.New()
+	input.ShowLineNumbers = false
+	input.Prompt = "> "
+	input.SetHeight(1)
+	input.SetWidth(styles.DefaultTextareaWidth)
+	input.Focus()
+	model := &roleParamsModel{
+		roleName:   roleName,
+		parameters: parameters,
+		values:     map[string]string{},
+		input:      input,
+	}
+	if _, err := tea.NewProgram(model, tea.WithContext(ctx)).Run(); err != nil {
+		return nil, fmt.Errorf("prompting for role parameters: %w", err)
+	}
+	if model.cancelled {
+		return nil, fmt.Errorf("role parameters prompt cancelled")
+	}
+	return model.values, nil
+}
@@ -2,6 +2,9 @@ package screen
 
 import (
 	"fmt"
+	"math"
+	"os"
+	"path"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -9,13 +12,17 @@ import (
 	"charm.land/bubbles/v2/textarea"
 	tea "charm.land/bubbletea/v2"
 	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"golang.design/x/clipboard"
+	grpcstatus "google.golang.org/grpc/status"
 
 	"github.com/malonaz/sgpt/cli/tui/editor"
 	"github.com/malonaz/sgpt/cli/tui/keymap"
 	"github.com/malonaz/sgpt/cli/tui/styles"
 	"github.com/malonaz/sgpt/cli/tui/timeline"
 	"github.com/malonaz/sgpt/cli/tui/widget"
+	"github.com/malonaz/sgpt/internal/export"
 	"github.com/malonaz/sgpt/internal/file"
+	"github.com/malonaz/sgpt/internal/gitcontext"
 	"github.com/malonaz/sgpt/internal/session"
 )
 
@@ -30,6 +37,13 @@ type sessionEventMsg struct {
 	event session.Event
 }
 
+// fileEstimatesMsg carries the token estimates of a file picker's
+// candidates, computed off the UI loop.
+type fileEstimatesMsg struct {
+	picker       *widget.Picker
+	pathToTokens map[string]int
+}
+
 var (
 	chatKeyCycleFocus     = keymap.New("tab", "Toggle input/timeline focus")
 	chatKeySubmit         = keymap.New("ctrl+j", "Send message / review tool call")
@@ -41,8 +55,11 @@ var (
 	chatKeyCycleReasoning = keymap.New("alt+t", "Cycle reasoning effort")
 	chatKeyToggleFavorite = keymap.New("alt+shift+f", "Toggle favorite")
 	chatKeyOpenAll        = keymap.New("alt+shift+o", "Open entire chat in $EDITOR")
+	chatKeyExport         = keymap.New("alt+x", "Export chat to markdown and HTML in the working directory")
 	chatKeyPickTools      = keymap.New("alt+shift+t", "Select/unselect tools (fuzzy)")
 	chatKeyPickFiles      = keymap.New("alt+shift+e", "Select/unselect files (fuzzy)")
+	chatKeyPasteImage     = keymap.New("alt+v", "Attach image from clipboard")
+	chatKeyToggleLive     = keymap.New("alt+shift+l", "Toggle live refresh of the selected injected file")
 	chatKeyDeleteMessage  = keymap.New("alt+d", "Delete selected message from the chat")
 	chatKeyInfo           = keymap.New("alt+i", "Show chat info (context, tokens, cost)")
 )
@@ -64,6 +81,9 @@ type ChatScreen struct {
 	// keys; pickerApply consumes its selection on confirm.
 	picker      *widget.Picker
 	pickerApply func(selected []string) tea.Cmd
+	// filePathToTokens holds the file picker's token estimates, empty until
+	// they arrive.
+	filePathToTokens map[string]int
 
 	// info, when non-nil, is the chat info modal: a read-only snapshot that
 	// swallows every key (any key closes it).
@@ -76,6 +96,9 @@ type ChatScreen struct {
 	focusedComponent FocusedComponent
 	// lastState detects the transition into review, to auto-jump exactly once.
 	lastState session.State
+	// pendingFocusMessage is a message to select once the timeline holds it,
+	// e.g. a search hit the chat was opened on.
+	pendingFocusMessage string
 }
 
 func NewChatScreen(
@@ -147,8 +170,8 @@ func (m *ChatScreen) Keymaps() []keymap.Map {
 			chatKeySubmit, chatKeyAccept, chatKeyAcceptAll, chatKeyAlwaysAccept,
 			chatKeyReject, chatKeyCancel, chatKeyCycleFocus,
 			chatKeyCycleReasoning, chatKeyToggleFavorite,
-			chatKeyOpenAll, chatKeyPickTools, chatKeyPickFiles,
-			chatKeyDeleteMessage, chatKeyInfo,
+			chatKeyOpenAll, chatKeyExport, chatKeyPickTools, chatKeyPickFiles,
+			chatKeyPasteImage, chatKeyToggleLive, chatKeyDeleteMessage, chatKeyInfo,
 		}},
 		timeline.Keymap(),
 		widget.InputKeymap(),
@@ -208,6 +231,18 @@ func (m *ChatScreen) Update(msg tea.Msg) tea.Cmd {
 	case sessionEventMsg:
 		return tea.Batch(m.handleSessionEvent(msg.event), m.listenForSessionEvents())
 
+	case fileEstimatesMsg:
+		// Estimates for a picker closed since are dropped.
+		if m.picker == msg.picker {
+			m.filePathToTokens = msg.pathToTokens
+			pathToNote := make(map[string]string, len(msg.pathToTokens))
+			for path, tokens := range msg.pathToTokens {
+				pathToNote[path] = "~" + file.FormatTokens(tokens)
+			}
+			m.picker.SetNotes(pathToNote)
+		}
+		return nil
+
 	case editor.ClosedMsg:
 		if m.focusedComponent == FocusTextarea {
 			if msg.Modified {
@@ -293,10 +328,16 @@ func (m *ChatScreen) handleKeyPress(msg tea.KeyPressMsg) tea.Cmd {
 		return m.toggleFavorite()
 	case key.Matches(msg, chatKeyOpenAll.Key):
 		return editor.Open(timeline.ConversationText(m.session.Messages()), "md")
+	case key.Matches(msg, chatKeyExport.Key):
+		return m.exportChat()
 	case key.Matches(msg, chatKeyPickTools.Key):
 		return m.openToolPicker()
 	case key.Matches(msg, chatKeyPickFiles.Key):
 		return m.openFilePicker()
+	case key.Matches(msg, chatKeyPasteImage.Key):
+		return m.attachClipboardImage()
+	case key.Matches(msg, chatKeyToggleLive.Key):
+		return m.toggleSelectedLiveFile()
 	case key.Matches(msg, chatKeyDeleteMessage.Key):
 		return m.deleteSelectedMessage()
 	case key.Matches(msg, chatKeyInfo.Key):
@@ -308,6 +349,18 @@ func (m *ChatScreen) handleKeyPress(msg tea.KeyPressMsg) tea.Cmd {
 
 	switch msg.String() {
 	case "ctrl+c":
+		// Stopping at a budget cap ends the turn as exceeded rather than
+		// cancelled, so the timeline says why it stopped.
+		if m.session.BudgetPauseReason() != "" {
+			m.session.StopAtBudget()
+			return nil
+		}
+		// Giving up on a retry ends the turn with the transient error, not as
+		// cancelled.
+		if m.session.RetryStatus() != nil {
+			m.session.StopRetrying()
+			return nil
+		}
 		// TurnInFlight covers the whole turn — pre-stream window, streaming,
 		// tool execution and review pauses; CancelTurn aborts it wherever it
 		// is and the turn resolves cleanly (cancelled tool results, inputs
@@ -333,10 +386,20 @@ func (m *ChatScreen) handleKeyPress(msg tea.KeyPressMsg) tea.Cmd {
 }
 
 func (m *ChatScreen) submit() tea.Cmd {
-	// An empty ctrl+j while a call awaits review approves it. Composed text
+	// An empty ctrl+j while a call awaits review approves it, one while the
+	// turn is paused at a budget cap continues past it, and one while it
+	// waits to retry a failed generation retries at once. Composed text
 	// is always a message — an interjection queued for the next generation
 	// when a turn is in flight — never an implicit verdict. Rejection stays
 	// explicit (alt+shift+r).
+	if m.session.BudgetPauseReason() != "" && m.input.Value() == "" {
+		m.session.ContinuePastBudget()
+		return nil
+	}
+	if m.session.RetryStatus() != nil && m.input.Value() == "" {
+		m.session.RetryNow()
+		return nil
+	}
 	pendingToolCallID := m.reviewTarget()
 	if pendingToolCallID != "" && m.input.Value() == "" {
 		m.session.ApproveToolCall(pendingToolCallID)
@@ -460,6 +523,33 @@ func (m *ChatScreen) maybeJumpToReview() {
 	}
 }
 
+// FocusMessage selects the given message's first item in the timeline, now or
+// as soon as the screen is laid out.
+func (m *ChatScreen) FocusMessage(messageName string) {
+	m.pendingFocusMessage = messageName
+	if m.ready {
+		m.applyPendingFocus()
+	}
+}
+
+func (m *ChatScreen) applyPendingFocus() {
+	messageName := m.pendingFocusMessage
+	if messageName == "" {
+		return
+	}
+	found := m.timeline.SelectFunc(func(item timeline.Item) bool {
+		messageOwned, ok := item.(timeline.MessageOwned)
+		return ok && messageOwned.MessageName() == messageName
+	})
+	if !found {
+		return
+	}
+	m.pendingFocusMessage = ""
+	m.focusedComponent = FocusViewport
+	m.input.Blur()
+	m.timeline.SetFocused(true)
+}
+
 // focusToolCall moves timeline focus onto the given call's item.
 func (m *ChatScreen) focusToolCall(toolCallID string) {
 	m.focusedComponent = FocusViewport
@@ -484,6 +574,34 @@ func (m *ChatScreen) toggleFavorite() tea.Cmd {
 	}
 }
 
+// exportChat writes the chat as markdown and HTML into the working directory,
+// named after the chat ID, with tool calls rendered as in the timeline.
+// Injected context and the system prompt are left out, as with `sgpt export`.
+func (m *ChatScreen) exportChat() tea.Cmd {
+	chat := m.session.Chat()
+	messages := m.session.Messages()
+	opts := &export.Options{Renderer: m.session.Registry()}
+	wrap := m.wrap
+	return func() tea.Msg {
+		if chat.GetName() == "" {
+			return wrap(AlertMsg{Text: "Nothing to export: the chat is empty"})
+		}
+		var paths []string
+		for _, format := range []string{export.FormatMarkdown, export.FormatHTML} {
+			content, err := export.Render(format, chat, messages, opts)
+			if err != nil {
+				return wrap(AlertMsg{Text: fmt.Sprintf("Export failed: %v", err)})
+			}
+			exportPath := fmt.Sprintf("sgpt-%s.%s", path.Base(chat.GetName()), format)
+			if err := os.WriteFile(exportPath, content, 0644); err != nil {
+				return wrap(AlertMsg{Text: fmt.Sprintf("Export failed: %v", err)})
+			}
+			paths = append(paths, exportPath)
+		}
+		return wrap(AlertMsg{Text: "Exported to " + strings.Join(paths, ", ")})
+	}
+}
+
 func (m *ChatScreen) handlePickerKey(msg tea.KeyPressMsg) tea.Cmd {
 	done, canceled := m.picker.HandleKey(msg)
 	if !done {
@@ -539,13 +657,33 @@ func (m *ChatScreen) openFilePicker() tea.Cmd {
 		injectedPathSet[path] = true
 		items = append(items, widget.PickerItem{Label: path, Selected: true})
 	}
-	for _, path := range file.Discover(".", 2000) {
+	for _, path := range append(gitcontext.Suggestions(), file.Discover(".", 2000)...) {
 		if !injectedPathSet[path] {
 			items = append(items, widget.PickerItem{Label: path})
 		}
 	}
 	m.picker = widget.NewPicker("📎 Files", items)
 	m.picker.SetSize(m.width, m.height)
+	m.filePathToTokens = nil
+	contextLimit := int(m.session.Info().ContextLimit)
+	m.picker.SetSummary(func(selected []string) string {
+		if m.filePathToTokens == nil {
+			return "Estimating tokens..."
+		}
+		var total int
+		for _, path := range selected {
+			total += m.filePathToTokens[path]
+		}
+		if contextLimit <= 0 {
+			return fmt.Sprintf("~%s tokens selected (context window unknown)", file.FormatTokens(total))
+		}
+		summary := fmt.Sprintf("~%s tokens selected · %.0f%% of the %s context window",
+			file.FormatTokens(total), float64(total)/float64(contextLimit)*100, file.FormatTokens(contextLimit))
+		if float64(total) > float64(contextLimit)*file.ContextBudgetRatio {
+			summary = "⚠ " + summary + " — over the injection budget"
+		}
+		return summary
+	})
 	m.pickerApply = func(selected []string) tea.Cmd {
 		sess := m.session
 		// SetInjectedFiles performs RPCs (soft-deleting removed file
@@ -555,7 +693,72 @@ func (m *ChatScreen) openFilePicker() tea.Cmd {
 			return nil
 		}
 	}
-	return nil
+	// Estimating reads attachments in full (images, PDFs): thousands of
+	// candidates would stall the UI. Virtual injections have no file to
+	// estimate.
+	paths := make([]string, 0, len(items))
+	for _, item := range items {
+		paths = append(paths, item.Label)
+	}
+	picker, wrap := m.picker, m.wrap
+	return func() tea.Msg {
+		pathToTokens := make(map[string]int, len(paths))
+		for _, path := range paths {
+			if tokens, err := file.EstimateFileTokens(path); err == nil {
+				pathToTokens[path] = tokens
+			}
+		}
+		return wrap(fileEstimatesMsg{picker: picker, pathToTokens: pathToTokens})
+	}
+}
+
+// attachClipboardImage injects the clipboard's image like any file: it is
+// saved under the cache directory first, so it has a path to persist with
+// the chat.
+func (m *ChatScreen) attachClipboardImage() tea.Cmd {
+	if err := clipboard.Init(); err != nil {
+		return m.alert(fmt.Sprintf("Clipboard unavailable: %v", err))
+	}
+	content := clipboard.Read(clipboard.FmtImage)
+	if len(content) == 0 {
+		return m.alert("No image in the clipboard")
+	}
+	path, err := file.SaveAttachment(content)
+	if err != nil {
+		return m.alert(fmt.Sprintf("Attaching clipboard image failed: %v", err))
+	}
+	description := file.DescribeAttachment(file.AttachmentMediaType(content), content)
+	sess, wrap := m.session, m.wrap
+	// SetInjectedFiles performs RPCs — off the UI loop.
+	return func() tea.Msg {
+		sess.SetInjectedFiles(append(sess.InjectedFiles(), path))
+		return wrap(AlertMsg{Text: "Attached " + description})
+	}
+}
+
+// toggleSelectedLiveFile makes the injected file under the timeline cursor
+// live, or static again: a live file's changes reach the model before each
+// turn.
+func (m *ChatScreen) toggleSelectedLiveFile() tea.Cmd {
+	if m.focusedComponent != FocusViewport {
+		return m.alert("Toggling a live file needs timeline focus — tab to navigate, then alt+shift+l")
+	}
+	filePath := m.timeline.SelectedInjectedFilePath()
+	if filePath == "" {
+		return m.alert("No injected file selected")
+	}
+	sess, wrap := m.session, m.wrap
+	live := !sess.IsLiveFile(filePath)
+	// SetLiveFile updates the persisted injection — off the UI loop.
+	return func() tea.Msg {
+		if err := sess.SetLiveFile(filePath, live); err != nil {
+			return wrap(AlertMsg{Text: fmt.Sprintf("Toggling live file failed: %v", err)})
+		}
+		if live {
+			return wrap(AlertMsg{Text: path.Base(filePath) + " is live: its changes reach the model before each turn"})
+		}
+		return wrap(AlertMsg{Text: path.Base(filePath) + " is no longer live"})
+	}
 }
 
 func (m *ChatScreen) cycleFocus() tea.Cmd {
@@ -606,6 +809,7 @@ func (m *ChatScreen) buildItems() []timeline.Item {
 		m.session.Messages(),
 		m.session.StreamingMessage(),
 		m.session.ExecutingToolCallID(),
+		m.session.ToolCallProgress(),
 		m.session.PendingToolCallIDs(),
 		m.session.Registry(),
 	)
@@ -616,6 +820,9 @@ func (m *ChatScreen) buildItems() []timeline.Item {
 			items = append(items, timeline.NewErrorItem("stream-error", err.Error()))
 		}
 	}
+	if retryStatus := m.session.RetryStatus(); retryStatus != nil {
+		items = append(items, timeline.NewRetryItem("generation-retry", retryText(retryStatus)))
+	}
 	// Interjections render at the bottom until the turn consumes them into
 	// the history proper.
 	for i, queuedMessage := range m.session.QueuedMessages() {
@@ -624,6 +831,19 @@ func (m *ChatScreen) buildItems() []timeline.Item {
 	return items
 }
 
+// retryText describes a pending generation retry with its countdown.
+func retryText(retryStatus *session.RetryStatus) string {
+	modelResourceName := &aipb.ModelResourceName{}
+	modelResourceName.UnmarshalString(retryStatus.Model)
+	model := fmt.Sprintf("%s/%s", modelResourceName.Provider, modelResourceName.Model)
+	seconds := int(math.Ceil(retryStatus.Remaining.Seconds()))
+	action := fmt.Sprintf("retrying %s in %ds (attempt %d/%d)", model, seconds, retryStatus.Attempt, retryStatus.MaxAttempts)
+	if retryStatus.Fallback {
+		action = fmt.Sprintf("falling back to %s in %ds", model, seconds)
+	}
+	return fmt.Sprintf("%s: %s — ctrl+j: retry now, ctrl+c: stop retrying", grpcstatus.Code(retryStatus.Err), action)
+}
+
 // messageText joins a message's text blocks for single-line display.
 func messageText(message *aipb.Message) string {
 	var parts []string
@@ -644,9 +864,18 @@ func (m *ChatScreen) refresh() {
 	if wasAtBottom {
 		m.timeline.GotoBottom()
 	}
+	m.applyPendingFocus()
 }
 
 func (m *ChatScreen) refreshPlaceholder() {
+	if reason := m.session.BudgetPauseReason(); reason != "" {
+		m.input.Textarea.Placeholder = fmt.Sprintf("budget reached: %s — ctrl+j: continue, ctrl+c: stop", reason)
+		return
+	}
+	if m.session.RetryStatus() != nil {
+		m.input.Textarea.Placeholder = "generation failed — ctrl+j: retry now, ctrl+c: stop retrying"
+		return
+	}
 	if toolCallID := m.reviewTarget(); toolCallID != "" {
 		m.input.Textarea.Placeholder = fmt.Sprintf(
 			"reviewing %s — ctrl+j: accept, alt+shift+r: reject (input = reason), alt+shift+a: always accept",
@@ -657,7 +886,7 @@ func (m *ChatScreen) refreshPlaceholder() {
 }
 
 func (m *ChatScreen) refreshTitle() {
-	m.titlebar.Refresh(m.session.Params(), m.session.TotalModelUsage(), m.session.LastModelUsage(), m.session.Price())
+	m.titlebar.Refresh(m.session.Params(), m.session.TotalModelUsage(), m.session.LastModelUsage(), m.session.Price(), m.session.Budget())
 }
 
 func (m *ChatScreen) recalculateLayout() {
@@ -685,6 +914,7 @@ func (m *ChatScreen) recalculateLayout() {
 		m.ready = true
 		m.refresh()
 		m.timeline.GotoBottom()
+		m.applyPendingFocus()
 	}
 }
 
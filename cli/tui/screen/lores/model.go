@@ -0,0 +1,259 @@
+// Package lores implements the lore browser tab: every reachable lore
+// library, filterable, with a rendered preview, and lores injectable into
+// the current chat as files.
+package lores
+
+import (
+	"regexp"
+
+	"charm.land/bubbles/v2/textarea"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/malonaz/sgpt/cli/tui/screen"
+	"github.com/malonaz/sgpt/cli/tui/styles"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/markdown"
+)
+
+// searchLimit caps the lores listed for a filter; libraries are small, so
+// this only trims the long tail of content-only matches.
+const searchLimit = 100
+
+type FocusTarget int
+
+const (
+	FocusFilter FocusTarget = iota
+	FocusList
+)
+
+type loresLoadedMsg struct {
+	lores []*sgptpb.Lore
+	err   error
+}
+
+type Model struct {
+	index *lore.Index
+	wrap  screen.WrapFunc
+
+	lores   []*sgptpb.Lore
+	loading bool
+	err     error
+
+	filterInput textarea.Model
+	filterText  string
+	// displayed is lores narrowed by the filter, best matches first.
+	displayed []*sgptpb.Lore
+
+	cursor      int
+	focusTarget FocusTarget
+	listYOffset int
+	listHeight  int
+
+	// previewCache memoizes each lore's rendered markdown, keyed by name;
+	// invalidated on resize and refresh.
+	previewCache   map[string]string
+	renderer       *markdown.Renderer
+	detailViewport viewport.Model
+	width          int
+	height         int
+	ready          bool
+	focused        bool
+}
+
+func New(index *lore.Index, wrap screen.WrapFunc) *Model {
+	filterInput := textarea.New()
+	filterInput.Placeholder = "Filter lores (regexp over titles, labels and content)..."
+	filterInput.CharLimit = 256
+	filterInput.SetHeight(1)
+	filterInput.ShowLineNumbers = false
+	filterInput.Prompt = "/ "
+
+	renderer, _ := markdown.NewRenderer(styles.DefaultTextareaWidth)
+
+	return &Model{
+		index:        index,
+		wrap:         wrap,
+		filterInput:  filterInput,
+		renderer:     renderer,
+		previewCache: map[string]string{},
+		focusTarget:  FocusFilter,
+	}
+}
+
+func (m *Model) Init() tea.Cmd {
+	return m.loadLores()
+}
+
+func (m *Model) Title() string {
+	return "Lores"
+}
+
+func (m *Model) ShortTitle() string {
+	return "Lores"
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+	m.recalculateLayout()
+}
+
+func (m *Model) OnFocus() tea.Cmd {
+	m.focused = true
+	return m.applyFocus()
+}
+
+func (m *Model) OnBlur() {
+	m.focused = false
+	m.filterInput.Blur()
+}
+
+func (m *Model) applyFocus() tea.Cmd {
+	m.filterInput.Blur()
+	if m.focusTarget == FocusFilter {
+		m.filterInput.Focus()
+		return textarea.Blink
+	}
+	return nil
+}
+
+// loadLores reads every library off the UI loop: imports may live on slow
+// disks, and a library is a directory walk.
+func (m *Model) loadLores() tea.Cmd {
+	m.loading = true
+	index := m.index
+	wrap := m.wrap
+	return func() tea.Msg {
+		var lores []*sgptpb.Lore
+		for _, library := range index.Libraries() {
+			libraryLores, err := library.Load()
+			if err != nil {
+				return wrap(loresLoadedMsg{err: err})
+			}
+			lores = append(lores, libraryLores...)
+		}
+		return wrap(loresLoadedMsg{lores: lores})
+	}
+}
+
+// refreshList re-applies the filter after the lores or the filter changed.
+func (m *Model) refreshList() {
+	m.displayed = m.lores
+	if m.filterText != "" {
+		matches, err := lore.Search(m.lores, m.filterText, searchLimit)
+		if err != nil {
+			// A half-typed pattern ("foo(") still filters, literally.
+			matches, _ = lore.Search(m.lores, regexp.QuoteMeta(m.filterText), searchLimit)
+		}
+		m.displayed = make([]*sgptpb.Lore, 0, len(matches))
+		for _, match := range matches {
+			m.displayed = append(m.displayed, match.Lore)
+		}
+	}
+	if m.cursor >= len(m.displayed) {
+		m.cursor = max(0, len(m.displayed)-1)
+	}
+	if len(m.displayed) == 0 && m.focusTarget == FocusList {
+		m.focusTarget = FocusFilter
+	}
+	m.updateSelection()
+	m.ensureCursorVisible()
+}
+
+// selectedLore returns the lore under the cursor; nil when the filter has
+// focus or nothing matches.
+func (m *Model) selectedLore() *sgptpb.Lore {
+	if m.focusTarget != FocusList || m.cursor >= len(m.displayed) {
+		return nil
+	}
+	return m.displayed[m.cursor]
+}
+
+func (m *Model) updateSelection() {
+	if !m.ready {
+		return
+	}
+	selected := m.selectedLore()
+	if selected == nil && len(m.displayed) > 0 {
+		// Preview the best match while the filter is being typed.
+		selected = m.displayed[0]
+	}
+	content := styles.DimTextStyle.Render(" Select a lore to preview")
+	if selected != nil {
+		var ok bool
+		if content, ok = m.previewCache[selected.GetName()]; !ok {
+			content = m.renderPreview(selected)
+			m.previewCache[selected.GetName()] = content
+		}
+	}
+	m.detailViewport.SetContent(content)
+	m.detailViewport.GotoTop()
+}
+
+// inject asks the app to add the selected lore's file to the current chat.
+func (m *Model) inject() tea.Cmd {
+	selected := m.selectedLore()
+	if selected == nil {
+		return nil
+	}
+	_, path, err := m.index.Resolve(selected.GetName())
+	if err != nil {
+		return m.wrapCmd(screen.AlertMsg{Text: "Injecting lore failed: " + err.Error()})
+	}
+	return m.wrapCmd(screen.InjectFilesMsg{Paths: []string{path}})
+}
+
+func (m *Model) ensureCursorVisible() {
+	if m.cursor < m.listYOffset {
+		m.listYOffset = m.cursor
+	} else if m.cursor >= m.listYOffset+m.listHeight {
+		m.listYOffset = m.cursor - m.listHeight + 1
+	}
+	m.listYOffset = max(0, min(m.listYOffset, len(m.displayed)-m.listHeight))
+}
+
+func (m *Model) listWidth() int {
+	return m.width / 2
+}
+
+func (m *Model) detailWidth() int {
+	return max(0, m.width-m.listWidth()-1)
+}
+
+func (m *Model) recalculateLayout() {
+	if m.width == 0 || m.height == 0 {
+		return
+	}
+
+	inputHeight := 3
+	totalViewportHeight := max(1, m.height-4)
+	m.listHeight = max(1, totalViewportHeight-inputHeight)
+
+	detailWidth := m.detailWidth()
+	if !m.ready {
+		m.detailViewport = viewport.New(
+			viewport.WithWidth(detailWidth),
+			viewport.WithHeight(totalViewportHeight),
+		)
+		m.ready = true
+	} else {
+		m.detailViewport.SetWidth(detailWidth)
+		m.detailViewport.SetHeight(totalViewportHeight)
+	}
+	m.renderer.SetWidth(max(10, detailWidth-4))
+	m.previewCache = map[string]string{}
+	m.filterInput.SetWidth(m.listWidth() - 6)
+	m.updateSelection()
+	m.ensureCursorVisible()
+}
+
+func (m *Model) wrapCmd(msg tea.Msg) tea.Cmd {
+	wrap := m.wrap
+	return func() tea.Msg {
+		return wrap(msg)
+	}
+}
+
+var _ screen.Screen = (*Model)(nil)
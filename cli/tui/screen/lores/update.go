@@ -0,0 +1,108 @@
+package lores
+
+import (
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/malonaz/sgpt/cli/tui/keymap"
+)
+
+var (
+	keyUp      = keymap.New("ctrl+p", "Move up")
+	keyDown    = keymap.New("ctrl+n", "Move down")
+	keyInject  = keymap.New("enter", "Inject lore into the current chat")
+	keyRefresh = keymap.New("alt+r", "Reload lores from disk")
+	keyToTop   = keymap.New("alt+<", "Jump to filter")
+)
+
+func (m *Model) Keymaps() []keymap.Map {
+	return []keymap.Map{{
+		Name:     "Lores",
+		Bindings: []keymap.Binding{keyUp, keyDown, keyInject, keyRefresh, keyToTop},
+	}}
+}
+
+func (m *Model) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+		return nil
+
+	case loresLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		m.lores = msg.lores
+		m.previewCache = map[string]string{}
+		m.refreshList()
+		return nil
+
+	case tea.KeyPressMsg:
+		return m.handleKey(msg)
+	}
+	return nil
+}
+
+func (m *Model) handleKey(msg tea.KeyPressMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keyToTop.Key):
+		m.focusTarget = FocusFilter
+		m.listYOffset = 0
+		m.updateSelection()
+		return m.applyFocus()
+
+	case key.Matches(msg, keyUp.Key):
+		if m.focusTarget != FocusList {
+			return nil
+		}
+		if m.cursor > 0 {
+			m.cursor--
+			m.updateSelection()
+			m.ensureCursorVisible()
+			return nil
+		}
+		m.focusTarget = FocusFilter
+		m.updateSelection()
+		return m.applyFocus()
+
+	case key.Matches(msg, keyDown.Key):
+		if m.focusTarget == FocusFilter {
+			if len(m.displayed) == 0 {
+				return nil
+			}
+			m.focusTarget = FocusList
+			m.cursor = 0
+		} else if m.cursor < len(m.displayed)-1 {
+			m.cursor++
+		}
+		m.updateSelection()
+		m.ensureCursorVisible()
+		return m.applyFocus()
+
+	case key.Matches(msg, keyInject.Key):
+		return m.inject()
+
+	case key.Matches(msg, keyRefresh.Key):
+		return m.loadLores()
+	}
+
+	if m.focusTarget == FocusFilter {
+		return m.handleFilterInput(msg)
+	}
+	return nil
+}
+
+func (m *Model) handleFilterInput(msg tea.KeyPressMsg) tea.Cmd {
+	var cmd tea.Cmd
+	m.filterInput, cmd = m.filterInput.Update(msg)
+
+	newFilter := strings.TrimSpace(m.filterInput.Value())
+	if newFilter == m.filterText {
+		return cmd
+	}
+	m.filterText = newFilter
+	m.cursor = 0
+	m.refreshList()
+	return cmd
+}
@@ -0,0 +1,111 @@
+package lores
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/malonaz/sgpt/cli/tui/styles"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/markdown"
+)
+
+func (m *Model) View() string {
+	if !m.ready {
+		return "Loading..."
+	}
+
+	var b strings.Builder
+	b.WriteString(styles.TitleStyle.Width(m.width).Render(" 📚 Lores "))
+	b.WriteString("\n")
+
+	var leftPanel strings.Builder
+	filterStyle := styles.SearchInputStyle.BorderForeground(styles.BorderColor)
+	if m.focusTarget == FocusFilter {
+		filterStyle = styles.SearchInputStyle.BorderForeground(styles.PrimaryColor)
+	}
+	leftPanel.WriteString(filterStyle.Width(m.listWidth() - 2).Render(m.filterInput.View()))
+	leftPanel.WriteString("\n")
+	leftPanel.WriteString(m.renderList())
+
+	separator := lipgloss.NewStyle().Foreground(styles.BorderColor).Render(
+		strings.Repeat("│\n", m.height-3),
+	)
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, leftPanel.String(), separator, m.detailViewport.View()))
+	b.WriteString("\n")
+
+	status := fmt.Sprintf("%d lores", len(m.lores))
+	if m.filterText != "" {
+		status = fmt.Sprintf("%d of %d lores match", len(m.displayed), len(m.lores))
+	}
+	helpText := fmt.Sprintf("C-p/C-n: navigate │ Enter: inject into current chat │ Alt+r: reload │ Alt+h: help │ %s", status)
+	b.WriteString(styles.HelpStyle.Render(helpText))
+	return b.String()
+}
+
+func (m *Model) renderList() string {
+	if m.loading && m.lores == nil {
+		return styles.DimTextStyle.Render("Loading lores...")
+	}
+	if m.err != nil {
+		return styles.ErrorStyle.Render(fmt.Sprintf("Error: %v", m.err))
+	}
+	if len(m.displayed) == 0 {
+		if m.filterText != "" {
+			return styles.DimTextStyle.Render("No lores match filter")
+		}
+		return styles.DimTextStyle.Render("No lores yet: create one with `sgpt lore new`")
+	}
+
+	listWidth := m.listWidth()
+	titleWidth := max(10, (listWidth-3)/2)
+	nameWidth := max(10, listWidth-3-titleWidth)
+	bottom := min(len(m.displayed), m.listYOffset+m.listHeight)
+	visible := make([]string, 0, m.listHeight)
+	for i := m.listYOffset; i < bottom; i++ {
+		displayedLore := m.displayed[i]
+		title := fmt.Sprintf("%-*s", titleWidth, styles.Truncate(displayedLore.GetTitle(), titleWidth))
+		style := styles.MenuItemStyle
+		if m.focusTarget == FocusList && i == m.cursor {
+			style = styles.MenuSelectedStyle
+		}
+		visible = append(visible, style.Width(listWidth).Render(
+			title+" "+styles.MenuTagStyle.Render(styles.Truncate(displayedLore.GetName(), nameWidth))))
+	}
+	// Pad so the horizontal join keeps the separator at full height.
+	for len(visible) < m.listHeight {
+		visible = append(visible, "")
+	}
+	return strings.Join(visible, "\n")
+}
+
+// renderPreview renders a lore as the agent reads it: metadata, then the
+// markdown content.
+func (m *Model) renderPreview(previewed *sgptpb.Lore) string {
+	detailWidth := m.detailWidth()
+
+	var b strings.Builder
+	b.WriteString(styles.MenuTitleStyle.Width(detailWidth - 1).Render(" " + previewed.GetTitle()))
+	b.WriteString("\n")
+	b.WriteString(styles.DimTextStyle.Render(" " + styles.Truncate(previewed.GetName(), detailWidth-2)))
+	b.WriteString("\n")
+	if labels := previewed.GetLabels(); len(labels) > 0 {
+		pairs := make([]string, 0, len(labels))
+		for key, value := range labels {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		b.WriteString(styles.MenuTagStyle.Render(" Labels: " + strings.Join(pairs, ", ")))
+		b.WriteString("\n")
+	}
+	if previewed.GetDescription() != "" {
+		b.WriteString(styles.DimTextStyle.Render(" " + previewed.GetDescription()))
+		b.WriteString("\n")
+	}
+	b.WriteString(styles.DividerStyle.Render(strings.Repeat("─", detailWidth)))
+	b.WriteString("\n")
+	b.WriteString(m.renderer.ToMarkdown(-1, true, markdown.ParseBlocks(strings.Trim(previewed.GetContent(), "\n"))...))
+	return b.String()
+}
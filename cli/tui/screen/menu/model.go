@@ -2,6 +2,7 @@ package menu
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,7 +13,9 @@ import (
 
 	"github.com/malonaz/sgpt/cli/tui/screen"
 	"github.com/malonaz/sgpt/cli/tui/styles"
+	"github.com/malonaz/sgpt/cli/tui/widget"
 	"github.com/malonaz/sgpt/internal/markdown"
+	"github.com/malonaz/sgpt/internal/search"
 	"github.com/malonaz/sgpt/internal/store"
 )
 
@@ -27,6 +30,12 @@ const (
 	// histories and their rendered markdown). Infinite scroll means an
 	// unbounded number of chats can be visited in one session.
 	previewCacheBudget = 64
+	// searchHitLimit caps the matches listed in search mode; past a couple
+	// hundred, refining the query beats scrolling.
+	searchHitLimit = 200
+	// searchDebounce is the pause in typing after which a search query runs:
+	// querying on every keystroke would mostly compute discarded results.
+	searchDebounce = 150 * time.Millisecond
 )
 
 type FocusTarget int
@@ -65,6 +74,23 @@ type chatMessagesLoadedMsg struct {
 	err      error
 }
 
+// searchIndexUpdatedMsg reports that background indexing changed the search
+// index, so displayed matches may be stale.
+type searchIndexUpdatedMsg struct{}
+
+// searchDueMsg fires once typing paused on a search query; a query typed
+// over since is dropped.
+type searchDueMsg struct {
+	query string
+}
+
+// searchResultsMsg delivers the matches of a search query, run off the UI
+// loop.
+type searchResultsMsg struct {
+	query string
+	hits  []search.Hit
+}
+
 // listLine is one visual line of the chat list. chatIndex >= 0 marks a chat
 // row (rendered lazily through rowCache); otherwise text is pre-rendered
 // (section headers, separators).
@@ -90,6 +116,21 @@ type Model struct {
 	filterInput textarea.Model
 	filterText  string
 
+	// In search mode the filter is a full-text query over message text, and
+	// the list shows one row per matching message (hits) instead of chats.
+	// Hits arrive asynchronously: the previous query's stay listed until
+	// then.
+	searchIndex *search.Index
+	searchMode  bool
+	hits        []search.Hit
+
+	// In agent tree mode sub-agent chats nest under the chats that launched
+	// them; displayedDepths holds each displayed chat's depth.
+	agentTreeMode   bool
+	displayedDepths []int
+	// liveAgentStatus reports the status of chats open in a tab.
+	liveAgentStatus func(chatName string) (widget.AgentStatus, bool)
+
 	// messagesCache holds each chat's fetched history for the detail
 	// preview; loadingMessagesSet guards against duplicate fetches.
 	messagesCache      map[string][]*aipb.Message
@@ -131,7 +172,7 @@ type Model struct {
 	focused        bool
 }
 
-func New(ctx context.Context, chatStore *store.Store, wrap screen.WrapFunc) *Model {
+func New(ctx context.Context, chatStore *store.Store, searchIndex *search.Index, wrap screen.WrapFunc) *Model {
 	filterInput := textarea.New()
 	filterInput.Placeholder = "Filter chats..."
 	filterInput.CharLimit = 256
@@ -146,6 +187,7 @@ func New(ctx context.Context, chatStore *store.Store, wrap screen.WrapFunc) *Mod
 		store:              chatStore,
 		wrap:               wrap,
 		filterInput:        filterInput,
+		searchIndex:        searchIndex,
 		renderer:           renderer,
 		detailCache:        map[string]string{},
 		rowCache:           map[string]string{},
@@ -233,6 +275,9 @@ func (m *Model) fetchChats(pageToken string, appendPage bool) tea.Cmd {
 		if err != nil {
 			return wrap(chatsLoadedMsg{Err: err, Append: appendPage})
 		}
+		// Archived chats are hidden, as from `sgpt chats list`.
+		favorites = slices.DeleteFunc(favorites, store.IsArchived)
+		others = slices.DeleteFunc(others, store.IsArchived)
 		return wrap(chatsLoadedMsg{
 			Favorites:     favorites,
 			Others:        others,
@@ -245,7 +290,8 @@ func (m *Model) fetchChats(pageToken string, appendPage bool) tea.Cmd {
 // maybeLoadMore extends the list in the background as the cursor approaches
 // the end — infinite scroll instead of explicit pagination.
 func (m *Model) maybeLoadMore() tea.Cmd {
-	if m.nextPageToken == "" || m.loadingMore || m.loading {
+	// Matches come from the index, not from the loaded pages.
+	if m.nextPageToken == "" || m.loadingMore || m.loading || m.searchMode {
 		return nil
 	}
 	if m.chatCursor < len(m.displayedChats())-loadMoreThreshold {
@@ -276,8 +322,48 @@ func (m *Model) toggleFavorite(chat *aipb.Chat) tea.Cmd {
 }
 
 // displayedChats returns favorites then others, with client-side filter
-// applied; memoized until refreshList invalidates it.
+// applied — or in search mode, the chat of each hit; memoized until
+// refreshList invalidates it.
 func (m *Model) displayedChats() []*aipb.Chat {
+	if !m.displayedValid && m.searchMode {
+		m.displayed = make([]*aipb.Chat, 0, len(m.hits))
+		for _, hit := range m.hits {
+			chat := m.loadedChat(hit.Chat)
+			if chat == nil {
+				// Not paged in yet: enough to preview and open it.
+				chat = &aipb.Chat{Name: hit.Chat, Title: hit.Title}
+			}
+			m.displayed = append(m.displayed, chat)
+		}
+		m.displayedFavCount = 0
+		m.displayedValid = true
+	}
+	if !m.displayedValid && m.agentTreeMode {
+		chats := append(m.applyFilter(m.favorites), m.applyFilter(m.others)...)
+		chatNameToChat := make(map[string]*aipb.Chat, len(chats))
+		nodes := make([]*widget.AgentNode, 0, len(chats))
+		for _, chat := range chats {
+			if _, ok := chatNameToChat[chat.GetName()]; ok {
+				// Favorites also show up in the main pages.
+				continue
+			}
+			chatNameToChat[chat.GetName()] = chat
+			nodes = append(nodes, &widget.AgentNode{
+				ID:           chat.GetName(),
+				ChatID:       chat.GetName()[strings.LastIndex(chat.GetName(), "/")+1:],
+				ParentChatID: store.ParentChatID(chat),
+			})
+		}
+		rows := widget.BuildAgentTree(nodes)
+		m.displayed = make([]*aipb.Chat, 0, len(rows))
+		m.displayedDepths = make([]int, 0, len(rows))
+		for _, row := range rows {
+			m.displayed = append(m.displayed, chatNameToChat[row.Node.ID])
+			m.displayedDepths = append(m.displayedDepths, row.Depth)
+		}
+		m.displayedFavCount = 0
+		m.displayedValid = true
+	}
 	if !m.displayedValid {
 		favorites := m.applyFilter(m.favorites)
 		others := m.applyFilter(m.others)
@@ -290,6 +376,171 @@ func (m *Model) displayedChats() []*aipb.Chat {
 	return m.displayed
 }
 
+// SetLiveAgentStatus wires the status of chats open in tabs, shown in agent
+// tree mode.
+func (m *Model) SetLiveAgentStatus(liveAgentStatus func(chatName string) (widget.AgentStatus, bool)) {
+	m.liveAgentStatus = liveAgentStatus
+}
+
+// agentStatus is a chat's live status, or for chats no tab is running, done
+// for sub-agents and idle otherwise.
+func (m *Model) agentStatus(chat *aipb.Chat) widget.AgentStatus {
+	if m.liveAgentStatus != nil {
+		if status, ok := m.liveAgentStatus(chat.GetName()); ok {
+			return status
+		}
+	}
+	if store.ParentChatID(chat) != "" {
+		return widget.AgentStatusDone
+	}
+	return widget.AgentStatusIdle
+}
+
+// toggleAgentTreeMode switches the list between sections and the agent tree.
+func (m *Model) toggleAgentTreeMode() {
+	m.agentTreeMode = !m.agentTreeMode
+	m.searchMode = false
+	m.filterInput.Placeholder = "Filter chats..."
+	m.chatCursor = 0
+	m.refreshList()
+}
+
+// loadedChat returns the listed chat with the given name, or nil when it is
+// not among the loaded pages.
+func (m *Model) loadedChat(name string) *aipb.Chat {
+	for _, chats := range [][]*aipb.Chat{m.favorites, m.others} {
+		for _, chat := range chats {
+			if chat.GetName() == name {
+				return chat
+			}
+		}
+	}
+	return nil
+}
+
+// selectedHit returns the match under the cursor in search mode.
+func (m *Model) selectedHit() *search.Hit {
+	m.displayedChats()
+	if !m.searchMode || m.chatCursor < 0 || m.chatCursor >= len(m.hits) {
+		return nil
+	}
+	return &m.hits[m.chatCursor]
+}
+
+// toggleSearchMode switches the filter between chat titles and message text.
+// Entering search mode syncs the whole index in the background: listing only
+// indexes the pages scrolled through.
+func (m *Model) toggleSearchMode() tea.Cmd {
+	m.searchMode = !m.searchMode
+	m.agentTreeMode = false
+	m.filterInput.Placeholder = "Filter chats..."
+	if m.searchMode {
+		m.filterInput.Placeholder = "Search messages..."
+	}
+	m.chatCursor = 0
+	m.hits = nil
+	m.refreshList()
+	if !m.searchMode {
+		return nil
+	}
+	return tea.Batch(m.syncIndex(), m.runSearch(m.filterText))
+}
+
+// scheduleSearch runs the current search query once typing pauses.
+func (m *Model) scheduleSearch() tea.Cmd {
+	wrap := m.wrap
+	query := m.filterText
+	return tea.Tick(searchDebounce, func(time.Time) tea.Msg { return wrap(searchDueMsg{query: query}) })
+}
+
+// runSearch queries the index off the UI loop: a query scans every indexed
+// message.
+func (m *Model) runSearch(query string) tea.Cmd {
+	wrap := m.wrap
+	index := m.searchIndex
+	return func() tea.Msg {
+		return wrap(searchResultsMsg{query: query, hits: index.Search(query, searchHitLimit)})
+	}
+}
+
+func (m *Model) syncIndex() tea.Cmd {
+	wrap := m.wrap
+	chatStore := m.store
+	index := m.searchIndex
+	return func() tea.Msg {
+		if err := index.Sync(m.ctx, chatStore, nil); err != nil {
+			return wrap(screen.AlertMsg{Text: "Indexing chats failed: " + err.Error()})
+		}
+		index.Save()
+		return wrap(searchIndexUpdatedMsg{})
+	}
+}
+
+// indexChats re-indexes, in the background, the listed chats that changed
+// since they were last indexed: listing is what keeps the index fresh.
+func (m *Model) indexChats(chats []*aipb.Chat) tea.Cmd {
+	wrap := m.wrap
+	chatStore := m.store
+	index := m.searchIndex
+	return func() tea.Msg {
+		var updated bool
+		for _, chat := range chats {
+			if !index.Stale(chat) {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+			messages, err := chatStore.ListMessages(ctx, chat.GetName())
+			cancel()
+			if err != nil {
+				// Left stale: retried on the next listing.
+				continue
+			}
+			index.Update(chat, messages)
+			updated = true
+		}
+		if !updated {
+			return nil
+		}
+		index.Save()
+		return wrap(searchIndexUpdatedMsg{})
+	}
+}
+
+// indexPreview indexes a history fetched for the preview, sparing the
+// background indexer the same fetch.
+func (m *Model) indexPreview(name string, messages []*aipb.Message) tea.Cmd {
+	chat := m.loadedChat(name)
+	if chat == nil || !m.searchIndex.Stale(chat) {
+		return nil
+	}
+	m.searchIndex.Update(chat, messages)
+	index := m.searchIndex
+	return func() tea.Msg {
+		index.Save()
+		return nil
+	}
+}
+
+// openHit opens the chat of a match, focused on the matching message. Chats
+// not among the loaded pages are fetched first.
+func (m *Model) openHit(hit *search.Hit) tea.Cmd {
+	if chat := m.loadedChat(hit.Chat); chat != nil {
+		return m.wrapCmd(screen.OpenChatMsg{Chat: chat, MessageName: hit.Message})
+	}
+	wrap := m.wrap
+	chatStore := m.store
+	chatName, messageName := hit.Chat, hit.Message
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, 10*time.Second)
+		defer cancel()
+		chat, err := chatStore.GetChat(ctx, chatName)
+		if err != nil {
+			return wrap(screen.AlertMsg{Text: "Opening chat failed: " + err.Error()})
+		}
+		return wrap(screen.OpenChatMsg{Chat: chat, MessageName: messageName})
+	}
+}
+
 // maybeLoadMessages fetches the selected chat's history for the preview,
 // once per chat.
 func (m *Model) maybeLoadMessages() tea.Cmd {
@@ -421,6 +672,22 @@ func (m *Model) rebuildListLines() {
 		}
 	}
 
+	if m.agentTreeMode {
+		if len(displayed) > 0 {
+			appendHeader("🌳 Agent tree")
+			appendChatRows(0, len(displayed))
+		}
+		m.clampListOffset()
+		return
+	}
+	if m.searchMode {
+		if len(displayed) > 0 {
+			appendHeader("🔎 Matches")
+			appendChatRows(0, len(displayed))
+		}
+		m.clampListOffset()
+		return
+	}
 	if favoriteCount > 0 {
 		appendHeader("⭐ Favorites")
 		appendChatRows(0, favoriteCount)
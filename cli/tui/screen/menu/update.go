@@ -19,6 +19,8 @@ var (
 	keyToTop        = keymap.New("alt+<", "Jump to filter")
 	keyToBottom     = keymap.New("alt+>", "Jump to last chat")
 	keyMenuFavorite = keymap.New("alt+shift+f", "Toggle favorite")
+	keySearch       = keymap.New("alt+s", "Toggle message search")
+	keyAgentTree    = keymap.New("alt+a", "Toggle agent tree")
 )
 
 func (m *Model) Keymaps() []keymap.Map {
@@ -26,7 +28,7 @@ func (m *Model) Keymaps() []keymap.Map {
 		Name: "Menu",
 		Bindings: []keymap.Binding{
 			keyUp, keyDown, keyOpen, keyDelete, keyMenuFavorite,
-			keyRefresh, keyToTop, keyToBottom,
+			keyRefresh, keySearch, keyAgentTree, keyToTop, keyToBottom,
 		},
 	}}
 }
@@ -47,6 +49,28 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 			return nil
 		}
 		m.applyChats(&msg)
+		return tea.Batch(m.maybeLoadMessages(), m.indexChats(append(msg.Favorites, msg.Others...)))
+
+	case searchIndexUpdatedMsg:
+		if !m.searchMode {
+			return nil
+		}
+		return m.runSearch(m.filterText)
+
+	case searchDueMsg:
+		if !m.searchMode || msg.query != m.filterText {
+			return nil
+		}
+		return m.runSearch(msg.query)
+
+	case searchResultsMsg:
+		// Results of a query typed over, or of a search mode left since,
+		// are dropped.
+		if !m.searchMode || msg.query != m.filterText {
+			return nil
+		}
+		m.hits = msg.hits
+		m.refreshList()
 		return m.maybeLoadMessages()
 
 	case chatDeletedMsg:
@@ -54,6 +78,7 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 			return m.wrapCmd(screen.AlertMsg{Text: "Delete failed: " + msg.Err.Error()})
 		}
 		delete(m.detailCache, msg.Name)
+		m.searchIndex.Remove(msg.Name)
 		m.removeChatByName(msg.Name)
 		m.refreshList()
 		return m.wrapCmd(screen.AlertMsg{Text: "Chat deleted"})
@@ -79,7 +104,7 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 		delete(m.detailCache, msg.name)
 		m.touchPreview(msg.name)
 		m.updateSelection()
-		return nil
+		return m.indexPreview(msg.name, msg.messages)
 
 	case tea.KeyPressMsg:
 		return m.handleKey(msg)
@@ -127,10 +152,14 @@ func (m *Model) handleKey(msg tea.KeyPressMsg) tea.Cmd {
 		return m.navigateDown()
 
 	case key.Matches(msg, keyOpen.Key):
-		if m.focusTarget == FocusChatList {
-			if chat := m.selectedChat(); chat != nil {
-				return m.wrapCmd(screen.OpenChatMsg{Chat: chat})
-			}
+		if m.focusTarget != FocusChatList {
+			return nil
+		}
+		if hit := m.selectedHit(); hit != nil {
+			return m.openHit(hit)
+		}
+		if chat := m.selectedChat(); chat != nil {
+			return m.wrapCmd(screen.OpenChatMsg{Chat: chat})
 		}
 		return nil
 
@@ -145,11 +174,23 @@ func (m *Model) handleKey(msg tea.KeyPressMsg) tea.Cmd {
 	case key.Matches(msg, keyMenuFavorite.Key):
 		if m.focusTarget == FocusChatList {
 			if chat := m.selectedChat(); chat != nil {
+				// A match's chat may be a stand-in built from the index:
+				// writing its labels back would wipe the real ones.
+				if m.loadedChat(chat.GetName()) == nil {
+					return m.wrapCmd(screen.AlertMsg{Text: "Open the chat to change its favorite status"})
+				}
 				return m.toggleFavorite(chat)
 			}
 		}
 		return nil
 
+	case key.Matches(msg, keySearch.Key):
+		return tea.Batch(m.toggleSearchMode(), m.maybeLoadMessages())
+
+	case key.Matches(msg, keyAgentTree.Key):
+		m.toggleAgentTreeMode()
+		return m.maybeLoadMessages()
+
 	case key.Matches(msg, keyRefresh.Key):
 		m.detailCache = map[string]string{}
 		return m.fetchChats("", false)
@@ -215,6 +256,9 @@ func (m *Model) handleFilterInput(msg tea.KeyPressMsg) tea.Cmd {
 	m.filterText = newFilter
 	m.chatCursor = 0
 	m.refreshList()
+	if m.searchMode {
+		return tea.Batch(cmd, m.scheduleSearch())
+	}
 	return cmd
 }
 
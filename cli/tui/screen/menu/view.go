@@ -40,10 +40,13 @@ func (m *Model) View() string {
 
 	b.WriteString("\n")
 	status := fmt.Sprintf("%d chats loaded", len(m.displayedChats()))
+	if m.searchMode {
+		status = fmt.Sprintf("%d matches", len(m.displayedChats()))
+	}
 	if m.loadingMore {
 		status += " (loading more...)"
 	}
-	helpText := fmt.Sprintf("C-p/C-n: navigate │ Enter: open │ Alt+d: delete │ Alt+f: favorite │ Alt+r: refresh │ Alt+h: help │ %s", status)
+	helpText := fmt.Sprintf("C-p/C-n: navigate │ Enter: open │ Alt+d: delete │ Alt+f: favorite │ Alt+r: refresh │ Alt+s: search messages │ Alt+a: agent tree │ Alt+h: help │ %s", status)
 	b.WriteString(styles.HelpStyle.Render(helpText))
 
 	return b.String()
@@ -68,6 +71,12 @@ func (m *Model) renderList() string {
 
 	displayed := m.displayedChats()
 	if len(displayed) == 0 {
+		if m.searchMode {
+			if m.filterText == "" {
+				return styles.DimTextStyle.Render("Type to search message text across all chats")
+			}
+			return styles.DimTextStyle.Render("No messages match")
+		}
 		if m.filterText != "" {
 			return styles.DimTextStyle.Render("No chats match filter")
 		}
@@ -81,7 +90,9 @@ func (m *Model) renderList() string {
 	}
 	visible := make([]string, 0, m.listHeight)
 	for _, line := range m.listLines[top:bottom] {
-		if line.chatIndex >= 0 {
+		if line.chatIndex >= 0 && m.searchMode {
+			visible = append(visible, m.renderHitRow(line.chatIndex))
+		} else if line.chatIndex >= 0 {
 			visible = append(visible, m.renderChatRow(displayed[line.chatIndex], line.chatIndex))
 		} else {
 			visible = append(visible, line.text)
@@ -100,7 +111,8 @@ func (m *Model) renderList() string {
 func (m *Model) renderChatRow(chat *aipb.Chat, globalIndex int) string {
 	selected := m.focusTarget == FocusChatList && globalIndex == m.chatCursor
 	cacheKey := fmt.Sprintf("%s|%t", chat.GetName(), selected)
-	if row, ok := m.rowCache[cacheKey]; ok {
+	// Tree rows carry live agent status: never served stale from the cache.
+	if row, ok := m.rowCache[cacheKey]; ok && !m.agentTreeMode {
 		return row
 	}
 
@@ -112,7 +124,15 @@ func (m *Model) renderChatRow(chat *aipb.Chat, globalIndex int) string {
 	if titleWidth < 20 {
 		titleWidth = 20
 	}
-	title := styles.Truncate(chat.GetTitle(), titleWidth)
+	title := chat.GetTitle()
+	if m.agentTreeMode && globalIndex < len(m.displayedDepths) {
+		prefix := ""
+		if depth := m.displayedDepths[globalIndex]; depth > 0 {
+			prefix = strings.Repeat("  ", depth-1) + "└ "
+		}
+		title = prefix + m.agentStatus(chat).Icon() + " " + title
+	}
+	title = styles.Truncate(title, titleWidth)
 	updated := relativeTime(chat.GetUpdateTime().AsTime())
 	tags := styles.Truncate(strings.Join(store.Tags(chat), ","), tagWidth)
 
@@ -130,6 +150,34 @@ func (m *Model) renderChatRow(chat *aipb.Chat, globalIndex int) string {
 	return row
 }
 
+// renderHitRow renders (and caches) a search match: the chat title, then the
+// text around the match.
+func (m *Model) renderHitRow(hitIndex int) string {
+	selected := m.focusTarget == FocusChatList && hitIndex == m.chatCursor
+	cacheKey := fmt.Sprintf("hit%d|%t", hitIndex, selected)
+	if row, ok := m.rowCache[cacheKey]; ok {
+		return row
+	}
+
+	hit := m.hits[hitIndex]
+	listWidth := m.listWidth()
+	titleWidth := min(24, listWidth/3)
+	snippetWidth := max(10, listWidth-2-titleWidth-1)
+	title := hit.Title
+	if title == "" {
+		title = "(untitled)"
+	}
+	title = fmt.Sprintf("%-*s", titleWidth, styles.Truncate(title, titleWidth))
+
+	style := styles.MenuItemStyle
+	if selected {
+		style = styles.MenuSelectedStyle
+	}
+	row := style.Width(listWidth).Render(styles.MenuTagStyle.Render(title) + " " + styles.Truncate(hit.Snippet, snippetWidth))
+	m.rowCache[cacheKey] = row
+	return row
+}
+
 // renderDetail previews the selected chat using the shared timeline items —
 // same rendering path as the chat screen. Results are cached per chat name
 // in updateSelection.
@@ -155,6 +203,10 @@ func (m *Model) renderDetail() string {
 	b.WriteString("\n")
 	b.WriteString(styles.DimTextStyle.Render(" " + styles.Truncate(chat.GetName(), detailWidth-2)))
 	b.WriteString("\n")
+	if parentChatID := store.ParentChatID(chat); parentChatID != "" {
+		b.WriteString(styles.DimTextStyle.Render(fmt.Sprintf(" Sub-agent of %s · %s", parentChatID, m.agentStatus(chat))))
+		b.WriteString("\n")
+	}
 	if model := store.CurrentModel(chat); model != "" {
 		b.WriteString(styles.DimTextStyle.Render(" Model: " + model))
 		b.WriteString("\n")
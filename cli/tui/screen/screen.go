@@ -26,6 +26,8 @@ type TabMsg struct {
 
 type OpenChatMsg struct {
 	Chat *aipb.Chat
+	// MessageName, when set, is the message to select once the chat opens.
+	MessageName string
 }
 
 type OpenMenuMsg struct{}
@@ -37,3 +39,8 @@ type CloseTabMsg struct {
 type AlertMsg struct {
 	Text string
 }
+
+// InjectFilesMsg asks the app to inject files into the current chat.
+type InjectFilesMsg struct {
+	Paths []string
+}
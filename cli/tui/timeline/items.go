@@ -10,6 +10,8 @@ import (
 	"github.com/malonaz/core/go/pbutil"
 
 	"github.com/malonaz/sgpt/cli/tui/styles"
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/file"
 	"github.com/malonaz/sgpt/internal/markdown"
 	"github.com/malonaz/sgpt/internal/store"
 	"github.com/malonaz/sgpt/internal/tool"
@@ -193,6 +195,9 @@ type ToolCallItem struct {
 	ToolCall  *aipb.ToolCall
 	Result    *aipb.ToolResult
 	Executing bool
+	// Progress is the markdown the executing call last reported (e.g. a
+	// batch of sub-agents' statuses).
+	Progress string
 	// Pending marks a call awaiting the user's verdict: the turn goroutine is
 	// blocked on it right now.
 	Pending bool
@@ -219,7 +224,7 @@ func (i *ToolCallItem) CacheKey() string {
 	if i.Partial {
 		return ""
 	}
-	return fmt.Sprintf("%s|r%t|e%t|p%t", i.id, i.Result != nil, i.Executing, i.Pending)
+	return fmt.Sprintf("%s|r%t|e%t|p%t|%s", i.id, i.Result != nil, i.Executing, i.Pending, i.Progress)
 }
 
 // Resolved calls fold to a one-line summary; pending/executing stay expanded.
@@ -244,6 +249,11 @@ func (i *ToolCallItem) Render(ctx RenderContext) string {
 	if !ctx.Collapsed {
 		b.WriteString("\n")
 		b.WriteString(i.request(ctx))
+		if i.Executing && i.Progress != "" {
+			b.WriteString("\n")
+			// seq+3: request, response and header take seq to seq+2.
+			b.WriteString(renderMarkdown(ctx, i.seq+3, false, markdown.ParseBlocks(i.Progress)...))
+		}
 		if i.Result != nil {
 			b.WriteString("\n")
 			b.WriteString(i.response(ctx))
@@ -262,6 +272,9 @@ func (i *ToolCallItem) header(ctx RenderContext) string {
 	case i.Pending:
 		suffix = " " + styles.ErrorStyle.Render("▶ pending review")
 	}
+	if attempts := i.resultMetadata().GetAttempts(); attempts > 1 {
+		suffix += " " + styles.DimTextStyle.Render(fmt.Sprintf("↻ %d attempts", attempts))
+	}
 	header := fmt.Sprintf("%s %s%s",
 		i.statusIndicator(),
 		i.headerContent(ctx),
@@ -344,17 +357,33 @@ func toolResultText(toolResult *aipb.ToolResult) string {
 	return toolResult.GetContent()
 }
 
+// resultMetadata returns the metadata attached to the call's result, nil
+// when there is none.
+func (i *ToolCallItem) resultMetadata() *sgptpb.ToolCallResultMetadata {
+	if i.Result == nil {
+		return nil
+	}
+	metadata, _ := tool.ParseToolResultMetadata(i.Result)
+	return metadata
+}
+
 // statusIndicator colours the leading dot: a result is terminal (green, or red
-// when it carries an error), anything else is still in flight.
+// when it carries an error), anything else is still in flight. Calls failed
+// by their execution policy rather than by the tool stand out: ⏱ when they
+// timed out, ⊘ when the tool was disabled by its circuit breaker.
 func (i *ToolCallItem) statusIndicator() string {
-	color := styles.MutedColor
+	color, glyph := styles.MutedColor, "●"
 	switch {
+	case i.resultMetadata().GetPolicyFailure() == sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_TIMEOUT:
+		color, glyph = styles.AccentColor, "⏱"
+	case i.resultMetadata().GetPolicyFailure() == sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN:
+		color, glyph = styles.AccentColor, "⊘"
 	case i.Result.GetError() != nil:
 		color = styles.ErrorColor
 	case i.Result != nil:
 		color = styles.SuccessColor
 	}
-	return lipgloss.NewStyle().Foreground(color).Render("●")
+	return lipgloss.NewStyle().Foreground(color).Render(glyph)
 }
 
 // ---- LineItem: single-line entries (system, errors) ----
@@ -384,6 +413,12 @@ func NewCancelledItem(id string) *LineItem {
 	return &LineItem{id: id, text: "Turn cancelled — your messages are queued and resume on the next send", style: styles.DimTextStyle}
 }
 
+// NewRetryItem announces the generation attempt a turn is waiting to make
+// after a transient failure; text carries the countdown.
+func NewRetryItem(id, text string) *LineItem {
+	return &LineItem{id: id, text: "⟳ " + text, style: styles.MessageInterruptStyle}
+}
+
 func (i *LineItem) ID() string                { return i.id }
 func (i *LineItem) Content() (string, string) { return i.text, "" }
 
@@ -464,9 +499,38 @@ type InjectedFileItem struct {
 	// messageNames parallels paths: each injected file is its own message, so
 	// a grouped item owns several.
 	messageNames []string
+	// notes parallels paths: a dim annotation after the path (an attachment's
+	// description, a live file's marker, a diff's line counts).
+	notes []string
 }
 
 func NewInjectedFileItem(id, path, content, messageName string) *InjectedFileItem {
+	return newInjectedFileItem(id, path, content, messageName, "")
+}
+
+// NewInjectedAttachmentItem renders an attached image or PDF as a
+// placeholder: its description (kind, dimensions) stands in for the content.
+func NewInjectedAttachmentItem(id, path, description, messageName string) *InjectedFileItem {
+	return newInjectedFileItem(id, path, description, messageName, "🖼 "+description)
+}
+
+// NewLiveFileDiffItem renders the changes of a live file sent as a diff:
+// the diff is the content, its line counts the note.
+func NewLiveFileDiffItem(id, path, diff, messageName string) *InjectedFileItem {
+	var added, removed int
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return newInjectedFileItem(id, path, diff, messageName, fmt.Sprintf("Δ +%d −%d", added, removed))
+}
+
+func newInjectedFileItem(id, path, content, messageName, note string) *InjectedFileItem {
 	return &InjectedFileItem{
 		id:           id,
 		path:         path,
@@ -474,15 +538,25 @@ func NewInjectedFileItem(id, path, content, messageName string) *InjectedFileIte
 		paths:        []string{path},
 		contents:     []string{content},
 		messageNames: []string{messageName},
+		notes:        []string{note},
 	}
 }
 
 // add folds a consecutively injected file into this group so a large
 // injection costs a few lines of vertical space instead of one box each.
-func (i *InjectedFileItem) add(path, content, messageName string) {
+func (i *InjectedFileItem) add(path, content, messageName, note string) {
 	i.paths = append(i.paths, path)
 	i.contents = append(i.contents, content)
 	i.messageNames = append(i.messageNames, messageName)
+	i.notes = append(i.notes, note)
+}
+
+// markLive notes that the item's file is live.
+func (i *InjectedFileItem) markLive() {
+	if i.notes[0] != "" {
+		i.notes[0] += " · "
+	}
+	i.notes[0] += "⟳ live"
 }
 
 func (i *InjectedFileItem) ID() string { return i.id }
@@ -506,10 +580,18 @@ func (i *InjectedFileItem) MessageNameAt(index int) string {
 	return i.messageNames[index]
 }
 
+// PathAt returns the file at the given sub-index, like MessageNameAt.
+func (i *InjectedFileItem) PathAt(index int) string {
+	if index < 0 || index >= len(i.paths) {
+		return i.path
+	}
+	return i.paths[index]
+}
+
 // CacheKey: injected-file messages are immutable, but the group grows as
-// consecutive files are folded in.
+// consecutive files are folded in, and a file may turn live.
 func (i *InjectedFileItem) CacheKey() string {
-	return fmt.Sprintf("%s|%d|%s", i.id, len(i.paths), strings.Join(i.paths, ","))
+	return fmt.Sprintf("%s|%d|%s|%s", i.id, len(i.paths), strings.Join(i.paths, ","), strings.Join(i.notes, ","))
 }
 
 // Content exposes the injected content for copy / open-in-editor; a group
@@ -558,6 +640,9 @@ func (i *InjectedFileItem) Render(ctx RenderContext) string {
 			prefix = indicatorStyle.Render(styles.BlockIndicatorChar) + " 📎 "
 		}
 		b.WriteString(fileNameStyle.Render(prefix) + styles.FileStyle.Render(directory) + fileNameStyle.Render(name))
+		if note := i.notes[index]; note != "" {
+			b.WriteString(" " + styles.DimTextStyle.Render("["+note+"]"))
+		}
 	}
 	return frame(ctx, style, b.String())
 }
@@ -570,6 +655,16 @@ func basenames(paths []string) []string {
 	return names
 }
 
+// messageText returns the first text block of a context message.
+func messageText(message *aipb.Message) string {
+	for _, block := range message.GetBlocks() {
+		if text := block.GetText(); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
 func (i *InjectedFileItem) SubOffsets(ctx RenderContext) []int {
 	offsets := make([]int, len(i.paths))
 	for index := range i.paths {
@@ -614,6 +709,7 @@ func (b *Builder) Build(
 	messages []*aipb.Message,
 	streamingMessage *aipb.Message,
 	executingToolCallID string,
+	executingToolCallProgress string,
 	pendingToolCallIDs map[string]bool,
 	requestRenderer RequestRenderer,
 ) []Item {
@@ -665,6 +761,10 @@ func (b *Builder) Build(
 			}
 			toolCallID := toolCallItem.ToolCall.GetId()
 			toolCallItem.Executing = executingToolCallID != "" && toolCallID == executingToolCallID
+			toolCallItem.Progress = ""
+			if toolCallItem.Executing {
+				toolCallItem.Progress = executingToolCallProgress
+			}
 			toolCallItem.Pending = pendingToolCallIDs[toolCallID]
 		}
 		items = append(items, entry.items...)
@@ -691,11 +791,11 @@ func groupInjectedFiles(items []Item) []Item {
 			continue
 		}
 		if current != nil {
-			current.add(fileItem.path, fileItem.content, fileItem.MessageName())
+			current.add(fileItem.path, fileItem.content, fileItem.MessageName(), fileItem.notes[0])
 			continue
 		}
 		// Copy: the cached per-message item must not accumulate siblings.
-		current = NewInjectedFileItem(fileItem.id, fileItem.path, fileItem.content, fileItem.MessageName())
+		current = newInjectedFileItem(fileItem.id, fileItem.path, fileItem.content, fileItem.MessageName(), fileItem.notes[0])
 		grouped = append(grouped, current)
 	}
 	return grouped
@@ -704,7 +804,7 @@ func groupInjectedFiles(items []Item) []Item {
 // BuildChatItems is the uncached one-shot variant — used by read-only
 // previews (menu detail pane). Stateful callers should hold a Builder.
 func BuildChatItems(messages []*aipb.Message, requestRenderer RequestRenderer) []Item {
-	return NewBuilder().Build(messages, nil, "", nil, requestRenderer)
+	return NewBuilder().Build(messages, nil, "", "", nil, requestRenderer)
 }
 
 func appendMessageItems(
@@ -723,14 +823,22 @@ func appendMessageItems(
 		// Injected files render in-place (injection order), label-detected:
 		// same message as any other, only the presentation differs.
 		if path := store.InjectedFilePath(message); path != "" {
-			var content string
-			for _, block := range message.GetBlocks() {
-				if text := block.GetText(); text != "" {
-					content = text
-					break
-				}
+			var fileItem *InjectedFileItem
+			if mediaType, content, ok := store.Attachment(message); ok {
+				description := file.DescribeAttachment(mediaType, content)
+				fileItem = NewInjectedAttachmentItem(fmt.Sprintf("m%d-file", messageIndex), path, description, messageName)
+			} else {
+				fileItem = NewInjectedFileItem(fmt.Sprintf("m%d-file", messageIndex), path, messageText(message), messageName)
 			}
-			items = append(items, NewInjectedFileItem(fmt.Sprintf("m%d-file", messageIndex), path, content, messageName))
+			if store.IsLiveFile(message) {
+				fileItem.markLive()
+			}
+			items = append(items, fileItem)
+			return items
+		}
+		// A live file's changes render with the files, not as user text.
+		if path := store.FileDiffPath(message); path != "" {
+			items = append(items, NewLiveFileDiffItem(fmt.Sprintf("m%d-diff", messageIndex), path, messageText(message), messageName))
 			return items
 		}
 		// One rectangle per user message; fences navigable within it.
@@ -204,6 +204,19 @@ func (m *Model) SelectedMessageName() string {
 	return owned.MessageName()
 }
 
+// SelectedInjectedFilePath returns the injected file under the cursor, or ""
+// when the selection is not one.
+func (m *Model) SelectedInjectedFilePath() string {
+	fileItem, ok := m.SelectedItem().(*InjectedFileItem)
+	if !ok {
+		return ""
+	}
+	if m.navMode == NavModeFence {
+		return fileItem.PathAt(m.cursorSub)
+	}
+	return fileItem.path
+}
+
 // View assembles only the visible window — O(viewport height), not O(chat).
 func (m *Model) View() string {
 	if !m.ready {
@@ -0,0 +1,172 @@
+package widget
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/malonaz/sgpt/cli/tui/styles"
+)
+
+// AgentStatus is the lifecycle phase of a chat in the agent tree.
+type AgentStatus int
+
+const (
+	// AgentStatusIdle is a chat with nothing in flight: a top-level chat
+	// between turns, or a persisted chat no tab is running.
+	AgentStatusIdle AgentStatus = iota
+	AgentStatusRunning
+	AgentStatusAwaitingReview
+	AgentStatusDone
+	AgentStatusFailed
+)
+
+// Icon is the status glyph shown in trees.
+func (s AgentStatus) Icon() string {
+	switch s {
+	case AgentStatusRunning:
+		return "●"
+	case AgentStatusAwaitingReview:
+		return "⏸"
+	case AgentStatusDone:
+		return "✓"
+	case AgentStatusFailed:
+		return "✗"
+	default:
+		return "○"
+	}
+}
+
+func (s AgentStatus) String() string {
+	switch s {
+	case AgentStatusRunning:
+		return "running"
+	case AgentStatusAwaitingReview:
+		return "awaiting review"
+	case AgentStatusDone:
+		return "done"
+	case AgentStatusFailed:
+		return "failed"
+	default:
+		return "idle"
+	}
+}
+
+// AgentNode is one chat of the agent tree. Parenthood is by chat ID, as the
+// sgpt.com/parent-chat label records it.
+type AgentNode struct {
+	ID           string
+	ChatID       string
+	ParentChatID string
+	Title        string
+	Status       AgentStatus
+	Price        float64
+	// Result is the sub-agent's answer (or error), once it has one.
+	Result string
+	Active bool
+}
+
+// AgentTreeRow is a node placed in the tree: rows come out depth-first, each
+// child right below its parent.
+type AgentTreeRow struct {
+	Node  *AgentNode
+	Depth int
+}
+
+// BuildAgentTree orders nodes depth-first, each node once. A node whose
+// parent is not among the nodes is a root; siblings keep their input order.
+// Nodes caught in a parent cycle have no root above them: each cycle is
+// rooted at its first node in input order.
+func BuildAgentTree(nodes []*AgentNode) []AgentTreeRow {
+	chatIDSet := map[string]bool{}
+	for _, node := range nodes {
+		if node.ChatID != "" {
+			chatIDSet[node.ChatID] = true
+		}
+	}
+	parentChatIDToChildren := map[string][]*AgentNode{}
+	var roots []*AgentNode
+	for _, node := range nodes {
+		if node.ParentChatID != "" && node.ParentChatID != node.ChatID && chatIDSet[node.ParentChatID] {
+			parentChatIDToChildren[node.ParentChatID] = append(parentChatIDToChildren[node.ParentChatID], node)
+			continue
+		}
+		roots = append(roots, node)
+	}
+
+	rows := make([]AgentTreeRow, 0, len(nodes))
+	visitedNodeSet := map[*AgentNode]bool{}
+	var visit func(node *AgentNode, depth int)
+	visit = func(node *AgentNode, depth int) {
+		// Labels are user-editable: never loop on a cycle.
+		if visitedNodeSet[node] {
+			return
+		}
+		visitedNodeSet[node] = true
+		rows = append(rows, AgentTreeRow{Node: node, Depth: depth})
+		if node.ChatID == "" {
+			return
+		}
+		for _, child := range parentChatIDToChildren[node.ChatID] {
+			visit(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+	for _, node := range nodes {
+		visit(node, 0)
+	}
+	return rows
+}
+
+// RenderAgentTree renders the tree as the tab bar, one row per tab, showing
+// at most maxRows rows around the active one.
+func RenderAgentTree(nodes []*AgentNode, width, maxRows int) string {
+	rows := BuildAgentTree(nodes)
+	activeIndex := 0
+	for i, row := range rows {
+		if row.Node.Active {
+			activeIndex = i
+		}
+	}
+	start := 0
+	if len(rows) > maxRows {
+		start = min(max(0, activeIndex-maxRows/2), len(rows)-maxRows)
+		rows = rows[start : start+maxRows]
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, renderAgentTreeRow(row, width))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func renderAgentTreeRow(row AgentTreeRow, width int) string {
+	node := row.Node
+	prefix := strings.Repeat("  ", row.Depth)
+	if row.Depth > 0 {
+		prefix = strings.Repeat("  ", row.Depth-1) + "└ "
+	}
+	label := fmt.Sprintf("%s%s %s", prefix, node.Status.Icon(), node.Title)
+	var details []string
+	if node.Status != AgentStatusIdle {
+		details = append(details, node.Status.String())
+	}
+	if node.Price > 0 {
+		details = append(details, fmt.Sprintf("$%.4f", node.Price))
+	}
+	if result := strings.Join(strings.Fields(node.Result), " "); result != "" {
+		details = append(details, "→ "+result)
+	}
+	if len(details) > 0 {
+		label += " · " + strings.Join(details, " · ")
+	}
+
+	style := styles.TabInactiveStyle
+	if node.Active {
+		style = styles.TabActiveStyle
+	}
+	// Padding(0, 1) on the tab styles takes two columns.
+	return style.Width(width).Render(styles.Truncate(label, max(1, width-2)))
+}
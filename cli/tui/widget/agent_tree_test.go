@@ -0,0 +1,115 @@
+package widget
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// treeRows renders rows as "title@depth", for comparison.
+func treeRows(rows []AgentTreeRow) []string {
+	rendered := make([]string, 0, len(rows))
+	for _, row := range rows {
+		rendered = append(rendered, row.Node.Title+"@"+strings.Repeat(">", row.Depth))
+	}
+	return rendered
+}
+
+func TestBuildAgentTree(t *testing.T) {
+	for name, test := range map[string]struct {
+		nodes []*AgentNode
+		want  []string
+	}{
+		"flat": {
+			nodes: []*AgentNode{{Title: "menu"}, {Title: "a", ChatID: "a"}, {Title: "b", ChatID: "b"}},
+			want:  []string{"menu@", "a@", "b@"},
+		},
+		"nested, children below their parent": {
+			nodes: []*AgentNode{
+				{Title: "child", ChatID: "c", ParentChatID: "p"},
+				{Title: "other", ChatID: "o"},
+				{Title: "grandchild", ChatID: "g", ParentChatID: "c"},
+				{Title: "parent", ChatID: "p"},
+				{Title: "sibling", ChatID: "s", ParentChatID: "p"},
+			},
+			want: []string{"other@", "parent@", "child@>", "grandchild@>>", "sibling@>"},
+		},
+		"parent not open": {
+			nodes: []*AgentNode{{Title: "orphan", ChatID: "c", ParentChatID: "closed"}},
+			want:  []string{"orphan@"},
+		},
+		"own parent": {
+			nodes: []*AgentNode{{Title: "self", ChatID: "s", ParentChatID: "s"}},
+			want:  []string{"self@"},
+		},
+		"unsaved chats parent nothing": {
+			nodes: []*AgentNode{{Title: "new"}, {Title: "child", ChatID: "c", ParentChatID: ""}},
+			want:  []string{"new@", "child@"},
+		},
+		"cycle": {
+			nodes: []*AgentNode{
+				{Title: "root", ChatID: "r"},
+				{Title: "a", ChatID: "a", ParentChatID: "b"},
+				{Title: "b", ChatID: "b", ParentChatID: "a"},
+			},
+			want: []string{"root@", "a@", "b@>"},
+		},
+		"cycle with a tail": {
+			nodes: []*AgentNode{
+				{Title: "a", ChatID: "a", ParentChatID: "c"},
+				{Title: "b", ChatID: "b", ParentChatID: "a"},
+				{Title: "c", ChatID: "c", ParentChatID: "b"},
+				{Title: "leaf", ChatID: "l", ParentChatID: "b"},
+			},
+			want: []string{"a@", "b@>", "c@>>", "leaf@>>"},
+		},
+	} {
+		if got := treeRows(BuildAgentTree(test.nodes)); !slices.Equal(got, test.want) {
+			t.Errorf("%s: BuildAgentTree = %v, want %v", name, got, test.want)
+		}
+	}
+}
+
+func TestRenderAgentTree(t *testing.T) {
+	nodes := []*AgentNode{
+		{Title: "parent", ChatID: "p"},
+		{Title: "worker", ChatID: "w", ParentChatID: "p", Status: AgentStatusDone, Price: 0.0125, Result: "all\ntests  pass"},
+	}
+	rendered := RenderAgentTree(nodes, 80, 10)
+	lines := strings.Split(rendered, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), rendered)
+	}
+	if !strings.Contains(lines[0], "○ parent") || strings.Contains(lines[0], "idle") {
+		t.Errorf("root line = %q, want the idle icon and no status", lines[0])
+	}
+	if !strings.Contains(lines[1], "└ ✓ worker · done · $0.0125 · → all tests pass") {
+		t.Errorf("child line = %q", lines[1])
+	}
+}
+
+func TestRenderAgentTreeWindow(t *testing.T) {
+	var nodes []*AgentNode
+	for _, title := range []string{"t0", "t1", "t2", "t3", "t4", "t5", "t6"} {
+		nodes = append(nodes, &AgentNode{Title: title, ChatID: title})
+	}
+	for active, want := range map[int][]string{
+		0: {"t0", "t1", "t2"},
+		3: {"t2", "t3", "t4"},
+		6: {"t4", "t5", "t6"},
+	} {
+		for i, node := range nodes {
+			node.Active = i == active
+		}
+		lines := strings.Split(RenderAgentTree(nodes, 40, 3), "\n")
+		if len(lines) != len(want) {
+			t.Errorf("active %d: got %d rows, want %d", active, len(lines), len(want))
+			continue
+		}
+		for i, title := range want {
+			if !strings.Contains(lines[i], title) {
+				t.Errorf("active %d: row %d = %q, want %s", active, i, lines[i], title)
+			}
+		}
+	}
+}
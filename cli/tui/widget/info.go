@@ -204,6 +204,9 @@ func infoSpendRows(info *session.Info) []infoRow {
 	if total > 0 {
 		rows = append(rows, infoRow{label: "per 1m tokens", value: fmt.Sprintf("$%.2f", info.Price/float64(total)*1_000_000)})
 	}
+	if info.Budget.Limited {
+		rows = append(rows, infoRow{label: "budget left", value: fmt.Sprintf("$%.2f", info.Budget.Remaining)})
+	}
 	if cacheRead := info.Usage.GetInputTokenCacheRead().GetQuantity(); cacheRead > 0 {
 		billedInput := info.Usage.GetInputToken().GetQuantity() + cacheRead
 		rows = append(rows, infoRow{
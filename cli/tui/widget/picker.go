@@ -16,6 +16,8 @@ import (
 type PickerItem struct {
 	Label    string
 	Selected bool
+	// Note renders dim after the label, e.g. a file's token estimate.
+	Note string
 }
 
 // Picker is a modal fuzzy-search multi-select (fzf-style): type to filter,
@@ -30,6 +32,8 @@ type Picker struct {
 	cursor  int
 	width   int
 	height  int
+	// summary, when set, describes the current selection under the title.
+	summary func(selected []string) string
 }
 
 func NewPicker(title string, items []PickerItem) *Picker {
@@ -50,6 +54,22 @@ func (p *Picker) SetSize(width, height int) {
 	p.input.SetWidth(min(60, width-8))
 }
 
+// SetSummary installs a line describing the current selection, refreshed as
+// items are toggled (e.g. the selected files' total token estimate).
+func (p *Picker) SetSummary(summary func(selected []string) string) {
+	p.summary = summary
+}
+
+// SetNotes replaces the notes of the items labeled in labelToNote, e.g.
+// estimates computed once the picker is open.
+func (p *Picker) SetNotes(labelToNote map[string]string) {
+	for i := range p.items {
+		if note, ok := labelToNote[p.items[i].Label]; ok {
+			p.items[i].Note = note
+		}
+	}
+}
+
 // Selected returns the labels currently toggled on.
 func (p *Picker) Selected() []string {
 	var selected []string
@@ -142,6 +162,10 @@ func (p *Picker) View() string {
 	var b strings.Builder
 	b.WriteString(styles.ConfirmTitleStyle.Render(fmt.Sprintf("%s (%d selected)", p.title, len(p.Selected()))))
 	b.WriteString("\n")
+	if p.summary != nil {
+		b.WriteString(styles.DimTextStyle.Render(p.summary(p.Selected())))
+		b.WriteString("\n")
+	}
 	b.WriteString(styles.SearchInputStyle.Render(p.input.View()))
 	b.WriteString("\n")
 	// Scroll the window so the cursor row stays visible.
@@ -162,6 +186,9 @@ func (p *Picker) View() string {
 			line = styles.MenuItemStyle.Render(line)
 		}
 		b.WriteString(line)
+		if item.Note != "" {
+			b.WriteString(" " + styles.DimTextStyle.Render(item.Note))
+		}
 		b.WriteString("\n")
 	}
 	if len(p.matches) == 0 {
@@ -45,8 +45,9 @@ func (t *TitleBar) render() {
 }
 
 // Refresh rebuilds the title string from session state.
-// price is the sum of the chat's server-priced messages.
-func (t *TitleBar) Refresh(params session.Params, totalUsage, lastUsage *aipb.ModelUsage, price float64) {
+// price is the sum of the chat's server-priced messages; budget is the spend
+// left before the tightest configured cap.
+func (t *TitleBar) Refresh(params session.Params, totalUsage, lastUsage *aipb.ModelUsage, price float64, budget session.BudgetStatus) {
 	roleName := "anon"
 	if params.Role != nil {
 		roleName = params.Role.Name
@@ -70,6 +71,9 @@ func (t *TitleBar) Refresh(params session.Params, totalUsage, lastUsage *aipb.Mo
 	totalInputTokens := totalUsage.GetInputToken().GetQuantity() + totalUsage.GetInputTokenCacheRead().GetQuantity()
 	totalOutputTokens := totalUsage.GetOutputToken().GetQuantity() + totalUsage.GetOutputReasoningToken().GetQuantity()
 	tokenStr := fmt.Sprintf("↑%s ↓%s $%.4f", formatTokenCount(totalInputTokens), formatTokenCount(totalOutputTokens), price)
+	if budget.Limited {
+		tokenStr += fmt.Sprintf(" (💰 $%.2f left)", budget.Remaining)
+	}
 
 	contextStr := ""
 	if contextLimit := params.Model.GetTtt().GetContextTokenLimit(); contextLimit > 0 {
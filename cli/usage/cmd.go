@@ -0,0 +1,177 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/spf13/cobra"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/cache"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// chatUsageCacheMaxAge bounds how long a rollup is trusted. Rollups are keyed
+// on the chat's update time and only go stale when the server reprices
+// history, so the bound is generous.
+const chatUsageCacheMaxAge = 30 * 24 * time.Hour
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+// NewCmd reports spend and token usage across all chats, grouped by day,
+// model, role, tag or tool.
+func NewCmd(config *sgptpb.Configuration, aiClient aiservicepb.AiServiceClient) *cobra.Command {
+	chatStore := store.New(config, aiClient)
+
+	var (
+		since, until string
+		dimension    string
+		format       string
+		refresh      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Report spend and token usage by day, model, role, tag or tool",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(dimensions, dimension) {
+				return fmt.Errorf("invalid --by %q: want one of %s", dimension, strings.Join(dimensions, ", "))
+			}
+			if format != formatTable && format != formatCSV && format != formatJSON {
+				return fmt.Errorf("invalid --format %q: want table, csv or json", format)
+			}
+			dateRange, err := parseDateRange(since, until)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			aggregator := newAggregator(dimension, dateRange)
+			pageToken := ""
+			for {
+				chats, nextPageToken, err := chatStore.ListChats(ctx, 100, pageToken, "")
+				if err != nil {
+					return err
+				}
+				for _, chat := range chats {
+					if dateRange.excludes(chat) {
+						continue
+					}
+					records, err := chatRecords(cmd, chatStore, chat, refresh)
+					if err != nil {
+						return err
+					}
+					aggregator.add(chat, records)
+				}
+				if nextPageToken == "" {
+					break
+				}
+				pageToken = nextPageToken
+			}
+
+			out := cmd.OutOrStdout()
+			switch format {
+			case formatCSV:
+				return writeCSV(out, dimension, aggregator.rows())
+			case formatJSON:
+				return writeJSON(out, aggregator.rows())
+			default:
+				return writeTable(out, dimension, aggregator)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&since, "since", "", "Only count usage on or after this day (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&until, "until", "", "Only count usage on or before this day (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&dimension, "by", byDay, "Group by: "+strings.Join(dimensions, ", "))
+	cmd.Flags().StringVar(&format, "format", formatTable, "Output format: table, csv or json")
+	cmd.Flags().BoolVar(&refresh, "refresh", false, "Ignore cached rollups and re-list every chat's messages")
+	return cmd
+}
+
+// chatRecords returns a chat's usage records, listing its messages only when
+// the cached rollup predates the chat's last update.
+func chatRecords(cmd *cobra.Command, chatStore *store.Store, chat *aipb.Chat, refresh bool) ([]*sgptpb.UsageRecord, error) {
+	cacheKey := "usage/" + strings.ReplaceAll(chat.GetName(), "/", "_")
+	if !refresh {
+		chatUsage, ok := cache.Get(cacheKey, chatUsageCacheMaxAge, &sgptpb.ChatUsage{})
+		if ok && chatUsage.GetUpdateTime().AsTime().Equal(chat.GetUpdateTime().AsTime()) {
+			return chatUsage.GetRecords(), nil
+		}
+	}
+
+	messages, err := chatStore.ListMessages(cmd.Context(), chat.GetName())
+	if err != nil {
+		return nil, err
+	}
+	chatUsage := &sgptpb.ChatUsage{
+		Chat:       chat.GetName(),
+		UpdateTime: chat.GetUpdateTime(),
+		Records:    rollup(messages, store.CurrentModel(chat)),
+	}
+	if err := cache.Store(cacheKey, chatUsage); err != nil {
+		// A cold cache only costs speed.
+		fmt.Fprintf(cmd.ErrOrStderr(), "caching usage of %s: %v\n", chat.GetName(), err)
+	}
+	return chatUsage.GetRecords(), nil
+}
+
+func writeTable(out io.Writer, dimension string, aggregator *aggregator) error {
+	tabWriter := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tabWriter, "%s\tprice\tmessages\ttool calls\tinput\tcache read\tcache write\toutput\treasoning\t\n", strings.ToUpper(dimension))
+	writeTableRow := func(r *row) {
+		fmt.Fprintf(tabWriter, "%s\t$%.4f\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n",
+			r.Key, r.Price, r.Messages, r.ToolCalls, r.InputTokens, r.InputCacheReadTokens,
+			r.InputCacheWriteTokens, r.OutputTokens, r.OutputReasoningTokens)
+	}
+	for _, r := range aggregator.rows() {
+		writeTableRow(r)
+	}
+	total := aggregator.total
+	total.Key = fmt.Sprintf("TOTAL (%d chats)", aggregator.chatsCount)
+	writeTableRow(&total)
+	return tabWriter.Flush()
+}
+
+func writeCSV(out io.Writer, dimension string, rows []*row) error {
+	csvWriter := csv.NewWriter(out)
+	header := []string{
+		dimension, "price", "messages", "tool_calls", "input_tokens", "input_cache_read_tokens",
+		"input_cache_write_tokens", "output_tokens", "output_reasoning_tokens",
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		record := []string{r.Key, strconv.FormatFloat(r.Price, 'f', -1, 64)}
+		for _, count := range []int64{
+			r.Messages, r.ToolCalls, r.InputTokens, r.InputCacheReadTokens,
+			r.InputCacheWriteTokens, r.OutputTokens, r.OutputReasoningTokens,
+		} {
+			record = append(record, strconv.FormatInt(count, 10))
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeJSON(out io.Writer, rows []*row) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
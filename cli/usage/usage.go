@@ -0,0 +1,235 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// dayLayout formats the local calendar day records are bucketed by.
+const dayLayout = "2006-01-02"
+
+// noneKey groups records lacking the dimension: no role recorded, no tags,
+// no tool calls.
+const noneKey = "(none)"
+
+// Dimensions a report can be grouped by.
+const (
+	byDay   = "day"
+	byModel = "model"
+	byRole  = "role"
+	byTag   = "tag"
+	byTool  = "tool"
+)
+
+var dimensions = []string{byDay, byModel, byRole, byTag, byTool}
+
+// rollup summarizes a chat's messages into one record per (day, model,
+// tool). fallbackModel attributes messages predating per-message models.
+func rollup(messages []*aipb.Message, fallbackModel string) []*sgptpb.UsageRecord {
+	type recordKey struct{ day, model, tool string }
+	keyToRecord := map[recordKey]*sgptpb.UsageRecord{}
+	var orderedKeys []recordKey
+	for _, message := range messages {
+		modelUsage := message.GetModelUsage()
+		if message.GetPrice() == 0 && modelUsage == nil {
+			// Not generated: user, tool and context messages cost nothing.
+			continue
+		}
+		model := message.GetModel()
+		if model == "" {
+			model = fallbackModel
+		}
+		var toolNames []string
+		for _, block := range message.GetBlocks() {
+			if toolCall := block.GetToolCall(); toolCall != nil {
+				toolNames = append(toolNames, toolCall.GetName())
+			}
+		}
+		toolCalls := len(toolNames)
+		if toolCalls == 0 {
+			toolNames = []string{""}
+		}
+
+		day := message.GetCreateTime().AsTime().Local().Format(dayLayout)
+		shares := int64(len(toolNames))
+		for i, toolName := range toolNames {
+			key := recordKey{day: day, model: model, tool: toolName}
+			record, ok := keyToRecord[key]
+			if !ok {
+				record = &sgptpb.UsageRecord{Day: day, Model: model, Tool: toolName}
+				keyToRecord[key] = record
+				orderedKeys = append(orderedKeys, key)
+			}
+			// The message counts once, on its first tool; its spend is split
+			// evenly so every dimension sums to the same total.
+			if i == 0 {
+				record.Messages++
+			}
+			if toolCalls > 0 {
+				record.ToolCalls++
+			}
+			record.Price += message.GetPrice() / float64(shares)
+			record.InputTokens += share(int64(modelUsage.GetInputToken().GetQuantity()), shares, i)
+			record.InputCacheReadTokens += share(int64(modelUsage.GetInputTokenCacheRead().GetQuantity()), shares, i)
+			record.InputCacheWriteTokens += share(int64(modelUsage.GetInputTokenCacheWrite().GetQuantity()), shares, i)
+			record.OutputTokens += share(int64(modelUsage.GetOutputToken().GetQuantity()), shares, i)
+			record.OutputReasoningTokens += share(int64(modelUsage.GetOutputReasoningToken().GetQuantity()), shares, i)
+		}
+	}
+
+	records := make([]*sgptpb.UsageRecord, 0, len(orderedKeys))
+	for _, key := range orderedKeys {
+		records = append(records, keyToRecord[key])
+	}
+	return records
+}
+
+// share returns the i-th of n integer parts of quantity; the remainder goes
+// to the first parts so the parts sum back to quantity.
+func share(quantity, n int64, i int) int64 {
+	part := quantity / n
+	if int64(i) < quantity%n {
+		part++
+	}
+	return part
+}
+
+// row is one line of a report.
+type row struct {
+	Key                   string  `json:"key"`
+	Price                 float64 `json:"price"`
+	Messages              int64   `json:"messages"`
+	ToolCalls             int64   `json:"tool_calls"`
+	InputTokens           int64   `json:"input_tokens"`
+	InputCacheReadTokens  int64   `json:"input_cache_read_tokens"`
+	InputCacheWriteTokens int64   `json:"input_cache_write_tokens"`
+	OutputTokens          int64   `json:"output_tokens"`
+	OutputReasoningTokens int64   `json:"output_reasoning_tokens"`
+}
+
+func (r *row) add(record *sgptpb.UsageRecord) {
+	r.Price += record.GetPrice()
+	r.Messages += record.GetMessages()
+	r.ToolCalls += record.GetToolCalls()
+	r.InputTokens += record.GetInputTokens()
+	r.InputCacheReadTokens += record.GetInputCacheReadTokens()
+	r.InputCacheWriteTokens += record.GetInputCacheWriteTokens()
+	r.OutputTokens += record.GetOutputTokens()
+	r.OutputReasoningTokens += record.GetOutputReasoningTokens()
+}
+
+// dateRange bounds a report to the local days in [since, until]; an empty
+// bound is open.
+type dateRange struct {
+	since, until string
+}
+
+func parseDateRange(since, until string) (dateRange, error) {
+	for _, day := range []string{since, until} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.ParseInLocation(dayLayout, day, time.Local); err != nil {
+			return dateRange{}, fmt.Errorf("invalid date %q: want YYYY-MM-DD", day)
+		}
+	}
+	if since != "" && until != "" && until < since {
+		return dateRange{}, fmt.Errorf("--until %s is before --since %s", until, since)
+	}
+	return dateRange{since: since, until: until}, nil
+}
+
+// contains reports whether a day falls in the range. Days are YYYY-MM-DD, so
+// they compare lexicographically.
+func (d dateRange) contains(day string) bool {
+	return (d.since == "" || day >= d.since) && (d.until == "" || day <= d.until)
+}
+
+// excludes reports whether none of a chat's messages can fall in the range:
+// the chat was created after it ended or last updated before it started.
+func (d dateRange) excludes(chat *aipb.Chat) bool {
+	if d.until != "" && chat.GetCreateTime().AsTime().Local().Format(dayLayout) > d.until {
+		return true
+	}
+	return d.since != "" && chat.GetUpdateTime().AsTime().Local().Format(dayLayout) < d.since
+}
+
+// aggregator folds chat rollups into report rows along one dimension.
+type aggregator struct {
+	dimension  string
+	dateRange  dateRange
+	keyToRow   map[string]*row
+	total      row
+	chatsCount int
+}
+
+func newAggregator(dimension string, dateRange dateRange) *aggregator {
+	return &aggregator{dimension: dimension, dateRange: dateRange, keyToRow: map[string]*row{}}
+}
+
+// add folds a chat's records in. A chat with several tags counts toward
+// each of them, so the tag rows may sum past the total.
+func (a *aggregator) add(chat *aipb.Chat, records []*sgptpb.UsageRecord) {
+	counted := false
+	for _, record := range records {
+		if !a.dateRange.contains(record.GetDay()) {
+			continue
+		}
+		counted = true
+		a.total.add(record)
+		for _, key := range a.keys(chat, record) {
+			if key == "" {
+				key = noneKey
+			}
+			r, ok := a.keyToRow[key]
+			if !ok {
+				r = &row{Key: key}
+				a.keyToRow[key] = r
+			}
+			r.add(record)
+		}
+	}
+	if counted {
+		a.chatsCount++
+	}
+}
+
+func (a *aggregator) keys(chat *aipb.Chat, record *sgptpb.UsageRecord) []string {
+	switch a.dimension {
+	case byModel:
+		return []string{record.GetModel()}
+	case byRole:
+		return []string{store.Role(chat)}
+	case byTag:
+		if tags := store.Tags(chat); len(tags) > 0 {
+			return tags
+		}
+		return []string{""}
+	case byTool:
+		return []string{record.GetTool()}
+	default:
+		return []string{record.GetDay()}
+	}
+}
+
+// rows returns the report rows: chronological by day, most expensive first
+// otherwise.
+func (a *aggregator) rows() []*row {
+	rows := make([]*row, 0, len(a.keyToRow))
+	for _, r := range a.keyToRow {
+		rows = append(rows, r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if a.dimension != byDay && rows[i].Price != rows[j].Price {
+			return rows[i].Price > rows[j].Price
+		}
+		return rows[i].Key < rows[j].Key
+	})
+	return rows
+}
@@ -0,0 +1,100 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+func newGeneratedMessage(createTime time.Time, model string, price float64, toolNames ...string) *aipb.Message {
+	message := &aipb.Message{
+		Role:       aipb.Role_ROLE_ASSISTANT,
+		Model:      model,
+		Price:      price,
+		CreateTime: timestamppb.New(createTime),
+	}
+	for _, toolName := range toolNames {
+		message.Blocks = append(message.Blocks, &aipb.Block{
+			Content: &aipb.Block_ToolCall{ToolCall: &aipb.ToolCall{Name: toolName}},
+		})
+	}
+	return message
+}
+
+func TestRollup(t *testing.T) {
+	day := time.Date(2026, 3, 14, 12, 0, 0, 0, time.Local)
+	messages := []*aipb.Message{
+		{Role: aipb.Role_ROLE_USER, CreateTime: timestamppb.New(day)},
+		newGeneratedMessage(day, "", 0.3, "read_file", "search_lores"),
+		newGeneratedMessage(day, "", 0.1),
+		newGeneratedMessage(day.AddDate(0, 0, 1), "models/other", 0.2, "read_file"),
+	}
+	records := rollup(messages, "models/default")
+
+	type recordKey struct{ day, model, tool string }
+	keyToRecord := map[recordKey]*sgptpb.UsageRecord{}
+	for _, record := range records {
+		keyToRecord[recordKey{record.GetDay(), record.GetModel(), record.GetTool()}] = record
+	}
+	if len(keyToRecord) != 4 {
+		t.Fatalf("got %d records, want 4: %v", len(keyToRecord), records)
+	}
+
+	readFile := keyToRecord[recordKey{"2026-03-14", "models/default", "read_file"}]
+	if math.Abs(readFile.GetPrice()-0.15) > 1e-9 || readFile.GetMessages() != 1 || readFile.GetToolCalls() != 1 {
+		t.Errorf("read_file record = %v, want half the price, the message and one call", readFile)
+	}
+	searchLores := keyToRecord[recordKey{"2026-03-14", "models/default", "search_lores"}]
+	if math.Abs(searchLores.GetPrice()-0.15) > 1e-9 || searchLores.GetMessages() != 0 || searchLores.GetToolCalls() != 1 {
+		t.Errorf("search_lores record = %v, want half the price, no message and one call", searchLores)
+	}
+	if noTool := keyToRecord[recordKey{"2026-03-14", "models/default", ""}]; noTool.GetToolCalls() != 0 || noTool.GetMessages() != 1 {
+		t.Errorf("tool-less record = %v, want one message and no calls", noTool)
+	}
+	if nextDay := keyToRecord[recordKey{"2026-03-15", "models/other", "read_file"}]; nextDay.GetPrice() != 0.2 {
+		t.Errorf("next day record = %v, want the full price", nextDay)
+	}
+}
+
+func TestShare(t *testing.T) {
+	var total int64
+	for i := 0; i < 3; i++ {
+		total += share(10, 3, i)
+	}
+	if total != 10 {
+		t.Fatalf("shares sum to %d, want 10", total)
+	}
+	if got := share(10, 3, 0); got != 4 {
+		t.Errorf("first share = %d, want 4", got)
+	}
+}
+
+func TestAggregatorByTag(t *testing.T) {
+	chat := &aipb.Chat{Annotations: map[string]string{"sgpt.com/tags": "owner/a,owner/b"}}
+	records := []*sgptpb.UsageRecord{
+		{Day: "2026-03-13", Price: 1},
+		{Day: "2026-03-14", Price: 2},
+	}
+	aggregator := newAggregator(byTag, dateRange{since: "2026-03-14"})
+	aggregator.add(chat, records)
+	aggregator.add(&aipb.Chat{}, records)
+
+	keyToPrice := map[string]float64{}
+	for _, r := range aggregator.rows() {
+		keyToPrice[r.Key] = r.Price
+	}
+	want := map[string]float64{"owner/a": 2, "owner/b": 2, noneKey: 2}
+	for key, price := range want {
+		if keyToPrice[key] != price {
+			t.Errorf("%s price = %v, want %v", key, keyToPrice[key], price)
+		}
+	}
+	if aggregator.total.Price != 4 || aggregator.chatsCount != 2 {
+		t.Errorf("total = $%v over %d chats, want $4 over 2", aggregator.total.Price, aggregator.chatsCount)
+	}
+}
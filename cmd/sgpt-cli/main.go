@@ -12,7 +12,16 @@ import (
 
 	"github.com/malonaz/sgpt/cli/cache"
 	"github.com/malonaz/sgpt/cli/chat"
+	"github.com/malonaz/sgpt/cli/chats"
+	"github.com/malonaz/sgpt/cli/export"
+	"github.com/malonaz/sgpt/cli/importer"
+	"github.com/malonaz/sgpt/cli/lore"
+	"github.com/malonaz/sgpt/cli/mcp"
+	"github.com/malonaz/sgpt/cli/roles"
+	"github.com/malonaz/sgpt/cli/search"
 	"github.com/malonaz/sgpt/cli/titles"
+	"github.com/malonaz/sgpt/cli/tools"
+	"github.com/malonaz/sgpt/cli/usage"
 	"github.com/malonaz/sgpt/internal/configuration"
 )
 
@@ -80,7 +89,16 @@ func run() error {
 	aiClient := aiservicepb.NewAiServiceClient(clientNameToGRPCConnection[config.GetAiService()].Get())
 
 	rootCmd.AddCommand(chat.NewCmd(config, aiClient, clientNameToGRPCConnection))
+	rootCmd.AddCommand(chats.NewCmd(config, aiClient))
 	rootCmd.AddCommand(cache.NewCmd())
 	rootCmd.AddCommand(titles.NewCmd(config, aiClient))
+	rootCmd.AddCommand(tools.NewCmd(config, clientNameToGRPCConnection))
+	rootCmd.AddCommand(export.NewCmd(config, aiClient))
+	rootCmd.AddCommand(importer.NewCmd(config, aiClient))
+	rootCmd.AddCommand(lore.NewCmd(config))
+	rootCmd.AddCommand(mcp.NewCmd(config))
+	rootCmd.AddCommand(roles.NewCmd(config, aiClient))
+	rootCmd.AddCommand(search.NewCmd(config, aiClient))
+	rootCmd.AddCommand(usage.NewCmd(config, aiClient))
 	return rootCmd.Execute()
 }
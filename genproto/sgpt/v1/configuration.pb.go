@@ -14,6 +14,7 @@ import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	unsafe "unsafe"
 )
@@ -45,7 +46,9 @@ type Configuration struct {
 	// External repos whose roles and tool sets are addressable as
 	// "@{name}@{selector}" (e.g. "@github.com/malonaz/core@go/grpc:architecture"
 	// or --role "@github.com/malonaz/core@reviewer").
-	Imports       []*Import `protobuf:"bytes,8,rep,name=imports,proto3" json:"imports,omitempty"`
+	Imports []*Import `protobuf:"bytes,8,rep,name=imports,proto3" json:"imports,omitempty"`
+	// Configuration of `sgpt mcp serve`.
+	McpServe      *McpServeConfiguration `protobuf:"bytes,9,opt,name=mcp_serve,json=mcpServe,proto3" json:"mcp_serve,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -124,6 +127,13 @@ func (x *Configuration) GetImports() []*Import {
 	return nil
 }
 
+func (x *Configuration) GetMcpServe() *McpServeConfiguration {
+	if x != nil {
+		return x.McpServe
+	}
+	return nil
+}
+
 func (x *Configuration) SetGrpcClients(v []*GrpcClient) {
 	x.GrpcClients = v
 }
@@ -152,6 +162,10 @@ func (x *Configuration) SetImports(v []*Import) {
 	x.Imports = v
 }
 
+func (x *Configuration) SetMcpServe(v *McpServeConfiguration) {
+	x.McpServe = v
+}
+
 func (x *Configuration) HasChat() bool {
 	if x == nil {
 		return false
@@ -159,10 +173,21 @@ func (x *Configuration) HasChat() bool {
 	return x.Chat != nil
 }
 
+func (x *Configuration) HasMcpServe() bool {
+	if x == nil {
+		return false
+	}
+	return x.McpServe != nil
+}
+
 func (x *Configuration) ClearChat() {
 	x.Chat = nil
 }
 
+func (x *Configuration) ClearMcpServe() {
+	x.McpServe = nil
+}
+
 type Configuration_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -184,6 +209,8 @@ type Configuration_builder struct {
 	// "@{name}@{selector}" (e.g. "@github.com/malonaz/core@go/grpc:architecture"
 	// or --role "@github.com/malonaz/core@reviewer").
 	Imports []*Import
+	// Configuration of `sgpt mcp serve`.
+	McpServe *McpServeConfiguration
 }
 
 func (b0 Configuration_builder) Build() *Configuration {
@@ -197,6 +224,7 @@ func (b0 Configuration_builder) Build() *Configuration {
 	x.Ignore = b.Ignore
 	x.Title = b.Title
 	x.Imports = b.Imports
+	x.McpServe = b.McpServe
 	return m0
 }
 
@@ -482,9 +510,26 @@ type ChatConfiguration struct {
 	// Lores injected into the context of every chat, as selectors
 	// ("lores/{lore}" locally, "@{import}//lores/{lore}" for an imported
 	// repo). Selectors rather than paths, so a lore survives being moved.
-	DefaultLores  []string `protobuf:"bytes,6,rep,name=default_lores,json=defaultLores,proto3" json:"default_lores,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	DefaultLores []string `protobuf:"bytes,6,rep,name=default_lores,json=defaultLores,proto3" json:"default_lores,omitempty"`
+	// Spend guardrails. Unset caps are unlimited.
+	Budget *Budget `protobuf:"bytes,7,opt,name=budget,proto3" json:"budget,omitempty"`
+	// Number of lores injected into the context before each turn, ranked by
+	// relevance to the user's message; lores already in the context are
+	// skipped. 0 disables automatic retrieval; --auto-lores overrides it.
+	AutoLores int32 `protobuf:"varint,8,opt,name=auto_lores,json=autoLores,proto3" json:"auto_lores,omitempty"`
+	// Execution policy of tools whose tool set or plugin declares none
+	// (built-ins, MCP servers).
+	ToolExecutionPolicy *ExecutionPolicy `protobuf:"bytes,9,opt,name=tool_execution_policy,json=toolExecutionPolicy,proto3" json:"tool_execution_policy,omitempty"`
+	// Retry of generations failing with a transient error (rate limiting,
+	// overload). Unset retries nothing.
+	GenerationRetry *GenerationRetry `protobuf:"bytes,10,opt,name=generation_retry,json=generationRetry,proto3" json:"generation_retry,omitempty"`
+	// Models generations fall back to, in order, once the chat's model has
+	// exhausted its retries. A role's `@fallback_model` chain replaces it.
+	// Ignored without generation_retry.
+	// Format: providers/{provider}/models/{model}
+	FallbackModels []string `protobuf:"bytes,11,rep,name=fallback_models,json=fallbackModels,proto3" json:"fallback_models,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ChatConfiguration) Reset() {
@@ -554,6 +599,41 @@ func (x *ChatConfiguration) GetDefaultLores() []string {
 	return nil
 }
 
+func (x *ChatConfiguration) GetBudget() *Budget {
+	if x != nil {
+		return x.Budget
+	}
+	return nil
+}
+
+func (x *ChatConfiguration) GetAutoLores() int32 {
+	if x != nil {
+		return x.AutoLores
+	}
+	return 0
+}
+
+func (x *ChatConfiguration) GetToolExecutionPolicy() *ExecutionPolicy {
+	if x != nil {
+		return x.ToolExecutionPolicy
+	}
+	return nil
+}
+
+func (x *ChatConfiguration) GetGenerationRetry() *GenerationRetry {
+	if x != nil {
+		return x.GenerationRetry
+	}
+	return nil
+}
+
+func (x *ChatConfiguration) GetFallbackModels() []string {
+	if x != nil {
+		return x.FallbackModels
+	}
+	return nil
+}
+
 func (x *ChatConfiguration) SetUser(v string) {
 	x.User = v
 }
@@ -578,6 +658,59 @@ func (x *ChatConfiguration) SetDefaultLores(v []string) {
 	x.DefaultLores = v
 }
 
+func (x *ChatConfiguration) SetBudget(v *Budget) {
+	x.Budget = v
+}
+
+func (x *ChatConfiguration) SetAutoLores(v int32) {
+	x.AutoLores = v
+}
+
+func (x *ChatConfiguration) SetToolExecutionPolicy(v *ExecutionPolicy) {
+	x.ToolExecutionPolicy = v
+}
+
+func (x *ChatConfiguration) SetGenerationRetry(v *GenerationRetry) {
+	x.GenerationRetry = v
+}
+
+func (x *ChatConfiguration) SetFallbackModels(v []string) {
+	x.FallbackModels = v
+}
+
+func (x *ChatConfiguration) HasBudget() bool {
+	if x == nil {
+		return false
+	}
+	return x.Budget != nil
+}
+
+func (x *ChatConfiguration) HasToolExecutionPolicy() bool {
+	if x == nil {
+		return false
+	}
+	return x.ToolExecutionPolicy != nil
+}
+
+func (x *ChatConfiguration) HasGenerationRetry() bool {
+	if x == nil {
+		return false
+	}
+	return x.GenerationRetry != nil
+}
+
+func (x *ChatConfiguration) ClearBudget() {
+	x.Budget = nil
+}
+
+func (x *ChatConfiguration) ClearToolExecutionPolicy() {
+	x.ToolExecutionPolicy = nil
+}
+
+func (x *ChatConfiguration) ClearGenerationRetry() {
+	x.GenerationRetry = nil
+}
+
 type ChatConfiguration_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -599,6 +732,23 @@ type ChatConfiguration_builder struct {
 	// ("lores/{lore}" locally, "@{import}//lores/{lore}" for an imported
 	// repo). Selectors rather than paths, so a lore survives being moved.
 	DefaultLores []string
+	// Spend guardrails. Unset caps are unlimited.
+	Budget *Budget
+	// Number of lores injected into the context before each turn, ranked by
+	// relevance to the user's message; lores already in the context are
+	// skipped. 0 disables automatic retrieval; --auto-lores overrides it.
+	AutoLores int32
+	// Execution policy of tools whose tool set or plugin declares none
+	// (built-ins, MCP servers).
+	ToolExecutionPolicy *ExecutionPolicy
+	// Retry of generations failing with a transient error (rate limiting,
+	// overload). Unset retries nothing.
+	GenerationRetry *GenerationRetry
+	// Models generations fall back to, in order, once the chat's model has
+	// exhausted its retries. A role's `@fallback_model` chain replaces it.
+	// Ignored without generation_retry.
+	// Format: providers/{provider}/models/{model}
+	FallbackModels []string
 }
 
 func (b0 ChatConfiguration_builder) Build() *ChatConfiguration {
@@ -611,51 +761,44 @@ func (b0 ChatConfiguration_builder) Build() *ChatConfiguration {
 	x.DefaultRole = b.DefaultRole
 	x.DefaultTools = b.DefaultTools
 	x.DefaultLores = b.DefaultLores
+	x.Budget = b.Budget
+	x.AutoLores = b.AutoLores
+	x.ToolExecutionPolicy = b.ToolExecutionPolicy
+	x.GenerationRetry = b.GenerationRetry
+	x.FallbackModels = b.FallbackModels
 	return m0
 }
 
-// A role defines a persona with a system prompt. Persisted as a
-// `.sgpt/{title}.role.md` markdown file — `@directive(...)` lines carry the
-// structured fields, the body is the prompt — and addressed please-style
-// ("//dir:title", "@import//dir:title").
-type Role struct {
+// How sgpt retries a generation failing with a transient error: the
+// provider reported rate limiting or overload, or was unreachable.
+type GenerationRetry struct {
 	state protoimpl.MessageState `protogen:"hybrid.v1"`
-	// Name of this role: its selector ("//dir:title"), derived from the file
-	// location. Never written in the file.
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// Short alias for this role (`@alias("go")`).
-	Alias string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
-	// System prompt for this role: the markdown body.
-	Prompt string `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
-	// The resource name of the model to use for this role (`@model("...")`).
-	// Format: providers/{provider}/models/{model}
-	Model string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
-	// Files to include as context for this role (`@file("path")`).
-	Files []string `protobuf:"bytes,5,rep,name=files,proto3" json:"files,omitempty"`
-	// Tools to include as part of this role (`@tool("exec_shell")`).
-	Tools []string `protobuf:"bytes,6,rep,name=tools,proto3" json:"tools,omitempty"`
-	// Selectors of other roles to include (`@role("//dir:title")`). Included
-	// roles are expanded depth-first: their prompts are prepended and their
-	// files/tools merged.
-	Roles         []string `protobuf:"bytes,7,rep,name=roles,proto3" json:"roles,omitempty"`
+	// Additional attempts made with each model before falling back to the
+	// next one. 0 disables retries.
+	MaxRetries int32 `protobuf:"varint,1,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	// Wait before the first retry, doubled before each subsequent one up to
+	// max_backoff; defaults to 1s.
+	InitialBackoff *durationpb.Duration `protobuf:"bytes,2,opt,name=initial_backoff,json=initialBackoff,proto3" json:"initial_backoff,omitempty"`
+	// Upper bound of the wait between two attempts; defaults to 30s.
+	MaxBackoff    *durationpb.Duration `protobuf:"bytes,3,opt,name=max_backoff,json=maxBackoff,proto3" json:"max_backoff,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *Role) Reset() {
-	*x = Role{}
+func (x *GenerationRetry) Reset() {
+	*x = GenerationRetry{}
 	mi := &file_sgpt_v1_configuration_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *Role) String() string {
+func (x *GenerationRetry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Role) ProtoMessage() {}
+func (*GenerationRetry) ProtoMessage() {}
 
-func (x *Role) ProtoReflect() protoreflect.Message {
+func (x *GenerationRetry) ProtoReflect() protoreflect.Message {
 	mi := &file_sgpt_v1_configuration_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -667,151 +810,264 @@ func (x *Role) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *Role) GetName() string {
+func (x *GenerationRetry) GetMaxRetries() int32 {
 	if x != nil {
-		return x.Name
+		return x.MaxRetries
 	}
-	return ""
+	return 0
 }
 
-func (x *Role) GetAlias() string {
+func (x *GenerationRetry) GetInitialBackoff() *durationpb.Duration {
 	if x != nil {
-		return x.Alias
+		return x.InitialBackoff
 	}
-	return ""
+	return nil
 }
 
-func (x *Role) GetPrompt() string {
+func (x *GenerationRetry) GetMaxBackoff() *durationpb.Duration {
 	if x != nil {
-		return x.Prompt
+		return x.MaxBackoff
 	}
-	return ""
+	return nil
 }
 
-func (x *Role) GetModel() string {
+func (x *GenerationRetry) SetMaxRetries(v int32) {
+	x.MaxRetries = v
+}
+
+func (x *GenerationRetry) SetInitialBackoff(v *durationpb.Duration) {
+	x.InitialBackoff = v
+}
+
+func (x *GenerationRetry) SetMaxBackoff(v *durationpb.Duration) {
+	x.MaxBackoff = v
+}
+
+func (x *GenerationRetry) HasInitialBackoff() bool {
+	if x == nil {
+		return false
+	}
+	return x.InitialBackoff != nil
+}
+
+func (x *GenerationRetry) HasMaxBackoff() bool {
+	if x == nil {
+		return false
+	}
+	return x.MaxBackoff != nil
+}
+
+func (x *GenerationRetry) ClearInitialBackoff() {
+	x.InitialBackoff = nil
+}
+
+func (x *GenerationRetry) ClearMaxBackoff() {
+	x.MaxBackoff = nil
+}
+
+type GenerationRetry_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Additional attempts made with each model before falling back to the
+	// next one. 0 disables retries.
+	MaxRetries int32
+	// Wait before the first retry, doubled before each subsequent one up to
+	// max_backoff; defaults to 1s.
+	InitialBackoff *durationpb.Duration
+	// Upper bound of the wait between two attempts; defaults to 30s.
+	MaxBackoff *durationpb.Duration
+}
+
+func (b0 GenerationRetry_builder) Build() *GenerationRetry {
+	m0 := &GenerationRetry{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.MaxRetries = b.MaxRetries
+	x.InitialBackoff = b.InitialBackoff
+	x.MaxBackoff = b.MaxBackoff
+	return m0
+}
+
+// How sgpt runs a tool's calls. Unset fields disable the corresponding
+// guard.
+//
+// A call fails when it times out or its tool's infrastructure does (a lost
+// connection, an unavailable engine). A call the tool rejects (a bad path, a
+// patch that doesn't apply) is the model's to fix: it is neither retried nor
+// counted by the circuit breaker.
+type ExecutionPolicy struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Deadline of each attempt (e.g. "30s"): an attempt running longer is
+	// cancelled and fails as timed out.
+	Timeout *durationpb.Duration `protobuf:"bytes,1,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Additional attempts made after a failed call to a side-effect free
+	// tool. Calls with side effects are never retried.
+	MaxRetries int32 `protobuf:"varint,2,opt,name=max_retries,json=maxRetries,proto3" json:"max_retries,omitempty"`
+	// Wait before the first retry, doubled before each subsequent one;
+	// defaults to 500ms.
+	RetryBackoff *durationpb.Duration `protobuf:"bytes,3,opt,name=retry_backoff,json=retryBackoff,proto3" json:"retry_backoff,omitempty"`
+	// Consecutive failed calls after which the tool is disabled for the rest
+	// of the session: its calls then fail without running. 0 never disables
+	// it.
+	CircuitBreakerThreshold int32 `protobuf:"varint,4,opt,name=circuit_breaker_threshold,json=circuitBreakerThreshold,proto3" json:"circuit_breaker_threshold,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *ExecutionPolicy) Reset() {
+	*x = ExecutionPolicy{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionPolicy) ProtoMessage() {}
+
+func (x *ExecutionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[6]
 	if x != nil {
-		return x.Model
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *Role) GetFiles() []string {
+func (x *ExecutionPolicy) GetTimeout() *durationpb.Duration {
 	if x != nil {
-		return x.Files
+		return x.Timeout
 	}
 	return nil
 }
 
-func (x *Role) GetTools() []string {
+func (x *ExecutionPolicy) GetMaxRetries() int32 {
 	if x != nil {
-		return x.Tools
+		return x.MaxRetries
 	}
-	return nil
+	return 0
 }
 
-func (x *Role) GetRoles() []string {
+func (x *ExecutionPolicy) GetRetryBackoff() *durationpb.Duration {
 	if x != nil {
-		return x.Roles
+		return x.RetryBackoff
 	}
 	return nil
 }
 
-func (x *Role) SetName(v string) {
-	x.Name = v
+func (x *ExecutionPolicy) GetCircuitBreakerThreshold() int32 {
+	if x != nil {
+		return x.CircuitBreakerThreshold
+	}
+	return 0
 }
 
-func (x *Role) SetAlias(v string) {
-	x.Alias = v
+func (x *ExecutionPolicy) SetTimeout(v *durationpb.Duration) {
+	x.Timeout = v
 }
 
-func (x *Role) SetPrompt(v string) {
-	x.Prompt = v
+func (x *ExecutionPolicy) SetMaxRetries(v int32) {
+	x.MaxRetries = v
 }
 
-func (x *Role) SetModel(v string) {
-	x.Model = v
+func (x *ExecutionPolicy) SetRetryBackoff(v *durationpb.Duration) {
+	x.RetryBackoff = v
 }
 
-func (x *Role) SetFiles(v []string) {
-	x.Files = v
+func (x *ExecutionPolicy) SetCircuitBreakerThreshold(v int32) {
+	x.CircuitBreakerThreshold = v
 }
 
-func (x *Role) SetTools(v []string) {
-	x.Tools = v
+func (x *ExecutionPolicy) HasTimeout() bool {
+	if x == nil {
+		return false
+	}
+	return x.Timeout != nil
 }
 
-func (x *Role) SetRoles(v []string) {
-	x.Roles = v
+func (x *ExecutionPolicy) HasRetryBackoff() bool {
+	if x == nil {
+		return false
+	}
+	return x.RetryBackoff != nil
 }
 
-type Role_builder struct {
-	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+func (x *ExecutionPolicy) ClearTimeout() {
+	x.Timeout = nil
+}
 
-	// Name of this role: its selector ("//dir:title"), derived from the file
-	// location. Never written in the file.
-	Name string
-	// Short alias for this role (`@alias("go")`).
-	Alias string
-	// System prompt for this role: the markdown body.
-	Prompt string
-	// The resource name of the model to use for this role (`@model("...")`).
-	// Format: providers/{provider}/models/{model}
-	Model string
-	// Files to include as context for this role (`@file("path")`).
-	Files []string
-	// Tools to include as part of this role (`@tool("exec_shell")`).
-	Tools []string
-	// Selectors of other roles to include (`@role("//dir:title")`). Included
-	// roles are expanded depth-first: their prompts are prepended and their
-	// files/tools merged.
-	Roles []string
+func (x *ExecutionPolicy) ClearRetryBackoff() {
+	x.RetryBackoff = nil
 }
 
-func (b0 Role_builder) Build() *Role {
-	m0 := &Role{}
+type ExecutionPolicy_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Deadline of each attempt (e.g. "30s"): an attempt running longer is
+	// cancelled and fails as timed out.
+	Timeout *durationpb.Duration
+	// Additional attempts made after a failed call to a side-effect free
+	// tool. Calls with side effects are never retried.
+	MaxRetries int32
+	// Wait before the first retry, doubled before each subsequent one;
+	// defaults to 500ms.
+	RetryBackoff *durationpb.Duration
+	// Consecutive failed calls after which the tool is disabled for the rest
+	// of the session: its calls then fail without running. 0 never disables
+	// it.
+	CircuitBreakerThreshold int32
+}
+
+func (b0 ExecutionPolicy_builder) Build() *ExecutionPolicy {
+	m0 := &ExecutionPolicy{}
 	b, x := &b0, m0
 	_, _ = b, x
-	x.Name = b.Name
-	x.Alias = b.Alias
-	x.Prompt = b.Prompt
-	x.Model = b.Model
-	x.Files = b.Files
-	x.Tools = b.Tools
-	x.Roles = b.Roles
+	x.Timeout = b.Timeout
+	x.MaxRetries = b.MaxRetries
+	x.RetryBackoff = b.RetryBackoff
+	x.CircuitBreakerThreshold = b.CircuitBreakerThreshold
 	return m0
 }
 
-// A remote tool engine that provides tool sets via gRPC. Persisted as a
-// `.sgpt/{title}.toolset` file; discovered and addressed please-style
-// ("//dir:title", "@import//dir:title").
-type ToolSet struct {
+// Spend guardrails. When a cap is reached the turn pauses until the user
+// either stops it or confirms, which grants one more allowance of the same
+// size — a runaway loop keeps asking instead of spending silently.
+type Budget struct {
 	state protoimpl.MessageState `protogen:"hybrid.v1"`
-	// Name of this tool set: its selector ("//dir:title"). Maintained by
-	// sgpt, never read from the file.
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	// Name of the gRPC client to use for the engine service.
-	EngineService string `protobuf:"bytes,2,opt,name=engine_service,json=engineService,proto3" json:"engine_service,omitempty"`
-	// Tool set definitions to create from this engine.
-	ToolSets      []*v1.CreateServiceToolSetRequest `protobuf:"bytes,3,rep,name=tool_sets,json=toolSets,proto3" json:"tool_sets,omitempty"`
+	// Maximum number of tool-call round trips (generate → execute tools →
+	// generate) within a single turn.
+	MaxToolIterations int32 `protobuf:"varint,1,opt,name=max_tool_iterations,json=maxToolIterations,proto3" json:"max_tool_iterations,omitempty"`
+	// Maximum spend of a single chat, in USD.
+	MaxChatPrice float64 `protobuf:"fixed64,2,opt,name=max_chat_price,json=maxChatPrice,proto3" json:"max_chat_price,omitempty"`
+	// Maximum combined spend of a chat and every sub-agent it launches
+	// (transitively), in USD.
+	MaxAgentTreePrice float64 `protobuf:"fixed64,3,opt,name=max_agent_tree_price,json=maxAgentTreePrice,proto3" json:"max_agent_tree_price,omitempty"`
+	// Maximum combined spend of the chats created today (local time), in USD.
+	MaxDailyPrice float64 `protobuf:"fixed64,4,opt,name=max_daily_price,json=maxDailyPrice,proto3" json:"max_daily_price,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolSet) Reset() {
-	*x = ToolSet{}
-	mi := &file_sgpt_v1_configuration_proto_msgTypes[6]
+func (x *Budget) Reset() {
+	*x = Budget{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolSet) String() string {
+func (x *Budget) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolSet) ProtoMessage() {}
+func (*Budget) ProtoMessage() {}
 
-func (x *ToolSet) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_configuration_proto_msgTypes[6]
+func (x *Budget) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -822,58 +1078,1324 @@ func (x *ToolSet) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-func (x *ToolSet) GetName() string {
+func (x *Budget) GetMaxToolIterations() int32 {
 	if x != nil {
-		return x.Name
+		return x.MaxToolIterations
 	}
-	return ""
+	return 0
 }
 
-func (x *ToolSet) GetEngineService() string {
+func (x *Budget) GetMaxChatPrice() float64 {
 	if x != nil {
-		return x.EngineService
+		return x.MaxChatPrice
 	}
-	return ""
+	return 0
 }
 
-func (x *ToolSet) GetToolSets() []*v1.CreateServiceToolSetRequest {
+func (x *Budget) GetMaxAgentTreePrice() float64 {
 	if x != nil {
-		return x.ToolSets
+		return x.MaxAgentTreePrice
 	}
-	return nil
+	return 0
 }
 
-func (x *ToolSet) SetName(v string) {
-	x.Name = v
+func (x *Budget) GetMaxDailyPrice() float64 {
+	if x != nil {
+		return x.MaxDailyPrice
+	}
+	return 0
 }
 
-func (x *ToolSet) SetEngineService(v string) {
-	x.EngineService = v
+func (x *Budget) SetMaxToolIterations(v int32) {
+	x.MaxToolIterations = v
 }
 
-func (x *ToolSet) SetToolSets(v []*v1.CreateServiceToolSetRequest) {
-	x.ToolSets = v
+func (x *Budget) SetMaxChatPrice(v float64) {
+	x.MaxChatPrice = v
 }
 
-type ToolSet_builder struct {
-	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+func (x *Budget) SetMaxAgentTreePrice(v float64) {
+	x.MaxAgentTreePrice = v
+}
+
+func (x *Budget) SetMaxDailyPrice(v float64) {
+	x.MaxDailyPrice = v
+}
+
+type Budget_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Maximum number of tool-call round trips (generate → execute tools →
+	// generate) within a single turn.
+	MaxToolIterations int32
+	// Maximum spend of a single chat, in USD.
+	MaxChatPrice float64
+	// Maximum combined spend of a chat and every sub-agent it launches
+	// (transitively), in USD.
+	MaxAgentTreePrice float64
+	// Maximum combined spend of the chats created today (local time), in USD.
+	MaxDailyPrice float64
+}
+
+func (b0 Budget_builder) Build() *Budget {
+	m0 := &Budget{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.MaxToolIterations = b.MaxToolIterations
+	x.MaxChatPrice = b.MaxChatPrice
+	x.MaxAgentTreePrice = b.MaxAgentTreePrice
+	x.MaxDailyPrice = b.MaxDailyPrice
+	return m0
+}
+
+// Permission policy of `sgpt mcp serve`, which exposes built-in tools to
+// other MCP clients (editors, agents). Those clients run tool calls without
+// sgpt's review prompt, so the policy stands in for it: a call sgpt would
+// auto-execute (e.g. read_files) runs, any other call is refused unless its
+// tool is auto-approved.
+type McpServeConfiguration struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Built-in tools exposed (e.g. "read_files", "search_lores"). Empty
+	// exposes every built-in that runs outside a chat; --tool overrides it.
+	Tools []string `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+	// Exposed tools whose calls run without review (e.g. "replace", "diff").
+	// --approve overrides it.
+	AutoApprovedTools []string `protobuf:"bytes,2,rep,name=auto_approved_tools,json=autoApprovedTools,proto3" json:"auto_approved_tools,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *McpServeConfiguration) Reset() {
+	*x = McpServeConfiguration{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpServeConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpServeConfiguration) ProtoMessage() {}
+
+func (x *McpServeConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *McpServeConfiguration) GetTools() []string {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+func (x *McpServeConfiguration) GetAutoApprovedTools() []string {
+	if x != nil {
+		return x.AutoApprovedTools
+	}
+	return nil
+}
+
+func (x *McpServeConfiguration) SetTools(v []string) {
+	x.Tools = v
+}
+
+func (x *McpServeConfiguration) SetAutoApprovedTools(v []string) {
+	x.AutoApprovedTools = v
+}
+
+type McpServeConfiguration_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Built-in tools exposed (e.g. "read_files", "search_lores"). Empty
+	// exposes every built-in that runs outside a chat; --tool overrides it.
+	Tools []string
+	// Exposed tools whose calls run without review (e.g. "replace", "diff").
+	// --approve overrides it.
+	AutoApprovedTools []string
+}
+
+func (b0 McpServeConfiguration_builder) Build() *McpServeConfiguration {
+	m0 := &McpServeConfiguration{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Tools = b.Tools
+	x.AutoApprovedTools = b.AutoApprovedTools
+	return m0
+}
+
+// A role defines a persona with a system prompt. Persisted as a
+// `.sgpt/{title}.role.md` markdown file — `@directive(...)` lines carry the
+// structured fields, the body is the prompt — and addressed please-style
+// ("//dir:title", "@import//dir:title").
+type Role struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of this role: its selector ("//dir:title"), derived from the file
+	// location. Never written in the file.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Short alias for this role (`@alias("go")`).
+	Alias string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	// System prompt for this role: the markdown body before the first
+	// `@section`.
+	Prompt string `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// The resource name of the model to use for this role (`@model("...")`).
+	// Format: providers/{provider}/models/{model}
+	Model string `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	// Files to include as context for this role (`@file("path")`).
+	Files []string `protobuf:"bytes,5,rep,name=files,proto3" json:"files,omitempty"`
+	// Tools to include as part of this role (`@tool("exec_shell")`).
+	Tools []string `protobuf:"bytes,6,rep,name=tools,proto3" json:"tools,omitempty"`
+	// Selectors of other roles to include (`@role("//dir:title")`). Included
+	// roles are expanded depth-first: their prompts are prepended and their
+	// files/tools merged.
+	Roles []string `protobuf:"bytes,7,rep,name=roles,proto3" json:"roles,omitempty"`
+	// Parameters declared by this role.
+	Parameters []*Role_Parameter `protobuf:"bytes,9,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	// Named prompt sections, after `prompt`. A section replaces, in place,
+	// the same-named section of the roles this role includes.
+	Sections []*Role_Section `protobuf:"bytes,10,rep,name=sections,proto3" json:"sections,omitempty"`
+	// Tools removed from the included roles (`@exclude_tool("diff")`).
+	ExcludedTools []string `protobuf:"bytes,11,rep,name=excluded_tools,json=excludedTools,proto3" json:"excluded_tools,omitempty"`
+	// Files removed from the included roles (`@exclude_file("path")`).
+	ExcludedFiles []string `protobuf:"bytes,12,rep,name=excluded_files,json=excludedFiles,proto3" json:"excluded_files,omitempty"`
+	// Model pinned for this role (`@override_model("...")`). Unlike `model`,
+	// it also wins over the `model` of the roles included alongside this one.
+	// The including role's own `model` or `override_model` still wins over it.
+	OverrideModel string `protobuf:"bytes,13,opt,name=override_model,json=overrideModel,proto3" json:"override_model,omitempty"`
+	// Models generations fall back to, in order, when the role's model keeps
+	// failing (`@fallback_model("...")`, repeatable). The outermost role
+	// declaring a chain wins.
+	FallbackModels []string `protobuf:"bytes,14,rep,name=fallback_models,json=fallbackModels,proto3" json:"fallback_models,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Role) Reset() {
+	*x = Role{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role) ProtoMessage() {}
+
+func (x *Role) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Role) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *Role) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *Role) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Role) GetFiles() []string {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *Role) GetTools() []string {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+func (x *Role) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *Role) GetParameters() []*Role_Parameter {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *Role) GetSections() []*Role_Section {
+	if x != nil {
+		return x.Sections
+	}
+	return nil
+}
+
+func (x *Role) GetExcludedTools() []string {
+	if x != nil {
+		return x.ExcludedTools
+	}
+	return nil
+}
+
+func (x *Role) GetExcludedFiles() []string {
+	if x != nil {
+		return x.ExcludedFiles
+	}
+	return nil
+}
+
+func (x *Role) GetOverrideModel() string {
+	if x != nil {
+		return x.OverrideModel
+	}
+	return ""
+}
+
+func (x *Role) GetFallbackModels() []string {
+	if x != nil {
+		return x.FallbackModels
+	}
+	return nil
+}
+
+func (x *Role) SetName(v string) {
+	x.Name = v
+}
+
+func (x *Role) SetAlias(v string) {
+	x.Alias = v
+}
+
+func (x *Role) SetPrompt(v string) {
+	x.Prompt = v
+}
+
+func (x *Role) SetModel(v string) {
+	x.Model = v
+}
+
+func (x *Role) SetFiles(v []string) {
+	x.Files = v
+}
+
+func (x *Role) SetTools(v []string) {
+	x.Tools = v
+}
+
+func (x *Role) SetRoles(v []string) {
+	x.Roles = v
+}
+
+func (x *Role) SetParameters(v []*Role_Parameter) {
+	x.Parameters = v
+}
+
+func (x *Role) SetSections(v []*Role_Section) {
+	x.Sections = v
+}
+
+func (x *Role) SetExcludedTools(v []string) {
+	x.ExcludedTools = v
+}
+
+func (x *Role) SetExcludedFiles(v []string) {
+	x.ExcludedFiles = v
+}
+
+func (x *Role) SetOverrideModel(v string) {
+	x.OverrideModel = v
+}
+
+func (x *Role) SetFallbackModels(v []string) {
+	x.FallbackModels = v
+}
+
+type Role_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Name of this role: its selector ("//dir:title"), derived from the file
+	// location. Never written in the file.
+	Name string
+	// Short alias for this role (`@alias("go")`).
+	Alias string
+	// System prompt for this role: the markdown body before the first
+	// `@section`.
+	Prompt string
+	// The resource name of the model to use for this role (`@model("...")`).
+	// Format: providers/{provider}/models/{model}
+	Model string
+	// Files to include as context for this role (`@file("path")`).
+	Files []string
+	// Tools to include as part of this role (`@tool("exec_shell")`).
+	Tools []string
+	// Selectors of other roles to include (`@role("//dir:title")`). Included
+	// roles are expanded depth-first: their prompts are prepended and their
+	// files/tools merged.
+	Roles []string
+	// Parameters declared by this role.
+	Parameters []*Role_Parameter
+	// Named prompt sections, after `prompt`. A section replaces, in place,
+	// the same-named section of the roles this role includes.
+	Sections []*Role_Section
+	// Tools removed from the included roles (`@exclude_tool("diff")`).
+	ExcludedTools []string
+	// Files removed from the included roles (`@exclude_file("path")`).
+	ExcludedFiles []string
+	// Model pinned for this role (`@override_model("...")`). Unlike `model`,
+	// it also wins over the `model` of the roles included alongside this one.
+	// The including role's own `model` or `override_model` still wins over it.
+	OverrideModel string
+	// Models generations fall back to, in order, when the role's model keeps
+	// failing (`@fallback_model("...")`, repeatable). The outermost role
+	// declaring a chain wins.
+	FallbackModels []string
+}
+
+func (b0 Role_builder) Build() *Role {
+	m0 := &Role{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Name = b.Name
+	x.Alias = b.Alias
+	x.Prompt = b.Prompt
+	x.Model = b.Model
+	x.Files = b.Files
+	x.Tools = b.Tools
+	x.Roles = b.Roles
+	x.Parameters = b.Parameters
+	x.Sections = b.Sections
+	x.ExcludedTools = b.ExcludedTools
+	x.ExcludedFiles = b.ExcludedFiles
+	x.OverrideModel = b.OverrideModel
+	x.FallbackModels = b.FallbackModels
+	return m0
+}
+
+// A remote tool engine that provides tool sets via gRPC. Persisted as a
+// `.sgpt/{title}.toolset` file; discovered and addressed please-style
+// ("//dir:title", "@import//dir:title").
+type ToolSet struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of this tool set: its selector ("//dir:title"). Maintained by
+	// sgpt, never read from the file.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Name of the gRPC client to use for the engine service.
+	EngineService string `protobuf:"bytes,2,opt,name=engine_service,json=engineService,proto3" json:"engine_service,omitempty"`
+	// Tool set definitions to create from this engine.
+	ToolSets []*v1.CreateServiceToolSetRequest `protobuf:"bytes,3,rep,name=tool_sets,json=toolSets,proto3" json:"tool_sets,omitempty"`
+	// How the engine's tools are run; defaults to the chat's
+	// tool_execution_policy.
+	ExecutionPolicy *ExecutionPolicy `protobuf:"bytes,4,opt,name=execution_policy,json=executionPolicy,proto3" json:"execution_policy,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolSet) Reset() {
+	*x = ToolSet{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolSet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolSet) ProtoMessage() {}
+
+func (x *ToolSet) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ToolSet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ToolSet) GetEngineService() string {
+	if x != nil {
+		return x.EngineService
+	}
+	return ""
+}
+
+func (x *ToolSet) GetToolSets() []*v1.CreateServiceToolSetRequest {
+	if x != nil {
+		return x.ToolSets
+	}
+	return nil
+}
+
+func (x *ToolSet) GetExecutionPolicy() *ExecutionPolicy {
+	if x != nil {
+		return x.ExecutionPolicy
+	}
+	return nil
+}
+
+func (x *ToolSet) SetName(v string) {
+	x.Name = v
+}
+
+func (x *ToolSet) SetEngineService(v string) {
+	x.EngineService = v
+}
+
+func (x *ToolSet) SetToolSets(v []*v1.CreateServiceToolSetRequest) {
+	x.ToolSets = v
+}
+
+func (x *ToolSet) SetExecutionPolicy(v *ExecutionPolicy) {
+	x.ExecutionPolicy = v
+}
+
+func (x *ToolSet) HasExecutionPolicy() bool {
+	if x == nil {
+		return false
+	}
+	return x.ExecutionPolicy != nil
+}
+
+func (x *ToolSet) ClearExecutionPolicy() {
+	x.ExecutionPolicy = nil
+}
+
+type ToolSet_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
 	// Name of this tool set: its selector ("//dir:title"). Maintained by
 	// sgpt, never read from the file.
 	Name string
-	// Name of the gRPC client to use for the engine service.
-	EngineService string
-	// Tool set definitions to create from this engine.
-	ToolSets []*v1.CreateServiceToolSetRequest
+	// Name of the gRPC client to use for the engine service.
+	EngineService string
+	// Tool set definitions to create from this engine.
+	ToolSets []*v1.CreateServiceToolSetRequest
+	// How the engine's tools are run; defaults to the chat's
+	// tool_execution_policy.
+	ExecutionPolicy *ExecutionPolicy
+}
+
+func (b0 ToolSet_builder) Build() *ToolSet {
+	m0 := &ToolSet{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Name = b.Name
+	x.EngineService = b.EngineService
+	x.ToolSets = b.ToolSets
+	x.ExecutionPolicy = b.ExecutionPolicy
+	return m0
+}
+
+// A Model Context Protocol server that provides tools. Persisted as a
+// `.sgpt/{title}.mcp` file (JSON); discovered and addressed please-style
+// ("//dir:title", "@import//dir:title"), like tool sets.
+type McpServer struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of this server: its selector ("//dir:title"). Maintained by sgpt,
+	// never read from the file.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// How to reach the server.
+	//
+	// Types that are valid to be assigned to Transport:
+	//
+	//	*McpServer_Stdio_
+	//	*McpServer_Http_
+	Transport isMcpServer_Transport `protobuf_oneof:"transport"`
+	// Prefix of the advertised tool names ("{prefix}_{tool}"), keeping tools
+	// of different servers apart; defaults to the file's title.
+	ToolPrefix    string `protobuf:"bytes,4,opt,name=tool_prefix,json=toolPrefix,proto3" json:"tool_prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *McpServer) Reset() {
+	*x = McpServer{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpServer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpServer) ProtoMessage() {}
+
+func (x *McpServer) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *McpServer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *McpServer) GetTransport() isMcpServer_Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return nil
+}
+
+func (x *McpServer) GetStdio() *McpServer_Stdio {
+	if x != nil {
+		if x, ok := x.Transport.(*McpServer_Stdio_); ok {
+			return x.Stdio
+		}
+	}
+	return nil
+}
+
+func (x *McpServer) GetHttp() *McpServer_Http {
+	if x != nil {
+		if x, ok := x.Transport.(*McpServer_Http_); ok {
+			return x.Http
+		}
+	}
+	return nil
+}
+
+func (x *McpServer) GetToolPrefix() string {
+	if x != nil {
+		return x.ToolPrefix
+	}
+	return ""
+}
+
+func (x *McpServer) SetName(v string) {
+	x.Name = v
+}
+
+func (x *McpServer) SetStdio(v *McpServer_Stdio) {
+	if v == nil {
+		x.Transport = nil
+		return
+	}
+	x.Transport = &McpServer_Stdio_{v}
+}
+
+func (x *McpServer) SetHttp(v *McpServer_Http) {
+	if v == nil {
+		x.Transport = nil
+		return
+	}
+	x.Transport = &McpServer_Http_{v}
+}
+
+func (x *McpServer) SetToolPrefix(v string) {
+	x.ToolPrefix = v
+}
+
+func (x *McpServer) HasTransport() bool {
+	if x == nil {
+		return false
+	}
+	return x.Transport != nil
+}
+
+func (x *McpServer) HasStdio() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.Transport.(*McpServer_Stdio_)
+	return ok
+}
+
+func (x *McpServer) HasHttp() bool {
+	if x == nil {
+		return false
+	}
+	_, ok := x.Transport.(*McpServer_Http_)
+	return ok
+}
+
+func (x *McpServer) ClearTransport() {
+	x.Transport = nil
+}
+
+func (x *McpServer) ClearStdio() {
+	if _, ok := x.Transport.(*McpServer_Stdio_); ok {
+		x.Transport = nil
+	}
+}
+
+func (x *McpServer) ClearHttp() {
+	if _, ok := x.Transport.(*McpServer_Http_); ok {
+		x.Transport = nil
+	}
+}
+
+const McpServer_Transport_not_set_case case_McpServer_Transport = 0
+const McpServer_Stdio_case case_McpServer_Transport = 2
+const McpServer_Http_case case_McpServer_Transport = 3
+
+func (x *McpServer) WhichTransport() case_McpServer_Transport {
+	if x == nil {
+		return McpServer_Transport_not_set_case
+	}
+	switch x.Transport.(type) {
+	case *McpServer_Stdio_:
+		return McpServer_Stdio_case
+	case *McpServer_Http_:
+		return McpServer_Http_case
+	default:
+		return McpServer_Transport_not_set_case
+	}
+}
+
+type McpServer_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Name of this server: its selector ("//dir:title"). Maintained by sgpt,
+	// never read from the file.
+	Name string
+	// How to reach the server.
+
+	// Fields of oneof Transport:
+	Stdio *McpServer_Stdio
+	Http  *McpServer_Http
+	// -- end of Transport
+	// Prefix of the advertised tool names ("{prefix}_{tool}"), keeping tools
+	// of different servers apart; defaults to the file's title.
+	ToolPrefix string
+}
+
+func (b0 McpServer_builder) Build() *McpServer {
+	m0 := &McpServer{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Name = b.Name
+	if b.Stdio != nil {
+		x.Transport = &McpServer_Stdio_{b.Stdio}
+	}
+	if b.Http != nil {
+		x.Transport = &McpServer_Http_{b.Http}
+	}
+	x.ToolPrefix = b.ToolPrefix
+	return m0
+}
+
+type case_McpServer_Transport protoreflect.FieldNumber
+
+func (x case_McpServer_Transport) String() string {
+	md := file_sgpt_v1_configuration_proto_msgTypes[11].Descriptor()
+	if x == 0 {
+		return "not set"
+	}
+	return protoimpl.X.MessageFieldStringOf(md, protoreflect.FieldNumber(x))
+}
+
+type isMcpServer_Transport interface {
+	isMcpServer_Transport()
+}
+
+type McpServer_Stdio_ struct {
+	Stdio *McpServer_Stdio `protobuf:"bytes,2,opt,name=stdio,proto3,oneof"`
+}
+
+type McpServer_Http_ struct {
+	Http *McpServer_Http `protobuf:"bytes,3,opt,name=http,proto3,oneof"`
+}
+
+func (*McpServer_Stdio_) isMcpServer_Transport() {}
+
+func (*McpServer_Http_) isMcpServer_Transport() {}
+
+// A local tool plugin: an executable speaking sgpt's plugin protocol
+// (newline-delimited JSON-RPC over stdin/stdout: describe, review,
+// execute), so repo-specific tools can ship as scripts. Persisted as a
+// `.sgpt/{title}.plugin` file (JSON); discovered and addressed
+// please-style ("//dir:title", "@import//dir:title"), like tool sets.
+type Plugin struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of this plugin: its selector ("//dir:title"). Maintained by sgpt,
+	// never read from the file.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Executable to run: looked up on the PATH when a bare name, resolved
+	// against the repo root when a relative path ("./tools/lint.py").
+	Command string `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	// Arguments passed to the command.
+	Args []string `protobuf:"bytes,3,rep,name=args,proto3" json:"args,omitempty"`
+	// Environment variables set on top of sgpt's own.
+	Env map[string]string `protobuf:"bytes,4,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Working directory, relative to the repo root; defaults to the repo
+	// root. Made absolute by sgpt.
+	Dir string `protobuf:"bytes,5,opt,name=dir,proto3" json:"dir,omitempty"`
+	// Prefix of the advertised tool names ("{prefix}_{tool}"); defaults to
+	// the file's title.
+	ToolPrefix string `protobuf:"bytes,6,opt,name=tool_prefix,json=toolPrefix,proto3" json:"tool_prefix,omitempty"`
+	// How the plugin's tools are run; defaults to the chat's
+	// tool_execution_policy.
+	ExecutionPolicy *ExecutionPolicy `protobuf:"bytes,7,opt,name=execution_policy,json=executionPolicy,proto3" json:"execution_policy,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Plugin) Reset() {
+	*x = Plugin{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plugin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plugin) ProtoMessage() {}
+
+func (x *Plugin) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Plugin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Plugin) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *Plugin) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *Plugin) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *Plugin) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *Plugin) GetToolPrefix() string {
+	if x != nil {
+		return x.ToolPrefix
+	}
+	return ""
+}
+
+func (x *Plugin) GetExecutionPolicy() *ExecutionPolicy {
+	if x != nil {
+		return x.ExecutionPolicy
+	}
+	return nil
+}
+
+func (x *Plugin) SetName(v string) {
+	x.Name = v
+}
+
+func (x *Plugin) SetCommand(v string) {
+	x.Command = v
+}
+
+func (x *Plugin) SetArgs(v []string) {
+	x.Args = v
+}
+
+func (x *Plugin) SetEnv(v map[string]string) {
+	x.Env = v
+}
+
+func (x *Plugin) SetDir(v string) {
+	x.Dir = v
+}
+
+func (x *Plugin) SetToolPrefix(v string) {
+	x.ToolPrefix = v
+}
+
+func (x *Plugin) SetExecutionPolicy(v *ExecutionPolicy) {
+	x.ExecutionPolicy = v
+}
+
+func (x *Plugin) HasExecutionPolicy() bool {
+	if x == nil {
+		return false
+	}
+	return x.ExecutionPolicy != nil
+}
+
+func (x *Plugin) ClearExecutionPolicy() {
+	x.ExecutionPolicy = nil
+}
+
+type Plugin_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Name of this plugin: its selector ("//dir:title"). Maintained by sgpt,
+	// never read from the file.
+	Name string
+	// Executable to run: looked up on the PATH when a bare name, resolved
+	// against the repo root when a relative path ("./tools/lint.py").
+	Command string
+	// Arguments passed to the command.
+	Args []string
+	// Environment variables set on top of sgpt's own.
+	Env map[string]string
+	// Working directory, relative to the repo root; defaults to the repo
+	// root. Made absolute by sgpt.
+	Dir string
+	// Prefix of the advertised tool names ("{prefix}_{tool}"); defaults to
+	// the file's title.
+	ToolPrefix string
+	// How the plugin's tools are run; defaults to the chat's
+	// tool_execution_policy.
+	ExecutionPolicy *ExecutionPolicy
+}
+
+func (b0 Plugin_builder) Build() *Plugin {
+	m0 := &Plugin{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Name = b.Name
+	x.Command = b.Command
+	x.Args = b.Args
+	x.Env = b.Env
+	x.Dir = b.Dir
+	x.ToolPrefix = b.ToolPrefix
+	x.ExecutionPolicy = b.ExecutionPolicy
+	return m0
 }
 
-func (b0 ToolSet_builder) Build() *ToolSet {
-	m0 := &ToolSet{}
+// A value the prompt takes (`@param("name", "default")`). Declaring one
+// makes the prompt a text/template reading it as {{ .Params.name }}; the
+// prompts of roles without parameters are used verbatim.
+type Role_Parameter struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of the parameter.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Value used when none is passed.
+	DefaultValue string `protobuf:"bytes,2,opt,name=default_value,json=defaultValue,proto3" json:"default_value,omitempty"`
+	// Set when the directive gives no default (`@param("name")`): a value
+	// must be passed.
+	Required      bool `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Role_Parameter) Reset() {
+	*x = Role_Parameter{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role_Parameter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role_Parameter) ProtoMessage() {}
+
+func (x *Role_Parameter) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Role_Parameter) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role_Parameter) GetDefaultValue() string {
+	if x != nil {
+		return x.DefaultValue
+	}
+	return ""
+}
+
+func (x *Role_Parameter) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *Role_Parameter) SetName(v string) {
+	x.Name = v
+}
+
+func (x *Role_Parameter) SetDefaultValue(v string) {
+	x.DefaultValue = v
+}
+
+func (x *Role_Parameter) SetRequired(v bool) {
+	x.Required = v
+}
+
+type Role_Parameter_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Name of the parameter.
+	Name string
+	// Value used when none is passed.
+	DefaultValue string
+	// Set when the directive gives no default (`@param("name")`): a value
+	// must be passed.
+	Required bool
+}
+
+func (b0 Role_Parameter_builder) Build() *Role_Parameter {
+	m0 := &Role_Parameter{}
 	b, x := &b0, m0
 	_, _ = b, x
 	x.Name = b.Name
-	x.EngineService = b.EngineService
-	x.ToolSets = b.ToolSets
+	x.DefaultValue = b.DefaultValue
+	x.Required = b.Required
+	return m0
+}
+
+// A named part of the prompt: an `@section("name")` line starts one, and
+// it runs until the next section or the end of the body.
+type Role_Section struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Name of the section.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Markdown content of the section.
+	Content       string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Role_Section) Reset() {
+	*x = Role_Section{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role_Section) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role_Section) ProtoMessage() {}
+
+func (x *Role_Section) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *Role_Section) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role_Section) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Role_Section) SetName(v string) {
+	x.Name = v
+}
+
+func (x *Role_Section) SetContent(v string) {
+	x.Content = v
+}
+
+type Role_Section_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Name of the section.
+	Name string
+	// Markdown content of the section.
+	Content string
+}
+
+func (b0 Role_Section_builder) Build() *Role_Section {
+	m0 := &Role_Section{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Name = b.Name
+	x.Content = b.Content
+	return m0
+}
+
+// A server launched as a subprocess, spoken to over its stdin/stdout.
+type McpServer_Stdio struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Executable to run: looked up on the PATH when a bare name, resolved
+	// against the repo root when a relative path ("./bin/server").
+	Command string `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	// Arguments passed to the command.
+	Args []string `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	// Environment variables set on top of sgpt's own.
+	Env map[string]string `protobuf:"bytes,3,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Working directory, relative to the repo root; defaults to the repo
+	// root. Made absolute by sgpt.
+	Dir           string `protobuf:"bytes,4,opt,name=dir,proto3" json:"dir,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *McpServer_Stdio) Reset() {
+	*x = McpServer_Stdio{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpServer_Stdio) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpServer_Stdio) ProtoMessage() {}
+
+func (x *McpServer_Stdio) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *McpServer_Stdio) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *McpServer_Stdio) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *McpServer_Stdio) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *McpServer_Stdio) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *McpServer_Stdio) SetCommand(v string) {
+	x.Command = v
+}
+
+func (x *McpServer_Stdio) SetArgs(v []string) {
+	x.Args = v
+}
+
+func (x *McpServer_Stdio) SetEnv(v map[string]string) {
+	x.Env = v
+}
+
+func (x *McpServer_Stdio) SetDir(v string) {
+	x.Dir = v
+}
+
+type McpServer_Stdio_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Executable to run: looked up on the PATH when a bare name, resolved
+	// against the repo root when a relative path ("./bin/server").
+	Command string
+	// Arguments passed to the command.
+	Args []string
+	// Environment variables set on top of sgpt's own.
+	Env map[string]string
+	// Working directory, relative to the repo root; defaults to the repo
+	// root. Made absolute by sgpt.
+	Dir string
+}
+
+func (b0 McpServer_Stdio_builder) Build() *McpServer_Stdio {
+	m0 := &McpServer_Stdio{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Command = b.Command
+	x.Args = b.Args
+	x.Env = b.Env
+	x.Dir = b.Dir
+	return m0
+}
+
+// A remote server spoken to over the streamable HTTP transport.
+type McpServer_Http struct {
+	state protoimpl.MessageState `protogen:"hybrid.v1"`
+	// Endpoint URL (e.g. "http://localhost:8080/mcp").
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Headers sent with every request (e.g. "Authorization").
+	Headers       map[string]string `protobuf:"bytes,2,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *McpServer_Http) Reset() {
+	*x = McpServer_Http{}
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpServer_Http) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpServer_Http) ProtoMessage() {}
+
+func (x *McpServer_Http) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_configuration_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *McpServer_Http) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *McpServer_Http) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *McpServer_Http) SetUrl(v string) {
+	x.Url = v
+}
+
+func (x *McpServer_Http) SetHeaders(v map[string]string) {
+	x.Headers = v
+}
+
+type McpServer_Http_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Endpoint URL (e.g. "http://localhost:8080/mcp").
+	Url string
+	// Headers sent with every request (e.g. "Authorization").
+	Headers map[string]string
+}
+
+func (b0 McpServer_Http_builder) Build() *McpServer_Http {
+	m0 := &McpServer_Http{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.Url = b.Url
+	x.Headers = b.Headers
 	return m0
 }
 
@@ -881,7 +2403,7 @@ var File_sgpt_v1_configuration_proto protoreflect.FileDescriptor
 
 const file_sgpt_v1_configuration_proto_rawDesc = "" +
 	"\n" +
-	"\x1bsgpt/v1/configuration.proto\x12\asgpt.v1\x1a\x1bbuf/validate/validate.proto\x1a\x19google/api/resource.proto\x1a'malonaz/ai/ai_engine/v1/ai_engine.proto\"\x97\x02\n" +
+	"\x1bsgpt/v1/configuration.proto\x12\asgpt.v1\x1a\x1bbuf/validate/validate.proto\x1a\x19google/api/resource.proto\x1a\x1egoogle/protobuf/duration.proto\x1a'malonaz/ai/ai_engine/v1/ai_engine.proto\"\xd4\x02\n" +
 	"\rConfiguration\x126\n" +
 	"\fgrpc_clients\x18\x01 \x03(\v2\x13.sgpt.v1.GrpcClientR\vgrpcClients\x12\x1d\n" +
 	"\n" +
@@ -890,7 +2412,8 @@ const file_sgpt_v1_configuration_proto_rawDesc = "" +
 	"\x04chat\x18\x04 \x01(\v2\x1a.sgpt.v1.ChatConfigurationR\x04chat\x12\x16\n" +
 	"\x06ignore\x18\x06 \x03(\tR\x06ignore\x12\x14\n" +
 	"\x05title\x18\a \x01(\tR\x05title\x12)\n" +
-	"\aimports\x18\b \x03(\v2\x0f.sgpt.v1.ImportR\aimports\"@\n" +
+	"\aimports\x18\b \x03(\v2\x0f.sgpt.v1.ImportR\aimports\x12;\n" +
+	"\tmcp_serve\x18\t \x01(\v2\x1e.sgpt.v1.McpServeConfigurationR\bmcpServe\"@\n" +
 	"\x06Import\x12\x1a\n" +
 	"\x04name\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04name\x12\x1a\n" +
 	"\x04path\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x04path\"\x8a\x01\n" +
@@ -903,7 +2426,7 @@ const file_sgpt_v1_configuration_proto_rawDesc = "" +
 	"\x05Model\x123\n" +
 	"\x04name\x18\x01 \x01(\tB\x1f\xfaA\x16\n" +
 	"\x14ai.malonaz.com/Model\xbaH\x03\xc8\x01\x01R\x04name\x12\x14\n" +
-	"\x05alias\x18\x02 \x01(\tR\x05alias\"\xae\x02\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\"\xd6\x04\n" +
 	"\x11ChatConfiguration\x12,\n" +
 	"\x04user\x18\x01 \x01(\tB\x18\xfaA\x15\n" +
 	"\x13ai.malonaz.com/UserR\x04user\x12>\n" +
@@ -913,7 +2436,35 @@ const file_sgpt_v1_configuration_proto_rawDesc = "" +
 	"\x14ai.malonaz.com/ModelR\fdefaultModel\x12!\n" +
 	"\fdefault_role\x18\x04 \x01(\tR\vdefaultRole\x12#\n" +
 	"\rdefault_tools\x18\x05 \x03(\tR\fdefaultTools\x12#\n" +
-	"\rdefault_lores\x18\x06 \x03(\tR\fdefaultLores\"\xc1\x01\n" +
+	"\rdefault_lores\x18\x06 \x03(\tR\fdefaultLores\x12'\n" +
+	"\x06budget\x18\a \x01(\v2\x0f.sgpt.v1.BudgetR\x06budget\x12&\n" +
+	"\n" +
+	"auto_lores\x18\b \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\tautoLores\x12L\n" +
+	"\x15tool_execution_policy\x18\t \x01(\v2\x18.sgpt.v1.ExecutionPolicyR\x13toolExecutionPolicy\x12C\n" +
+	"\x10generation_retry\x18\n" +
+	" \x01(\v2\x18.sgpt.v1.GenerationRetryR\x0fgenerationRetry\x12B\n" +
+	"\x0ffallback_models\x18\v \x03(\tB\x19\xfaA\x16\n" +
+	"\x14ai.malonaz.com/ModelR\x0efallbackModels\"\xbb\x01\n" +
+	"\x0fGenerationRetry\x12(\n" +
+	"\vmax_retries\x18\x01 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\n" +
+	"maxRetries\x12B\n" +
+	"\x0finitial_backoff\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x0einitialBackoff\x12:\n" +
+	"\vmax_backoff\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\n" +
+	"maxBackoff\"\xf5\x01\n" +
+	"\x0fExecutionPolicy\x123\n" +
+	"\atimeout\x18\x01 \x01(\v2\x19.google.protobuf.DurationR\atimeout\x12(\n" +
+	"\vmax_retries\x18\x02 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\n" +
+	"maxRetries\x12>\n" +
+	"\rretry_backoff\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\fretryBackoff\x12C\n" +
+	"\x19circuit_breaker_threshold\x18\x04 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\x17circuitBreakerThreshold\"\xf0\x01\n" +
+	"\x06Budget\x127\n" +
+	"\x13max_tool_iterations\x18\x01 \x01(\x05B\a\xbaH\x04\x1a\x02(\x00R\x11maxToolIterations\x124\n" +
+	"\x0emax_chat_price\x18\x02 \x01(\x01B\x0e\xbaH\v\x12\t)\x00\x00\x00\x00\x00\x00\x00\x00R\fmaxChatPrice\x12?\n" +
+	"\x14max_agent_tree_price\x18\x03 \x01(\x01B\x0e\xbaH\v\x12\t)\x00\x00\x00\x00\x00\x00\x00\x00R\x11maxAgentTreePrice\x126\n" +
+	"\x0fmax_daily_price\x18\x04 \x01(\x01B\x0e\xbaH\v\x12\t)\x00\x00\x00\x00\x00\x00\x00\x00R\rmaxDailyPrice\"]\n" +
+	"\x15McpServeConfiguration\x12\x14\n" +
+	"\x05tools\x18\x01 \x03(\tR\x05tools\x12.\n" +
+	"\x13auto_approved_tools\x18\x02 \x03(\tR\x11autoApprovedTools\"\x9c\x05\n" +
 	"\x04Role\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
 	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x16\n" +
@@ -922,34 +2473,117 @@ const file_sgpt_v1_configuration_proto_rawDesc = "" +
 	"\x14ai.malonaz.com/ModelR\x05model\x12\x14\n" +
 	"\x05files\x18\x05 \x03(\tR\x05files\x12\x14\n" +
 	"\x05tools\x18\x06 \x03(\tR\x05tools\x12\x14\n" +
-	"\x05roles\x18\a \x03(\tR\x05rolesJ\x04\b\b\x10\t\"\x9f\x01\n" +
+	"\x05roles\x18\a \x03(\tR\x05roles\x127\n" +
+	"\n" +
+	"parameters\x18\t \x03(\v2\x17.sgpt.v1.Role.ParameterR\n" +
+	"parameters\x121\n" +
+	"\bsections\x18\n" +
+	" \x03(\v2\x15.sgpt.v1.Role.SectionR\bsections\x12%\n" +
+	"\x0eexcluded_tools\x18\v \x03(\tR\rexcludedTools\x12%\n" +
+	"\x0eexcluded_files\x18\f \x03(\tR\rexcludedFiles\x12@\n" +
+	"\x0eoverride_model\x18\r \x01(\tB\x19\xfaA\x16\n" +
+	"\x14ai.malonaz.com/ModelR\roverrideModel\x12B\n" +
+	"\x0ffallback_models\x18\x0e \x03(\tB\x19\xfaA\x16\n" +
+	"\x14ai.malonaz.com/ModelR\x0efallbackModels\x1a`\n" +
+	"\tParameter\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
+	"\rdefault_value\x18\x02 \x01(\tR\fdefaultValue\x12\x1a\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x1a7\n" +
+	"\aSection\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontentJ\x04\b\b\x10\t\"\xe4\x01\n" +
 	"\aToolSet\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12-\n" +
 	"\x0eengine_service\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\rengineService\x12Q\n" +
-	"\ttool_sets\x18\x03 \x03(\v24.malonaz.ai.ai_engine.v1.CreateServiceToolSetRequestR\btoolSetsB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
-
-var file_sgpt_v1_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+	"\ttool_sets\x18\x03 \x03(\v24.malonaz.ai.ai_engine.v1.CreateServiceToolSetRequestR\btoolSets\x12C\n" +
+	"\x10execution_policy\x18\x04 \x01(\v2\x18.sgpt.v1.ExecutionPolicyR\x0fexecutionPolicy\"\x93\x04\n" +
+	"\tMcpServer\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x120\n" +
+	"\x05stdio\x18\x02 \x01(\v2\x18.sgpt.v1.McpServer.StdioH\x00R\x05stdio\x12-\n" +
+	"\x04http\x18\x03 \x01(\v2\x17.sgpt.v1.McpServer.HttpH\x00R\x04http\x12\x1f\n" +
+	"\vtool_prefix\x18\x04 \x01(\tR\n" +
+	"toolPrefix\x1a\xbc\x01\n" +
+	"\x05Stdio\x12 \n" +
+	"\acommand\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\acommand\x12\x12\n" +
+	"\x04args\x18\x02 \x03(\tR\x04args\x123\n" +
+	"\x03env\x18\x03 \x03(\v2!.sgpt.v1.McpServer.Stdio.EnvEntryR\x03env\x12\x10\n" +
+	"\x03dir\x18\x04 \x01(\tR\x03dir\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a\x9c\x01\n" +
+	"\x04Http\x12\x18\n" +
+	"\x03url\x18\x01 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\x03url\x12>\n" +
+	"\aheaders\x18\x02 \x03(\v2$.sgpt.v1.McpServer.Http.HeadersEntryR\aheaders\x1a:\n" +
+	"\fHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\x12\n" +
+	"\ttransport\x12\x05\xbaH\x02\b\x01\"\xae\x02\n" +
+	"\x06Plugin\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\acommand\x18\x02 \x01(\tB\x06\xbaH\x03\xc8\x01\x01R\acommand\x12\x12\n" +
+	"\x04args\x18\x03 \x03(\tR\x04args\x12*\n" +
+	"\x03env\x18\x04 \x03(\v2\x18.sgpt.v1.Plugin.EnvEntryR\x03env\x12\x10\n" +
+	"\x03dir\x18\x05 \x01(\tR\x03dir\x12\x1f\n" +
+	"\vtool_prefix\x18\x06 \x01(\tR\n" +
+	"toolPrefix\x12C\n" +
+	"\x10execution_policy\x18\a \x01(\v2\x18.sgpt.v1.ExecutionPolicyR\x0fexecutionPolicy\x1a6\n" +
+	"\bEnvEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
+
+var file_sgpt_v1_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
 var file_sgpt_v1_configuration_proto_goTypes = []any{
 	(*Configuration)(nil),                  // 0: sgpt.v1.Configuration
 	(*Import)(nil),                         // 1: sgpt.v1.Import
 	(*GrpcClient)(nil),                     // 2: sgpt.v1.GrpcClient
 	(*Model)(nil),                          // 3: sgpt.v1.Model
 	(*ChatConfiguration)(nil),              // 4: sgpt.v1.ChatConfiguration
-	(*Role)(nil),                           // 5: sgpt.v1.Role
-	(*ToolSet)(nil),                        // 6: sgpt.v1.ToolSet
-	(*v1.CreateServiceToolSetRequest)(nil), // 7: malonaz.ai.ai_engine.v1.CreateServiceToolSetRequest
+	(*GenerationRetry)(nil),                // 5: sgpt.v1.GenerationRetry
+	(*ExecutionPolicy)(nil),                // 6: sgpt.v1.ExecutionPolicy
+	(*Budget)(nil),                         // 7: sgpt.v1.Budget
+	(*McpServeConfiguration)(nil),          // 8: sgpt.v1.McpServeConfiguration
+	(*Role)(nil),                           // 9: sgpt.v1.Role
+	(*ToolSet)(nil),                        // 10: sgpt.v1.ToolSet
+	(*McpServer)(nil),                      // 11: sgpt.v1.McpServer
+	(*Plugin)(nil),                         // 12: sgpt.v1.Plugin
+	(*Role_Parameter)(nil),                 // 13: sgpt.v1.Role.Parameter
+	(*Role_Section)(nil),                   // 14: sgpt.v1.Role.Section
+	(*McpServer_Stdio)(nil),                // 15: sgpt.v1.McpServer.Stdio
+	(*McpServer_Http)(nil),                 // 16: sgpt.v1.McpServer.Http
+	nil,                                    // 17: sgpt.v1.McpServer.Stdio.EnvEntry
+	nil,                                    // 18: sgpt.v1.McpServer.Http.HeadersEntry
+	nil,                                    // 19: sgpt.v1.Plugin.EnvEntry
+	(*durationpb.Duration)(nil),            // 20: google.protobuf.Duration
+	(*v1.CreateServiceToolSetRequest)(nil), // 21: malonaz.ai.ai_engine.v1.CreateServiceToolSetRequest
 }
 var file_sgpt_v1_configuration_proto_depIdxs = []int32{
-	2, // 0: sgpt.v1.Configuration.grpc_clients:type_name -> sgpt.v1.GrpcClient
-	3, // 1: sgpt.v1.Configuration.models:type_name -> sgpt.v1.Model
-	4, // 2: sgpt.v1.Configuration.chat:type_name -> sgpt.v1.ChatConfiguration
-	1, // 3: sgpt.v1.Configuration.imports:type_name -> sgpt.v1.Import
-	7, // 4: sgpt.v1.ToolSet.tool_sets:type_name -> malonaz.ai.ai_engine.v1.CreateServiceToolSetRequest
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	2,  // 0: sgpt.v1.Configuration.grpc_clients:type_name -> sgpt.v1.GrpcClient
+	3,  // 1: sgpt.v1.Configuration.models:type_name -> sgpt.v1.Model
+	4,  // 2: sgpt.v1.Configuration.chat:type_name -> sgpt.v1.ChatConfiguration
+	1,  // 3: sgpt.v1.Configuration.imports:type_name -> sgpt.v1.Import
+	8,  // 4: sgpt.v1.Configuration.mcp_serve:type_name -> sgpt.v1.McpServeConfiguration
+	7,  // 5: sgpt.v1.ChatConfiguration.budget:type_name -> sgpt.v1.Budget
+	6,  // 6: sgpt.v1.ChatConfiguration.tool_execution_policy:type_name -> sgpt.v1.ExecutionPolicy
+	5,  // 7: sgpt.v1.ChatConfiguration.generation_retry:type_name -> sgpt.v1.GenerationRetry
+	20, // 8: sgpt.v1.GenerationRetry.initial_backoff:type_name -> google.protobuf.Duration
+	20, // 9: sgpt.v1.GenerationRetry.max_backoff:type_name -> google.protobuf.Duration
+	20, // 10: sgpt.v1.ExecutionPolicy.timeout:type_name -> google.protobuf.Duration
+	20, // 11: sgpt.v1.ExecutionPolicy.retry_backoff:type_name -> google.protobuf.Duration
+	13, // 12: sgpt.v1.Role.parameters:type_name -> sgpt.v1.Role.Parameter
+	14, // 13: sgpt.v1.Role.sections:type_name -> sgpt.v1.Role.Section
+	21, // 14: sgpt.v1.ToolSet.tool_sets:type_name -> malonaz.ai.ai_engine.v1.CreateServiceToolSetRequest
+	6,  // 15: sgpt.v1.ToolSet.execution_policy:type_name -> sgpt.v1.ExecutionPolicy
+	15, // 16: sgpt.v1.McpServer.stdio:type_name -> sgpt.v1.McpServer.Stdio
+	16, // 17: sgpt.v1.McpServer.http:type_name -> sgpt.v1.McpServer.Http
+	19, // 18: sgpt.v1.Plugin.env:type_name -> sgpt.v1.Plugin.EnvEntry
+	6,  // 19: sgpt.v1.Plugin.execution_policy:type_name -> sgpt.v1.ExecutionPolicy
+	17, // 20: sgpt.v1.McpServer.Stdio.env:type_name -> sgpt.v1.McpServer.Stdio.EnvEntry
+	18, // 21: sgpt.v1.McpServer.Http.headers:type_name -> sgpt.v1.McpServer.Http.HeadersEntry
+	22, // [22:22] is the sub-list for method output_type
+	22, // [22:22] is the sub-list for method input_type
+	22, // [22:22] is the sub-list for extension type_name
+	22, // [22:22] is the sub-list for extension extendee
+	0,  // [0:22] is the sub-list for field type_name
 }
 
 func init() { file_sgpt_v1_configuration_proto_init() }
@@ -957,13 +2591,17 @@ func file_sgpt_v1_configuration_proto_init() {
 	if File_sgpt_v1_configuration_proto != nil {
 		return
 	}
+	file_sgpt_v1_configuration_proto_msgTypes[11].OneofWrappers = []any{
+		(*McpServer_Stdio_)(nil),
+		(*McpServer_Http_)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sgpt_v1_configuration_proto_rawDesc), len(file_sgpt_v1_configuration_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   20,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
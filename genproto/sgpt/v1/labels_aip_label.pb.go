@@ -10,6 +10,10 @@ type LabelParentChat struct {
 	GetKey func() string
 }
 
+type LabelArchived struct {
+	GetKey func() string
+}
+
 type LabelInjectedFile struct {
 	GetKey func() string
 }
@@ -21,6 +25,7 @@ type LabelContext struct {
 type LabelSet struct {
 	Favorite     LabelFavorite
 	ParentChat   LabelParentChat
+	Archived     LabelArchived
 	InjectedFile LabelInjectedFile
 	Context      LabelContext
 }
@@ -36,6 +41,11 @@ var Labels = LabelSet{
 			return "sgpt.com/parent-chat"
 		},
 	},
+	Archived: LabelArchived{
+		GetKey: func() string {
+			return "sgpt.com/archived"
+		},
+	},
 	InjectedFile: LabelInjectedFile{
 		GetKey: func() string {
 			return "sgpt.com/injected-file"
@@ -0,0 +1,523 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.32.1
+// source: sgpt/v1/search.proto
+
+//go:build protoopaque
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// A local full-text index over the text of every indexed chat's messages.
+// Cached on disk and updated chat by chat: a chat is re-indexed only when
+// its update time moves.
+type SearchIndex struct {
+	state               protoimpl.MessageState     `protogen:"opaque.v1"`
+	xxx_hidden_Chats    map[string]*IndexedChat    `protobuf:"bytes,1,rep,name=chats,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Postings map[string]*SearchPostings `protobuf:"bytes,2,rep,name=postings,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *SearchIndex) Reset() {
+	*x = SearchIndex{}
+	mi := &file_sgpt_v1_search_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchIndex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchIndex) ProtoMessage() {}
+
+func (x *SearchIndex) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_search_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *SearchIndex) GetChats() map[string]*IndexedChat {
+	if x != nil {
+		return x.xxx_hidden_Chats
+	}
+	return nil
+}
+
+func (x *SearchIndex) GetPostings() map[string]*SearchPostings {
+	if x != nil {
+		return x.xxx_hidden_Postings
+	}
+	return nil
+}
+
+func (x *SearchIndex) SetChats(v map[string]*IndexedChat) {
+	x.xxx_hidden_Chats = v
+}
+
+func (x *SearchIndex) SetPostings(v map[string]*SearchPostings) {
+	x.xxx_hidden_Postings = v
+}
+
+type SearchIndex_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Indexed chats, by resource name.
+	Chats map[string]*IndexedChat
+	// Inverted index: the messages each term appears in, by term.
+	Postings map[string]*SearchPostings
+}
+
+func (b0 SearchIndex_builder) Build() *SearchIndex {
+	m0 := &SearchIndex{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Chats = b.Chats
+	x.xxx_hidden_Postings = b.Postings
+	return m0
+}
+
+// A chat as indexed.
+type IndexedChat struct {
+	state                 protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_UpdateTime *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=update_time,json=updateTime,proto3"`
+	xxx_hidden_Title      string                 `protobuf:"bytes,2,opt,name=title,proto3"`
+	xxx_hidden_Messages   *[]*IndexedMessage     `protobuf:"bytes,3,rep,name=messages,proto3"`
+	xxx_hidden_Terms      []string               `protobuf:"bytes,4,rep,name=terms,proto3"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *IndexedChat) Reset() {
+	*x = IndexedChat{}
+	mi := &file_sgpt_v1_search_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexedChat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexedChat) ProtoMessage() {}
+
+func (x *IndexedChat) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_search_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *IndexedChat) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_UpdateTime
+	}
+	return nil
+}
+
+func (x *IndexedChat) GetTitle() string {
+	if x != nil {
+		return x.xxx_hidden_Title
+	}
+	return ""
+}
+
+func (x *IndexedChat) GetMessages() []*IndexedMessage {
+	if x != nil {
+		if x.xxx_hidden_Messages != nil {
+			return *x.xxx_hidden_Messages
+		}
+	}
+	return nil
+}
+
+func (x *IndexedChat) GetTerms() []string {
+	if x != nil {
+		return x.xxx_hidden_Terms
+	}
+	return nil
+}
+
+func (x *IndexedChat) SetUpdateTime(v *timestamppb.Timestamp) {
+	x.xxx_hidden_UpdateTime = v
+}
+
+func (x *IndexedChat) SetTitle(v string) {
+	x.xxx_hidden_Title = v
+}
+
+func (x *IndexedChat) SetMessages(v []*IndexedMessage) {
+	x.xxx_hidden_Messages = &v
+}
+
+func (x *IndexedChat) SetTerms(v []string) {
+	x.xxx_hidden_Terms = v
+}
+
+func (x *IndexedChat) HasUpdateTime() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_UpdateTime != nil
+}
+
+func (x *IndexedChat) ClearUpdateTime() {
+	x.xxx_hidden_UpdateTime = nil
+}
+
+type IndexedChat_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Update time of the chat when it was indexed.
+	UpdateTime *timestamppb.Timestamp
+	// Title of the chat when it was indexed.
+	Title string
+	// The chat's searchable messages, in history order.
+	Messages []*IndexedMessage
+	// Distinct terms of the chat, so re-indexing it only visits its own
+	// postings.
+	Terms []string
+}
+
+func (b0 IndexedChat_builder) Build() *IndexedChat {
+	m0 := &IndexedChat{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_UpdateTime = b.UpdateTime
+	x.xxx_hidden_Title = b.Title
+	x.xxx_hidden_Messages = &b.Messages
+	x.xxx_hidden_Terms = b.Terms
+	return m0
+}
+
+// The searchable text of a message: user and assistant text, context
+// excluded.
+type IndexedMessage struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name string                 `protobuf:"bytes,1,opt,name=name,proto3"`
+	xxx_hidden_Text string                 `protobuf:"bytes,2,opt,name=text,proto3"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *IndexedMessage) Reset() {
+	*x = IndexedMessage{}
+	mi := &file_sgpt_v1_search_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexedMessage) ProtoMessage() {}
+
+func (x *IndexedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_search_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *IndexedMessage) GetName() string {
+	if x != nil {
+		return x.xxx_hidden_Name
+	}
+	return ""
+}
+
+func (x *IndexedMessage) GetText() string {
+	if x != nil {
+		return x.xxx_hidden_Text
+	}
+	return ""
+}
+
+func (x *IndexedMessage) SetName(v string) {
+	x.xxx_hidden_Name = v
+}
+
+func (x *IndexedMessage) SetText(v string) {
+	x.xxx_hidden_Text = v
+}
+
+type IndexedMessage_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the message.
+	Name string
+	// Text of the message, kept for match snippets.
+	Text string
+}
+
+func (b0 IndexedMessage_builder) Build() *IndexedMessage {
+	m0 := &IndexedMessage{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Name = b.Name
+	x.xxx_hidden_Text = b.Text
+	return m0
+}
+
+// The occurrences of one term.
+type SearchPostings struct {
+	state               protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Postings *[]*SearchPosting      `protobuf:"bytes,1,rep,name=postings,proto3"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *SearchPostings) Reset() {
+	*x = SearchPostings{}
+	mi := &file_sgpt_v1_search_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPostings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPostings) ProtoMessage() {}
+
+func (x *SearchPostings) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_search_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *SearchPostings) GetPostings() []*SearchPosting {
+	if x != nil {
+		if x.xxx_hidden_Postings != nil {
+			return *x.xxx_hidden_Postings
+		}
+	}
+	return nil
+}
+
+func (x *SearchPostings) SetPostings(v []*SearchPosting) {
+	x.xxx_hidden_Postings = &v
+}
+
+type SearchPostings_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	Postings []*SearchPosting
+}
+
+func (b0 SearchPostings_builder) Build() *SearchPostings {
+	m0 := &SearchPostings{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Postings = &b.Postings
+	return m0
+}
+
+// One message a term appears in.
+type SearchPosting struct {
+	state              protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Chat    string                 `protobuf:"bytes,1,opt,name=chat,proto3"`
+	xxx_hidden_Message int32                  `protobuf:"varint,2,opt,name=message,proto3"`
+	xxx_hidden_Count   int32                  `protobuf:"varint,3,opt,name=count,proto3"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SearchPosting) Reset() {
+	*x = SearchPosting{}
+	mi := &file_sgpt_v1_search_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPosting) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPosting) ProtoMessage() {}
+
+func (x *SearchPosting) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_search_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *SearchPosting) GetChat() string {
+	if x != nil {
+		return x.xxx_hidden_Chat
+	}
+	return ""
+}
+
+func (x *SearchPosting) GetMessage() int32 {
+	if x != nil {
+		return x.xxx_hidden_Message
+	}
+	return 0
+}
+
+func (x *SearchPosting) GetCount() int32 {
+	if x != nil {
+		return x.xxx_hidden_Count
+	}
+	return 0
+}
+
+func (x *SearchPosting) SetChat(v string) {
+	x.xxx_hidden_Chat = v
+}
+
+func (x *SearchPosting) SetMessage(v int32) {
+	x.xxx_hidden_Message = v
+}
+
+func (x *SearchPosting) SetCount(v int32) {
+	x.xxx_hidden_Count = v
+}
+
+type SearchPosting_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the chat.
+	Chat string
+	// Index of the message in the chat's indexed messages.
+	Message int32
+	// Number of occurrences of the term in the message.
+	Count int32
+}
+
+func (b0 SearchPosting_builder) Build() *SearchPosting {
+	m0 := &SearchPosting{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Chat = b.Chat
+	x.xxx_hidden_Message = b.Message
+	x.xxx_hidden_Count = b.Count
+	return m0
+}
+
+var File_sgpt_v1_search_proto protoreflect.FileDescriptor
+
+const file_sgpt_v1_search_proto_rawDesc = "" +
+	"\n" +
+	"\x14sgpt/v1/search.proto\x12\asgpt.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xaa\x02\n" +
+	"\vSearchIndex\x125\n" +
+	"\x05chats\x18\x01 \x03(\v2\x1f.sgpt.v1.SearchIndex.ChatsEntryR\x05chats\x12>\n" +
+	"\bpostings\x18\x02 \x03(\v2\".sgpt.v1.SearchIndex.PostingsEntryR\bpostings\x1aN\n" +
+	"\n" +
+	"ChatsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.sgpt.v1.IndexedChatR\x05value:\x028\x01\x1aT\n" +
+	"\rPostingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\x05value\x18\x02 \x01(\v2\x17.sgpt.v1.SearchPostingsR\x05value:\x028\x01\"\xab\x01\n" +
+	"\vIndexedChat\x12;\n" +
+	"\vupdate_time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x123\n" +
+	"\bmessages\x18\x03 \x03(\v2\x17.sgpt.v1.IndexedMessageR\bmessages\x12\x14\n" +
+	"\x05terms\x18\x04 \x03(\tR\x05terms\"8\n" +
+	"\x0eIndexedMessage\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"D\n" +
+	"\x0eSearchPostings\x122\n" +
+	"\bpostings\x18\x01 \x03(\v2\x16.sgpt.v1.SearchPostingR\bpostings\"S\n" +
+	"\rSearchPosting\x12\x12\n" +
+	"\x04chat\x18\x01 \x01(\tR\x04chat\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\x05R\amessage\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05countB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
+
+var file_sgpt_v1_search_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_sgpt_v1_search_proto_goTypes = []any{
+	(*SearchIndex)(nil),           // 0: sgpt.v1.SearchIndex
+	(*IndexedChat)(nil),           // 1: sgpt.v1.IndexedChat
+	(*IndexedMessage)(nil),        // 2: sgpt.v1.IndexedMessage
+	(*SearchPostings)(nil),        // 3: sgpt.v1.SearchPostings
+	(*SearchPosting)(nil),         // 4: sgpt.v1.SearchPosting
+	nil,                           // 5: sgpt.v1.SearchIndex.ChatsEntry
+	nil,                           // 6: sgpt.v1.SearchIndex.PostingsEntry
+	(*timestamppb.Timestamp)(nil), // 7: google.protobuf.Timestamp
+}
+var file_sgpt_v1_search_proto_depIdxs = []int32{
+	5, // 0: sgpt.v1.SearchIndex.chats:type_name -> sgpt.v1.SearchIndex.ChatsEntry
+	6, // 1: sgpt.v1.SearchIndex.postings:type_name -> sgpt.v1.SearchIndex.PostingsEntry
+	7, // 2: sgpt.v1.IndexedChat.update_time:type_name -> google.protobuf.Timestamp
+	2, // 3: sgpt.v1.IndexedChat.messages:type_name -> sgpt.v1.IndexedMessage
+	4, // 4: sgpt.v1.SearchPostings.postings:type_name -> sgpt.v1.SearchPosting
+	1, // 5: sgpt.v1.SearchIndex.ChatsEntry.value:type_name -> sgpt.v1.IndexedChat
+	3, // 6: sgpt.v1.SearchIndex.PostingsEntry.value:type_name -> sgpt.v1.SearchPostings
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_sgpt_v1_search_proto_init() }
+func file_sgpt_v1_search_proto_init() {
+	if File_sgpt_v1_search_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sgpt_v1_search_proto_rawDesc), len(file_sgpt_v1_search_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_sgpt_v1_search_proto_goTypes,
+		DependencyIndexes: file_sgpt_v1_search_proto_depIdxs,
+		MessageInfos:      file_sgpt_v1_search_proto_msgTypes,
+	}.Build()
+	File_sgpt_v1_search_proto = out.File
+	file_sgpt_v1_search_proto_goTypes = nil
+	file_sgpt_v1_search_proto_depIdxs = nil
+}
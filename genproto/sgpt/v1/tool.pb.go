@@ -22,6 +22,53 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Why an execution policy failed a tool call.
+type ExecutionPolicyFailure int32
+
+const (
+	ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_UNSPECIFIED ExecutionPolicyFailure = 0
+	// Every attempt exceeded the policy's timeout.
+	ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_TIMEOUT ExecutionPolicyFailure = 1
+	// The tool was disabled by its circuit breaker and the call never ran.
+	ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN ExecutionPolicyFailure = 2
+)
+
+// Enum value maps for ExecutionPolicyFailure.
+var (
+	ExecutionPolicyFailure_name = map[int32]string{
+		0: "EXECUTION_POLICY_FAILURE_UNSPECIFIED",
+		1: "EXECUTION_POLICY_FAILURE_TIMEOUT",
+		2: "EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN",
+	}
+	ExecutionPolicyFailure_value = map[string]int32{
+		"EXECUTION_POLICY_FAILURE_UNSPECIFIED":  0,
+		"EXECUTION_POLICY_FAILURE_TIMEOUT":      1,
+		"EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN": 2,
+	}
+)
+
+func (x ExecutionPolicyFailure) Enum() *ExecutionPolicyFailure {
+	p := new(ExecutionPolicyFailure)
+	*p = x
+	return p
+}
+
+func (x ExecutionPolicyFailure) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExecutionPolicyFailure) Descriptor() protoreflect.EnumDescriptor {
+	return file_sgpt_v1_tool_proto_enumTypes[0].Descriptor()
+}
+
+func (ExecutionPolicyFailure) Type() protoreflect.EnumType {
+	return &file_sgpt_v1_tool_proto_enumTypes[0]
+}
+
+func (x ExecutionPolicyFailure) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
 // Metadata attached to a tool call via annotations.
 type ToolCallMetadata struct {
 	state protoimpl.MessageState `protogen:"hybrid.v1"`
@@ -30,7 +77,16 @@ type ToolCallMetadata struct {
 	// Whether this tool call should be automatically executed without user confirmation.
 	AutoExecute bool `protobuf:"varint,2,opt,name=auto_execute,json=autoExecute,proto3" json:"auto_execute,omitempty"`
 	// Unified diff computed at review time by the edit_file tool.
-	Diff          string `protobuf:"bytes,3,opt,name=diff,proto3" json:"diff,omitempty"`
+	Diff string `protobuf:"bytes,3,opt,name=diff,proto3" json:"diff,omitempty"`
+	// Header markdown computed at review time, for tools that cannot render
+	// one on the fly (plugins).
+	Header string `protobuf:"bytes,4,opt,name=header,proto3" json:"header,omitempty"`
+	// Request markdown computed at review time, replacing the raw JSON
+	// arguments (plugins).
+	RequestMarkdown string `protobuf:"bytes,5,opt,name=request_markdown,json=requestMarkdown,proto3" json:"request_markdown,omitempty"`
+	// Whether the call has no side effects, so a failed attempt can be
+	// retried. Computed at review time.
+	Idempotent    bool `protobuf:"varint,6,opt,name=idempotent,proto3" json:"idempotent,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,6 +137,27 @@ func (x *ToolCallMetadata) GetDiff() string {
 	return ""
 }
 
+func (x *ToolCallMetadata) GetHeader() string {
+	if x != nil {
+		return x.Header
+	}
+	return ""
+}
+
+func (x *ToolCallMetadata) GetRequestMarkdown() string {
+	if x != nil {
+		return x.RequestMarkdown
+	}
+	return ""
+}
+
+func (x *ToolCallMetadata) GetIdempotent() bool {
+	if x != nil {
+		return x.Idempotent
+	}
+	return false
+}
+
 func (x *ToolCallMetadata) SetDisplayMessage(v *DisplayMessage) {
 	x.DisplayMessage = v
 }
@@ -93,6 +170,18 @@ func (x *ToolCallMetadata) SetDiff(v string) {
 	x.Diff = v
 }
 
+func (x *ToolCallMetadata) SetHeader(v string) {
+	x.Header = v
+}
+
+func (x *ToolCallMetadata) SetRequestMarkdown(v string) {
+	x.RequestMarkdown = v
+}
+
+func (x *ToolCallMetadata) SetIdempotent(v bool) {
+	x.Idempotent = v
+}
+
 func (x *ToolCallMetadata) HasDisplayMessage() bool {
 	if x == nil {
 		return false
@@ -113,6 +202,15 @@ type ToolCallMetadata_builder struct {
 	AutoExecute bool
 	// Unified diff computed at review time by the edit_file tool.
 	Diff string
+	// Header markdown computed at review time, for tools that cannot render
+	// one on the fly (plugins).
+	Header string
+	// Request markdown computed at review time, replacing the raw JSON
+	// arguments (plugins).
+	RequestMarkdown string
+	// Whether the call has no side effects, so a failed attempt can be
+	// retried. Computed at review time.
+	Idempotent bool
 }
 
 func (b0 ToolCallMetadata_builder) Build() *ToolCallMetadata {
@@ -122,6 +220,9 @@ func (b0 ToolCallMetadata_builder) Build() *ToolCallMetadata {
 	x.DisplayMessage = b.DisplayMessage
 	x.AutoExecute = b.AutoExecute
 	x.Diff = b.Diff
+	x.Header = b.Header
+	x.RequestMarkdown = b.RequestMarkdown
+	x.Idempotent = b.Idempotent
 	return m0
 }
 
@@ -130,8 +231,13 @@ type ToolCallResultMetadata struct {
 	state protoimpl.MessageState `protogen:"hybrid.v1"`
 	// Display information for the tool call.
 	DisplayMessage *DisplayMessage `protobuf:"bytes,1,opt,name=display_message,json=displayMessage,proto3" json:"display_message,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Set when the call was failed by its execution policy rather than by
+	// the tool.
+	PolicyFailure ExecutionPolicyFailure `protobuf:"varint,2,opt,name=policy_failure,json=policyFailure,proto3,enum=sgpt.v1.ExecutionPolicyFailure" json:"policy_failure,omitempty"`
+	// Number of attempts made, retries included.
+	Attempts      int32 `protobuf:"varint,3,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ToolCallResultMetadata) Reset() {
@@ -166,10 +272,32 @@ func (x *ToolCallResultMetadata) GetDisplayMessage() *DisplayMessage {
 	return nil
 }
 
+func (x *ToolCallResultMetadata) GetPolicyFailure() ExecutionPolicyFailure {
+	if x != nil {
+		return x.PolicyFailure
+	}
+	return ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_UNSPECIFIED
+}
+
+func (x *ToolCallResultMetadata) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
 func (x *ToolCallResultMetadata) SetDisplayMessage(v *DisplayMessage) {
 	x.DisplayMessage = v
 }
 
+func (x *ToolCallResultMetadata) SetPolicyFailure(v ExecutionPolicyFailure) {
+	x.PolicyFailure = v
+}
+
+func (x *ToolCallResultMetadata) SetAttempts(v int32) {
+	x.Attempts = v
+}
+
 func (x *ToolCallResultMetadata) HasDisplayMessage() bool {
 	if x == nil {
 		return false
@@ -186,6 +314,11 @@ type ToolCallResultMetadata_builder struct {
 
 	// Display information for the tool call.
 	DisplayMessage *DisplayMessage
+	// Set when the call was failed by its execution policy rather than by
+	// the tool.
+	PolicyFailure ExecutionPolicyFailure
+	// Number of attempts made, retries included.
+	Attempts int32
 }
 
 func (b0 ToolCallResultMetadata_builder) Build() *ToolCallResultMetadata {
@@ -193,6 +326,8 @@ func (b0 ToolCallResultMetadata_builder) Build() *ToolCallResultMetadata {
 	b, x := &b0, m0
 	_, _ = b, x
 	x.DisplayMessage = b.DisplayMessage
+	x.PolicyFailure = b.PolicyFailure
+	x.Attempts = b.Attempts
 	return m0
 }
 
@@ -276,31 +411,45 @@ var File_sgpt_v1_tool_proto protoreflect.FileDescriptor
 
 const file_sgpt_v1_tool_proto_rawDesc = "" +
 	"\n" +
-	"\x12sgpt/v1/tool.proto\x12\asgpt.v1\"\x8b\x01\n" +
+	"\x12sgpt/v1/tool.proto\x12\asgpt.v1\"\xee\x01\n" +
 	"\x10ToolCallMetadata\x12@\n" +
 	"\x0fdisplay_message\x18\x01 \x01(\v2\x17.sgpt.v1.DisplayMessageR\x0edisplayMessage\x12!\n" +
 	"\fauto_execute\x18\x02 \x01(\bR\vautoExecute\x12\x12\n" +
-	"\x04diff\x18\x03 \x01(\tR\x04diff\"Z\n" +
+	"\x04diff\x18\x03 \x01(\tR\x04diff\x12\x16\n" +
+	"\x06header\x18\x04 \x01(\tR\x06header\x12)\n" +
+	"\x10request_markdown\x18\x05 \x01(\tR\x0frequestMarkdown\x12\x1e\n" +
+	"\n" +
+	"idempotent\x18\x06 \x01(\bR\n" +
+	"idempotent\"\xbe\x01\n" +
 	"\x16ToolCallResultMetadata\x12@\n" +
-	"\x0fdisplay_message\x18\x01 \x01(\v2\x17.sgpt.v1.DisplayMessageR\x0edisplayMessage\"B\n" +
+	"\x0fdisplay_message\x18\x01 \x01(\v2\x17.sgpt.v1.DisplayMessageR\x0edisplayMessage\x12F\n" +
+	"\x0epolicy_failure\x18\x02 \x01(\x0e2\x1f.sgpt.v1.ExecutionPolicyFailureR\rpolicyFailure\x12\x1a\n" +
+	"\battempts\x18\x03 \x01(\x05R\battempts\"B\n" +
 	"\x0eDisplayMessage\x12\x18\n" +
 	"\acontent\x18\x01 \x01(\tR\acontent\x12\x16\n" +
-	"\x06hidden\x18\x02 \x01(\bR\x06hiddenB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
+	"\x06hidden\x18\x02 \x01(\bR\x06hidden*\x93\x01\n" +
+	"\x16ExecutionPolicyFailure\x12(\n" +
+	"$EXECUTION_POLICY_FAILURE_UNSPECIFIED\x10\x00\x12$\n" +
+	" EXECUTION_POLICY_FAILURE_TIMEOUT\x10\x01\x12)\n" +
+	"%EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN\x10\x02B*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
 
+var file_sgpt_v1_tool_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
 var file_sgpt_v1_tool_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_sgpt_v1_tool_proto_goTypes = []any{
-	(*ToolCallMetadata)(nil),       // 0: sgpt.v1.ToolCallMetadata
-	(*ToolCallResultMetadata)(nil), // 1: sgpt.v1.ToolCallResultMetadata
-	(*DisplayMessage)(nil),         // 2: sgpt.v1.DisplayMessage
+	(ExecutionPolicyFailure)(0),    // 0: sgpt.v1.ExecutionPolicyFailure
+	(*ToolCallMetadata)(nil),       // 1: sgpt.v1.ToolCallMetadata
+	(*ToolCallResultMetadata)(nil), // 2: sgpt.v1.ToolCallResultMetadata
+	(*DisplayMessage)(nil),         // 3: sgpt.v1.DisplayMessage
 }
 var file_sgpt_v1_tool_proto_depIdxs = []int32{
-	2, // 0: sgpt.v1.ToolCallMetadata.display_message:type_name -> sgpt.v1.DisplayMessage
-	2, // 1: sgpt.v1.ToolCallResultMetadata.display_message:type_name -> sgpt.v1.DisplayMessage
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	3, // 0: sgpt.v1.ToolCallMetadata.display_message:type_name -> sgpt.v1.DisplayMessage
+	3, // 1: sgpt.v1.ToolCallResultMetadata.display_message:type_name -> sgpt.v1.DisplayMessage
+	0, // 2: sgpt.v1.ToolCallResultMetadata.policy_failure:type_name -> sgpt.v1.ExecutionPolicyFailure
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_sgpt_v1_tool_proto_init() }
@@ -313,13 +462,14 @@ func file_sgpt_v1_tool_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sgpt_v1_tool_proto_rawDesc), len(file_sgpt_v1_tool_proto_rawDesc)),
-			NumEnums:      0,
+			NumEnums:      1,
 			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_sgpt_v1_tool_proto_goTypes,
 		DependencyIndexes: file_sgpt_v1_tool_proto_depIdxs,
+		EnumInfos:         file_sgpt_v1_tool_proto_enumTypes,
 		MessageInfos:      file_sgpt_v1_tool_proto_msgTypes,
 	}.Build()
 	File_sgpt_v1_tool_proto = out.File
@@ -12,6 +12,7 @@ import (
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	reflect "reflect"
 	unsafe "unsafe"
 )
@@ -532,11 +533,12 @@ func (b0 ReadFilesResponse_builder) Build() *ReadFilesResponse {
 
 // Request for the `search_lores` tool.
 type SearchLoresRequest struct {
-	state            protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Query string                 `protobuf:"bytes,1,opt,name=query,proto3"`
-	xxx_hidden_TopN  int32                  `protobuf:"varint,2,opt,name=top_n,json=topN,proto3"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Query  string                 `protobuf:"bytes,1,opt,name=query,proto3"`
+	xxx_hidden_TopN   int32                  `protobuf:"varint,2,opt,name=top_n,json=topN,proto3"`
+	xxx_hidden_Ranked bool                   `protobuf:"varint,3,opt,name=ranked,proto3"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SearchLoresRequest) Reset() {
@@ -578,6 +580,13 @@ func (x *SearchLoresRequest) GetTopN() int32 {
 	return 0
 }
 
+func (x *SearchLoresRequest) GetRanked() bool {
+	if x != nil {
+		return x.xxx_hidden_Ranked
+	}
+	return false
+}
+
 func (x *SearchLoresRequest) SetQuery(v string) {
 	x.xxx_hidden_Query = v
 }
@@ -586,14 +595,23 @@ func (x *SearchLoresRequest) SetTopN(v int32) {
 	x.xxx_hidden_TopN = v
 }
 
+func (x *SearchLoresRequest) SetRanked(v bool) {
+	x.xxx_hidden_Ranked = v
+}
+
 type SearchLoresRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
 	// Case-insensitive regular expression (Go/RE2 syntax), run over every
-	// lore's title, description, labels and content, grep-style.
+	// lore's title, description, labels and content, grep-style. With
+	// `ranked`, free text instead: a question or a few keywords.
 	Query string
 	// Maximum number of lores to return; defaults to 10.
 	TopN int32
+	// Rank lores by relevance (BM25 over title, description, labels and
+	// content) instead of grepping. Prefer it for natural-language questions
+	// whose wording is unknown; snippets are the lines holding a query word.
+	Ranked bool
 }
 
 func (b0 SearchLoresRequest_builder) Build() *SearchLoresRequest {
@@ -602,6 +620,7 @@ func (b0 SearchLoresRequest_builder) Build() *SearchLoresRequest {
 	_, _ = b, x
 	x.xxx_hidden_Query = b.Query
 	x.xxx_hidden_TopN = b.TopN
+	x.xxx_hidden_Ranked = b.Ranked
 	return m0
 }
 
@@ -666,6 +685,363 @@ func (b0 SearchLoresResponse_builder) Build() *SearchLoresResponse {
 	return m0
 }
 
+// Request for the `write_lore` tool.
+type WriteLoreRequest struct {
+	state                  protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name        string                 `protobuf:"bytes,1,opt,name=name,proto3"`
+	xxx_hidden_Title       string                 `protobuf:"bytes,2,opt,name=title,proto3"`
+	xxx_hidden_Description string                 `protobuf:"bytes,3,opt,name=description,proto3"`
+	xxx_hidden_Labels      map[string]string      `protobuf:"bytes,4,rep,name=labels,proto3" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	xxx_hidden_Content     string                 `protobuf:"bytes,5,opt,name=content,proto3"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *WriteLoreRequest) Reset() {
+	*x = WriteLoreRequest{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteLoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteLoreRequest) ProtoMessage() {}
+
+func (x *WriteLoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *WriteLoreRequest) GetName() string {
+	if x != nil {
+		return x.xxx_hidden_Name
+	}
+	return ""
+}
+
+func (x *WriteLoreRequest) GetTitle() string {
+	if x != nil {
+		return x.xxx_hidden_Title
+	}
+	return ""
+}
+
+func (x *WriteLoreRequest) GetDescription() string {
+	if x != nil {
+		return x.xxx_hidden_Description
+	}
+	return ""
+}
+
+func (x *WriteLoreRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.xxx_hidden_Labels
+	}
+	return nil
+}
+
+func (x *WriteLoreRequest) GetContent() string {
+	if x != nil {
+		return x.xxx_hidden_Content
+	}
+	return ""
+}
+
+func (x *WriteLoreRequest) SetName(v string) {
+	x.xxx_hidden_Name = v
+}
+
+func (x *WriteLoreRequest) SetTitle(v string) {
+	x.xxx_hidden_Title = v
+}
+
+func (x *WriteLoreRequest) SetDescription(v string) {
+	x.xxx_hidden_Description = v
+}
+
+func (x *WriteLoreRequest) SetLabels(v map[string]string) {
+	x.xxx_hidden_Labels = v
+}
+
+func (x *WriteLoreRequest) SetContent(v string) {
+	x.xxx_hidden_Content = v
+}
+
+type WriteLoreRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the lore to write ("lores/{lore}"); `{lore}` may
+	// contain "/" subdirectory segments.
+	Name string
+	// Short human-readable title.
+	Title string
+	// One-or-two-line description of the content.
+	Description string
+	// Labels, reusing the library's existing keys and values where they fit.
+	Labels map[string]string
+	// The markdown content.
+	Content string
+}
+
+func (b0 WriteLoreRequest_builder) Build() *WriteLoreRequest {
+	m0 := &WriteLoreRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Name = b.Name
+	x.xxx_hidden_Title = b.Title
+	x.xxx_hidden_Description = b.Description
+	x.xxx_hidden_Labels = b.Labels
+	x.xxx_hidden_Content = b.Content
+	return m0
+}
+
+// Result of the `write_lore` tool.
+type WriteLoreResponse struct {
+	state                protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name      string                 `protobuf:"bytes,1,opt,name=name,proto3"`
+	xxx_hidden_Path      string                 `protobuf:"bytes,2,opt,name=path,proto3"`
+	xxx_hidden_Created   bool                   `protobuf:"varint,3,opt,name=created,proto3"`
+	xxx_hidden_NewLabels []string               `protobuf:"bytes,4,rep,name=new_labels,json=newLabels,proto3"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *WriteLoreResponse) Reset() {
+	*x = WriteLoreResponse{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteLoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteLoreResponse) ProtoMessage() {}
+
+func (x *WriteLoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *WriteLoreResponse) GetName() string {
+	if x != nil {
+		return x.xxx_hidden_Name
+	}
+	return ""
+}
+
+func (x *WriteLoreResponse) GetPath() string {
+	if x != nil {
+		return x.xxx_hidden_Path
+	}
+	return ""
+}
+
+func (x *WriteLoreResponse) GetCreated() bool {
+	if x != nil {
+		return x.xxx_hidden_Created
+	}
+	return false
+}
+
+func (x *WriteLoreResponse) GetNewLabels() []string {
+	if x != nil {
+		return x.xxx_hidden_NewLabels
+	}
+	return nil
+}
+
+func (x *WriteLoreResponse) SetName(v string) {
+	x.xxx_hidden_Name = v
+}
+
+func (x *WriteLoreResponse) SetPath(v string) {
+	x.xxx_hidden_Path = v
+}
+
+func (x *WriteLoreResponse) SetCreated(v bool) {
+	x.xxx_hidden_Created = v
+}
+
+func (x *WriteLoreResponse) SetNewLabels(v []string) {
+	x.xxx_hidden_NewLabels = v
+}
+
+type WriteLoreResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the written lore.
+	Name string
+	// Path of the lore file.
+	Path string
+	// Whether the lore is new rather than overwritten.
+	Created bool
+	// Labels ("key" or "key=value") the write added to the vocabulary.
+	NewLabels []string
+}
+
+func (b0 WriteLoreResponse_builder) Build() *WriteLoreResponse {
+	m0 := &WriteLoreResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Name = b.Name
+	x.xxx_hidden_Path = b.Path
+	x.xxx_hidden_Created = b.Created
+	x.xxx_hidden_NewLabels = b.NewLabels
+	return m0
+}
+
+// Request for the `delete_lore` tool.
+type DeleteLoreRequest struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name string                 `protobuf:"bytes,1,opt,name=name,proto3"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteLoreRequest) Reset() {
+	*x = DeleteLoreRequest{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteLoreRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteLoreRequest) ProtoMessage() {}
+
+func (x *DeleteLoreRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DeleteLoreRequest) GetName() string {
+	if x != nil {
+		return x.xxx_hidden_Name
+	}
+	return ""
+}
+
+func (x *DeleteLoreRequest) SetName(v string) {
+	x.xxx_hidden_Name = v
+}
+
+type DeleteLoreRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the lore to delete ("lores/{lore}").
+	Name string
+}
+
+func (b0 DeleteLoreRequest_builder) Build() *DeleteLoreRequest {
+	m0 := &DeleteLoreRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Name = b.Name
+	return m0
+}
+
+// Result of the `delete_lore` tool.
+type DeleteLoreResponse struct {
+	state           protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Name string                 `protobuf:"bytes,1,opt,name=name,proto3"`
+	xxx_hidden_Path string                 `protobuf:"bytes,2,opt,name=path,proto3"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *DeleteLoreResponse) Reset() {
+	*x = DeleteLoreResponse{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteLoreResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteLoreResponse) ProtoMessage() {}
+
+func (x *DeleteLoreResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *DeleteLoreResponse) GetName() string {
+	if x != nil {
+		return x.xxx_hidden_Name
+	}
+	return ""
+}
+
+func (x *DeleteLoreResponse) GetPath() string {
+	if x != nil {
+		return x.xxx_hidden_Path
+	}
+	return ""
+}
+
+func (x *DeleteLoreResponse) SetName(v string) {
+	x.xxx_hidden_Name = v
+}
+
+func (x *DeleteLoreResponse) SetPath(v string) {
+	x.xxx_hidden_Path = v
+}
+
+type DeleteLoreResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the deleted lore.
+	Name string
+	// Path of the deleted lore file.
+	Path string
+}
+
+func (b0 DeleteLoreResponse_builder) Build() *DeleteLoreResponse {
+	m0 := &DeleteLoreResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Name = b.Name
+	x.xxx_hidden_Path = b.Path
+	return m0
+}
+
 // Request for the `exec_shell` tool.
 type ExecShellRequest struct {
 	state                       protoimpl.MessageState `protogen:"opaque.v1"`
@@ -677,7 +1053,7 @@ type ExecShellRequest struct {
 
 func (x *ExecShellRequest) Reset() {
 	*x = ExecShellRequest{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[9]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -689,7 +1065,7 @@ func (x *ExecShellRequest) String() string {
 func (*ExecShellRequest) ProtoMessage() {}
 
 func (x *ExecShellRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[9]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -753,7 +1129,7 @@ type ExecShellResponse struct {
 
 func (x *ExecShellResponse) Reset() {
 	*x = ExecShellResponse{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[10]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -765,7 +1141,7 @@ func (x *ExecShellResponse) String() string {
 func (*ExecShellResponse) ProtoMessage() {}
 
 func (x *ExecShellResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[10]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -832,19 +1208,20 @@ func (b0 ExecShellResponse_builder) Build() *ExecShellResponse {
 
 // Request for the `agent` tool.
 type AgentRequest struct {
-	state            protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Query string                 `protobuf:"bytes,1,opt,name=query,proto3"`
-	xxx_hidden_Title string                 `protobuf:"bytes,5,opt,name=title,proto3"`
-	xxx_hidden_Files []string               `protobuf:"bytes,2,rep,name=files,proto3"`
-	xxx_hidden_Tools []string               `protobuf:"bytes,3,rep,name=tools,proto3"`
-	xxx_hidden_Model string                 `protobuf:"bytes,4,opt,name=model,proto3"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state                     protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Query          string                 `protobuf:"bytes,1,opt,name=query,proto3"`
+	xxx_hidden_Title          string                 `protobuf:"bytes,5,opt,name=title,proto3"`
+	xxx_hidden_Files          []string               `protobuf:"bytes,2,rep,name=files,proto3"`
+	xxx_hidden_Tools          []string               `protobuf:"bytes,3,rep,name=tools,proto3"`
+	xxx_hidden_Model          string                 `protobuf:"bytes,4,opt,name=model,proto3"`
+	xxx_hidden_ResponseSchema string                 `protobuf:"bytes,6,opt,name=response_schema,json=responseSchema,proto3"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
 }
 
 func (x *AgentRequest) Reset() {
 	*x = AgentRequest{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[11]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -856,7 +1233,7 @@ func (x *AgentRequest) String() string {
 func (*AgentRequest) ProtoMessage() {}
 
 func (x *AgentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[11]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -902,6 +1279,13 @@ func (x *AgentRequest) GetModel() string {
 	return ""
 }
 
+func (x *AgentRequest) GetResponseSchema() string {
+	if x != nil {
+		return x.xxx_hidden_ResponseSchema
+	}
+	return ""
+}
+
 func (x *AgentRequest) SetQuery(v string) {
 	x.xxx_hidden_Query = v
 }
@@ -922,6 +1306,10 @@ func (x *AgentRequest) SetModel(v string) {
 	x.xxx_hidden_Model = v
 }
 
+func (x *AgentRequest) SetResponseSchema(v string) {
+	x.xxx_hidden_ResponseSchema = v
+}
+
 type AgentRequest_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
@@ -935,6 +1323,10 @@ type AgentRequest_builder struct {
 	Tools []string
 	// Optional model override for the sub-agent.
 	Model string
+	// Optional JSON schema (as a JSON document) the answer must conform to.
+	// The sub-agent ends with a JSON answer validated against it, and is
+	// asked to fix it until it validates.
+	ResponseSchema string
 }
 
 func (b0 AgentRequest_builder) Build() *AgentRequest {
@@ -946,20 +1338,22 @@ func (b0 AgentRequest_builder) Build() *AgentRequest {
 	x.xxx_hidden_Files = b.Files
 	x.xxx_hidden_Tools = b.Tools
 	x.xxx_hidden_Model = b.Model
+	x.xxx_hidden_ResponseSchema = b.ResponseSchema
 	return m0
 }
 
 // Result of the `agent` tool.
 type AgentResponse struct {
-	state               protoimpl.MessageState `protogen:"opaque.v1"`
-	xxx_hidden_Response string                 `protobuf:"bytes,1,opt,name=response,proto3"`
-	unknownFields       protoimpl.UnknownFields
-	sizeCache           protoimpl.SizeCache
+	state                         protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Response           string                 `protobuf:"bytes,1,opt,name=response,proto3"`
+	xxx_hidden_StructuredResponse *structpb.Value        `protobuf:"bytes,2,opt,name=structured_response,json=structuredResponse,proto3"`
+	unknownFields                 protoimpl.UnknownFields
+	sizeCache                     protoimpl.SizeCache
 }
 
 func (x *AgentResponse) Reset() {
 	*x = AgentResponse{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[12]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -971,7 +1365,7 @@ func (x *AgentResponse) String() string {
 func (*AgentResponse) ProtoMessage() {}
 
 func (x *AgentResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[12]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -989,15 +1383,40 @@ func (x *AgentResponse) GetResponse() string {
 	return ""
 }
 
+func (x *AgentResponse) GetStructuredResponse() *structpb.Value {
+	if x != nil {
+		return x.xxx_hidden_StructuredResponse
+	}
+	return nil
+}
+
 func (x *AgentResponse) SetResponse(v string) {
 	x.xxx_hidden_Response = v
 }
 
+func (x *AgentResponse) SetStructuredResponse(v *structpb.Value) {
+	x.xxx_hidden_StructuredResponse = v
+}
+
+func (x *AgentResponse) HasStructuredResponse() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_StructuredResponse != nil
+}
+
+func (x *AgentResponse) ClearStructuredResponse() {
+	x.xxx_hidden_StructuredResponse = nil
+}
+
 type AgentResponse_builder struct {
 	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
 
 	// The sub-agent's final answer.
 	Response string
+	// The answer parsed as JSON, when a response_schema was given; it
+	// validates against that schema.
+	StructuredResponse *structpb.Value
 }
 
 func (b0 AgentResponse_builder) Build() *AgentResponse {
@@ -1005,6 +1424,145 @@ func (b0 AgentResponse_builder) Build() *AgentResponse {
 	b, x := &b0, m0
 	_, _ = b, x
 	x.xxx_hidden_Response = b.Response
+	x.xxx_hidden_StructuredResponse = b.StructuredResponse
+	return m0
+}
+
+// Request for the `agent_batch` tool.
+type AgentBatchRequest struct {
+	state                     protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Agents         *[]*AgentRequest       `protobuf:"bytes,1,rep,name=agents,proto3"`
+	xxx_hidden_MaxConcurrency int32                  `protobuf:"varint,2,opt,name=max_concurrency,json=maxConcurrency,proto3"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *AgentBatchRequest) Reset() {
+	*x = AgentBatchRequest{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentBatchRequest) ProtoMessage() {}
+
+func (x *AgentBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *AgentBatchRequest) GetAgents() []*AgentRequest {
+	if x != nil {
+		if x.xxx_hidden_Agents != nil {
+			return *x.xxx_hidden_Agents
+		}
+	}
+	return nil
+}
+
+func (x *AgentBatchRequest) GetMaxConcurrency() int32 {
+	if x != nil {
+		return x.xxx_hidden_MaxConcurrency
+	}
+	return 0
+}
+
+func (x *AgentBatchRequest) SetAgents(v []*AgentRequest) {
+	x.xxx_hidden_Agents = &v
+}
+
+func (x *AgentBatchRequest) SetMaxConcurrency(v int32) {
+	x.xxx_hidden_MaxConcurrency = v
+}
+
+type AgentBatchRequest_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Sub-agents to launch, each with its own self-contained query.
+	Agents []*AgentRequest
+	// Maximum number of sub-agents running at once; defaults to 3, capped at
+	// 8.
+	MaxConcurrency int32
+}
+
+func (b0 AgentBatchRequest_builder) Build() *AgentBatchRequest {
+	m0 := &AgentBatchRequest{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Agents = &b.Agents
+	x.xxx_hidden_MaxConcurrency = b.MaxConcurrency
+	return m0
+}
+
+// Result of the `agent_batch` tool.
+type AgentBatchResponse struct {
+	state              protoimpl.MessageState        `protogen:"opaque.v1"`
+	xxx_hidden_Results *[]*AgentBatchResponse_Result `protobuf:"bytes,1,rep,name=results,proto3"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *AgentBatchResponse) Reset() {
+	*x = AgentBatchResponse{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentBatchResponse) ProtoMessage() {}
+
+func (x *AgentBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *AgentBatchResponse) GetResults() []*AgentBatchResponse_Result {
+	if x != nil {
+		if x.xxx_hidden_Results != nil {
+			return *x.xxx_hidden_Results
+		}
+	}
+	return nil
+}
+
+func (x *AgentBatchResponse) SetResults(v []*AgentBatchResponse_Result) {
+	x.xxx_hidden_Results = &v
+}
+
+type AgentBatchResponse_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// One result per requested sub-agent, in request order.
+	Results []*AgentBatchResponse_Result
+}
+
+func (b0 AgentBatchResponse_builder) Build() *AgentBatchResponse {
+	m0 := &AgentBatchResponse{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Results = &b.Results
 	return m0
 }
 
@@ -1020,7 +1578,7 @@ type ReadFilesResponse_File struct {
 
 func (x *ReadFilesResponse_File) Reset() {
 	*x = ReadFilesResponse_File{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[13]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1032,7 +1590,7 @@ func (x *ReadFilesResponse_File) String() string {
 func (*ReadFilesResponse_File) ProtoMessage() {}
 
 func (x *ReadFilesResponse_File) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[13]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1112,7 +1670,7 @@ type SearchLoresResponse_Match struct {
 
 func (x *SearchLoresResponse_Match) Reset() {
 	*x = SearchLoresResponse_Match{}
-	mi := &file_sgpt_v1_tools_proto_msgTypes[14]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1124,7 +1682,7 @@ func (x *SearchLoresResponse_Match) String() string {
 func (*SearchLoresResponse_Match) ProtoMessage() {}
 
 func (x *SearchLoresResponse_Match) ProtoReflect() protoreflect.Message {
-	mi := &file_sgpt_v1_tools_proto_msgTypes[14]
+	mi := &file_sgpt_v1_tools_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1231,11 +1789,141 @@ func (b0 SearchLoresResponse_Match_builder) Build() *SearchLoresResponse_Match {
 	return m0
 }
 
+// The outcome of one sub-agent.
+type AgentBatchResponse_Result struct {
+	state                         protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Title              string                 `protobuf:"bytes,1,opt,name=title,proto3"`
+	xxx_hidden_Response           string                 `protobuf:"bytes,2,opt,name=response,proto3"`
+	xxx_hidden_Error              string                 `protobuf:"bytes,3,opt,name=error,proto3"`
+	xxx_hidden_Price              float64                `protobuf:"fixed64,4,opt,name=price,proto3"`
+	xxx_hidden_StructuredResponse *structpb.Value        `protobuf:"bytes,5,opt,name=structured_response,json=structuredResponse,proto3"`
+	unknownFields                 protoimpl.UnknownFields
+	sizeCache                     protoimpl.SizeCache
+}
+
+func (x *AgentBatchResponse_Result) Reset() {
+	*x = AgentBatchResponse_Result{}
+	mi := &file_sgpt_v1_tools_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentBatchResponse_Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentBatchResponse_Result) ProtoMessage() {}
+
+func (x *AgentBatchResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_tools_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *AgentBatchResponse_Result) GetTitle() string {
+	if x != nil {
+		return x.xxx_hidden_Title
+	}
+	return ""
+}
+
+func (x *AgentBatchResponse_Result) GetResponse() string {
+	if x != nil {
+		return x.xxx_hidden_Response
+	}
+	return ""
+}
+
+func (x *AgentBatchResponse_Result) GetError() string {
+	if x != nil {
+		return x.xxx_hidden_Error
+	}
+	return ""
+}
+
+func (x *AgentBatchResponse_Result) GetPrice() float64 {
+	if x != nil {
+		return x.xxx_hidden_Price
+	}
+	return 0
+}
+
+func (x *AgentBatchResponse_Result) GetStructuredResponse() *structpb.Value {
+	if x != nil {
+		return x.xxx_hidden_StructuredResponse
+	}
+	return nil
+}
+
+func (x *AgentBatchResponse_Result) SetTitle(v string) {
+	x.xxx_hidden_Title = v
+}
+
+func (x *AgentBatchResponse_Result) SetResponse(v string) {
+	x.xxx_hidden_Response = v
+}
+
+func (x *AgentBatchResponse_Result) SetError(v string) {
+	x.xxx_hidden_Error = v
+}
+
+func (x *AgentBatchResponse_Result) SetPrice(v float64) {
+	x.xxx_hidden_Price = v
+}
+
+func (x *AgentBatchResponse_Result) SetStructuredResponse(v *structpb.Value) {
+	x.xxx_hidden_StructuredResponse = v
+}
+
+func (x *AgentBatchResponse_Result) HasStructuredResponse() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_StructuredResponse != nil
+}
+
+func (x *AgentBatchResponse_Result) ClearStructuredResponse() {
+	x.xxx_hidden_StructuredResponse = nil
+}
+
+type AgentBatchResponse_Result_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Title of the sub-agent's chat.
+	Title string
+	// The sub-agent's final answer; empty if it failed.
+	Response string
+	// Launch or turn error, if any.
+	Error string
+	// Cost of the sub-agent's chat in USD.
+	Price float64
+	// The answer parsed as JSON, when a response_schema was given.
+	StructuredResponse *structpb.Value
+}
+
+func (b0 AgentBatchResponse_Result_builder) Build() *AgentBatchResponse_Result {
+	m0 := &AgentBatchResponse_Result{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Title = b.Title
+	x.xxx_hidden_Response = b.Response
+	x.xxx_hidden_Error = b.Error
+	x.xxx_hidden_Price = b.Price
+	x.xxx_hidden_StructuredResponse = b.StructuredResponse
+	return m0
+}
+
 var File_sgpt_v1_tools_proto protoreflect.FileDescriptor
 
 const file_sgpt_v1_tools_proto_rawDesc = "" +
 	"\n" +
-	"\x13sgpt/v1/tools.proto\x12\asgpt.v1\x1a\x1fgoogle/api/field_behavior.proto\"?\n" +
+	"\x13sgpt/v1/tools.proto\x12\asgpt.v1\x1a\x1fgoogle/api/field_behavior.proto\x1a\x1cgoogle/protobuf/struct.proto\"?\n" +
 	"\vDiffRequest\x12\x17\n" +
 	"\x04path\x18\x01 \x01(\tB\x03\xe0A\x02R\x04path\x12\x17\n" +
 	"\x04diff\x18\x02 \x01(\tB\x03\xe0A\x02R\x04diff\"G\n" +
@@ -1260,10 +1948,11 @@ const file_sgpt_v1_tools_proto_rawDesc = "" +
 	"\x04File\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x18\n" +
 	"\acontent\x18\x02 \x01(\tR\acontent\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"D\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\\\n" +
 	"\x12SearchLoresRequest\x12\x19\n" +
 	"\x05query\x18\x01 \x01(\tB\x03\xe0A\x02R\x05query\x12\x13\n" +
-	"\x05top_n\x18\x02 \x01(\x05R\x04topN\"\xe9\x02\n" +
+	"\x05top_n\x18\x02 \x01(\x05R\x04topN\x12\x16\n" +
+	"\x06ranked\x18\x03 \x01(\bR\x06ranked\"\xe9\x02\n" +
 	"\x13SearchLoresResponse\x12<\n" +
 	"\amatches\x18\x01 \x03(\v2\".sgpt.v1.SearchLoresResponse.MatchR\amatches\x1a\x93\x02\n" +
 	"\x05Match\x12\x12\n" +
@@ -1276,31 +1965,69 @@ const file_sgpt_v1_tools_proto_rawDesc = "" +
 	"matchCount\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"^\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x81\x02\n" +
+	"\x10WriteLoreRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\x12\x19\n" +
+	"\x05title\x18\x02 \x01(\tB\x03\xe0A\x02R\x05title\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12=\n" +
+	"\x06labels\x18\x04 \x03(\v2%.sgpt.v1.WriteLoreRequest.LabelsEntryR\x06labels\x12\x1d\n" +
+	"\acontent\x18\x05 \x01(\tB\x03\xe0A\x02R\acontent\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"t\n" +
+	"\x11WriteLoreResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x18\n" +
+	"\acreated\x18\x03 \x01(\bR\acreated\x12\x1d\n" +
+	"\n" +
+	"new_labels\x18\x04 \x03(\tR\tnewLabels\",\n" +
+	"\x11DeleteLoreRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"<\n" +
+	"\x12DeleteLoreResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"^\n" +
 	"\x10ExecShellRequest\x12\x1d\n" +
 	"\acommand\x18\x01 \x01(\tB\x03\xe0A\x02R\acommand\x12+\n" +
 	"\x11working_directory\x18\x02 \x01(\tR\x10workingDirectory\"^\n" +
 	"\x11ExecShellResponse\x12\x16\n" +
 	"\x06output\x18\x01 \x01(\tR\x06output\x12\x1b\n" +
 	"\texit_code\x18\x02 \x01(\x05R\bexitCode\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"\x86\x01\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\xaf\x01\n" +
 	"\fAgentRequest\x12\x19\n" +
 	"\x05query\x18\x01 \x01(\tB\x03\xe0A\x02R\x05query\x12\x19\n" +
 	"\x05title\x18\x05 \x01(\tB\x03\xe0A\x02R\x05title\x12\x14\n" +
 	"\x05files\x18\x02 \x03(\tR\x05files\x12\x14\n" +
 	"\x05tools\x18\x03 \x03(\tR\x05tools\x12\x14\n" +
-	"\x05model\x18\x04 \x01(\tR\x05model\"+\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\x12'\n" +
+	"\x0fresponse_schema\x18\x06 \x01(\tR\x0eresponseSchema\"t\n" +
 	"\rAgentResponse\x12\x1a\n" +
-	"\bresponse\x18\x01 \x01(\tR\bresponse2\x94\x03\n" +
+	"\bresponse\x18\x01 \x01(\tR\bresponse\x12G\n" +
+	"\x13structured_response\x18\x02 \x01(\v2\x16.google.protobuf.ValueR\x12structuredResponse\"p\n" +
+	"\x11AgentBatchRequest\x122\n" +
+	"\x06agents\x18\x01 \x03(\v2\x15.sgpt.v1.AgentRequestB\x03\xe0A\x02R\x06agents\x12'\n" +
+	"\x0fmax_concurrency\x18\x02 \x01(\x05R\x0emaxConcurrency\"\x84\x02\n" +
+	"\x12AgentBatchResponse\x12<\n" +
+	"\aresults\x18\x01 \x03(\v2\".sgpt.v1.AgentBatchResponse.ResultR\aresults\x1a\xaf\x01\n" +
+	"\x06Result\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x1a\n" +
+	"\bresponse\x18\x02 \x01(\tR\bresponse\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x12G\n" +
+	"\x13structured_response\x18\x05 \x01(\v2\x16.google.protobuf.ValueR\x12structuredResponse2\xe6\x04\n" +
 	"\vToolService\x123\n" +
 	"\x04Diff\x12\x14.sgpt.v1.DiffRequest\x1a\x15.sgpt.v1.DiffResponse\x12<\n" +
 	"\aReplace\x12\x17.sgpt.v1.ReplaceRequest\x1a\x18.sgpt.v1.ReplaceResponse\x12G\n" +
 	"\tReadFiles\x12\x19.sgpt.v1.ReadFilesRequest\x1a\x1a.sgpt.v1.ReadFilesResponse\"\x03\x90\x02\x01\x12B\n" +
 	"\tExecShell\x12\x19.sgpt.v1.ExecShellRequest\x1a\x1a.sgpt.v1.ExecShellResponse\x12M\n" +
-	"\vSearchLores\x12\x1b.sgpt.v1.SearchLoresRequest\x1a\x1c.sgpt.v1.SearchLoresResponse\"\x03\x90\x02\x01\x126\n" +
-	"\x05Agent\x12\x15.sgpt.v1.AgentRequest\x1a\x16.sgpt.v1.AgentResponseB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
+	"\vSearchLores\x12\x1b.sgpt.v1.SearchLoresRequest\x1a\x1c.sgpt.v1.SearchLoresResponse\"\x03\x90\x02\x01\x12B\n" +
+	"\tWriteLore\x12\x19.sgpt.v1.WriteLoreRequest\x1a\x1a.sgpt.v1.WriteLoreResponse\x12E\n" +
+	"\n" +
+	"DeleteLore\x12\x1a.sgpt.v1.DeleteLoreRequest\x1a\x1b.sgpt.v1.DeleteLoreResponse\x126\n" +
+	"\x05Agent\x12\x15.sgpt.v1.AgentRequest\x1a\x16.sgpt.v1.AgentResponse\x12E\n" +
+	"\n" +
+	"AgentBatch\x12\x1a.sgpt.v1.AgentBatchRequest\x1a\x1b.sgpt.v1.AgentBatchResponseB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
 
-var file_sgpt_v1_tools_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_sgpt_v1_tools_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_sgpt_v1_tools_proto_goTypes = []any{
 	(*DiffRequest)(nil),               // 0: sgpt.v1.DiffRequest
 	(*DiffResponse)(nil),              // 1: sgpt.v1.DiffResponse
@@ -1311,36 +2038,56 @@ var file_sgpt_v1_tools_proto_goTypes = []any{
 	(*ReadFilesResponse)(nil),         // 6: sgpt.v1.ReadFilesResponse
 	(*SearchLoresRequest)(nil),        // 7: sgpt.v1.SearchLoresRequest
 	(*SearchLoresResponse)(nil),       // 8: sgpt.v1.SearchLoresResponse
-	(*ExecShellRequest)(nil),          // 9: sgpt.v1.ExecShellRequest
-	(*ExecShellResponse)(nil),         // 10: sgpt.v1.ExecShellResponse
-	(*AgentRequest)(nil),              // 11: sgpt.v1.AgentRequest
-	(*AgentResponse)(nil),             // 12: sgpt.v1.AgentResponse
-	(*ReadFilesResponse_File)(nil),    // 13: sgpt.v1.ReadFilesResponse.File
-	(*SearchLoresResponse_Match)(nil), // 14: sgpt.v1.SearchLoresResponse.Match
-	nil,                               // 15: sgpt.v1.SearchLoresResponse.Match.LabelsEntry
+	(*WriteLoreRequest)(nil),          // 9: sgpt.v1.WriteLoreRequest
+	(*WriteLoreResponse)(nil),         // 10: sgpt.v1.WriteLoreResponse
+	(*DeleteLoreRequest)(nil),         // 11: sgpt.v1.DeleteLoreRequest
+	(*DeleteLoreResponse)(nil),        // 12: sgpt.v1.DeleteLoreResponse
+	(*ExecShellRequest)(nil),          // 13: sgpt.v1.ExecShellRequest
+	(*ExecShellResponse)(nil),         // 14: sgpt.v1.ExecShellResponse
+	(*AgentRequest)(nil),              // 15: sgpt.v1.AgentRequest
+	(*AgentResponse)(nil),             // 16: sgpt.v1.AgentResponse
+	(*AgentBatchRequest)(nil),         // 17: sgpt.v1.AgentBatchRequest
+	(*AgentBatchResponse)(nil),        // 18: sgpt.v1.AgentBatchResponse
+	(*ReadFilesResponse_File)(nil),    // 19: sgpt.v1.ReadFilesResponse.File
+	(*SearchLoresResponse_Match)(nil), // 20: sgpt.v1.SearchLoresResponse.Match
+	nil,                               // 21: sgpt.v1.SearchLoresResponse.Match.LabelsEntry
+	nil,                               // 22: sgpt.v1.WriteLoreRequest.LabelsEntry
+	(*AgentBatchResponse_Result)(nil), // 23: sgpt.v1.AgentBatchResponse.Result
+	(*structpb.Value)(nil),            // 24: google.protobuf.Value
 }
 var file_sgpt_v1_tools_proto_depIdxs = []int32{
 	3,  // 0: sgpt.v1.ReplaceRequest.patches:type_name -> sgpt.v1.Patch
-	13, // 1: sgpt.v1.ReadFilesResponse.files:type_name -> sgpt.v1.ReadFilesResponse.File
-	14, // 2: sgpt.v1.SearchLoresResponse.matches:type_name -> sgpt.v1.SearchLoresResponse.Match
-	15, // 3: sgpt.v1.SearchLoresResponse.Match.labels:type_name -> sgpt.v1.SearchLoresResponse.Match.LabelsEntry
-	0,  // 4: sgpt.v1.ToolService.Diff:input_type -> sgpt.v1.DiffRequest
-	2,  // 5: sgpt.v1.ToolService.Replace:input_type -> sgpt.v1.ReplaceRequest
-	5,  // 6: sgpt.v1.ToolService.ReadFiles:input_type -> sgpt.v1.ReadFilesRequest
-	9,  // 7: sgpt.v1.ToolService.ExecShell:input_type -> sgpt.v1.ExecShellRequest
-	7,  // 8: sgpt.v1.ToolService.SearchLores:input_type -> sgpt.v1.SearchLoresRequest
-	11, // 9: sgpt.v1.ToolService.Agent:input_type -> sgpt.v1.AgentRequest
-	1,  // 10: sgpt.v1.ToolService.Diff:output_type -> sgpt.v1.DiffResponse
-	4,  // 11: sgpt.v1.ToolService.Replace:output_type -> sgpt.v1.ReplaceResponse
-	6,  // 12: sgpt.v1.ToolService.ReadFiles:output_type -> sgpt.v1.ReadFilesResponse
-	10, // 13: sgpt.v1.ToolService.ExecShell:output_type -> sgpt.v1.ExecShellResponse
-	8,  // 14: sgpt.v1.ToolService.SearchLores:output_type -> sgpt.v1.SearchLoresResponse
-	12, // 15: sgpt.v1.ToolService.Agent:output_type -> sgpt.v1.AgentResponse
-	10, // [10:16] is the sub-list for method output_type
-	4,  // [4:10] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	19, // 1: sgpt.v1.ReadFilesResponse.files:type_name -> sgpt.v1.ReadFilesResponse.File
+	20, // 2: sgpt.v1.SearchLoresResponse.matches:type_name -> sgpt.v1.SearchLoresResponse.Match
+	22, // 3: sgpt.v1.WriteLoreRequest.labels:type_name -> sgpt.v1.WriteLoreRequest.LabelsEntry
+	24, // 4: sgpt.v1.AgentResponse.structured_response:type_name -> google.protobuf.Value
+	15, // 5: sgpt.v1.AgentBatchRequest.agents:type_name -> sgpt.v1.AgentRequest
+	23, // 6: sgpt.v1.AgentBatchResponse.results:type_name -> sgpt.v1.AgentBatchResponse.Result
+	21, // 7: sgpt.v1.SearchLoresResponse.Match.labels:type_name -> sgpt.v1.SearchLoresResponse.Match.LabelsEntry
+	24, // 8: sgpt.v1.AgentBatchResponse.Result.structured_response:type_name -> google.protobuf.Value
+	0,  // 9: sgpt.v1.ToolService.Diff:input_type -> sgpt.v1.DiffRequest
+	2,  // 10: sgpt.v1.ToolService.Replace:input_type -> sgpt.v1.ReplaceRequest
+	5,  // 11: sgpt.v1.ToolService.ReadFiles:input_type -> sgpt.v1.ReadFilesRequest
+	13, // 12: sgpt.v1.ToolService.ExecShell:input_type -> sgpt.v1.ExecShellRequest
+	7,  // 13: sgpt.v1.ToolService.SearchLores:input_type -> sgpt.v1.SearchLoresRequest
+	9,  // 14: sgpt.v1.ToolService.WriteLore:input_type -> sgpt.v1.WriteLoreRequest
+	11, // 15: sgpt.v1.ToolService.DeleteLore:input_type -> sgpt.v1.DeleteLoreRequest
+	15, // 16: sgpt.v1.ToolService.Agent:input_type -> sgpt.v1.AgentRequest
+	17, // 17: sgpt.v1.ToolService.AgentBatch:input_type -> sgpt.v1.AgentBatchRequest
+	1,  // 18: sgpt.v1.ToolService.Diff:output_type -> sgpt.v1.DiffResponse
+	4,  // 19: sgpt.v1.ToolService.Replace:output_type -> sgpt.v1.ReplaceResponse
+	6,  // 20: sgpt.v1.ToolService.ReadFiles:output_type -> sgpt.v1.ReadFilesResponse
+	14, // 21: sgpt.v1.ToolService.ExecShell:output_type -> sgpt.v1.ExecShellResponse
+	8,  // 22: sgpt.v1.ToolService.SearchLores:output_type -> sgpt.v1.SearchLoresResponse
+	10, // 23: sgpt.v1.ToolService.WriteLore:output_type -> sgpt.v1.WriteLoreResponse
+	12, // 24: sgpt.v1.ToolService.DeleteLore:output_type -> sgpt.v1.DeleteLoreResponse
+	16, // 25: sgpt.v1.ToolService.Agent:output_type -> sgpt.v1.AgentResponse
+	18, // 26: sgpt.v1.ToolService.AgentBatch:output_type -> sgpt.v1.AgentBatchResponse
+	18, // [18:27] is the sub-list for method output_type
+	9,  // [9:18] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_sgpt_v1_tools_proto_init() }
@@ -1354,7 +2101,7 @@ func file_sgpt_v1_tools_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sgpt_v1_tools_proto_rawDesc), len(file_sgpt_v1_tools_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,404 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.32.1
+// source: sgpt/v1/usage.proto
+
+//go:build protoopaque
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The usage of one chat, rolled up from its messages. Cached by `sgpt usage`
+// and reused for as long as the chat's update time is unchanged, so repeat
+// reports only list the messages of chats that moved since.
+type ChatUsage struct {
+	state                 protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Chat       string                 `protobuf:"bytes,1,opt,name=chat,proto3"`
+	xxx_hidden_UpdateTime *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=update_time,json=updateTime,proto3"`
+	xxx_hidden_Records    *[]*UsageRecord        `protobuf:"bytes,3,rep,name=records,proto3"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *ChatUsage) Reset() {
+	*x = ChatUsage{}
+	mi := &file_sgpt_v1_usage_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatUsage) ProtoMessage() {}
+
+func (x *ChatUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_usage_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *ChatUsage) GetChat() string {
+	if x != nil {
+		return x.xxx_hidden_Chat
+	}
+	return ""
+}
+
+func (x *ChatUsage) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.xxx_hidden_UpdateTime
+	}
+	return nil
+}
+
+func (x *ChatUsage) GetRecords() []*UsageRecord {
+	if x != nil {
+		if x.xxx_hidden_Records != nil {
+			return *x.xxx_hidden_Records
+		}
+	}
+	return nil
+}
+
+func (x *ChatUsage) SetChat(v string) {
+	x.xxx_hidden_Chat = v
+}
+
+func (x *ChatUsage) SetUpdateTime(v *timestamppb.Timestamp) {
+	x.xxx_hidden_UpdateTime = v
+}
+
+func (x *ChatUsage) SetRecords(v []*UsageRecord) {
+	x.xxx_hidden_Records = &v
+}
+
+func (x *ChatUsage) HasUpdateTime() bool {
+	if x == nil {
+		return false
+	}
+	return x.xxx_hidden_UpdateTime != nil
+}
+
+func (x *ChatUsage) ClearUpdateTime() {
+	x.xxx_hidden_UpdateTime = nil
+}
+
+type ChatUsage_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Resource name of the chat.
+	Chat string
+	// Update time of the chat when the rollup was computed.
+	UpdateTime *timestamppb.Timestamp
+	// One record per (day, model, tool) the chat's messages spent on. Role and
+	// tags are chat-level and read from the chat itself.
+	Records []*UsageRecord
+}
+
+func (b0 ChatUsage_builder) Build() *ChatUsage {
+	m0 := &ChatUsage{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Chat = b.Chat
+	x.xxx_hidden_UpdateTime = b.UpdateTime
+	x.xxx_hidden_Records = &b.Records
+	return m0
+}
+
+// Spend and token counts for a (day, model, tool) slice of a chat.
+type UsageRecord struct {
+	state                            protoimpl.MessageState `protogen:"opaque.v1"`
+	xxx_hidden_Day                   string                 `protobuf:"bytes,1,opt,name=day,proto3"`
+	xxx_hidden_Model                 string                 `protobuf:"bytes,2,opt,name=model,proto3"`
+	xxx_hidden_Tool                  string                 `protobuf:"bytes,3,opt,name=tool,proto3"`
+	xxx_hidden_Price                 float64                `protobuf:"fixed64,4,opt,name=price,proto3"`
+	xxx_hidden_Messages              int64                  `protobuf:"varint,5,opt,name=messages,proto3"`
+	xxx_hidden_ToolCalls             int64                  `protobuf:"varint,6,opt,name=tool_calls,json=toolCalls,proto3"`
+	xxx_hidden_InputTokens           int64                  `protobuf:"varint,7,opt,name=input_tokens,json=inputTokens,proto3"`
+	xxx_hidden_InputCacheReadTokens  int64                  `protobuf:"varint,8,opt,name=input_cache_read_tokens,json=inputCacheReadTokens,proto3"`
+	xxx_hidden_InputCacheWriteTokens int64                  `protobuf:"varint,9,opt,name=input_cache_write_tokens,json=inputCacheWriteTokens,proto3"`
+	xxx_hidden_OutputTokens          int64                  `protobuf:"varint,10,opt,name=output_tokens,json=outputTokens,proto3"`
+	xxx_hidden_OutputReasoningTokens int64                  `protobuf:"varint,11,opt,name=output_reasoning_tokens,json=outputReasoningTokens,proto3"`
+	unknownFields                    protoimpl.UnknownFields
+	sizeCache                        protoimpl.SizeCache
+}
+
+func (x *UsageRecord) Reset() {
+	*x = UsageRecord{}
+	mi := &file_sgpt_v1_usage_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageRecord) ProtoMessage() {}
+
+func (x *UsageRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_sgpt_v1_usage_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+func (x *UsageRecord) GetDay() string {
+	if x != nil {
+		return x.xxx_hidden_Day
+	}
+	return ""
+}
+
+func (x *UsageRecord) GetModel() string {
+	if x != nil {
+		return x.xxx_hidden_Model
+	}
+	return ""
+}
+
+func (x *UsageRecord) GetTool() string {
+	if x != nil {
+		return x.xxx_hidden_Tool
+	}
+	return ""
+}
+
+func (x *UsageRecord) GetPrice() float64 {
+	if x != nil {
+		return x.xxx_hidden_Price
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetMessages() int64 {
+	if x != nil {
+		return x.xxx_hidden_Messages
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetToolCalls() int64 {
+	if x != nil {
+		return x.xxx_hidden_ToolCalls
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetInputTokens() int64 {
+	if x != nil {
+		return x.xxx_hidden_InputTokens
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetInputCacheReadTokens() int64 {
+	if x != nil {
+		return x.xxx_hidden_InputCacheReadTokens
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetInputCacheWriteTokens() int64 {
+	if x != nil {
+		return x.xxx_hidden_InputCacheWriteTokens
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetOutputTokens() int64 {
+	if x != nil {
+		return x.xxx_hidden_OutputTokens
+	}
+	return 0
+}
+
+func (x *UsageRecord) GetOutputReasoningTokens() int64 {
+	if x != nil {
+		return x.xxx_hidden_OutputReasoningTokens
+	}
+	return 0
+}
+
+func (x *UsageRecord) SetDay(v string) {
+	x.xxx_hidden_Day = v
+}
+
+func (x *UsageRecord) SetModel(v string) {
+	x.xxx_hidden_Model = v
+}
+
+func (x *UsageRecord) SetTool(v string) {
+	x.xxx_hidden_Tool = v
+}
+
+func (x *UsageRecord) SetPrice(v float64) {
+	x.xxx_hidden_Price = v
+}
+
+func (x *UsageRecord) SetMessages(v int64) {
+	x.xxx_hidden_Messages = v
+}
+
+func (x *UsageRecord) SetToolCalls(v int64) {
+	x.xxx_hidden_ToolCalls = v
+}
+
+func (x *UsageRecord) SetInputTokens(v int64) {
+	x.xxx_hidden_InputTokens = v
+}
+
+func (x *UsageRecord) SetInputCacheReadTokens(v int64) {
+	x.xxx_hidden_InputCacheReadTokens = v
+}
+
+func (x *UsageRecord) SetInputCacheWriteTokens(v int64) {
+	x.xxx_hidden_InputCacheWriteTokens = v
+}
+
+func (x *UsageRecord) SetOutputTokens(v int64) {
+	x.xxx_hidden_OutputTokens = v
+}
+
+func (x *UsageRecord) SetOutputReasoningTokens(v int64) {
+	x.xxx_hidden_OutputReasoningTokens = v
+}
+
+type UsageRecord_builder struct {
+	_ [0]func() // Prevents comparability and use of unkeyed literals for the builder.
+
+	// Local calendar day of the messages, as YYYY-MM-DD.
+	Day string
+	// Resource name of the model that generated the messages.
+	Model string
+	// Tool called by the messages. Empty for messages without tool calls. The
+	// usage of a message calling several tools is split evenly between them.
+	Tool string
+	// Price of the messages.
+	Price float64
+	// Number of generated messages.
+	Messages int64
+	// Number of tool calls made.
+	ToolCalls int64
+	// Uncached input tokens.
+	InputTokens int64
+	// Input tokens read from the provider's prompt cache.
+	InputCacheReadTokens int64
+	// Input tokens written to the provider's prompt cache.
+	InputCacheWriteTokens int64
+	// Output tokens, reasoning excluded.
+	OutputTokens int64
+	// Reasoning output tokens.
+	OutputReasoningTokens int64
+}
+
+func (b0 UsageRecord_builder) Build() *UsageRecord {
+	m0 := &UsageRecord{}
+	b, x := &b0, m0
+	_, _ = b, x
+	x.xxx_hidden_Day = b.Day
+	x.xxx_hidden_Model = b.Model
+	x.xxx_hidden_Tool = b.Tool
+	x.xxx_hidden_Price = b.Price
+	x.xxx_hidden_Messages = b.Messages
+	x.xxx_hidden_ToolCalls = b.ToolCalls
+	x.xxx_hidden_InputTokens = b.InputTokens
+	x.xxx_hidden_InputCacheReadTokens = b.InputCacheReadTokens
+	x.xxx_hidden_InputCacheWriteTokens = b.InputCacheWriteTokens
+	x.xxx_hidden_OutputTokens = b.OutputTokens
+	x.xxx_hidden_OutputReasoningTokens = b.OutputReasoningTokens
+	return m0
+}
+
+var File_sgpt_v1_usage_proto protoreflect.FileDescriptor
+
+const file_sgpt_v1_usage_proto_rawDesc = "" +
+	"\n" +
+	"\x13sgpt/v1/usage.proto\x12\asgpt.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8c\x01\n" +
+	"\tChatUsage\x12\x12\n" +
+	"\x04chat\x18\x01 \x01(\tR\x04chat\x12;\n" +
+	"\vupdate_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\x12.\n" +
+	"\arecords\x18\x03 \x03(\v2\x14.sgpt.v1.UsageRecordR\arecords\"\x8a\x03\n" +
+	"\vUsageRecord\x12\x10\n" +
+	"\x03day\x18\x01 \x01(\tR\x03day\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12\x12\n" +
+	"\x04tool\x18\x03 \x01(\tR\x04tool\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bmessages\x18\x05 \x01(\x03R\bmessages\x12\x1d\n" +
+	"\n" +
+	"tool_calls\x18\x06 \x01(\x03R\ttoolCalls\x12!\n" +
+	"\finput_tokens\x18\a \x01(\x03R\vinputTokens\x125\n" +
+	"\x17input_cache_read_tokens\x18\b \x01(\x03R\x14inputCacheReadTokens\x127\n" +
+	"\x18input_cache_write_tokens\x18\t \x01(\x03R\x15inputCacheWriteTokens\x12#\n" +
+	"\routput_tokens\x18\n" +
+	" \x01(\x03R\foutputTokens\x126\n" +
+	"\x17output_reasoning_tokens\x18\v \x01(\x03R\x15outputReasoningTokensB*Z(github.com/malonaz/sgpt/genproto/sgpt/v1b\x06proto3"
+
+var file_sgpt_v1_usage_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_sgpt_v1_usage_proto_goTypes = []any{
+	(*ChatUsage)(nil),             // 0: sgpt.v1.ChatUsage
+	(*UsageRecord)(nil),           // 1: sgpt.v1.UsageRecord
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_sgpt_v1_usage_proto_depIdxs = []int32{
+	2, // 0: sgpt.v1.ChatUsage.update_time:type_name -> google.protobuf.Timestamp
+	1, // 1: sgpt.v1.ChatUsage.records:type_name -> sgpt.v1.UsageRecord
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_sgpt_v1_usage_proto_init() }
+func file_sgpt_v1_usage_proto_init() {
+	if File_sgpt_v1_usage_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sgpt_v1_usage_proto_rawDesc), len(file_sgpt_v1_usage_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_sgpt_v1_usage_proto_goTypes,
+		DependencyIndexes: file_sgpt_v1_usage_proto_depIdxs,
+		MessageInfos:      file_sgpt_v1_usage_proto_msgTypes,
+	}.Build()
+	File_sgpt_v1_usage_proto = out.File
+	file_sgpt_v1_usage_proto_goTypes = nil
+	file_sgpt_v1_usage_proto_depIdxs = nil
+}
@@ -50,3 +50,21 @@ func Store[T proto.Message](key string, message T) error {
 	}
 	return os.WriteFile(cachePath, data, 0644)
 }
+
+// Age returns how long ago the entry under the given key was stored.
+// Returns false if missing.
+func Age(key string) (time.Duration, bool) {
+	info, err := os.Stat(path(key))
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(info.ModTime()), true
+}
+
+// Delete removes the entry under the given key, if any.
+func Delete(key string) error {
+	if err := os.Remove(path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+)
+
+// BundleVersion is the version of the bundle format written by MarshalBundle.
+const BundleVersion = 1
+
+// Bundle is a chat with its full message history, exactly as stored.
+type Bundle struct {
+	Chat     *aipb.Chat
+	Messages []*aipb.Message
+}
+
+// bundleJSON is the wire form of a bundle: resources are embedded as
+// protojson so no field is lost.
+type bundleJSON struct {
+	Version  int               `json:"version"`
+	Chat     json.RawMessage   `json:"chat"`
+	Messages []json.RawMessage `json:"messages"`
+}
+
+// MarshalBundle encodes a chat and its messages as an indented JSON bundle.
+func MarshalBundle(chat *aipb.Chat, messages []*aipb.Message) ([]byte, error) {
+	chatBytes, err := pbutil.JSONMarshal(chat)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling chat: %w", err)
+	}
+	wire := bundleJSON{Version: BundleVersion, Chat: chatBytes, Messages: make([]json.RawMessage, 0, len(messages))}
+	for _, message := range messages {
+		messageBytes, err := pbutil.JSONMarshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling message %s: %w", message.GetName(), err)
+		}
+		wire.Messages = append(wire.Messages, messageBytes)
+	}
+	bytes, err := json.MarshalIndent(wire, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling bundle: %w", err)
+	}
+	return bytes, nil
+}
+
+// UnmarshalBundle decodes a bundle written by MarshalBundle.
+func UnmarshalBundle(data []byte) (*Bundle, error) {
+	wire := &bundleJSON{}
+	if err := json.Unmarshal(data, wire); err != nil {
+		return nil, fmt.Errorf("parsing bundle: %w", err)
+	}
+	if wire.Version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (want %d)", wire.Version, BundleVersion)
+	}
+	if len(wire.Chat) == 0 {
+		return nil, fmt.Errorf("bundle has no chat")
+	}
+	bundle := &Bundle{Chat: &aipb.Chat{}}
+	if err := pbutil.JSONUnmarshal(wire.Chat, bundle.Chat); err != nil {
+		return nil, fmt.Errorf("parsing bundle chat: %w", err)
+	}
+	for i, messageBytes := range wire.Messages {
+		message := &aipb.Message{}
+		if err := pbutil.JSONUnmarshal(messageBytes, message); err != nil {
+			return nil, fmt.Errorf("parsing bundle message %d: %w", i, err)
+		}
+		bundle.Messages = append(bundle.Messages, message)
+	}
+	return bundle, nil
+}
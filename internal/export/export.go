@@ -0,0 +1,268 @@
+// Package export renders a chat for use outside the TUI: markdown to read
+// or paste, standalone HTML to share, and a lossless JSON bundle that
+// `sgpt import` reads back — along with OpenAI-style transcripts from other
+// tools.
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+
+	"github.com/malonaz/sgpt/internal/file"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// Renderer renders tool calls the way the timeline does. The tool registry
+// implements it; every method may decline, falling back to raw JSON.
+type Renderer interface {
+	RenderHeader(toolCall *aipb.ToolCall) (string, bool)
+	RenderRequest(toolCall *aipb.ToolCall) (string, bool)
+	RenderResult(toolCall *aipb.ToolCall, toolResult *aipb.ToolResult) (string, bool)
+}
+
+// Options controls what a rendered export contains. The JSON bundle ignores
+// them: it is always the full history.
+type Options struct {
+	// IncludeContext keeps the context sgpt injected, such as file contents.
+	IncludeContext bool
+	// IncludeSystem keeps the system prompt.
+	IncludeSystem bool
+	// Renderer, when set, renders tool calls with their tools' renderers.
+	Renderer Renderer
+}
+
+// section is one message of the export, under a heading.
+type section struct {
+	heading string
+	parts   []part
+}
+
+// part is one block of a message. Parts with a summary (thoughts, tool
+// calls, files, the system prompt) are secondary: HTML folds them away.
+type part struct {
+	summary  string
+	markdown string
+}
+
+// buildSections walks the history into sections. Tool results render with
+// their calls, so tool messages yield no section of their own.
+func buildSections(messages []*aipb.Message, opts *Options) []section {
+	toolCallIDToResult := map[string]*aipb.ToolResult{}
+	for _, message := range messages {
+		for _, block := range message.GetBlocks() {
+			if toolResult := block.GetToolResult(); toolResult != nil {
+				toolCallIDToResult[toolResult.GetToolCallId()] = toolResult
+			}
+		}
+	}
+
+	var sections []section
+	for _, message := range messages {
+		if message.GetDeleteTime() != nil {
+			continue
+		}
+		var current section
+		switch message.GetRole() {
+		case aipb.Role_ROLE_SYSTEM:
+			if !opts.IncludeSystem {
+				continue
+			}
+			current.heading = "⚙️ System"
+			if text := messageText(message); text != "" {
+				current.parts = append(current.parts, part{summary: "System prompt", markdown: text})
+			}
+
+		case aipb.Role_ROLE_USER:
+			if store.IsContextMessage(message) && !opts.IncludeContext {
+				continue
+			}
+			if path := store.InjectedFilePath(message); path != "" {
+				current.heading = "📄 Injected file"
+				// Transcripts stay text: an attachment is described, not embedded.
+				if mediaType, content, ok := store.Attachment(message); ok {
+					current.parts = append(current.parts, part{summary: path, markdown: fmt.Sprintf("_Attached %s._", file.DescribeAttachment(mediaType, content))})
+					break
+				}
+				current.parts = append(current.parts, part{summary: path, markdown: fence(messageText(message), "")})
+				break
+			}
+			// A live file's diff is already fenced markdown.
+			if path := store.FileDiffPath(message); path != "" {
+				current.heading = "📄 File changes"
+				current.parts = append(current.parts, part{summary: path, markdown: messageText(message)})
+				break
+			}
+			current.heading = "🧑 User"
+			if text := messageText(message); text != "" {
+				current.parts = append(current.parts, part{markdown: text})
+			}
+
+		case aipb.Role_ROLE_ASSISTANT:
+			current.heading = "🤖 Assistant"
+			for _, block := range message.GetBlocks() {
+				if thought := block.GetThought(); thought != "" {
+					current.parts = append(current.parts, part{summary: "🧠 Thinking", markdown: thought})
+				} else if text := block.GetText(); text != "" {
+					current.parts = append(current.parts, part{markdown: text})
+				} else if toolCall := block.GetToolCall(); toolCall != nil {
+					toolResult := toolCall.GetResult()
+					if toolResult == nil {
+						toolResult = toolCallIDToResult[toolCall.GetId()]
+					}
+					current.parts = append(current.parts, toolCallPart(toolCall, toolResult, opts.Renderer))
+				}
+			}
+
+		default:
+			continue
+		}
+		if errText := store.MessageError(message); errText != "" {
+			current.parts = append(current.parts, part{markdown: "> ⚠️ Error: " + errText})
+		}
+		if len(current.parts) > 0 {
+			sections = append(sections, current)
+		}
+	}
+	return sections
+}
+
+// toolCallPart renders a call and its result, if any, preferring the tool's
+// own renderers.
+func toolCallPart(toolCall *aipb.ToolCall, toolResult *aipb.ToolResult, renderer Renderer) part {
+	summary := "🛠 " + toolCall.GetName()
+	var request string
+	if renderer != nil {
+		if md, ok := renderer.RenderHeader(toolCall); ok {
+			summary = md
+		}
+		request, _ = renderer.RenderRequest(toolCall)
+	}
+	if request == "" {
+		bytes, _ := pbutil.JSONMarshalPretty(toolCall.GetArguments())
+		request = fence(string(bytes), "json")
+	}
+
+	var b strings.Builder
+	b.WriteString(request)
+	switch {
+	case toolResult == nil:
+		b.WriteString("\n\n_no result_")
+	case toolResult.GetError() != nil:
+		fmt.Fprintf(&b, "\n\n**↳ error:** %s", toolResult.GetError().GetMessage())
+	default:
+		b.WriteString("\n\n**↳ result**\n\n")
+		result := ""
+		if renderer != nil {
+			result, _ = renderer.RenderResult(toolCall, toolResult)
+		}
+		if result == "" {
+			result = fence(toolResultText(toolResult), "json")
+		}
+		b.WriteString(result)
+	}
+	return part{summary: summary, markdown: b.String()}
+}
+
+func toolResultText(toolResult *aipb.ToolResult) string {
+	if structured := toolResult.GetStructuredContent(); structured != nil {
+		bytes, _ := pbutil.JSONMarshalPretty(structured)
+		return string(bytes)
+	}
+	return toolResult.GetContent()
+}
+
+// messageText joins the text blocks of a message.
+func messageText(message *aipb.Message) string {
+	var texts []string
+	for _, block := range message.GetBlocks() {
+		if text := block.GetText(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// fence wraps content in a code fence long enough that backtick runs inside
+// the content cannot close it.
+func fence(content, language string) string {
+	delimiter := "```"
+	for strings.Contains(content, delimiter) {
+		delimiter += "`"
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s", delimiter, language, strings.TrimRight(content, "\n"), delimiter)
+}
+
+// title is the chat's display title.
+func title(chat *aipb.Chat) string {
+	if chat.GetTitle() != "" {
+		return chat.GetTitle()
+	}
+	return "Untitled chat"
+}
+
+// subtitle summarizes the chat's identity and spend on one line.
+func subtitle(chat *aipb.Chat) string {
+	facts := []string{}
+	if chat.GetName() != "" {
+		facts = append(facts, chat.GetName())
+	}
+	if createTime := chat.GetCreateTime(); createTime != nil {
+		facts = append(facts, createTime.AsTime().Local().Format("2006-01-02 15:04"))
+	}
+	if model := store.CurrentModel(chat); model != "" {
+		facts = append(facts, model)
+	}
+	if price := chat.GetPrice(); price > 0 {
+		facts = append(facts, fmt.Sprintf("$%.4f", price))
+	}
+	return strings.Join(facts, " · ")
+}
+
+// Markdown renders a chat as a markdown document. Secondary parts render
+// under their summary as a bold line.
+func Markdown(chat *aipb.Chat, messages []*aipb.Message, opts *Options) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title(chat))
+	if subtitle := subtitle(chat); subtitle != "" {
+		fmt.Fprintf(&b, "_%s_\n\n", subtitle)
+	}
+	for _, section := range buildSections(messages, opts) {
+		fmt.Fprintf(&b, "## %s\n\n", section.heading)
+		for _, part := range section.parts {
+			if part.summary != "" {
+				fmt.Fprintf(&b, "**%s**\n\n", part.summary)
+			}
+			b.WriteString(part.markdown)
+			b.WriteString("\n\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// Export formats, named after their file extensions.
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+)
+
+// Render renders a chat in the given format.
+func Render(format string, chat *aipb.Chat, messages []*aipb.Message, opts *Options) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return []byte(Markdown(chat, messages, opts)), nil
+	case FormatHTML:
+		page, err := HTML(chat, messages, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(page), nil
+	case FormatJSON:
+		return MarshalBundle(chat, messages)
+	default:
+		return nil, fmt.Errorf("unknown export format %q: want md, html or json", format)
+	}
+}
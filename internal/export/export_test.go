@@ -0,0 +1,110 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/aip"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+func textBlock(text string) *aipb.Block {
+	return &aipb.Block{Content: &aipb.Block_Text{Text: text}}
+}
+
+func testHistory() []*aipb.Message {
+	systemMessage := &aipb.Message{Role: aipb.Role_ROLE_SYSTEM, Blocks: []*aipb.Block{textBlock("You are terse.")}}
+	aip.SetLabel(systemMessage, sgptpb.Labels.Context.GetKey(), aip.LabelValueTrue)
+	return []*aipb.Message{
+		systemMessage,
+		store.NewInjectedFileMessage("main.go", "package main"),
+		{Role: aipb.Role_ROLE_USER, Blocks: []*aipb.Block{textBlock("List the files.")}},
+		{Role: aipb.Role_ROLE_ASSISTANT, Blocks: []*aipb.Block{
+			{Content: &aipb.Block_Thought{Thought: "Use the shell."}},
+			{Content: &aipb.Block_ToolCall{ToolCall: &aipb.ToolCall{Id: "call-1", Name: "shell"}}},
+		}},
+		{Role: aipb.Role_ROLE_TOOL, Blocks: []*aipb.Block{
+			{Content: &aipb.Block_ToolResult{ToolResult: &aipb.ToolResult{ToolCallId: "call-1", Content: "main.go"}}},
+		}},
+		{Role: aipb.Role_ROLE_ASSISTANT, Blocks: []*aipb.Block{textBlock("Just `main.go`.")}},
+	}
+}
+
+// stubRenderer renders every request as a fixed marker.
+type stubRenderer struct{}
+
+func (stubRenderer) RenderHeader(*aipb.ToolCall) (string, bool) { return "", false }
+func (stubRenderer) RenderRequest(*aipb.ToolCall) (string, bool) {
+	return "RENDERED REQUEST", true
+}
+func (stubRenderer) RenderResult(*aipb.ToolCall, *aipb.ToolResult) (string, bool) {
+	return "", false
+}
+
+func TestMarkdownFiltersContext(t *testing.T) {
+	chat := &aipb.Chat{Title: "Files"}
+	md := Markdown(chat, testHistory(), &Options{Renderer: stubRenderer{}})
+	for _, want := range []string{"# Files", "List the files.", "RENDERED REQUEST", "main.go", "Just `main.go`.", "🧠 Thinking"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+	for _, unwanted := range []string{"You are terse.", "package main"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("markdown contains excluded %q:\n%s", unwanted, md)
+		}
+	}
+
+	md = Markdown(chat, testHistory(), &Options{IncludeContext: true, IncludeSystem: true})
+	for _, want := range []string{"You are terse.", "package main"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing included %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHTMLFoldsSecondaryParts(t *testing.T) {
+	messages := append(testHistory(), &aipb.Message{
+		Role:   aipb.Role_ROLE_USER,
+		Blocks: []*aipb.Block{textBlock("<script>alert(1)</script>")},
+	})
+	page, err := HTML(&aipb.Chat{}, messages, &Options{})
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	if got := strings.Count(page, "<details>"); got != 2 {
+		t.Errorf("got %d collapsible blocks, want 2 (thought and tool call)", got)
+	}
+	if strings.Contains(page, "<script>") {
+		t.Error("raw HTML from a message leaked into the page")
+	}
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	chat := &aipb.Chat{Name: "organizations/o/users/u/chats/c", Title: "Files"}
+	messages := testHistory()
+	data, err := MarshalBundle(chat, messages)
+	if err != nil {
+		t.Fatalf("MarshalBundle: %v", err)
+	}
+	bundle, err := UnmarshalBundle(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBundle: %v", err)
+	}
+	if bundle.Chat.GetTitle() != "Files" || len(bundle.Messages) != len(messages) {
+		t.Fatalf("round trip = %v with %d messages", bundle.Chat, len(bundle.Messages))
+	}
+	if path := store.InjectedFilePath(bundle.Messages[1]); path != "main.go" {
+		t.Errorf("injected file path = %q, want main.go", path)
+	}
+}
+
+func TestFenceOutgrowsContent(t *testing.T) {
+	got := fence("a ``` b", "")
+	if !strings.HasPrefix(got, "````\n") || !strings.HasSuffix(got, "\n````") {
+		t.Errorf("fence = %q, want a four-backtick fence", got)
+	}
+}
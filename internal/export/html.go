@@ -0,0 +1,120 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdownToHTML converts GFM to HTML. Raw HTML in messages is dropped
+// rather than passed through: the page is meant to be shared.
+var markdownToHTML = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// htmlTemplate is a self-contained page: inline styles, no scripts, so the
+// file opens anywhere. Secondary parts fold into <details>.
+var htmlTemplate = template.Must(template.New("chat").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { max-width: 52rem; margin: 2rem auto; padding: 0 1rem; font: 15px/1.55 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; }
+header p { color: #656d76; }
+section { border: 1px solid #d0d7de; border-radius: 8px; padding: 0.25rem 1rem; margin: 1rem 0; }
+section h2 { font-size: 0.95rem; color: #656d76; margin: 0.75rem 0 0.25rem; }
+details { border-left: 3px solid #d0d7de; padding-left: 0.75rem; margin: 0.75rem 0; }
+summary { cursor: pointer; color: #656d76; }
+summary p { display: inline; margin: 0; }
+pre { background: #f6f8fa; padding: 0.75rem; border-radius: 6px; overflow-x: auto; }
+code { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 0.9em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 0.25rem 0.5rem; }
+blockquote { color: #656d76; border-left: 3px solid #d0d7de; margin: 0; padding-left: 0.75rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{ .Title }}</h1>
+{{- if .Subtitle }}
+<p>{{ .Subtitle }}</p>
+{{- end }}
+</header>
+{{- range .Sections }}
+<section>
+<h2>{{ .Heading }}</h2>
+{{- range .Parts }}
+{{- if .Summary }}
+<details>
+<summary>{{ .Summary }}</summary>
+{{ .Body }}
+</details>
+{{- else }}
+{{ .Body }}
+{{- end }}
+{{- end }}
+</section>
+{{- end }}
+</body>
+</html>
+`))
+
+type htmlPage struct {
+	Title    string
+	Subtitle string
+	Sections []htmlSection
+}
+
+type htmlSection struct {
+	Heading string
+	Parts   []htmlPart
+}
+
+type htmlPart struct {
+	Summary template.HTML
+	Body    template.HTML
+}
+
+// HTML renders a chat as a standalone HTML page, with thoughts, tool calls
+// and injected context folded into collapsible blocks.
+func HTML(chat *aipb.Chat, messages []*aipb.Message, opts *Options) (string, error) {
+	page := htmlPage{Title: title(chat), Subtitle: subtitle(chat)}
+	for _, section := range buildSections(messages, opts) {
+		current := htmlSection{Heading: section.heading}
+		for _, part := range section.parts {
+			body, err := renderHTML(part.markdown)
+			if err != nil {
+				return "", err
+			}
+			var summary template.HTML
+			if part.summary != "" {
+				if summary, err = renderHTML(part.summary); err != nil {
+					return "", err
+				}
+			}
+			current.Parts = append(current.Parts, htmlPart{Summary: summary, Body: body})
+		}
+		page.Sections = append(page.Sections, current)
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, page); err != nil {
+		return "", fmt.Errorf("rendering html: %w", err)
+	}
+	return b.String(), nil
+}
+
+// renderHTML converts markdown to HTML. The output is trusted by the
+// template: goldmark escapes text and omits raw HTML.
+func renderHTML(md string) (template.HTML, error) {
+	var b bytes.Buffer
+	if err := markdownToHTML.Convert([]byte(md), &b); err != nil {
+		return "", fmt.Errorf("converting markdown: %w", err)
+	}
+	return template.HTML(strings.TrimSpace(b.String())), nil
+}
@@ -0,0 +1,250 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+	"github.com/malonaz/core/go/aip"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// IsBundle reports whether data looks like a bundle written by MarshalBundle
+// rather than an OpenAI-style transcript.
+func IsBundle(data []byte) bool {
+	probe := struct {
+		Version int             `json:"version"`
+		Chat    json.RawMessage `json:"chat"`
+	}{}
+	return json.Unmarshal(data, &probe) == nil && probe.Version != 0 && len(probe.Chat) > 0
+}
+
+// ForImport readies a bundle to be recreated as a new chat. Output-only
+// fields are left behind, as are deleted and failed messages: the server
+// excluded them from generation, and recreated they would rejoin it. A tool
+// call and its result go together: dropping either half drops the other. The
+// history must be well-formed, every tool call answered and every result
+// matched to a call, or providers would reject the imported chat outright.
+func ForImport(bundle *Bundle) (*Bundle, error) {
+	chat := &aipb.Chat{
+		Title:       bundle.Chat.GetTitle(),
+		Labels:      bundle.Chat.GetLabels(),
+		Annotations: bundle.Chat.GetAnnotations(),
+	}
+	// The launching chat does not travel with a sub-agent's bundle.
+	aip.DeleteLabel(chat, sgptpb.Labels.ParentChat.GetKey())
+
+	droppedToolCallIDSet := map[string]bool{}
+	for _, message := range bundle.Messages {
+		if !isImported(message) {
+			for _, toolCallID := range toolCallIDs(message) {
+				// An ID-less call cannot be matched: recorded, it would match
+				// every block that is no tool call at all.
+				if toolCallID != "" {
+					droppedToolCallIDSet[toolCallID] = true
+				}
+			}
+		}
+	}
+	messages := make([]*aipb.Message, 0, len(bundle.Messages))
+	for _, message := range bundle.Messages {
+		if !isImported(message) {
+			continue
+		}
+		blocks := make([]*aipb.Block, 0, len(message.GetBlocks()))
+		for _, block := range message.GetBlocks() {
+			if toolCallID := blockToolCallID(block); toolCallID != "" && droppedToolCallIDSet[toolCallID] {
+				continue
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) == 0 && len(message.GetBlocks()) > 0 {
+			continue
+		}
+		messages = append(messages, &aipb.Message{
+			Role:        message.GetRole(),
+			Blocks:      blocks,
+			Labels:      message.GetLabels(),
+			Annotations: message.GetAnnotations(),
+		})
+	}
+	if err := validateToolCalls(messages); err != nil {
+		return nil, err
+	}
+	return &Bundle{Chat: chat, Messages: messages}, nil
+}
+
+// isImported reports whether a message is recreated on import: deleted and
+// failed messages are not.
+func isImported(message *aipb.Message) bool {
+	return message.GetDeleteTime() == nil && store.MessageError(message) == ""
+}
+
+// toolCallIDs returns the IDs of the tool calls a message makes or answers.
+func toolCallIDs(message *aipb.Message) []string {
+	var ids []string
+	for _, block := range message.GetBlocks() {
+		if block.GetToolCall() != nil || block.GetToolResult() != nil {
+			ids = append(ids, blockToolCallID(block))
+		}
+	}
+	return ids
+}
+
+// blockToolCallID returns the ID of the tool call a block makes or answers;
+// empty for other blocks.
+func blockToolCallID(block *aipb.Block) string {
+	if toolCall := block.GetToolCall(); toolCall != nil {
+		return toolCall.GetId()
+	}
+	return block.GetToolResult().GetToolCallId()
+}
+
+// validateToolCalls rejects a history with unanswered tool calls, or results
+// answering no call.
+func validateToolCalls(messages []*aipb.Message) error {
+	if orphanedToolCalls := store.OrphanedToolCalls(messages); len(orphanedToolCalls) > 0 {
+		descriptions := make([]string, 0, len(orphanedToolCalls))
+		for _, toolCall := range orphanedToolCalls {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%s)", toolCall.GetName(), toolCall.GetId()))
+		}
+		return fmt.Errorf("tool calls without results: %s", strings.Join(descriptions, ", "))
+	}
+	toolCallIDSet := map[string]bool{}
+	for _, message := range messages {
+		for _, block := range message.GetBlocks() {
+			if toolCall := block.GetToolCall(); toolCall != nil {
+				toolCallIDSet[toolCall.GetId()] = true
+			}
+			if toolResult := block.GetToolResult(); toolResult != nil && !toolCallIDSet[toolResult.GetToolCallId()] {
+				return fmt.Errorf("tool result %s answers no preceding tool call", toolResult.GetToolCallId())
+			}
+		}
+	}
+	return nil
+}
+
+// openAIMessage is a message of the OpenAI chat completions format, which
+// most tools export transcripts in.
+type openAIMessage struct {
+	Role string `json:"role"`
+	// Content is a string, an array of content parts, or null.
+	Content    json.RawMessage  `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls"`
+	ToolCallID string           `json:"tool_call_id"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is a JSON object, encoded as a string.
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// ParseOpenAIMessages converts an OpenAI-style transcript — a `messages`
+// array, bare or wrapped in an object — into a history. Run it through
+// ForImport before writing it.
+func ParseOpenAIMessages(data []byte) ([]*aipb.Message, error) {
+	var openAIMessages []openAIMessage
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &openAIMessages); err != nil {
+			return nil, fmt.Errorf("parsing messages: %w", err)
+		}
+	} else {
+		wrapper := struct {
+			Messages []openAIMessage `json:"messages"`
+		}{}
+		if err := json.Unmarshal(trimmed, &wrapper); err != nil {
+			return nil, fmt.Errorf("parsing messages: %w", err)
+		}
+		openAIMessages = wrapper.Messages
+	}
+	if len(openAIMessages) == 0 {
+		return nil, fmt.Errorf("transcript has no messages")
+	}
+
+	toolCallIDToName := map[string]string{}
+	messages := make([]*aipb.Message, 0, len(openAIMessages))
+	for i, openAIMessage := range openAIMessages {
+		text, err := openAIContentText(openAIMessage.Content)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		switch openAIMessage.Role {
+		case "system", "developer":
+			messages = append(messages, ai.NewSystemMessage(ai.NewTextBlock(text)))
+		case "user":
+			messages = append(messages, ai.NewUserMessage(ai.NewTextBlock(text)))
+		case "assistant":
+			var blocks []*aipb.Block
+			if text != "" {
+				blocks = append(blocks, ai.NewTextBlock(text))
+			}
+			for _, openAIToolCall := range openAIMessage.ToolCalls {
+				arguments, err := parseOpenAIArguments(openAIToolCall.Function.Arguments)
+				if err != nil {
+					return nil, fmt.Errorf("message %d: tool call %s: %w", i, openAIToolCall.ID, err)
+				}
+				toolCallIDToName[openAIToolCall.ID] = openAIToolCall.Function.Name
+				blocks = append(blocks, ai.NewToolCallBlock(&aipb.ToolCall{
+					Id:        openAIToolCall.ID,
+					Name:      openAIToolCall.Function.Name,
+					Arguments: arguments,
+				}))
+			}
+			messages = append(messages, ai.NewAssistantMessage(blocks...))
+		case "tool":
+			toolResult := ai.NewToolResult(toolCallIDToName[openAIMessage.ToolCallID], openAIMessage.ToolCallID, text)
+			messages = append(messages, ai.NewToolMessage(ai.NewToolResultBlock(toolResult)))
+		default:
+			return nil, fmt.Errorf("message %d: unsupported role %q", i, openAIMessage.Role)
+		}
+	}
+	return messages, nil
+}
+
+// openAIContentText flattens message content to text. Only text parts are
+// supported: a transcript with images would import incomplete.
+func openAIContentText(content json.RawMessage) (string, error) {
+	if len(content) == 0 || string(content) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &parts); err != nil {
+		return "", fmt.Errorf("content is neither a string nor content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("unsupported content part %q", part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+func parseOpenAIArguments(raw string) (*structpb.Struct, error) {
+	if strings.TrimSpace(raw) == "" {
+		return &structpb.Struct{}, nil
+	}
+	arguments := map[string]any{}
+	if err := json.Unmarshal([]byte(raw), &arguments); err != nil {
+		return nil, fmt.Errorf("parsing arguments: %w", err)
+	}
+	return structpb.NewStruct(arguments)
+}
@@ -0,0 +1,130 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/aip"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+const openAITranscript = `{"messages": [
+  {"role": "system", "content": "You are terse."},
+  {"role": "user", "content": [{"type": "text", "text": "List the files."}]},
+  {"role": "assistant", "content": null, "tool_calls": [
+    {"id": "call-1", "type": "function", "function": {"name": "shell", "arguments": "{\"command\": \"ls\"}"}}
+  ]},
+  {"role": "tool", "tool_call_id": "call-1", "content": "main.go"},
+  {"role": "assistant", "content": "Just main.go."}
+]}`
+
+func TestParseOpenAIMessages(t *testing.T) {
+	messages, err := ParseOpenAIMessages([]byte(openAITranscript))
+	if err != nil {
+		t.Fatalf("ParseOpenAIMessages: %v", err)
+	}
+	if len(messages) != 5 {
+		t.Fatalf("got %d messages, want 5", len(messages))
+	}
+	toolCall := messages[2].GetBlocks()[0].GetToolCall()
+	if toolCall.GetName() != "shell" || toolCall.GetArguments().GetFields()["command"].GetStringValue() != "ls" {
+		t.Errorf("tool call = %v, want shell with command ls", toolCall)
+	}
+	if _, err := ForImport(&Bundle{Chat: &aipb.Chat{}, Messages: messages}); err != nil {
+		t.Errorf("ForImport: %v", err)
+	}
+}
+
+func TestParseOpenAIMessagesBareArray(t *testing.T) {
+	messages, err := ParseOpenAIMessages([]byte(`[{"role": "user", "content": "hi"}]`))
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ParseOpenAIMessages = %d messages, %v; want 1", len(messages), err)
+	}
+}
+
+func TestForImportRejectsOrphanedToolCalls(t *testing.T) {
+	transcript := strings.Replace(openAITranscript, `{"role": "tool", "tool_call_id": "call-1", "content": "main.go"},`, "", 1)
+	messages, err := ParseOpenAIMessages([]byte(transcript))
+	if err != nil {
+		t.Fatalf("ParseOpenAIMessages: %v", err)
+	}
+	_, err = ForImport(&Bundle{Chat: &aipb.Chat{}, Messages: messages})
+	if err == nil || !strings.Contains(err.Error(), "call-1") {
+		t.Fatalf("ForImport = %v, want an error naming call-1", err)
+	}
+}
+
+func TestForImportDropsToolCallPairs(t *testing.T) {
+	messages, err := ParseOpenAIMessages([]byte(openAITranscript))
+	if err != nil {
+		t.Fatalf("ParseOpenAIMessages: %v", err)
+	}
+	// Deleting the assistant's tool call leaves its result answering nothing.
+	messages[2].DeleteTime = timestamppb.Now()
+	bundle, err := ForImport(&Bundle{Chat: &aipb.Chat{}, Messages: messages})
+	if err != nil {
+		t.Fatalf("ForImport: %v", err)
+	}
+	if len(bundle.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3: the call and its result both dropped", len(bundle.Messages))
+	}
+	for _, message := range bundle.Messages {
+		for _, block := range message.GetBlocks() {
+			if block.GetToolCall() != nil || block.GetToolResult() != nil {
+				t.Errorf("message %v kept half of a dropped tool call", message)
+			}
+		}
+	}
+}
+
+func TestForImportDropsIDlessToolCalls(t *testing.T) {
+	transcript := strings.ReplaceAll(openAITranscript, `"call-1"`, `""`)
+	messages, err := ParseOpenAIMessages([]byte(transcript))
+	if err != nil {
+		t.Fatalf("ParseOpenAIMessages: %v", err)
+	}
+	messages[2].DeleteTime = timestamppb.Now()
+	messages[3].DeleteTime = timestamppb.Now()
+	bundle, err := ForImport(&Bundle{Chat: &aipb.Chat{}, Messages: messages})
+	if err != nil {
+		t.Fatalf("ForImport: %v", err)
+	}
+	// The dropped call's empty ID matches none of the text blocks.
+	if len(bundle.Messages) != 3 {
+		t.Fatalf("got %d messages, want the 3 text messages", len(bundle.Messages))
+	}
+	for _, message := range bundle.Messages {
+		if len(message.GetBlocks()) == 0 {
+			t.Errorf("message %v lost its blocks", message)
+		}
+	}
+}
+
+func TestForImportStripsBundle(t *testing.T) {
+	chat := &aipb.Chat{Name: "organizations/o/users/u/chats/c", Title: "Files", Price: 1.5}
+	aip.SetLabel(chat, sgptpb.Labels.ParentChat.GetKey(), "parent")
+	data, err := MarshalBundle(chat, testHistory())
+	if err != nil {
+		t.Fatalf("MarshalBundle: %v", err)
+	}
+	if !IsBundle(data) || IsBundle([]byte(openAITranscript)) {
+		t.Fatal("IsBundle misclassified the inputs")
+	}
+	bundle, err := UnmarshalBundle(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBundle: %v", err)
+	}
+	bundle, err = ForImport(bundle)
+	if err != nil {
+		t.Fatalf("ForImport: %v", err)
+	}
+	if bundle.Chat.GetName() != "" || bundle.Chat.GetPrice() != 0 || bundle.Chat.GetTitle() != "Files" {
+		t.Errorf("chat = %v, want only the title and metadata kept", bundle.Chat)
+	}
+	if value, _ := aip.GetLabel(bundle.Chat, sgptpb.Labels.ParentChat.GetKey()); value != "" {
+		t.Errorf("parent chat label = %q, want it dropped", value)
+	}
+}
@@ -0,0 +1,114 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MediaTypePDF is the media type of PDF attachments.
+const MediaTypePDF = "application/pdf"
+
+// attachmentMediaTypeSet lists the media types sent to the model as media
+// blocks rather than text: the images and documents providers accept.
+var attachmentMediaTypeSet = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+	MediaTypePDF: true,
+}
+
+// MediaType detects the file's media type from its content, returning it
+// only for attachments (images, PDFs); "" means the file injects as text.
+// Extensions are not trusted: a PNG named notes.txt is still an image.
+func (f *File) MediaType() string {
+	return AttachmentMediaType(f.Content)
+}
+
+// AttachmentMediaType detects the media type of attachment content; ""
+// when the content is not an attachment.
+func AttachmentMediaType(content []byte) string {
+	mediaType, _, _ := strings.Cut(http.DetectContentType(content), ";")
+	if !attachmentMediaTypeSet[mediaType] {
+		return ""
+	}
+	return mediaType
+}
+
+// IsImage reports whether an attachment media type is an image.
+func IsImage(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "image/")
+}
+
+// ImageSize decodes the dimensions of a PNG, JPEG or GIF image without
+// decoding its pixels.
+func ImageSize(content []byte) (int, int, bool) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return 0, 0, false
+	}
+	return config.Width, config.Height, true
+}
+
+// DescribeAttachment summarizes an attachment for display, e.g.
+// "PNG image, 800×600" or "PDF, 1.2 MB".
+func DescribeAttachment(mediaType string, content []byte) string {
+	kind := "PDF"
+	if IsImage(mediaType) {
+		kind = strings.ToUpper(strings.TrimPrefix(mediaType, "image/")) + " image"
+		if width, height, ok := ImageSize(content); ok {
+			return fmt.Sprintf("%s, %d×%d", kind, width, height)
+		}
+	}
+	return fmt.Sprintf("%s, %s", kind, formatSize(len(content)))
+}
+
+func formatSize(size int) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// SaveAttachment writes attachment content (e.g. an image pasted from the
+// clipboard) under the user cache directory and returns its path, so it
+// injects like any file and survives resuming the chat. Files are named by
+// content: pasting the same image twice yields the same path.
+func SaveAttachment(content []byte) (string, error) {
+	mediaType := AttachmentMediaType(content)
+	if mediaType == "" {
+		return "", fmt.Errorf("content is not an image or a PDF")
+	}
+	cacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating cache directory: %w", err)
+	}
+	directory := filepath.Join(cacheDirectory, "sgpt", "attachments")
+	if err := CreateDirectoryIfNotExist(directory); err != nil {
+		return "", err
+	}
+	extension := "pdf"
+	if IsImage(mediaType) {
+		extension = strings.TrimPrefix(mediaType, "image/")
+	}
+	digest := sha256.Sum256(content)
+	path := filepath.Join(directory, hex.EncodeToString(digest[:8])+"."+extension)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return "", fmt.Errorf("writing attachment: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,66 @@
+package file
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestAttachmentMediaType(t *testing.T) {
+	for name, test := range map[string]struct {
+		content []byte
+		want    string
+	}{
+		"png":  {content: newPNG(t, 2, 2), want: "image/png"},
+		"pdf":  {content: []byte("%PDF-1.7\n..."), want: MediaTypePDF},
+		"text": {content: []byte("package main\n"), want: ""},
+		"zip":  {content: []byte("PK\x03\x04"), want: ""},
+	} {
+		if got := AttachmentMediaType(test.content); got != test.want {
+			t.Errorf("%s: AttachmentMediaType = %q, want %q", name, got, test.want)
+		}
+	}
+}
+
+func TestDescribeAttachment(t *testing.T) {
+	if got, want := DescribeAttachment("image/png", newPNG(t, 800, 600)), "PNG image, 800×600"; got != want {
+		t.Errorf("DescribeAttachment(png) = %q, want %q", got, want)
+	}
+	if got, want := DescribeAttachment(MediaTypePDF, make([]byte, 3<<10)), "PDF, 3.0 KB"; got != want {
+		t.Errorf("DescribeAttachment(pdf) = %q, want %q", got, want)
+	}
+}
+
+func TestSaveAttachment(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	content := newPNG(t, 4, 4)
+	path, err := SaveAttachment(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(path) != ".png" {
+		t.Errorf("path = %s, want a .png file", path)
+	}
+	if saved, err := os.ReadFile(path); err != nil || !bytes.Equal(saved, content) {
+		t.Errorf("saved content differs (%v)", err)
+	}
+	if again, err := SaveAttachment(content); err != nil || again != path {
+		t.Errorf("second save = %s (%v), want the same path %s", again, err, path)
+	}
+	if _, err := SaveAttachment([]byte("not an image")); err == nil {
+		t.Error("SaveAttachment accepted text")
+	}
+}
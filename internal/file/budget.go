@@ -0,0 +1,301 @@
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// bytesPerToken is the usual rule of thumb for BPE tokenizers on code and
+	// English prose. Estimates only: tokenizers differ across providers.
+	bytesPerToken = 4
+	// pixelsPerImageToken and maxImageTokens follow the providers' image
+	// pricing: about a token per 750 pixels, images being downscaled past
+	// roughly 1.15 megapixels.
+	pixelsPerImageToken = 750
+	maxImageTokens      = 1600
+	// pdfPageTokens approximates a PDF page: its text plus the page image.
+	pdfPageTokens = 1500
+	// generatedMarkerWindow is how far into a file generated-code markers
+	// are looked for.
+	generatedMarkerWindow = 1024
+	// budgetReportFiles caps the files a budget report lists.
+	budgetReportFiles = 10
+)
+
+// ContextBudgetRatio is the share of the context window injected files may
+// fill; the rest is left to the conversation.
+const ContextBudgetRatio = 0.5
+
+// Fit strategies bring injected files within a token budget; see Fit.
+const (
+	// FitGenerated drops generated files (protobuf stubs, lockfiles,
+	// minified bundles): large, and rarely what a question is about.
+	FitGenerated = "generated"
+	// FitOutline injects the largest files as outlines: their declarations,
+	// without bodies.
+	FitOutline = "outline"
+	// FitRecent keeps the most recently modified files, dropping the oldest.
+	FitRecent = "recent"
+)
+
+// FitStrategies lists the fit strategies, in their suggested order.
+var FitStrategies = []string{FitGenerated, FitOutline, FitRecent}
+
+// attachmentExtensionSet lists the extensions whose estimate needs the
+// content: an image's cost depends on its dimensions, not its size.
+var attachmentExtensionSet = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".pdf": true,
+}
+
+// generatedFileNameSet and generatedFileSuffixes recognize generated files
+// by name, for formats that cannot carry a marker.
+var (
+	generatedFileNameSet = map[string]bool{
+		"go.sum": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+		"Cargo.lock": true, "poetry.lock": true, "Gemfile.lock": true,
+	}
+	generatedFileSuffixes = []string{".pb.go", "_pb2.py", ".pb.ts", ".min.js", ".min.css", ".map"}
+)
+
+// EstimateTokens estimates what content costs in the model context.
+func EstimateTokens(content []byte) int {
+	switch mediaType := AttachmentMediaType(content); {
+	case mediaType == MediaTypePDF:
+		pages := bytes.Count(content, []byte("/Type /Page")) - bytes.Count(content, []byte("/Type /Pages"))
+		if pages > 0 {
+			return pages * pdfPageTokens
+		}
+	case IsImage(mediaType):
+		if width, height, ok := ImageSize(content); ok {
+			return max(min(width*height/pixelsPerImageToken, maxImageTokens), 1)
+		}
+		return maxImageTokens
+	}
+	return (len(content) + bytesPerToken - 1) / bytesPerToken
+}
+
+// EstimateFileTokens estimates a file's cost without reading it, from its
+// size; only images and PDFs are read.
+func EstimateFileTokens(path string) (int, error) {
+	expandedPath, err := ExpandPath(path)
+	if err != nil {
+		return 0, fmt.Errorf("expanding path: %w", err)
+	}
+	if attachmentExtensionSet[strings.ToLower(filepath.Ext(expandedPath))] {
+		content, err := os.ReadFile(expandedPath)
+		if err != nil {
+			return 0, fmt.Errorf("reading file: %w", err)
+		}
+		return EstimateTokens(content), nil
+	}
+	info, err := os.Stat(expandedPath)
+	if err != nil {
+		return 0, fmt.Errorf("stating file: %w", err)
+	}
+	return int((info.Size() + bytesPerToken - 1) / bytesPerToken), nil
+}
+
+// FormatTokens renders a token count compactly: 950, 12.3k, 1.2m.
+func FormatTokens(count int) string {
+	switch {
+	case count < 1000:
+		return fmt.Sprintf("%d", count)
+	case count < 1000000:
+		return fmt.Sprintf("%.1fk", float64(count)/1000)
+	default:
+		return fmt.Sprintf("%.1fm", float64(count)/1000000)
+	}
+}
+
+// TotalTokens sums the estimates of files.
+func TotalTokens(files []*File) int {
+	var total int
+	for _, file := range files {
+		total += file.Tokens
+	}
+	return total
+}
+
+// IsGenerated reports whether a file is generated: by the conventional
+// "Code generated ... DO NOT EDIT." or "@generated" marker near its top, or
+// by a name that only tools write.
+func IsGenerated(path string, content []byte) bool {
+	name := filepath.Base(path)
+	if generatedFileNameSet[name] {
+		return true
+	}
+	for _, suffix := range generatedFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	head := content[:min(len(content), generatedMarkerWindow)]
+	if bytes.Contains(head, []byte("@generated")) {
+		return true
+	}
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if bytes.HasPrefix(line, []byte("// Code generated ")) && bytes.HasSuffix(line, []byte("DO NOT EDIT.")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Outline condenses a text file to its skeleton: Go declarations without
+// function bodies, markdown headings, or for anything else the unindented
+// lines, which in most languages are the top-level declarations. Reports
+// false when there is nothing to outline.
+func Outline(path string, content []byte) ([]byte, bool) {
+	if AttachmentMediaType(content) != "" {
+		return nil, false
+	}
+	var outline []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".go":
+		outline = outlineGo(path, content)
+	case ".md", ".markdown":
+		outline = outlineLines(content, func(line []byte) bool { return bytes.HasPrefix(line, []byte("#")) })
+	default:
+		outline = outlineLines(content, isTopLevelLine)
+	}
+	if len(outline) == 0 || len(outline) >= len(content) {
+		return nil, false
+	}
+	return outline, true
+}
+
+// outlineGo prints a Go file's declarations without function bodies, or
+// falls back to the generic outline when the file does not parse.
+func outlineGo(path string, content []byte) []byte {
+	fileSet := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fileSet, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return outlineLines(content, isTopLevelLine)
+	}
+	for _, declaration := range parsedFile.Decls {
+		if function, ok := declaration.(*ast.FuncDecl); ok {
+			function.Body = nil
+		}
+	}
+	var b bytes.Buffer
+	if err := format.Node(&b, fileSet, parsedFile); err != nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// isTopLevelLine reports whether a line is unindented, and more than the
+// closing delimiters of a block.
+func isTopLevelLine(line []byte) bool {
+	if len(line) == 0 || line[0] == ' ' || line[0] == '\t' {
+		return false
+	}
+	return len(bytes.Trim(bytes.TrimSpace(line), "})];,")) > 0
+}
+
+// outlineLines keeps the lines matching keep.
+func outlineLines(content []byte, keep func(line []byte) bool) []byte {
+	var b bytes.Buffer
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if keep(line) {
+			b.Write(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// Fit applies strategies, in order, until files fit within budget tokens;
+// a strategy is skipped once they do. It returns the files kept, outlined
+// ones carrying their outline as content, and the files dropped. The
+// result may still overflow the budget: the caller decides what that means.
+func Fit(files []*File, budget int, strategies []string) (kept, dropped []*File, err error) {
+	for _, strategy := range strategies {
+		if !slices.Contains(FitStrategies, strategy) {
+			return nil, nil, fmt.Errorf("unknown fit strategy %q (want one of %s)", strategy, strings.Join(FitStrategies, ", "))
+		}
+	}
+	kept = slices.Clone(files)
+	for _, strategy := range strategies {
+		if TotalTokens(kept) <= budget {
+			break
+		}
+		switch strategy {
+		case FitGenerated:
+			kept = slices.DeleteFunc(kept, func(file *File) bool {
+				if IsGenerated(file.Path, file.Content) {
+					dropped = append(dropped, file)
+					return true
+				}
+				return false
+			})
+		case FitOutline:
+			// Largest first: they free the most per outline.
+			bySize := slices.Clone(kept)
+			slices.SortStableFunc(bySize, func(a, b *File) int { return b.Tokens - a.Tokens })
+			total := TotalTokens(kept)
+			for _, file := range bySize {
+				if total <= budget {
+					break
+				}
+				if file.Outlined {
+					continue
+				}
+				outline, ok := Outline(file.Path, file.Content)
+				if !ok {
+					continue
+				}
+				outlined := &File{Path: file.Path, Content: outline, Tokens: EstimateTokens(outline), ModTime: file.ModTime, Outlined: true}
+				total -= file.Tokens - outlined.Tokens
+				kept[slices.Index(kept, file)] = outlined
+			}
+		case FitRecent:
+			byRecency := slices.Clone(kept)
+			slices.SortStableFunc(byRecency, func(a, b *File) int { return b.ModTime.Compare(a.ModTime) })
+			keptSet := map[*File]bool{}
+			var total int
+			for _, file := range byRecency {
+				if total+file.Tokens > budget {
+					dropped = append(dropped, file)
+					continue
+				}
+				total += file.Tokens
+				keptSet[file] = true
+			}
+			kept = slices.DeleteFunc(kept, func(file *File) bool { return !keptSet[file] })
+		}
+	}
+	return kept, dropped, nil
+}
+
+// BudgetReport summarizes what files cost against a budget and the context
+// limit: the total, then the most expensive files.
+func BudgetReport(files []*File, budget, limit int) string {
+	var b strings.Builder
+	total := TotalTokens(files)
+	fmt.Fprintf(&b, "injected files: ~%s tokens for %d files (budget %s, context window %s)\n",
+		FormatTokens(total), len(files), FormatTokens(budget), FormatTokens(limit))
+	byCost := slices.Clone(files)
+	slices.SortStableFunc(byCost, func(a, b *File) int { return b.Tokens - a.Tokens })
+	for _, file := range byCost[:min(len(byCost), budgetReportFiles)] {
+		note := ""
+		if IsGenerated(file.Path, file.Content) {
+			note = " (generated)"
+		}
+		fmt.Fprintf(&b, "  %8s  %s%s\n", "~"+FormatTokens(file.Tokens), file.Path, note)
+	}
+	if len(byCost) > budgetReportFiles {
+		fmt.Fprintf(&b, "  … and %d more\n", len(byCost)-budgetReportFiles)
+	}
+	return b.String()
+}
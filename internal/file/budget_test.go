@@ -0,0 +1,131 @@
+package file
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTextFile(path, content string, modTime time.Time) *File {
+	return &File{Path: path, Content: []byte(content), Tokens: EstimateTokens([]byte(content)), ModTime: modTime}
+}
+
+func paths(files []*File) []string {
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens([]byte("12345678")); got != 2 {
+		t.Errorf("EstimateTokens(8 bytes) = %d, want 2", got)
+	}
+	if got := EstimateTokens(newPNG(t, 1500, 1500)); got != maxImageTokens {
+		t.Errorf("EstimateTokens(large png) = %d, want the %d cap", got, maxImageTokens)
+	}
+	if got := EstimateTokens(newPNG(t, 75, 100)); got != 10 {
+		t.Errorf("EstimateTokens(75×100 png) = %d, want 10", got)
+	}
+	pdf := []byte("%PDF-1.7\n<< /Type /Pages >>\n<< /Type /Page >>\n<< /Type /Page >>\n")
+	if got := EstimateTokens(pdf); got != 2*pdfPageTokens {
+		t.Errorf("EstimateTokens(2-page pdf) = %d, want %d", got, 2*pdfPageTokens)
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	for name, test := range map[string]struct {
+		path    string
+		content string
+		want    bool
+	}{
+		"go marker":    {path: "api.go", content: "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n", want: true},
+		"lockfile":     {path: "/repo/go.sum", want: true},
+		"stub suffix":  {path: "/repo/api.pb.go", content: "package api\n", want: true},
+		"hand written": {path: "/repo/main.go", content: "package main\n", want: false},
+	} {
+		if got := IsGenerated(test.path, []byte(test.content)); got != test.want {
+			t.Errorf("%s: IsGenerated = %t, want %t", name, got, test.want)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	content := "package main\n\n// Run runs.\nfunc Run(n int) error {\n\tfor i := 0; i < n; i++ {\n\t\tprintln(i)\n\t}\n\treturn nil\n}\n"
+	outline, ok := Outline("main.go", []byte(content))
+	if !ok {
+		t.Fatal("Outline(go) = false")
+	}
+	if !strings.Contains(string(outline), "func Run(n int) error") || strings.Contains(string(outline), "println") {
+		t.Errorf("Outline(go) = %q, want the signature without the body", outline)
+	}
+
+	outline, ok = Outline("notes.md", []byte("# Title\nprose\n## Section\nmore prose\n"))
+	if !ok || string(outline) != "# Title\n## Section\n" {
+		t.Errorf("Outline(md) = %q (%t)", outline, ok)
+	}
+	if _, ok := Outline("shot.png", newPNG(t, 2, 2)); ok {
+		t.Error("Outline outlined an image")
+	}
+}
+
+func TestFit(t *testing.T) {
+	now := time.Now()
+	files := []*File{
+		newTextFile("old.txt", strings.Repeat("a", 400), now.Add(-time.Hour)),
+		newTextFile("api.pb.go", strings.Repeat("b", 400), now),
+		newTextFile("new.txt", strings.Repeat("c", 400), now),
+	}
+
+	kept, dropped, err := Fit(files, 250, []string{FitGenerated, FitRecent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(paths(kept), ","); got != "old.txt,new.txt" {
+		t.Errorf("kept = %s, want the files left once generated ones are dropped", got)
+	}
+	if got := strings.Join(paths(dropped), ","); got != "api.pb.go" {
+		t.Errorf("dropped = %s", got)
+	}
+
+	kept, dropped, err = Fit(files, 150, []string{FitGenerated, FitRecent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(paths(kept), ","); got != "new.txt" {
+		t.Errorf("kept = %s, want the most recent file", got)
+	}
+	if len(dropped) != 2 {
+		t.Errorf("dropped = %v", paths(dropped))
+	}
+
+	// Under budget, nothing changes.
+	if kept, dropped, _ := Fit(files, 1000, FitStrategies); len(kept) != 3 || len(dropped) != 0 {
+		t.Errorf("Fit under budget kept %v, dropped %v", paths(kept), paths(dropped))
+	}
+	if _, _, err := Fit(files, 1000, []string{"smallest"}); err == nil {
+		t.Error("Fit accepted an unknown strategy")
+	}
+}
+
+func TestFitOutline(t *testing.T) {
+	var body strings.Builder
+	body.WriteString("package main\n\nfunc Run() {\n")
+	for range 100 {
+		body.WriteString("\tprintln(\"a line of the body\")\n")
+	}
+	body.WriteString("}\n")
+	files := []*File{newTextFile("main.go", body.String(), time.Now())}
+
+	kept, dropped, err := Fit(files, 100, []string{FitOutline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 || !kept[0].Outlined || len(dropped) != 0 {
+		t.Fatalf("Fit kept %v, dropped %v, want main.go outlined", paths(kept), paths(dropped))
+	}
+	if kept[0].Tokens >= files[0].Tokens || files[0].Outlined {
+		t.Errorf("outlined tokens %d, original %d (original must be untouched)", kept[0].Tokens, files[0].Tokens)
+	}
+}
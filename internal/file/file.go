@@ -7,27 +7,46 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 // Opts for file injection.
 type InjectionOpts struct {
-	Files          []string
+	Files []string
+	// WatchFiles are injected live: their changes reach the model before
+	// each turn.
+	WatchFiles     []string
 	FileExtensions []string
+	// FitStrategies bring the files within the context budget when they
+	// overflow it (see Fit).
+	FitStrategies []string
+	// GitSources are git selectors (see package gitcontext), injected like
+	// files under a virtual path.
+	GitSources []string
 }
 
 // File represents a parsed file.
 type File struct {
 	Path    string
 	Content []byte
+	// Tokens estimates what Content costs in the model context.
+	Tokens  int
+	ModTime time.Time
+	// Outlined is set when Content is the file's outline (see Fit).
+	Outlined bool
 }
 
 // GetOpts on the given command.
 func GetOpts(cmd *cobra.Command) *InjectionOpts {
 	opts := &InjectionOpts{}
-	cmd.Flags().StringSliceVarP(&opts.Files, "file", "f", nil, "specify file content to inject into the context")
+	cmd.Flags().StringSliceVarP(&opts.Files, "file", "f", nil, "specify file content to inject into the context (images and PDFs are attached as media)")
+	cmd.Flags().StringSliceVar(&opts.WatchFiles, "watch-file", nil, "specify file content to inject live into the context: its changes reach the model before each turn")
 	cmd.Flags().StringSliceVar(&opts.FileExtensions, "ext", nil, "specify file extensions to accept")
+	cmd.Flags().StringSliceVar(&opts.GitSources, "git", nil, "specify git context to inject: diff, diff:<rev>, staged, log:[<n>:]<path>, blame:<path>:<start>-<end>")
+	cmd.Flags().StringSliceVar(&opts.FitStrategies, "fit", nil, "when injected files overflow the context budget, apply these strategies in order: "+
+		"generated (drop generated files), outline (inject the largest files as outlines), recent (keep the most recently modified files)")
 	return opts
 }
 
@@ -106,7 +125,11 @@ func Parse(opts *InjectionOpts) ([]*File, error) {
 		if err != nil {
 			return fmt.Errorf("reading file: %w", err)
 		}
-		file := &File{Path: filepath, Content: bytes}
+		info, err := os.Stat(filepath)
+		if err != nil {
+			return fmt.Errorf("stating file: %w", err)
+		}
+		file := &File{Path: filepath, Content: bytes, Tokens: EstimateTokens(bytes), ModTime: info.ModTime()}
 		files = append(files, file)
 		return nil
 	}
@@ -0,0 +1,183 @@
+// Package gitcontext turns git state into context sources: the working-tree
+// or staged diff, the diff against a revision, a path's recent commits, the
+// blame for a line range. Each is addressed by a virtual path (a selector),
+// so it injects, toggles and refreshes like a file.
+package gitcontext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Prefix marks an injected path as a git selector.
+const Prefix = "git:"
+
+// defaultLogCommits is the commits a log selector without a count shows.
+const defaultLogCommits = 10
+
+// Kinds of git sources.
+const (
+	// KindDiff is the working-tree diff, or the diff against Rev.
+	KindDiff = "diff"
+	// KindStaged is the diff of the staged changes.
+	KindStaged = "staged"
+	// KindLog is the last Commits commits touching Path.
+	KindLog = "log"
+	// KindBlame is the blame of Path's lines StartLine to EndLine.
+	KindBlame = "blame"
+)
+
+// Source is a parsed git selector.
+type Source struct {
+	Kind      string
+	Rev       string
+	Path      string
+	Commits   int
+	StartLine int
+	EndLine   int
+}
+
+// IsSelector reports whether an injected path is a git selector.
+func IsSelector(path string) bool {
+	return strings.HasPrefix(path, Prefix)
+}
+
+// Parse parses a selector, with or without its "git:" prefix:
+//
+//	diff                     working-tree changes
+//	diff:<rev>               changes since <rev>
+//	staged                   staged changes
+//	log:<path>               the last 10 commits touching <path>
+//	log:<n>                  the last <n> commits
+//	log:<n>:<path>           the last <n> commits touching <path>
+//	blame:<path>:<start>-<end>
+func Parse(selector string) (*Source, error) {
+	kind, rest, _ := strings.Cut(strings.TrimPrefix(selector, Prefix), ":")
+	source := &Source{Kind: kind}
+	switch kind {
+	case KindDiff:
+		// A revision is never an option: "--output=..." would write files.
+		if strings.HasPrefix(rest, "-") {
+			return nil, fmt.Errorf("git selector %q: invalid revision %q", selector, rest)
+		}
+		source.Rev = rest
+	case KindStaged:
+		if rest != "" {
+			return nil, fmt.Errorf("git selector %q: staged takes no argument", selector)
+		}
+	case KindLog:
+		source.Commits, source.Path = defaultLogCommits, rest
+		if count, path, ok := strings.Cut(rest, ":"); ok {
+			commits, err := strconv.Atoi(count)
+			if err != nil || commits <= 0 {
+				return nil, fmt.Errorf("git selector %q: invalid commit count %q", selector, count)
+			}
+			source.Commits, source.Path = commits, path
+		} else if commits, err := strconv.Atoi(rest); err == nil && commits > 0 {
+			source.Commits, source.Path = commits, ""
+		}
+		if source.Path == "" {
+			source.Path = "."
+		}
+	case KindBlame:
+		// Paths may hold colons; the range never does.
+		index := strings.LastIndex(rest, ":")
+		if index <= 0 {
+			return nil, fmt.Errorf("git selector %q: want blame:<path>:<start>-<end>", selector)
+		}
+		source.Path = rest[:index]
+		start, end, _ := strings.Cut(rest[index+1:], "-")
+		startLine, startErr := strconv.Atoi(start)
+		endLine, endErr := strconv.Atoi(end)
+		if startErr != nil || endErr != nil || startLine <= 0 || endLine < startLine {
+			return nil, fmt.Errorf("git selector %q: invalid line range %q", selector, rest[index+1:])
+		}
+		source.StartLine, source.EndLine = startLine, endLine
+	default:
+		return nil, fmt.Errorf("git selector %q: unknown kind %q (want %s, %s, %s or %s)", selector, kind, KindDiff, KindStaged, KindLog, KindBlame)
+	}
+	return source, nil
+}
+
+// String returns the source's canonical selector: its virtual path.
+func (s *Source) String() string {
+	switch s.Kind {
+	case KindDiff:
+		if s.Rev != "" {
+			return Prefix + KindDiff + ":" + s.Rev
+		}
+	case KindLog:
+		return fmt.Sprintf("%s%s:%d:%s", Prefix, KindLog, s.Commits, s.Path)
+	case KindBlame:
+		return fmt.Sprintf("%s%s:%s:%d-%d", Prefix, KindBlame, s.Path, s.StartLine, s.EndLine)
+	}
+	return Prefix + s.Kind
+}
+
+// Args returns the git command line producing the source.
+func (s *Source) Args() []string {
+	switch s.Kind {
+	case KindStaged:
+		return []string{"diff", "--staged"}
+	case KindLog:
+		return []string{"log", "-n", strconv.Itoa(s.Commits), "--stat", "--date=short", "--", s.Path}
+	case KindBlame:
+		return []string{"blame", "--date=short", "-L", fmt.Sprintf("%d,%d", s.StartLine, s.EndLine), "--", s.Path}
+	}
+	if s.Rev != "" {
+		return []string{"diff", s.Rev}
+	}
+	return []string{"diff"}
+}
+
+// Describe says what the source holds, e.g. "staged changes".
+func (s *Source) Describe() string {
+	switch s.Kind {
+	case KindStaged:
+		return "staged changes"
+	case KindLog:
+		return fmt.Sprintf("last %d commits touching %s", s.Commits, s.Path)
+	case KindBlame:
+		return fmt.Sprintf("blame of %s, lines %d-%d", s.Path, s.StartLine, s.EndLine)
+	}
+	if s.Rev != "" {
+		return "changes since " + s.Rev
+	}
+	return "working-tree changes"
+}
+
+// Load runs git in the working directory and returns the source's content.
+func (s *Source) Load(ctx context.Context) (string, error) {
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, "git", s.Args()...)
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(s.Args(), " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// Format renders loaded content as the context message text: labeled, and
+// fenced as a diff where it is one.
+func (s *Source) Format(content string) string {
+	if strings.TrimSpace(content) == "" {
+		return fmt.Sprintf("%s (%s): none", s.String(), s.Describe())
+	}
+	language := ""
+	if s.Kind == KindDiff || s.Kind == KindStaged {
+		language = "diff"
+	}
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return fmt.Sprintf("%s (%s):\n```%s\n%s```", s.String(), s.Describe(), language, content)
+}
+
+// Suggestions are the selectors offered for injection alongside files.
+func Suggestions() []string {
+	return []string{Prefix + KindDiff, Prefix + KindStaged, fmt.Sprintf("%s%s:%d:.", Prefix, KindLog, defaultLogCommits)}
+}
@@ -0,0 +1,99 @@
+package gitcontext
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for selector, want := range map[string]string{
+		"git:diff":                        "git:diff",
+		"diff:HEAD~3":                     "git:diff:HEAD~3",
+		"git:staged":                      "git:staged",
+		"git:log:internal/session":        "git:log:10:internal/session",
+		"git:log:5":                       "git:log:5:.",
+		"git:log:3:cli/chat":              "git:log:3:cli/chat",
+		"git:blame:cli/chat/cmd.go:10-20": "git:blame:cli/chat/cmd.go:10-20",
+	} {
+		source, err := Parse(selector)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", selector, err)
+			continue
+		}
+		if got := source.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", selector, got, want)
+		}
+	}
+	for _, selector := range []string{"git:status", "git:diff:--output=x", "git:staged:HEAD", "git:log:0:.", "git:blame:main.go", "git:blame:main.go:20-10"} {
+		if _, err := Parse(selector); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", selector)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	for selector, want := range map[string]string{
+		"git:diff":              "diff",
+		"git:diff:main":         "diff main",
+		"git:staged":            "diff --staged",
+		"git:log:2:go.mod":      "log -n 2 --stat --date=short -- go.mod",
+		"git:blame:main.go:3-4": "blame --date=short -L 3,4 -- main.go",
+	} {
+		source, err := Parse(selector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(source.Args(), " "); got != want {
+			t.Errorf("Parse(%q).Args() = %q, want %q", selector, got, want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	source := &Source{Kind: KindStaged}
+	if got, want := source.Format(""), "git:staged (staged changes): none"; got != want {
+		t.Errorf("Format(empty) = %q, want %q", got, want)
+	}
+	if got, want := source.Format("-a\n+b"), "git:staged (staged changes):\n```diff\n-a\n+b\n```"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	directory := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		command := exec.Command("git", args...)
+		command.Dir = directory
+		command.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@b", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@b")
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Skipf("git %v: %v: %s", args, err, output)
+		}
+	}
+	run("init", "-q")
+	if err := os.WriteFile(filepath.Join(directory, "notes.txt"), []byte("one\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "notes.txt")
+	run("commit", "-q", "-m", "add notes")
+	if err := os.WriteFile(filepath.Join(directory, "notes.txt"), []byte("two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(directory)
+
+	diff, err := (&Source{Kind: KindDiff}).Load(context.Background())
+	if err != nil || !strings.Contains(diff, "-one") || !strings.Contains(diff, "+two") {
+		t.Errorf("Load(diff) = %q (%v)", diff, err)
+	}
+	log, err := (&Source{Kind: KindLog, Commits: 1, Path: "."}).Load(context.Background())
+	if err != nil || !strings.Contains(log, "add notes") {
+		t.Errorf("Load(log) = %q (%v)", log, err)
+	}
+	if _, err := (&Source{Kind: KindDiff, Rev: "no-such-rev"}).Load(context.Background()); err == nil {
+		t.Error("Load(diff against a missing revision) succeeded")
+	}
+}
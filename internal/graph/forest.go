@@ -146,6 +146,140 @@ func toolSetsOf(trees []*Tree) []*sgptpb.ToolSet {
 	return toolSets
 }
 
+// McpServers returns every MCP server across the forest, qualified.
+func (f *Forest) McpServers() []*sgptpb.McpServer {
+	return mcpServersOf(f.trees())
+}
+
+// PrimaryMcpServers returns the enclosing repo's MCP servers only.
+func (f *Forest) PrimaryMcpServers() []*sgptpb.McpServer {
+	if f == nil {
+		return nil
+	}
+	return mcpServersOf([]*Tree{f.Primary})
+}
+
+func mcpServersOf(trees []*Tree) []*sgptpb.McpServer {
+	var mcpServers []*sgptpb.McpServer
+	for _, tree := range trees {
+		for _, mcpServerFile := range tree.McpServerFiles() {
+			mcpServers = append(mcpServers, qualifyMcpServer(tree, mcpServerFile))
+		}
+	}
+	return mcpServers
+}
+
+// Plugins returns every plugin across the forest, qualified.
+func (f *Forest) Plugins() []*sgptpb.Plugin {
+	return pluginsOf(f.trees())
+}
+
+// PrimaryPlugins returns the enclosing repo's plugins only.
+func (f *Forest) PrimaryPlugins() []*sgptpb.Plugin {
+	if f == nil {
+		return nil
+	}
+	return pluginsOf([]*Tree{f.Primary})
+}
+
+func pluginsOf(trees []*Tree) []*sgptpb.Plugin {
+	var plugins []*sgptpb.Plugin
+	for _, tree := range trees {
+		for _, pluginFile := range tree.PluginFiles() {
+			plugins = append(plugins, qualifyPlugin(tree, pluginFile))
+		}
+	}
+	return plugins
+}
+
+// Artifact is a qualified artifact together with where it was read from.
+type Artifact[T proto.Message] struct {
+	Message T
+	// Import is the import the artifact comes from; empty for the primary
+	// repo.
+	Import string
+	// FilePath is the artifact file's absolute path.
+	FilePath string
+}
+
+// RoleArtifacts returns every role across the forest, qualified, with its
+// source file.
+func (f *Forest) RoleArtifacts() []*Artifact[*sgptpb.Role] {
+	var artifacts []*Artifact[*sgptpb.Role]
+	for _, tree := range f.trees() {
+		for _, roleFile := range tree.RoleFiles() {
+			artifacts = append(artifacts, &Artifact[*sgptpb.Role]{
+				Message:  qualifyRole(tree, roleFile),
+				Import:   strings.TrimPrefix(tree.Prefix, repo.Prefix),
+				FilePath: roleFile.FilePath(tree.Root),
+			})
+		}
+	}
+	return artifacts
+}
+
+// ToolSetArtifacts returns every tool set across the forest, qualified, with
+// its source file.
+func (f *Forest) ToolSetArtifacts() []*Artifact[*sgptpb.ToolSet] {
+	var artifacts []*Artifact[*sgptpb.ToolSet]
+	for _, tree := range f.trees() {
+		for _, toolSetFile := range tree.ToolSetFiles() {
+			toolSet := proto.CloneOf(toolSetFile.Message)
+			toolSet.Name = tree.Prefix + toolSetFile.Selector()
+			artifacts = append(artifacts, &Artifact[*sgptpb.ToolSet]{
+				Message:  toolSet,
+				Import:   strings.TrimPrefix(tree.Prefix, repo.Prefix),
+				FilePath: toolSetFile.FilePath(tree.Root),
+			})
+		}
+	}
+	return artifacts
+}
+
+// McpServerArtifacts returns every MCP server across the forest, qualified,
+// with its source file.
+func (f *Forest) McpServerArtifacts() []*Artifact[*sgptpb.McpServer] {
+	var artifacts []*Artifact[*sgptpb.McpServer]
+	for _, tree := range f.trees() {
+		for _, mcpServerFile := range tree.McpServerFiles() {
+			artifacts = append(artifacts, &Artifact[*sgptpb.McpServer]{
+				Message:  qualifyMcpServer(tree, mcpServerFile),
+				Import:   strings.TrimPrefix(tree.Prefix, repo.Prefix),
+				FilePath: mcpServerFile.FilePath(tree.Root),
+			})
+		}
+	}
+	return artifacts
+}
+
+// PluginArtifacts returns every plugin across the forest, qualified, with
+// its source file.
+func (f *Forest) PluginArtifacts() []*Artifact[*sgptpb.Plugin] {
+	var artifacts []*Artifact[*sgptpb.Plugin]
+	for _, tree := range f.trees() {
+		for _, pluginFile := range tree.PluginFiles() {
+			artifacts = append(artifacts, &Artifact[*sgptpb.Plugin]{
+				Message:  qualifyPlugin(tree, pluginFile),
+				Import:   strings.TrimPrefix(tree.Prefix, repo.Prefix),
+				FilePath: pluginFile.FilePath(tree.Root),
+			})
+		}
+	}
+	return artifacts
+}
+
+// ImportErrors loads every import, returning why each one that fails does,
+// by import name. The listing methods skip such imports silently.
+func (f *Forest) ImportErrors() map[string]error {
+	importNameToError := map[string]error{}
+	for _, importName := range f.imports.Names() {
+		if _, err := f.importTree(importName); err != nil {
+			importNameToError[importName] = err
+		}
+	}
+	return importNameToError
+}
+
 // trees returns the primary tree plus every loadable import, sorted.
 // Nil-safe: construction failures leave callers with a nil forest.
 func (f *Forest) trees() []*Tree {
@@ -180,22 +314,65 @@ func qualifyRole(tree *Tree, roleFile *RoleFile) *sgptpb.Role {
 	for i, includedName := range role.GetRoles() {
 		role.Roles[i] = tree.qualifySelector(includedName)
 	}
-	for i, toolName := range role.GetTools() {
-		// Tool entries may be builtins ("diff") or tool set selectors.
-		if strings.HasPrefix(toolName, localPrefix) || strings.HasPrefix(toolName, repo.Prefix) {
-			role.Tools[i] = tree.qualifySelector(toolName)
+	for _, toolNames := range [][]string{role.GetTools(), role.GetExcludedTools()} {
+		for i, toolName := range toolNames {
+			// Tool entries may be builtins ("diff") or tool set selectors.
+			if strings.HasPrefix(toolName, localPrefix) || strings.HasPrefix(toolName, repo.Prefix) {
+				toolNames[i] = tree.qualifySelector(toolName)
+			}
 		}
 	}
-	for i, filePath := range role.GetFiles() {
-		// "~" and absolute paths point outside the repo; only root-relative
-		// paths are anchored to it.
-		if !filepath.IsAbs(filePath) && !strings.HasPrefix(filePath, "~") {
-			role.Files[i] = filepath.Join(tree.Root, filePath)
+	for _, filePaths := range [][]string{role.GetFiles(), role.GetExcludedFiles()} {
+		for i, filePath := range filePaths {
+			// "~" and absolute paths point outside the repo; only
+			// root-relative paths are anchored to it.
+			if !filepath.IsAbs(filePath) && !strings.HasPrefix(filePath, "~") {
+				filePaths[i] = filepath.Join(tree.Root, filePath)
+			}
 		}
 	}
 	return role
 }
 
+// qualifyMcpServer rewrites an MCP server for use outside its home repo:
+// its name gains the import prefix, its tool prefix defaults to the file's
+// title, and a subprocess runs from its own repo, whoever launches it.
+func qualifyMcpServer(tree *Tree, mcpServerFile *McpServerFile) *sgptpb.McpServer {
+	mcpServer := proto.CloneOf(mcpServerFile.Message)
+	mcpServer.Name = tree.Prefix + mcpServerFile.Selector()
+	if mcpServer.ToolPrefix == "" {
+		mcpServer.ToolPrefix = mcpServerFile.Title
+	}
+	if stdio := mcpServer.GetStdio(); stdio != nil {
+		stdio.Dir = filepath.Join(tree.Root, stdio.GetDir())
+		stdio.Command = repoCommand(tree, stdio.GetCommand())
+	}
+	return mcpServer
+}
+
+// qualifyPlugin rewrites a plugin like qualifyMcpServer does a subprocess
+// MCP server.
+func qualifyPlugin(tree *Tree, pluginFile *PluginFile) *sgptpb.Plugin {
+	plugin := proto.CloneOf(pluginFile.Message)
+	plugin.Name = tree.Prefix + pluginFile.Selector()
+	if plugin.ToolPrefix == "" {
+		plugin.ToolPrefix = pluginFile.Title
+	}
+	plugin.Dir = filepath.Join(tree.Root, plugin.GetDir())
+	plugin.Command = repoCommand(tree, plugin.GetCommand())
+	return plugin
+}
+
+// repoCommand resolves a relative command path ("./bin/server"), the repo's
+// own script, against the tree's root; bare names are looked up on the
+// PATH.
+func repoCommand(tree *Tree, command string) string {
+	if strings.Contains(command, "/") && !filepath.IsAbs(command) {
+		return filepath.Join(tree.Root, command)
+	}
+	return command
+}
+
 // qualifySelector prefixes a repo-local selector with the tree's import
 // prefix; already-external selectors and bare root shorthands are prefixed
 // canonically too.
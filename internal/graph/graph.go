@@ -1,6 +1,7 @@
 // Package graph implements discovery of the repository's `.sgpt/` artifacts:
 // a tree rooted by a `.sgpt.json` configuration where any directory can hold
-// roles (`{title}.role.md`) and tool sets (`{title}.toolset`), all addressed
+// roles (`{title}.role.md`), tool sets (`{title}.toolset`), MCP servers
+// (`{title}.mcp`) and plugins (`{title}.plugin`), all addressed
 // please-style ("//dir:title").
 package graph
 
@@ -27,6 +28,10 @@ const (
 	RoleExtension = ".role.md"
 	// ToolSetExtension is the extension of tool set files (JSON).
 	ToolSetExtension = ".toolset"
+	// McpServerExtension is the extension of MCP server files (JSON).
+	McpServerExtension = ".mcp"
+	// PluginExtension is the extension of plugin files (JSON).
+	PluginExtension = ".plugin"
 )
 
 // FindRoot walks up from dir looking for a .sgpt.json, returning the
@@ -60,8 +65,10 @@ type File[T proto.Message] struct {
 
 // Aliases for the artifact kinds.
 type (
-	RoleFile    = File[*sgptpb.Role]
-	ToolSetFile = File[*sgptpb.ToolSet]
+	RoleFile      = File[*sgptpb.Role]
+	ToolSetFile   = File[*sgptpb.ToolSet]
+	McpServerFile = File[*sgptpb.McpServer]
+	PluginFile    = File[*sgptpb.Plugin]
 )
 
 // Selector is the artifact's user-facing identifier, please-style:
@@ -113,6 +113,93 @@ func TestImportedRoleQualification(t *testing.T) {
 	}
 }
 
+func TestMcpServerDiscovery(t *testing.T) {
+	importRoot := t.TempDir()
+	write(t, importRoot, ".sgpt.json", "{}")
+	write(t, importRoot, "tools/.sgpt/search.mcp", `{"stdio": {"command": "./bin/search", "args": ["--stdio"], "dir": "tools"}}`)
+
+	primaryRoot := t.TempDir()
+	write(t, primaryRoot, ".sgpt.json", "{}")
+	write(t, primaryRoot, ".sgpt/github.mcp", `{"http": {"url": "http://localhost:8080/mcp"}, "tool_prefix": "gh"}`)
+	write(t, primaryRoot, ".sgpt/broken.mcp", `{}`)
+	if _, err := Scan(primaryRoot, nil); err == nil || !strings.Contains(err.Error(), "want a stdio command or an http url") {
+		t.Fatalf("Scan with a transportless server: err = %v", err)
+	}
+	if err := os.Remove(filepath.Join(primaryRoot, ".sgpt/broken.mcp")); err != nil {
+		t.Fatal(err)
+	}
+
+	primaryTree, err := Scan(primaryRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoImport := &sgptpb.Import{}
+	repoImport.SetName("tools")
+	repoImport.SetPath(importRoot)
+	forest := NewForest(primaryTree, repo.NewImports([]*sgptpb.Import{repoImport}), nil)
+
+	primary := forest.PrimaryMcpServers()
+	if len(primary) != 1 || primary[0].GetName() != "//github" || primary[0].GetToolPrefix() != "gh" {
+		t.Fatalf("primary MCP servers = %v", primary)
+	}
+	mcpServers := forest.McpServers()
+	if len(mcpServers) != 2 {
+		t.Fatalf("MCP servers = %d, want 2", len(mcpServers))
+	}
+	imported := mcpServers[1]
+	assertEqual(t,
+		[]string{imported.GetName(), imported.GetToolPrefix(), imported.GetStdio().GetCommand(), imported.GetStdio().GetDir()},
+		[]string{"@tools//tools:search", "search", filepath.Join(importRoot, "bin/search"), filepath.Join(importRoot, "tools")})
+	if _, err := primaryTree.ResolveMcpServer("//github"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPluginDiscovery(t *testing.T) {
+	importRoot := t.TempDir()
+	write(t, importRoot, ".sgpt.json", "{}")
+	write(t, importRoot, "ci/.sgpt/lint.plugin", `{"command": "./ci/lint.py", "env": {"STRICT": "1"}}`)
+
+	primaryRoot := t.TempDir()
+	write(t, primaryRoot, ".sgpt.json", "{}")
+	write(t, primaryRoot, ".sgpt/deploy.plugin", `{"command": "python3", "args": ["deploy.py"], "dir": "ops", "tool_prefix": "ops"}`)
+	write(t, primaryRoot, ".sgpt/broken.plugin", `{"args": ["x"]}`)
+	if _, err := Scan(primaryRoot, nil); err == nil || !strings.Contains(err.Error(), "want a command") {
+		t.Fatalf("Scan with a commandless plugin: err = %v", err)
+	}
+	if err := os.Remove(filepath.Join(primaryRoot, ".sgpt/broken.plugin")); err != nil {
+		t.Fatal(err)
+	}
+
+	primaryTree, err := Scan(primaryRoot, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoImport := &sgptpb.Import{}
+	repoImport.SetName("ci")
+	repoImport.SetPath(importRoot)
+	forest := NewForest(primaryTree, repo.NewImports([]*sgptpb.Import{repoImport}), nil)
+
+	primary := forest.PrimaryPlugins()
+	if len(primary) != 1 {
+		t.Fatalf("primary plugins = %v", primary)
+	}
+	assertEqual(t,
+		[]string{primary[0].GetName(), primary[0].GetToolPrefix(), primary[0].GetCommand(), primary[0].GetDir()},
+		[]string{"//deploy", "ops", "python3", filepath.Join(primaryRoot, "ops")})
+	plugins := forest.Plugins()
+	if len(plugins) != 2 {
+		t.Fatalf("plugins = %d, want 2", len(plugins))
+	}
+	imported := plugins[1]
+	assertEqual(t,
+		[]string{imported.GetName(), imported.GetToolPrefix(), imported.GetCommand(), imported.GetDir()},
+		[]string{"@ci//ci:lint", "lint", filepath.Join(importRoot, "ci/lint.py"), importRoot})
+	if _, err := primaryTree.ResolvePlugin("//deploy"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestRootSelectorAmbiguity(t *testing.T) {
 	root := t.TempDir()
 	// A directory "overview" AND a root role "overview".
@@ -142,12 +229,19 @@ func TestArtifactMarkdownErrors(t *testing.T) {
 	write(t, root, ".sgpt.json", "{}")
 
 	for name, content := range map[string]string{
-		"unknown directive": "@bogus(\"x\")\n",
-		"unterminated":      "@alias(\nnever closed\n)\n@tool(\"x\"\n",
-		"alias arity":       "@alias(\"a\", \"b\")\n",
-		"unquoted argument": "@file(unquoted)\n",
-		"duplicate alias":   "@alias(\"a\")\n@alias(\"b\")\n",
-		"removed @node":     "@node(\"//a:b\")\n",
+		"unknown directive":  "@bogus(\"x\")\n",
+		"unterminated":       "@alias(\nnever closed\n)\n@tool(\"x\"\n",
+		"alias arity":        "@alias(\"a\", \"b\")\n",
+		"unquoted argument":  "@file(unquoted)\n",
+		"duplicate alias":    "@alias(\"a\")\n@alias(\"b\")\n",
+		"removed @node":      "@node(\"//a:b\")\n",
+		"param arity":        "@param(\"a\", \"b\", \"c\")\n",
+		"param name":         "@param(\"not-a-name\")\n",
+		"duplicate param":    "@param(\"a\")\n@param(\"a\", \"x\")\n",
+		"duplicate override": "@override_model(\"a\")\n@override_model(\"b\")\n",
+		"duplicate fallback": "@fallback_model(\"a\")\n@fallback_model(\"a\")\n",
+		"empty section":      "@section(\"\")\n",
+		"duplicate section":  "@section(\"a\")\none\n@section(\"a\")\ntwo\n",
 	} {
 		write(t, root, ".sgpt/bad"+RoleExtension, content)
 		if _, err := Scan(root, nil); err == nil {
@@ -166,3 +260,67 @@ func TestArtifactMarkdownErrors(t *testing.T) {
 		t.Fatalf("parsed role = %v", role)
 	}
 }
+
+func TestRoleParameters(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, ".sgpt.json", "{}")
+	write(t, root, ".sgpt/reviewer"+RoleExtension, "@param(\"language\")\n@param(\"style\", \"terse\")\n\nReview {{ .Params.language }} code.\n")
+
+	tree, err := Scan(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parameters := tree.PathToDir["."].Roles[0].Message.GetParameters()
+	if len(parameters) != 2 {
+		t.Fatalf("parameters = %v, want 2", parameters)
+	}
+	if parameters[0].GetName() != "language" || !parameters[0].GetRequired() {
+		t.Errorf("parameters[0] = %v, want required language", parameters[0])
+	}
+	if parameters[1].GetName() != "style" || parameters[1].GetRequired() || parameters[1].GetDefaultValue() != "terse" {
+		t.Errorf("parameters[1] = %v, want style defaulting to terse", parameters[1])
+	}
+}
+
+func TestRoleComposition(t *testing.T) {
+	root := t.TempDir()
+	write(t, root, ".sgpt.json", "{}")
+	write(t, root, "go/.sgpt/reviewer"+RoleExtension, `@override_model("providers/a/models/pinned")
+@fallback_model("providers/b/models/spare")
+@fallback_model("providers/c/models/last")
+@role("base")
+@exclude_tool("exec_shell")
+@exclude_file("go/notes.md")
+
+Review code.
+
+@section("style")
+Be terse.
+@section("rules")
+Never guess.
+`)
+
+	tree, err := Scan(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forest := NewForest(tree, repo.NewImports(nil), nil)
+	role, err := forest.ResolveRole("//go:reviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.GetPrompt() != "Review code." {
+		t.Errorf("prompt = %q, want the body before the first @section", role.GetPrompt())
+	}
+	sections := role.GetSections()
+	if len(sections) != 2 || sections[0].GetName() != "style" || sections[0].GetContent() != "Be terse." ||
+		sections[1].GetName() != "rules" || sections[1].GetContent() != "Never guess." {
+		t.Fatalf("sections = %v", sections)
+	}
+	if role.GetOverrideModel() != "providers/a/models/pinned" {
+		t.Errorf("override model = %q", role.GetOverrideModel())
+	}
+	assertEqual(t, role.GetFallbackModels(), []string{"providers/b/models/spare", "providers/c/models/last"})
+	assertEqual(t, role.GetExcludedTools(), []string{"exec_shell"})
+	assertEqual(t, role.GetExcludedFiles(), []string{filepath.Join(root, "go/notes.md")})
+}
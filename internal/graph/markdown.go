@@ -3,6 +3,7 @@ package graph
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/malonaz/core/go/pbutil"
@@ -36,11 +37,14 @@ type directive struct {
 	args []string
 	// text is set for the text form.
 	text string
+	// bodyLine is the number of body lines before the directive, locating
+	// it within the body.
+	bodyLine int
 }
 
 // parseArtifactMarkdown splits a markdown artifact into its directives and
-// body.
-func parseArtifactMarkdown(data string) ([]directive, string, error) {
+// body lines.
+func parseArtifactMarkdown(data string) ([]directive, []string, error) {
 	var directives []directive
 	var bodyLines []string
 	lines := strings.Split(data, "\n")
@@ -57,7 +61,7 @@ func parseArtifactMarkdown(data string) ([]directive, string, error) {
 		// Inline form: the directive closes on the same line.
 		if strings.HasSuffix(rest, ")") {
 			interior := strings.TrimSuffix(rest, ")")
-			parsed := directive{name: name}
+			parsed := directive{name: name, bodyLine: len(bodyLines)}
 			if args, ok := parseQuotedArguments(interior); ok {
 				parsed.args = args
 			} else {
@@ -81,11 +85,11 @@ func parseArtifactMarkdown(data string) ([]directive, string, error) {
 			textLines = append(textLines, lines[lineIndex])
 		}
 		if !terminated {
-			return nil, "", fmt.Errorf("@%s( is never closed by a lone \")\" line", name)
+			return nil, nil, fmt.Errorf("@%s( is never closed by a lone \")\" line", name)
 		}
-		directives = append(directives, directive{name: name, text: strings.TrimSpace(strings.Join(textLines, "\n"))})
+		directives = append(directives, directive{name: name, text: strings.TrimSpace(strings.Join(textLines, "\n")), bodyLine: len(bodyLines)})
 	}
-	return directives, strings.TrimSpace(strings.Join(bodyLines, "\n")), nil
+	return directives, bodyLines, nil
 }
 
 // parseQuotedArguments parses `"a", "b"` interiors; ok is false when the
@@ -145,14 +149,71 @@ func parseToolSetJSON(data []byte) (*sgptpb.ToolSet, error) {
 	return toolSet, nil
 }
 
-// parseRoleMarkdown builds a Role from a .role.md file: body = prompt;
-// directives: @alias, @model, @tool, @role, @file (all quoted-arg).
+// parseMcpServerJSON parses a .mcp file (strict JSON).
+func parseMcpServerJSON(data []byte) (*sgptpb.McpServer, error) {
+	mcpServer := &sgptpb.McpServer{}
+	if err := pbutil.JSONUnmarshalStrict(data, mcpServer); err != nil {
+		return nil, err
+	}
+	if mcpServer.GetStdio().GetCommand() == "" && mcpServer.GetHttp().GetUrl() == "" {
+		return nil, fmt.Errorf("want a stdio command or an http url")
+	}
+	return mcpServer, nil
+}
+
+// parsePluginJSON parses a .plugin file (strict JSON).
+func parsePluginJSON(data []byte) (*sgptpb.Plugin, error) {
+	plugin := &sgptpb.Plugin{}
+	if err := pbutil.JSONUnmarshalStrict(data, plugin); err != nil {
+		return nil, err
+	}
+	if plugin.GetCommand() == "" {
+		return nil, fmt.Errorf("want a command")
+	}
+	return plugin, nil
+}
+
+// parameterNamePattern keeps parameter names usable as {{ .Params.name }}.
+var parameterNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseRoleMarkdown builds a Role from a .role.md file: body = prompt, split
+// into named sections by @section lines; directives: @alias, @model,
+// @override_model, @fallback_model, @tool, @exclude_tool, @role, @file,
+// @exclude_file, @param, @section (all quoted-arg).
 func parseRoleMarkdown(data []byte) (*sgptpb.Role, error) {
-	directives, body, err := parseArtifactMarkdown(string(data))
+	directives, bodyLines, err := parseArtifactMarkdown(string(data))
 	if err != nil {
 		return nil, err
 	}
-	role := &sgptpb.Role{Prompt: body}
+	role := &sgptpb.Role{}
+	// Body lines up to sectionStart belong to the previous section (or the
+	// prompt, before any section).
+	sectionStart := len(bodyLines)
+	for i := len(directives) - 1; i >= 0; i-- {
+		parsed := directives[i]
+		if parsed.name != "section" {
+			continue
+		}
+		args, err := parsed.arguments(1)
+		if err != nil {
+			return nil, err
+		}
+		if args[0] == "" {
+			return nil, fmt.Errorf("@section name is empty")
+		}
+		content := strings.TrimSpace(strings.Join(bodyLines[parsed.bodyLine:sectionStart], "\n"))
+		role.Sections = append([]*sgptpb.Role_Section{{Name: args[0], Content: content}}, role.Sections...)
+		sectionStart = parsed.bodyLine
+	}
+	role.Prompt = strings.TrimSpace(strings.Join(bodyLines[:sectionStart], "\n"))
+	sectionNameSet := map[string]bool{}
+	for _, section := range role.Sections {
+		if sectionNameSet[section.GetName()] {
+			return nil, fmt.Errorf("@section %q declared twice", section.GetName())
+		}
+		sectionNameSet[section.GetName()] = true
+	}
+
 	for _, parsed := range directives {
 		switch parsed.name {
 		case "alias":
@@ -173,6 +234,38 @@ func parseRoleMarkdown(data []byte) (*sgptpb.Role, error) {
 				return nil, fmt.Errorf("@model declared twice")
 			}
 			role.Model = args[0]
+		case "override_model":
+			args, err := parsed.arguments(1)
+			if err != nil {
+				return nil, err
+			}
+			if role.OverrideModel != "" {
+				return nil, fmt.Errorf("@override_model declared twice")
+			}
+			role.OverrideModel = args[0]
+		case "fallback_model":
+			args, err := parsed.arguments(1)
+			if err != nil {
+				return nil, err
+			}
+			if slices.Contains(role.FallbackModels, args[0]) {
+				return nil, fmt.Errorf("@fallback_model %q declared twice", args[0])
+			}
+			role.FallbackModels = append(role.FallbackModels, args[0])
+		case "exclude_tool":
+			args, err := parsed.arguments(1)
+			if err != nil {
+				return nil, err
+			}
+			role.ExcludedTools = append(role.ExcludedTools, args[0])
+		case "exclude_file":
+			args, err := parsed.arguments(1)
+			if err != nil {
+				return nil, err
+			}
+			role.ExcludedFiles = append(role.ExcludedFiles, args[0])
+		case "section":
+			// Parsed with the body above.
 		case "tool":
 			args, err := parsed.arguments(1)
 			if err != nil {
@@ -191,8 +284,31 @@ func parseRoleMarkdown(data []byte) (*sgptpb.Role, error) {
 				return nil, err
 			}
 			role.Files = append(role.Files, args[0])
+		case "param":
+			// @param("name") is required; @param("name", "default") is not.
+			required := len(parsed.args) == 1
+			if required {
+				parsed.args = append(parsed.args, "")
+			}
+			args, err := parsed.arguments(2)
+			if err != nil {
+				return nil, err
+			}
+			if !parameterNamePattern.MatchString(args[0]) {
+				return nil, fmt.Errorf("@param name %q must be a letter or underscore followed by letters, digits or underscores", args[0])
+			}
+			for _, parameter := range role.Parameters {
+				if parameter.GetName() == args[0] {
+					return nil, fmt.Errorf("@param %q declared twice", args[0])
+				}
+			}
+			role.Parameters = append(role.Parameters, &sgptpb.Role_Parameter{
+				Name:         args[0],
+				DefaultValue: args[1],
+				Required:     required,
+			})
 		default:
-			return nil, fmt.Errorf("unknown role directive @%s (want @alias, @model, @tool, @role, @file)", parsed.name)
+			return nil, fmt.Errorf("unknown role directive @%s (want @alias, @model, @override_model, @fallback_model, @tool, @exclude_tool, @role, @file, @exclude_file, @param, @section)", parsed.name)
 		}
 	}
 	return role, nil
@@ -75,6 +75,16 @@ func (t *Tree) ResolveToolSet(name string) (*ToolSetFile, error) {
 	return resolveFile(t, name, "tool set", func(dir *Dir) []*ToolSetFile { return dir.ToolSets })
 }
 
+// ResolveMcpServer maps a selector to an MCP server file.
+func (t *Tree) ResolveMcpServer(name string) (*McpServerFile, error) {
+	return resolveFile(t, name, "MCP server", func(dir *Dir) []*McpServerFile { return dir.McpServers })
+}
+
+// ResolvePlugin maps a selector to a plugin file.
+func (t *Tree) ResolvePlugin(name string) (*PluginFile, error) {
+	return resolveFile(t, name, "plugin", func(dir *Dir) []*PluginFile { return dir.Plugins })
+}
+
 // RoleFiles returns every role of the tree, BFS order.
 func (t *Tree) RoleFiles() []*RoleFile {
 	var files []*RoleFile
@@ -92,3 +102,21 @@ func (t *Tree) ToolSetFiles() []*ToolSetFile {
 	}
 	return files
 }
+
+// McpServerFiles returns every MCP server of the tree, BFS order.
+func (t *Tree) McpServerFiles() []*McpServerFile {
+	var files []*McpServerFile
+	for _, dir := range t.Dirs {
+		files = append(files, dir.McpServers...)
+	}
+	return files
+}
+
+// PluginFiles returns every plugin of the tree, BFS order.
+func (t *Tree) PluginFiles() []*PluginFile {
+	var files []*PluginFile
+	for _, dir := range t.Dirs {
+		files = append(files, dir.Plugins...)
+	}
+	return files
+}
@@ -17,9 +17,12 @@ type Dir struct {
 	Files []string
 	// Children in name order.
 	Children []*Dir
-	// Roles and ToolSets are the directory's artifacts, in name order.
-	Roles    []*RoleFile
-	ToolSets []*ToolSetFile
+	// Roles, ToolSets, McpServers and Plugins are the directory's
+	// artifacts, in name order.
+	Roles      []*RoleFile
+	ToolSets   []*ToolSetFile
+	McpServers []*McpServerFile
+	Plugins    []*PluginFile
 }
 
 // Tree is a walked graph tree: every non-ignored directory with its
@@ -97,5 +100,17 @@ func (d *Dir) loadArtifacts(root string) error {
 	for _, toolSetFile := range d.ToolSets {
 		toolSetFile.Message.Name = toolSetFile.Selector()
 	}
+	if d.McpServers, err = loadFiles(root, d.Path, McpServerExtension, parseMcpServerJSON); err != nil {
+		return err
+	}
+	for _, mcpServerFile := range d.McpServers {
+		mcpServerFile.Message.Name = mcpServerFile.Selector()
+	}
+	if d.Plugins, err = loadFiles(root, d.Path, PluginExtension, parsePluginJSON); err != nil {
+		return err
+	}
+	for _, pluginFile := range d.Plugins {
+		pluginFile.Message.Name = pluginFile.Selector()
+	}
 	return nil
 }
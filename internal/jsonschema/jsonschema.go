@@ -0,0 +1,342 @@
+// Package jsonschema validates JSON values against the subset of JSON Schema
+// models reliably write: types, enums, object properties, array items, bounds
+// and anyOf. Any other validation keyword is rejected at compile time: a
+// schema silently checked in part would let through answers its author meant
+// to refuse.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// supportedKeywords are the validation keywords Compile implements.
+var supportedKeywords = []string{
+	"type", "enum", "const",
+	"properties", "required", "additionalProperties",
+	"items", "anyOf",
+	"minItems", "maxItems", "minLength", "maxLength", "minimum", "maximum",
+}
+
+// annotationKeywords document a schema without constraining values: they are
+// accepted, and have no effect.
+var annotationKeywords = []string{"$schema", "$comment", "title", "description", "default", "examples"}
+
+// Schema is a compiled JSON schema.
+type Schema struct {
+	types                []string
+	enum                 []any
+	constant             any
+	hasConstant          bool
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	noAdditional         bool
+	items                *Schema
+	anyOf                []*Schema
+	minItems, maxItems   *int
+	minLength, maxLength *int
+	minimum, maximum     *float64
+}
+
+// Compile parses a JSON schema document.
+func Compile(document string) (*Schema, error) {
+	var raw any
+	if err := json.Unmarshal([]byte(document), &raw); err != nil {
+		return nil, fmt.Errorf("parsing JSON schema: %w", err)
+	}
+	return compile(raw, "$")
+}
+
+func compile(raw any, path string) (*Schema, error) {
+	// `true` accepts anything; `false` is expressed as "no type allowed".
+	if accept, ok := raw.(bool); ok {
+		if accept {
+			return &Schema{}, nil
+		}
+		return &Schema{types: []string{}}, nil
+	}
+	object, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", path)
+	}
+	var unsupported []string
+	for keyword := range object {
+		if !slices.Contains(supportedKeywords, keyword) && !slices.Contains(annotationKeywords, keyword) {
+			unsupported = append(unsupported, keyword)
+		}
+	}
+	if len(unsupported) > 0 {
+		slices.Sort(unsupported)
+		return nil, fmt.Errorf("%s: unsupported keywords %s; supported keywords are %s",
+			path, strings.Join(unsupported, ", "), strings.Join(supportedKeywords, ", "))
+	}
+
+	schema := &Schema{}
+	switch value := object["type"].(type) {
+	case nil:
+	case string:
+		schema.types = []string{value}
+	case []any:
+		for _, item := range value {
+			typ, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s.type: must be a string or an array of strings", path)
+			}
+			schema.types = append(schema.types, typ)
+		}
+	default:
+		return nil, fmt.Errorf("%s.type: must be a string or an array of strings", path)
+	}
+	for _, typ := range schema.types {
+		if !slices.Contains([]string{"object", "array", "string", "number", "integer", "boolean", "null"}, typ) {
+			return nil, fmt.Errorf("%s.type: unknown type %q", path, typ)
+		}
+	}
+
+	if value, ok := object["enum"]; ok {
+		enum, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.enum: must be an array", path)
+		}
+		schema.enum = enum
+	}
+	if value, ok := object["const"]; ok {
+		schema.constant, schema.hasConstant = value, true
+	}
+
+	if value, ok := object["properties"]; ok {
+		properties, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.properties: must be an object", path)
+		}
+		schema.properties = make(map[string]*Schema, len(properties))
+		for name, rawProperty := range properties {
+			property, err := compile(rawProperty, path+".properties."+name)
+			if err != nil {
+				return nil, err
+			}
+			schema.properties[name] = property
+		}
+	}
+	if value, ok := object["required"]; ok {
+		required, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s.required: must be an array of strings", path)
+		}
+		for _, item := range required {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s.required: must be an array of strings", path)
+			}
+			schema.required = append(schema.required, name)
+		}
+	}
+	switch value := object["additionalProperties"].(type) {
+	case nil:
+	case bool:
+		schema.noAdditional = !value
+	default:
+		additionalProperties, err := compile(value, path+".additionalProperties")
+		if err != nil {
+			return nil, err
+		}
+		schema.additionalProperties = additionalProperties
+	}
+
+	if value, ok := object["items"]; ok {
+		items, err := compile(value, path+".items")
+		if err != nil {
+			return nil, err
+		}
+		schema.items = items
+	}
+	if value, ok := object["anyOf"]; ok {
+		anyOf, ok := value.([]any)
+		if !ok || len(anyOf) == 0 {
+			return nil, fmt.Errorf("%s.anyOf: must be a non-empty array", path)
+		}
+		for i, rawBranch := range anyOf {
+			branch, err := compile(rawBranch, fmt.Sprintf("%s.anyOf[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			schema.anyOf = append(schema.anyOf, branch)
+		}
+	}
+
+	var err error
+	for keyword, bound := range map[string]**int{
+		"minItems": &schema.minItems, "maxItems": &schema.maxItems,
+		"minLength": &schema.minLength, "maxLength": &schema.maxLength,
+	} {
+		if *bound, err = intKeyword(object, keyword, path); err != nil {
+			return nil, err
+		}
+	}
+	for keyword, bound := range map[string]**float64{"minimum": &schema.minimum, "maximum": &schema.maximum} {
+		value, ok := object[keyword]
+		if !ok {
+			continue
+		}
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: must be a number", path, keyword)
+		}
+		*bound = &number
+	}
+	return schema, nil
+}
+
+func intKeyword(object map[string]any, keyword, path string) (*int, error) {
+	value, ok := object[keyword]
+	if !ok {
+		return nil, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number < 0 || number != math.Trunc(number) {
+		return nil, fmt.Errorf("%s.%s: must be a non-negative integer", path, keyword)
+	}
+	bound := int(number)
+	return &bound, nil
+}
+
+// Validate checks a value decoded by encoding/json against the schema. The
+// error lists every violation, each with its path, so a model can fix them
+// all in one go.
+func (s *Schema) Validate(value any) error {
+	var violations []string
+	s.validate(value, "$", &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(violations, "; "))
+}
+
+func (s *Schema) validate(value any, path string, violations *[]string) {
+	report := func(format string, args ...any) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.types != nil && !slices.ContainsFunc(s.types, func(typ string) bool { return hasType(value, typ) }) {
+		if len(s.types) == 0 {
+			report("no value is allowed here")
+		} else {
+			report("got %s, want %s", typeName(value), strings.Join(s.types, " or "))
+		}
+		return
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(allowed any) bool { return equal(value, allowed) }) {
+		report("%s is not one of the allowed values", encode(value))
+	}
+	if s.hasConstant && !equal(value, s.constant) {
+		report("got %s, want %s", encode(value), encode(s.constant))
+	}
+	if len(s.anyOf) > 0 && !slices.ContainsFunc(s.anyOf, func(branch *Schema) bool { return branch.Validate(value) == nil }) {
+		report("matches none of the anyOf schemas")
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := value[name]; !ok {
+				report("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			propertyPath := path + "." + name
+			if property, ok := s.properties[name]; ok {
+				property.validate(value[name], propertyPath, violations)
+				continue
+			}
+			switch {
+			case s.noAdditional:
+				*violations = append(*violations, propertyPath+": property is not allowed")
+			case s.additionalProperties != nil:
+				s.additionalProperties.validate(value[name], propertyPath, violations)
+			}
+		}
+	case []any:
+		if s.minItems != nil && len(value) < *s.minItems {
+			report("has %d items, want at least %d", len(value), *s.minItems)
+		}
+		if s.maxItems != nil && len(value) > *s.maxItems {
+			report("has %d items, want at most %d", len(value), *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range value {
+				s.items.validate(item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(value)
+		if s.minLength != nil && length < *s.minLength {
+			report("is %d characters, want at least %d", length, *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			report("is %d characters, want at most %d", length, *s.maxLength)
+		}
+	case float64:
+		if s.minimum != nil && value < *s.minimum {
+			report("%v is below the minimum %v", value, *s.minimum)
+		}
+		if s.maximum != nil && value > *s.maximum {
+			report("%v is above the maximum %v", value, *s.maximum)
+		}
+	}
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return typeName(value) == typ
+	}
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// equal compares decoded JSON values structurally.
+func equal(a, b any) bool {
+	return encode(a) == encode(b)
+}
+
+// encode is deterministic: encoding/json sorts map keys.
+func encode(value any) string {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
@@ -0,0 +1,137 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const findingsSchema = `{
+  "type": "object",
+  "properties": {
+    "summary": {"type": "string", "minLength": 1},
+    "severity": {"enum": ["low", "medium", "high"]},
+    "files": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+    "count": {"type": "integer", "minimum": 0}
+  },
+  "required": ["summary", "severity"],
+  "additionalProperties": false
+}`
+
+func decode(t *testing.T, document string) any {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatalf("decoding %s: %v", document, err)
+	}
+	return value
+}
+
+func TestValidate(t *testing.T) {
+	schema, err := Compile(findingsSchema)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	for _, tc := range []struct {
+		name     string
+		value    string
+		wantErrs []string
+	}{
+		{name: "valid", value: `{"summary": "ok", "severity": "low", "files": ["a.go"], "count": 3}`},
+		{name: "missing required", value: `{"summary": "ok"}`, wantErrs: []string{`$: missing required property "severity"`}},
+		{name: "wrong type", value: `[]`, wantErrs: []string{"$: got array, want object"}},
+		{name: "enum", value: `{"summary": "ok", "severity": "urgent"}`, wantErrs: []string{`$.severity: "urgent" is not one of the allowed values`}},
+		{name: "integer", value: `{"summary": "ok", "severity": "low", "count": 1.5}`, wantErrs: []string{"$.count: got number, want integer"}},
+		{name: "minimum", value: `{"summary": "ok", "severity": "low", "count": -1}`, wantErrs: []string{"$.count: -1 is below the minimum 0"}},
+		{name: "additional property", value: `{"summary": "ok", "severity": "low", "extra": 1}`, wantErrs: []string{"$.extra: property is not allowed"}},
+		{
+			name:     "items and bounds",
+			value:    `{"summary": "", "severity": "low", "files": ["a.go", 2, "c.go"]}`,
+			wantErrs: []string{"$.files: has 3 items, want at most 2", "$.files[1]: got number, want string", "$.summary: is 0 characters, want at least 1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Validate(decode(t, tc.value))
+			if len(tc.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate: want errors %q, got none", tc.wantErrs)
+			}
+			for _, wantErr := range tc.wantErrs {
+				if !strings.Contains(err.Error(), wantErr) {
+					t.Errorf("Validate error %q does not contain %q", err, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateAnyOf(t *testing.T) {
+	schema, err := Compile(`{"anyOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}]}`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if err := schema.Validate(decode(t, `["a", "b"]`)); err != nil {
+		t.Errorf("Validate array: %v", err)
+	}
+	if err := schema.Validate(decode(t, `"a"`)); err != nil {
+		t.Errorf("Validate string: %v", err)
+	}
+	if err := schema.Validate(decode(t, `1`)); err == nil {
+		t.Errorf("Validate number: want error, got none")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, document := range []string{
+		`not json`,
+		`"object"`,
+		`{"type": "map"}`,
+		`{"required": "name"}`,
+		`{"properties": {"a": {"type": 1}}}`,
+		`{"maxItems": -1}`,
+	} {
+		if _, err := Compile(document); err == nil {
+			t.Errorf("Compile(%s): want error, got none", document)
+		}
+	}
+}
+
+func TestCompileUnsupportedKeywords(t *testing.T) {
+	for _, document := range []string{
+		`{"$ref": "#/$defs/name", "$defs": {"name": {"type": "string"}}}`,
+		`{"allOf": [{"type": "string"}]}`,
+		`{"oneOf": [{"type": "string"}, {"type": "number"}]}`,
+		`{"not": {"type": "null"}}`,
+		`{"type": "string", "pattern": "^a"}`,
+		`{"type": "string", "format": "date"}`,
+		`{"type": "number", "exclusiveMinimum": 0}`,
+		`{"type": "array", "uniqueItems": true}`,
+		// Nested schemas are checked too.
+		`{"properties": {"name": {"type": "string", "pattern": "^a"}}}`,
+		`{"items": {"oneOf": [{"type": "string"}]}}`,
+	} {
+		_, err := Compile(document)
+		if err == nil {
+			t.Errorf("Compile(%s): want error, got none", document)
+			continue
+		}
+		// The error tells the schema's author what they can use instead.
+		if !strings.Contains(err.Error(), "supported keywords are type, enum, const") {
+			t.Errorf("Compile(%s) = %v, want the supported keywords listed", document, err)
+		}
+	}
+
+	// Annotations document a schema without constraining it.
+	schema, err := Compile(`{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Name", "description": "A name.", "type": "string", "default": "a", "examples": ["b"]}`)
+	if err != nil {
+		t.Fatalf("Compile with annotations: %v", err)
+	}
+	if err := schema.Validate(decode(t, `"c"`)); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
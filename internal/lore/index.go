@@ -67,24 +67,52 @@ func (x *Index) Libraries() []Library {
 // canonical — alias spellings of the same repo resolve to one name — so it
 // is safe to use as a dedupe key against search results.
 func (x *Index) Resolve(selector string) (name, path string, err error) {
+	library, id, err := x.lookup(selector)
+	if err != nil {
+		return "", "", err
+	}
+	path = library.Path(id)
+	if _, err := os.Stat(path); err != nil {
+		return "", "", fmt.Errorf("lore %q not found at %s", selector, path)
+	}
+	return library.QualifyName(loresDirName + "/" + id), path, nil
+}
+
+// Writable maps a lore selector, existing or not, to the enclosing repo's
+// library and the lore's ID. Imported libraries are read-only: selectors
+// into them are rejected, unless the import is the enclosing repo itself.
+func (x *Index) Writable(selector string) (Library, string, error) {
+	library, id, err := x.lookup(selector)
+	if err != nil {
+		return Library{}, "", err
+	}
+	if library.Prefix != "" {
+		return Library{}, "", fmt.Errorf("lore %q is in the read-only %s library: only the enclosing repo's lores can be written", selector, library.Prefix)
+	}
+	if err := ValidateID(id); err != nil {
+		return Library{}, "", err
+	}
+	return library, id, nil
+}
+
+// lookup splits a lore selector into its canonical library and lore ID.
+func (x *Index) lookup(selector string) (Library, string, error) {
 	prefix, local := "", selector
 	if importName, rest, ok := repo.Split(selector); ok {
 		prefix, local = repo.Prefix+importName, strings.TrimPrefix(rest, "//")
 	}
 	id := strings.TrimPrefix(local, loresDirName+"/")
 	if id == local || id == "" {
-		return "", "", fmt.Errorf("invalid lore selector %q: want \"lores/{lore}\" or \"@{import}//lores/{lore}\"", selector)
+		return Library{}, "", fmt.Errorf("invalid lore selector %q: want \"lores/{lore}\" or \"@{import}//lores/{lore}\"", selector)
 	}
 	i, ok := x.prefixToLibrary[prefix]
 	if !ok {
-		return "", "", fmt.Errorf("lore %q: repo %q is not imported in the configuration", selector, strings.TrimPrefix(prefix, repo.Prefix))
-	}
-	library := x.libraries[i]
-	path = filepath.Join(library.Dir(), filepath.FromSlash(id)+Extension)
-	if _, err := os.Stat(path); err != nil {
-		return "", "", fmt.Errorf("lore %q not found at %s", selector, path)
+		if prefix == "" {
+			return Library{}, "", fmt.Errorf("lore %q: not inside a repo", selector)
+		}
+		return Library{}, "", fmt.Errorf("lore %q: repo %q is not imported in the configuration", selector, strings.TrimPrefix(prefix, repo.Prefix))
 	}
-	return library.QualifyName(loresDirName + "/" + id), path, nil
+	return x.libraries[i], id, nil
 }
 
 // NameForPath maps a lore file path back to its canonical name; ok is false
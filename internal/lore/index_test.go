@@ -0,0 +1,44 @@
+package lore
+
+import (
+	"strings"
+	"testing"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/repo"
+)
+
+func TestWritable(t *testing.T) {
+	root, importRoot := t.TempDir(), t.TempDir()
+	index := NewIndex(root, repo.NewImports([]*sgptpb.Import{
+		{Name: "core", Path: importRoot},
+		// The enclosing repo, imported under another name.
+		{Name: "self", Path: root},
+	}))
+
+	for _, selector := range []string{"lores/go/errors", "@self//lores/go/errors"} {
+		library, id, err := index.Writable(selector)
+		if err != nil {
+			t.Errorf("Writable(%q): %v", selector, err)
+			continue
+		}
+		if library.Root != root || id != "go/errors" {
+			t.Errorf("Writable(%q) = %s, %q", selector, library.Root, id)
+		}
+	}
+	for selector, want := range map[string]string{
+		"@core//lores/go/errors": "read-only",
+		"@other//lores/x":        "not imported",
+		"go/errors":              "invalid lore selector",
+		"lores/../escape":        "invalid lore ID",
+	} {
+		if _, _, err := index.Writable(selector); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Writable(%q) error = %v, want it to contain %q", selector, err, want)
+		}
+	}
+
+	outside := NewIndex("", repo.NewImports(nil))
+	if _, _, err := outside.Writable("lores/x"); err == nil || !strings.Contains(err.Error(), "not inside a repo") {
+		t.Errorf("Writable outside a repo: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package lore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// Problem is an issue Lint found in a library file.
+type Problem struct {
+	Path    string
+	Message string
+}
+
+// Lint checks a library: every lore's front matter (present, well-formed,
+// titled), titles duplicated across lores, label spellings drifting apart
+// (keys or values equal but for case and separators), and whether
+// `.sgpt/labels` still matches the lores.
+func (l Library) Lint() ([]*Problem, error) {
+	var problems []*Problem
+	report := func(path, format string, args ...any) {
+		problems = append(problems, &Problem{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var paths []string
+	var lores []*sgptpb.Lore
+	err := filepath.WalkDir(l.Dir(), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), Extension) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		header, body, hasFrontMatter := splitFrontMatter(string(data))
+		lore := &sgptpb.Lore{Content: strings.TrimLeft(body, "\n")}
+		if !hasFrontMatter {
+			report(path, "no front matter: start the file with a --- block holding at least a title")
+		} else {
+			// Strict: a misspelled key would otherwise be dropped silently.
+			decoder := yaml.NewDecoder(strings.NewReader(header))
+			decoder.KnownFields(true)
+			parsedFrontMatter := &frontMatter{}
+			if err := decoder.Decode(parsedFrontMatter); err != nil && !errors.Is(err, io.EOF) {
+				report(path, "invalid front matter: %v", err)
+			}
+			lore.Title = parsedFrontMatter.Title
+			lore.Labels = parsedFrontMatter.Labels
+			if lore.Title == "" {
+				report(path, "front matter has no title")
+			}
+			for key, value := range lore.Labels {
+				if key == "" || value == "" {
+					report(path, "label %q=%q: keys and values must be non-empty", key, value)
+				}
+			}
+		}
+		if strings.TrimSpace(lore.Content) == "" {
+			report(path, "no content below the front matter")
+		}
+		paths = append(paths, path)
+		lores = append(lores, lore)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	titleToPath := map[string]string{}
+	for i, lore := range lores {
+		title := strings.ToLower(strings.TrimSpace(lore.GetTitle()))
+		if title == "" {
+			continue
+		}
+		if firstPath, ok := titleToPath[title]; ok {
+			report(paths[i], "title %q is also used by %s", lore.GetTitle(), firstPath)
+			continue
+		}
+		titleToPath[title] = paths[i]
+	}
+
+	labelKeySet, err := l.labelKeySet()
+	if err != nil {
+		return nil, err
+	}
+	for _, drift := range labelDrifts(lores, labelKeySet) {
+		report(paths[drift.index], "%s", drift.message)
+	}
+
+	data, err := os.ReadFile(l.labelsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if string(data) != renderLabels(lores) {
+		report(l.labelsPath(), "out of date with the lores' labels (rewritten on the next search, or by `sgpt lore lint --sync`)")
+	}
+	return problems, nil
+}
+
+// labelKeySet returns the keys listed in the library's `.sgpt/labels`.
+func (l Library) labelKeySet() (map[string]bool, error) {
+	data, err := os.ReadFile(l.labelsPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]bool{}, nil
+		}
+		return nil, err
+	}
+	keySet := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, _, ok := strings.Cut(line, ":"); ok {
+			keySet[strings.TrimSpace(key)] = true
+		}
+	}
+	return keySet, nil
+}
+
+// normalizeLabel folds the differences that make two label spellings the
+// same word: case and separators.
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(label))
+}
+
+type labelDrift struct {
+	// index of the drifting lore.
+	index   int
+	message string
+}
+
+// labelDrifts finds lores whose label keys, or values under one key, are
+// spelled differently from the same normalized word elsewhere. The
+// canonical spelling is the one in `.sgpt/labels` (for keys) or else the
+// most used.
+func labelDrifts(lores []*sgptpb.Lore, labelKeySet map[string]bool) []labelDrift {
+	keyToCount := map[string]int{}
+	keyToValueToCount := map[string]map[string]int{}
+	for _, lore := range lores {
+		for key, value := range lore.GetLabels() {
+			keyToCount[key]++
+			normalizedKey := normalizeLabel(key)
+			if keyToValueToCount[normalizedKey] == nil {
+				keyToValueToCount[normalizedKey] = map[string]int{}
+			}
+			keyToValueToCount[normalizedKey][value]++
+		}
+	}
+	canonicalKeys := canonicalSpellings(keyToCount, labelKeySet)
+	normalizedKeyToCanonicalValues := map[string]map[string]string{}
+	for normalizedKey, valueToCount := range keyToValueToCount {
+		normalizedKeyToCanonicalValues[normalizedKey] = canonicalSpellings(valueToCount, nil)
+	}
+
+	var drifts []labelDrift
+	for i, lore := range lores {
+		keys := make([]string, 0, len(lore.GetLabels()))
+		for key := range lore.GetLabels() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if canonicalKey := canonicalKeys[key]; canonicalKey != key {
+				drifts = append(drifts, labelDrift{index: i, message: fmt.Sprintf(
+					"label key %q drifts from %q (used by %d lore(s))", key, canonicalKey, keyToCount[canonicalKey])})
+			}
+			value := lore.GetLabels()[key]
+			if canonicalValue := normalizedKeyToCanonicalValues[normalizeLabel(key)][value]; canonicalValue != value {
+				drifts = append(drifts, labelDrift{index: i, message: fmt.Sprintf(
+					"label %s=%q drifts from the value %q", key, value, canonicalValue)})
+			}
+		}
+	}
+	return drifts
+}
+
+// canonicalSpellings maps each spelling to the canonical spelling of its
+// normalized form: a preferred one if exactly one is preferred, else the
+// most used, ties broken alphabetically.
+func canonicalSpellings(spellingToCount map[string]int, preferredSet map[string]bool) map[string]string {
+	normalizedToSpellings := map[string][]string{}
+	for spelling := range spellingToCount {
+		normalized := normalizeLabel(spelling)
+		normalizedToSpellings[normalized] = append(normalizedToSpellings[normalized], spelling)
+	}
+	spellingToCanonical := make(map[string]string, len(spellingToCount))
+	for _, spellings := range normalizedToSpellings {
+		sort.Slice(spellings, func(i, j int) bool {
+			if spellingToCount[spellings[i]] != spellingToCount[spellings[j]] {
+				return spellingToCount[spellings[i]] > spellingToCount[spellings[j]]
+			}
+			return spellings[i] < spellings[j]
+		})
+		canonical := spellings[0]
+		var preferred []string
+		for _, spelling := range spellings {
+			if preferredSet[spelling] {
+				preferred = append(preferred, spelling)
+			}
+		}
+		if len(preferred) == 1 {
+			canonical = preferred[0]
+		}
+		for _, spelling := range spellings {
+			spellingToCanonical[spelling] = canonical
+		}
+	}
+	return spellingToCanonical
+}
+
+// CheckLabels validates the labels of the lore with the given ID against
+// the vocabulary of the library's other lores. A key or value spelled like
+// an existing one but for case and separators is an error naming the
+// spelling to reuse; the labels new to the vocabulary are returned as sorted
+// "key=value" pairs.
+func (l Library) CheckLabels(id string, labels map[string]string) ([]string, error) {
+	lores, err := l.Load()
+	if err != nil {
+		return nil, err
+	}
+	name := l.QualifyName(loresDirName + "/" + id)
+	keyToValueSet := map[string]map[string]bool{}
+	for _, lore := range lores {
+		if lore.GetName() == name {
+			continue
+		}
+		for key, value := range lore.GetLabels() {
+			if keyToValueSet[key] == nil {
+				keyToValueSet[key] = map[string]bool{}
+			}
+			keyToValueSet[key][value] = true
+		}
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var problems, newLabels []string
+	for _, key := range keys {
+		value := labels[key]
+		if key == "" || value == "" {
+			problems = append(problems, fmt.Sprintf("label %q=%q: keys and values must be non-empty", key, value))
+			continue
+		}
+		valueSet, ok := keyToValueSet[key]
+		if !ok {
+			if existingKey := sameSpelling(key, keyToValueSet); existingKey != "" {
+				problems = append(problems, fmt.Sprintf("label key %q: use the existing %q", key, existingKey))
+				continue
+			}
+		}
+		if valueSet[value] {
+			continue
+		}
+		if existingValue := sameSpelling(value, valueSet); existingValue != "" {
+			problems = append(problems, fmt.Sprintf("label %s=%q: use the existing value %q", key, value, existingValue))
+			continue
+		}
+		newLabels = append(newLabels, key+"="+value)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("labels drift from the library's vocabulary: %s", strings.Join(problems, "; "))
+	}
+	return newLabels, nil
+}
+
+// sameSpelling returns the key of set that normalizes like spelling, the
+// alphabetically first if several; empty if none.
+func sameSpelling[V any](spelling string, set map[string]V) string {
+	var matches []string
+	for candidate := range set {
+		if normalizeLabel(candidate) == normalizeLabel(spelling) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[0]
+}
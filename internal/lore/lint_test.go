@@ -0,0 +1,139 @@
+package lore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+func writeLore(t *testing.T, library Library, id, content string) {
+	t.Helper()
+	path := library.Path(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	library := Library{Root: t.TempDir()}
+	writeLore(t, library, "go/errors", "---\ntitle: Go errors\nlabels:\n  language: go\n---\n\nWrap errors.\n")
+	writeLore(t, library, "go/tests", "---\ntitle: Go tests\nlabels:\n  language: go\n---\n\nTable tests.\n")
+	writeLore(t, library, "go/drift", "---\ntitle: go errors\nlabels:\n  Language: Go\n---\n\nDuplicate.\n")
+	writeLore(t, library, "bare", "No front matter.\n")
+	writeLore(t, library, "typo", "---\ntitel: Typo\n---\n\nBody.\n")
+	writeLore(t, library, "empty", "---\ntitle: Empty\n---\n")
+
+	problems, err := library.Lint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, problem := range problems {
+		relative, _ := filepath.Rel(library.Root, problem.Path)
+		lines = append(lines, relative+": "+problem.Message)
+	}
+	got := strings.Join(lines, "\n")
+	for _, want := range []string{
+		".sgpt/lores/bare.md: no front matter",
+		".sgpt/lores/typo.md: invalid front matter",
+		".sgpt/lores/typo.md: front matter has no title",
+		".sgpt/lores/empty.md: no content",
+		`.sgpt/lores/go/errors.md: title "Go errors" is also used by`,
+		`.sgpt/lores/go/drift.md: label key "Language" drifts from "language" (used by 2 lore(s))`,
+		`.sgpt/lores/go/drift.md: label Language="Go" drifts from the value "go"`,
+		".sgpt/labels: out of date",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("problems do not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "go/tests.md") {
+		t.Errorf("a clean lore was reported:\n%s", got)
+	}
+
+	// Synced labels are no longer reported.
+	lores, err := library.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := library.SyncLabels(lores); err != nil {
+		t.Fatal(err)
+	}
+	problems, err = library.Lint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		if strings.HasSuffix(problem.Path, labelsFileName) {
+			t.Errorf("synced labels reported: %s", problem.Message)
+		}
+	}
+}
+
+func TestSaveAndDelete(t *testing.T) {
+	library := Library{Root: t.TempDir()}
+	path, err := library.Save("go/errors", &sgptpb.Lore{Title: "Go errors", Content: "Wrap them."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != library.Path("go/errors") {
+		t.Errorf("Save wrote %s, want %s", path, library.Path("go/errors"))
+	}
+	lores, err := library.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lores) != 1 || lores[0].GetName() != "lores/go/errors" || lores[0].GetTitle() != "Go errors" {
+		t.Fatalf("loaded %v", lores)
+	}
+	if err := library.Delete("go/errors"); err != nil {
+		t.Fatal(err)
+	}
+	// The emptied subdirectory goes too; the library directory stays.
+	if _, err := os.Stat(filepath.Join(library.Dir(), "go")); !os.IsNotExist(err) {
+		t.Errorf("empty subdirectory left behind: %v", err)
+	}
+	if _, err := os.Stat(library.Dir()); err != nil {
+		t.Errorf("library directory removed: %v", err)
+	}
+
+	for _, id := range []string{"", "../escape", "a//b", ".hidden", "a/./b", "c:\\x"} {
+		if _, err := library.Save(id, &sgptpb.Lore{Title: "x"}); err == nil {
+			t.Errorf("Save(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestCheckLabels(t *testing.T) {
+	library := Library{Root: t.TempDir()}
+	writeLore(t, library, "go/errors", "---\ntitle: Go errors\nlabels:\n  language: go\n  topic: error-handling\n---\n\nWrap errors.\n")
+
+	newLabels, err := library.CheckLabels("go/tests", map[string]string{"language": "go", "topic": "testing", "team": "infra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(newLabels, ","); got != "team=infra,topic=testing" {
+		t.Errorf("new labels = %q", got)
+	}
+
+	_, err = library.CheckLabels("go/tests", map[string]string{"Language": "go", "topic": "Error_Handling"})
+	if err == nil {
+		t.Fatal("drifting labels were accepted")
+	}
+	for _, want := range []string{`label key "Language": use the existing "language"`, `use the existing value "error-handling"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+
+	// A lore's own labels are not vocabulary it can drift from.
+	if _, err := library.CheckLabels("go/errors", map[string]string{"Language": "Go"}); err != nil {
+		t.Errorf("rewriting a lore's own labels: %v", err)
+	}
+}
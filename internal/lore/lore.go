@@ -2,9 +2,9 @@
 // unstructured knowledge stored as markdown files (YAML front matter for
 // metadata, markdown body for content) under a repo's `.sgpt/lores`
 // directory — committed with the repo, so lore travels from repo to repo
-// via the configuration's imports. Searched grep-style via the search_lores
-// tool; each library's label vocabulary is persisted in its repo's
-// `.sgpt/labels`.
+// via the configuration's imports. Searched grep-style or BM25-ranked via
+// the search_lores tool and written via write_lore/delete_lore; each
+// library's label vocabulary is persisted in its repo's `.sgpt/labels`.
 package lore
 
 import (
@@ -43,6 +43,69 @@ func (l Library) Dir() string {
 	return filepath.Join(l.Root, ".sgpt", loresDirName)
 }
 
+// Path is the file path of the lore with the given ID ("{lore}", without
+// the "lores/" prefix) in the library.
+func (l Library) Path(id string) string {
+	return filepath.Join(l.Dir(), filepath.FromSlash(id)+Extension)
+}
+
+// Save writes a lore to the library under the given ID, creating its
+// subdirectories as needed, and returns the file's path.
+func (l Library) Save(id string, lore *sgptpb.Lore) (string, error) {
+	if err := ValidateID(id); err != nil {
+		return "", err
+	}
+	data, err := MarshalMarkdown(lore)
+	if err != nil {
+		return "", err
+	}
+	path := l.Path(id)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Delete removes the lore with the given ID, then the subdirectories it
+// leaves empty.
+func (l Library) Delete(id string) error {
+	if err := ValidateID(id); err != nil {
+		return err
+	}
+	path := l.Path(id)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	for dir := filepath.Dir(path); dir != l.Dir(); dir = filepath.Dir(dir) {
+		// Fails, ending the cleanup, on the first non-empty directory.
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// ValidateID checks a lore ID ("{lore}", possibly with "/" segments) names a
+// file inside the library.
+func ValidateID(id string) error {
+	if id == "" {
+		return fmt.Errorf("empty lore ID")
+	}
+	for _, segment := range strings.Split(id, "/") {
+		// Also rules out "." and "..": a lore never escapes its library.
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			return fmt.Errorf("invalid lore ID %q: segments must be non-empty and not start with \".\"", id)
+		}
+	}
+	if strings.ContainsAny(id, "\\:") {
+		return fmt.Errorf("invalid lore ID %q: want \"/\"-separated segments", id)
+	}
+	return nil
+}
+
 // QualifyName prefixes a lore name with the library's import prefix
 // ("lores/x" -> "@core//lores/x"), matching role/tool-set addressing.
 func (l Library) QualifyName(name string) string {
@@ -99,6 +162,19 @@ func (l Library) Load() ([]*sgptpb.Lore, error) {
 // index of the label vocabulary, so agents reuse keys instead of inventing
 // near-duplicates.
 func (l Library) SyncLabels(lores []*sgptpb.Lore) error {
+	if err := os.MkdirAll(filepath.Dir(l.labelsPath()), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(l.labelsPath(), []byte(renderLabels(lores)), 0o644)
+}
+
+func (l Library) labelsPath() string {
+	return filepath.Join(l.Root, ".sgpt", labelsFileName)
+}
+
+// renderLabels renders the label vocabulary of lores, one "key: value, ..."
+// line per key, sorted.
+func renderLabels(lores []*sgptpb.Lore) string {
 	keyToValueSet := map[string]map[string]bool{}
 	for _, lore := range lores {
 		for key, value := range lore.GetLabels() {
@@ -122,11 +198,7 @@ func (l Library) SyncLabels(lores []*sgptpb.Lore) error {
 		sort.Strings(values)
 		fmt.Fprintf(&b, "%s: %s\n", key, strings.Join(values, ", "))
 	}
-	sgptDir := filepath.Join(l.Root, ".sgpt")
-	if err := os.MkdirAll(sgptDir, 0o755); err != nil {
-		return err
-	}
-	return os.WriteFile(filepath.Join(sgptDir, labelsFileName), []byte(b.String()), 0o644)
+	return b.String()
 }
 
 // Match is one lore matched by a search.
@@ -136,8 +208,9 @@ type Match struct {
 	Snippets []string
 	// MatchCount is the total number of matching content lines.
 	MatchCount int
-	// score orders matches: metadata hits outweigh content hits.
-	score int
+	// score orders matches: for grep-style search, metadata hits outweigh
+	// content hits; for ranked search, it is the BM25 relevance.
+	score float64
 }
 
 // maxSnippetsPerLore caps snippets so one giant lore doesn't flood results.
@@ -174,7 +247,7 @@ func Search(lores []*sgptpb.Lore, query string, topN int) ([]*Match, error) {
 				match.Snippets = append(match.Snippets, strings.TrimSpace(line))
 			}
 		}
-		match.score += match.MatchCount
+		match.score += float64(match.MatchCount)
 		if match.score > 0 {
 			matches = append(matches, match)
 		}
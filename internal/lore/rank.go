@@ -0,0 +1,268 @@
+package lore
+
+import (
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+const (
+	// BM25 parameters, at their customary values: k1 saturates term
+	// frequency, b normalizes for document length.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Field weights: a term in the title says more about a lore than one in
+// its body. Applied by counting each field's terms that many times.
+const (
+	titleWeight       = 3
+	descriptionWeight = 2
+	labelWeight       = 2
+	contentWeight     = 1
+)
+
+// stopWords are dropped from documents and queries alike: they occur in
+// every lore, so they only dilute a natural-language query.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "does": true, "for": true,
+	"from": true, "how": true, "i": true, "if": true, "in": true, "is": true,
+	"it": true, "me": true, "my": true, "not": true, "of": true, "on": true,
+	"or": true, "should": true, "that": true, "the": true, "this": true,
+	"to": true, "we": true, "what": true, "when": true, "where": true,
+	"which": true, "why": true, "with": true, "you": true,
+}
+
+// Tokenize splits text into lowercase terms at every rune that is neither a
+// letter nor a digit, dropping stop words and single characters. Ranked
+// search and its highlighting share it, so both agree on what a term is.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) > 1 && !stopWords[field] {
+			terms = append(terms, field)
+		}
+	}
+	return terms
+}
+
+// Ranker ranks lores by BM25 relevance to a free-text query, over every
+// library of an index. Its in-memory index is rebuilt only when a lore file
+// is added, removed or modified, so ranking on every turn costs a directory
+// walk, not a reparse. Safe for concurrent use.
+type Ranker struct {
+	index *Index
+
+	mu sync.Mutex
+	// pathToModTime fingerprints the lore files the documents were built
+	// from; nil until the first build.
+	pathToModTime map[string]time.Time
+	documents     []*document
+	// termToDocumentCount is each term's document frequency.
+	termToDocumentCount map[string]int
+	averageLength       float64
+}
+
+// document is one lore's weighted bag of terms.
+type document struct {
+	lore            *sgptpb.Lore
+	termToFrequency map[string]int
+	length          int
+}
+
+// NewRanker returns a ranker over the index's libraries. The index is built
+// lazily, on the first search.
+func NewRanker(index *Index) *Ranker {
+	return &Ranker{index: index}
+}
+
+// Search returns the topN lores most relevant to query (10 when topN <= 0),
+// best first. Snippets are the content lines holding any query term. Lores
+// sharing no term with the query never match.
+func (r *Ranker) Search(query string, topN int) ([]*Match, error) {
+	if topN <= 0 {
+		topN = 10
+	}
+	queryTerms := uniqueTerms(Tokenize(query))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.refresh(); err != nil {
+		return nil, err
+	}
+	var matches []*Match
+	for _, document := range r.documents {
+		score := r.score(document, queryTerms)
+		if score <= 0 {
+			continue
+		}
+		match := &Match{Lore: document.lore, score: score}
+		for _, line := range strings.Split(document.lore.GetContent(), "\n") {
+			if !containsAny(Tokenize(line), queryTerms) {
+				continue
+			}
+			match.MatchCount++
+			if len(match.Snippets) < maxSnippetsPerLore {
+				match.Snippets = append(match.Snippets, strings.TrimSpace(line))
+			}
+		}
+		matches = append(matches, match)
+	}
+	// Stable: equal scores keep name order from Load.
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > topN {
+		matches = matches[:topN]
+	}
+	return matches, nil
+}
+
+// score is the BM25 score of a document for the query terms. Caller holds
+// the lock.
+func (r *Ranker) score(document *document, queryTerms []string) float64 {
+	var score float64
+	documentCount := float64(len(r.documents))
+	lengthNorm := 1 - bm25B + bm25B*float64(document.length)/r.averageLength
+	for _, term := range queryTerms {
+		frequency := float64(document.termToFrequency[term])
+		if frequency == 0 {
+			continue
+		}
+		documentFrequency := float64(r.termToDocumentCount[term])
+		// The "+1" variant of IDF stays positive even for a term present in
+		// every lore, so a lone common term still ranks.
+		idf := math.Log(1 + (documentCount-documentFrequency+0.5)/(documentFrequency+0.5))
+		score += idf * frequency * (bm25K1 + 1) / (frequency + bm25K1*lengthNorm)
+	}
+	return score
+}
+
+// refresh rebuilds the documents when the libraries' files changed since
+// the last build. Caller holds the lock.
+func (r *Ranker) refresh() error {
+	pathToModTime, err := r.fingerprint()
+	if err != nil {
+		return err
+	}
+	if r.pathToModTime != nil && sameModTimes(pathToModTime, r.pathToModTime) {
+		return nil
+	}
+	var lores []*sgptpb.Lore
+	for _, library := range r.index.Libraries() {
+		libraryLores, err := library.Load()
+		if err != nil {
+			return err
+		}
+		lores = append(lores, libraryLores...)
+	}
+	r.build(lores)
+	r.pathToModTime = pathToModTime
+	return nil
+}
+
+// fingerprint stats every lore file of every library. Caller holds the lock.
+func (r *Ranker) fingerprint() (map[string]time.Time, error) {
+	pathToModTime := map[string]time.Time{}
+	for _, library := range r.index.Libraries() {
+		err := filepath.WalkDir(library.Dir(), func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), Extension) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			pathToModTime[path] = info.ModTime()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pathToModTime, nil
+}
+
+// build indexes lores as weighted documents. Caller holds the lock.
+func (r *Ranker) build(lores []*sgptpb.Lore) {
+	r.documents = make([]*document, 0, len(lores))
+	r.termToDocumentCount = map[string]int{}
+	totalLength := 0
+	for _, lore := range lores {
+		document := &document{lore: lore, termToFrequency: map[string]int{}}
+		add := func(text string, weight int) {
+			for _, term := range Tokenize(text) {
+				document.termToFrequency[term] += weight
+				document.length += weight
+			}
+		}
+		add(lore.GetTitle(), titleWeight)
+		add(lore.GetDescription(), descriptionWeight)
+		for key, value := range lore.GetLabels() {
+			add(key+" "+value, labelWeight)
+		}
+		add(lore.GetContent(), contentWeight)
+		for term := range document.termToFrequency {
+			r.termToDocumentCount[term]++
+		}
+		totalLength += document.length
+		r.documents = append(r.documents, document)
+	}
+	r.averageLength = 1
+	if len(r.documents) > 0 && totalLength > 0 {
+		r.averageLength = float64(totalLength) / float64(len(r.documents))
+	}
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, modTime := range a {
+		if other, ok := b[path]; !ok || !other.Equal(modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueTerms drops repeated terms, keeping first-occurrence order: a query
+// repeating a word does not weigh it twice.
+func uniqueTerms(terms []string) []string {
+	seen := map[string]bool{}
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+func containsAny(terms, wanted []string) bool {
+	for _, term := range terms {
+		for _, want := range wanted {
+			if term == want {
+				return true
+			}
+		}
+	}
+	return false
+}
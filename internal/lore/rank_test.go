@@ -0,0 +1,73 @@
+package lore
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/malonaz/sgpt/internal/repo"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("How do I wrap Go errors? Use fmt.Errorf(\"%w\") — x")
+	want := []string{"wrap", "go", "errors", "use", "fmt", "errorf"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestRankerSearch(t *testing.T) {
+	library := Library{Root: t.TempDir()}
+	index := NewIndex(library.Root, repo.NewImports(nil))
+	writeLore(t, library, "go/errors", "---\ntitle: Go errors\ndescription: Wrapping conventions\nlabels:\n  language: go\n---\n\nWrap errors with fmt.Errorf.\nNever discard them.\n")
+	writeLore(t, library, "go/tests", "---\ntitle: Go tests\nlabels:\n  language: go\n---\n\nTable tests; compare errors with errors.Is.\n")
+	writeLore(t, library, "python/style", "---\ntitle: Python style\nlabels:\n  language: python\n---\n\nFormat with black.\n")
+	ranker := NewRanker(index)
+
+	matches, err := ranker.Search("how should I wrap errors?", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, match := range matches {
+		names = append(names, match.Lore.GetName())
+	}
+	// The title hit outranks the passing mention; the python lore shares no
+	// term and never matches.
+	if want := []string{"lores/go/errors", "lores/go/tests"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("ranked %q, want %q", names, want)
+	}
+	if matches[0].MatchCount != 1 || matches[0].Snippets[0] != "Wrap errors with fmt.Errorf." {
+		t.Errorf("snippets = %q (%d)", matches[0].Snippets, matches[0].MatchCount)
+	}
+
+	// A modified file is re-indexed on the next search.
+	writeLore(t, library, "python/style", "---\ntitle: Python errors\n---\n\nRaise, never wrap, errors.\n")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(library.Path("python/style"), future, future); err != nil {
+		t.Fatal(err)
+	}
+	matches, err = ranker.Search("wrap errors", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("topN=1 returned %d matches", len(matches))
+	}
+	matches, err = ranker.Search("python", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Lore.GetTitle() != "Python errors" {
+		t.Errorf("stale index after modification: %v", matches)
+	}
+
+	// So is a removed one.
+	if err := library.Delete("python/style"); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ = ranker.Search("python", 0); len(matches) != 0 {
+		t.Errorf("deleted lore still ranked: %v", matches[0].Lore.GetName())
+	}
+}
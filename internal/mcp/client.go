@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+// Transport carries JSON-RPC messages between a client and one server.
+type Transport interface {
+	// Call sends a request and waits for its response.
+	Call(ctx context.Context, request *Message) (*Message, error)
+	// Notify sends a notification; there is no response.
+	Notify(ctx context.Context, notification *Message) error
+	// Close releases the connection (and terminates a subprocess server).
+	Close() error
+}
+
+// Client is a connected, initialized MCP client. Safe for concurrent use.
+type Client struct {
+	transport Transport
+	nextID    atomic.Int64
+
+	// ServerInfo names the server, as it introduced itself.
+	ServerInfo Implementation
+	// Instructions are the server's optional usage notes for the model.
+	Instructions string
+}
+
+// Connect performs the initialize handshake over the transport. The
+// transport is closed when the handshake fails.
+func Connect(ctx context.Context, transport Transport) (*Client, error) {
+	client := &Client{transport: transport}
+	result := &initializeResult{}
+	err := client.request(ctx, "initialize", &initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "sgpt"},
+	}, result)
+	if err == nil {
+		err = transport.Notify(ctx, &Message{JSONRPC: "2.0", Method: "notifications/initialized"})
+	}
+	if err != nil {
+		transport.Close()
+		return nil, fmt.Errorf("initializing MCP session: %w", err)
+	}
+	if httpTransport, ok := transport.(*httpTransport); ok {
+		httpTransport.setProtocolVersion(result.ProtocolVersion)
+	}
+	client.ServerInfo = result.ServerInfo
+	client.Instructions = result.Instructions
+	return client, nil
+}
+
+// ListTools lists every tool of the server, following pagination.
+func (c *Client) ListTools(ctx context.Context) ([]*Tool, error) {
+	var tools []*Tool
+	params := &listToolsParams{}
+	for {
+		result := &listToolsResult{}
+		if err := c.request(ctx, "tools/list", params, result); err != nil {
+			return nil, fmt.Errorf("listing tools: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		params.Cursor = result.NextCursor
+	}
+}
+
+// CallTool invokes a tool with its JSON arguments object.
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	result := &CallToolResult{}
+	if err := c.request(ctx, "tools/call", &callToolParams{Name: name, Arguments: arguments}, result); err != nil {
+		return nil, fmt.Errorf("calling tool %q: %w", name, err)
+	}
+	return result, nil
+}
+
+// Ping checks the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	return c.request(ctx, "ping", struct{}{}, nil)
+}
+
+// Exited reports whether the server is gone for good: a subprocess server
+// that exited. Every further call would fail with its exit reason. An HTTP
+// server never exits: each request reaches it anew.
+func (c *Client) Exited() bool {
+	return TransportExited(c.transport)
+}
+
+// TransportExited reports whether a transport's subprocess exited; always
+// false for transports without one.
+func TransportExited(transport Transport) bool {
+	stdioTransport, ok := transport.(*stdioTransport)
+	if !ok {
+		return false
+	}
+	select {
+	case <-stdioTransport.waitDone:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close ends the session.
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+// request sends one request and decodes its result into result (skipped
+// when nil). JSON-RPC errors are returned as *Error.
+func (c *Client) request(ctx context.Context, method string, params, result any) error {
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshaling %s params: %w", method, err)
+	}
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	response, err := c.transport.Call(ctx, &Message{JSONRPC: "2.0", ID: id, Method: method, Params: paramsBytes})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("parsing %s result: %w", method, err)
+	}
+	return nil
+}
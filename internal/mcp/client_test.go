@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubServerEnv turns the test binary into a stdio stub server.
+const stubServerEnv = "SGPT_MCP_STUB_SERVER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) != "" {
+		serveStubStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stubHandle answers one request like a minimal MCP server with two tools:
+// a read-only "echo" and a failing "fail". Tools are listed one per page, to
+// exercise pagination.
+func stubHandle(request *Message) *Message {
+	response := &Message{JSONRPC: "2.0", ID: request.ID}
+	var result any
+	switch request.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "stub", "version": "1"},
+			"instructions":    "Be nice.",
+		}
+	case "ping":
+		result = map[string]any{}
+	case "tools/list":
+		params := &listToolsParams{}
+		json.Unmarshal(request.Params, params)
+		if params.Cursor == "" {
+			result = map[string]any{
+				"tools": []map[string]any{{
+					"name":        "echo",
+					"description": "Echoes its text.",
+					"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"text": map[string]any{"type": "string"}}},
+					"annotations": map[string]any{"readOnlyHint": true},
+				}},
+				"nextCursor": "page2",
+			}
+		} else {
+			result = map[string]any{"tools": []map[string]any{{"name": "fail", "inputSchema": map[string]any{"type": "object"}}}}
+		}
+	case "tools/call":
+		params := &callToolParams{}
+		json.Unmarshal(request.Params, params)
+		arguments := map[string]string{}
+		json.Unmarshal(params.Arguments, &arguments)
+		switch params.Name {
+		case "echo":
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": arguments["text"]}}}
+		case "fail":
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "it broke"}}, "isError": true}
+		default:
+			response.Error = &Error{Code: CodeInvalidParams, Message: "unknown tool " + params.Name}
+			return response
+		}
+	default:
+		response.Error = &Error{Code: CodeMethodNotFound, Message: "unknown method " + request.Method}
+		return response
+	}
+	response.Result, _ = json.Marshal(result)
+	return response
+}
+
+func serveStubStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		request := &Message{}
+		if json.Unmarshal(scanner.Bytes(), request) != nil || !request.IsRequest() {
+			continue
+		}
+		bytes, _ := json.Marshal(stubHandle(request))
+		fmt.Println(string(bytes))
+	}
+}
+
+// serveStubHTTP answers tools/call over an event stream and everything else
+// as plain JSON, assigning a session on initialize.
+func serveStubHTTP(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			return
+		}
+		request := &Message{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Method != "initialize" && r.Header.Get(sessionIDHeader) != "session-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		if !request.IsRequest() {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		bytes, _ := json.Marshal(stubHandle(request))
+		if request.Method == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", bytes)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(sessionIDHeader, "session-1")
+		w.Write(bytes)
+	}))
+}
+
+func exerciseClient(t *testing.T, transport Transport) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if client.ServerInfo.Name != "stub" || client.Instructions != "Be nice." {
+		t.Errorf("server info = %+v, instructions = %q", client.ServerInfo, client.Instructions)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 2 || tools[0].Name != "echo" || tools[1].Name != "fail" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	if !tools[0].ReadOnly() || tools[1].ReadOnly() {
+		t.Errorf("read-only hints: echo=%v fail=%v", tools[0].ReadOnly(), tools[1].ReadOnly())
+	}
+
+	result, err := client.CallTool(ctx, "echo", json.RawMessage(`{"text":"hello"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || result.Text() != "hello" {
+		t.Errorf("echo result = %+v", result)
+	}
+	result, err = client.CallTool(ctx, "fail", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || result.Text() != "it broke" {
+		t.Errorf("fail result = %+v", result)
+	}
+	_, err = client.CallTool(ctx, "missing", nil)
+	if rpcErr, ok := err.(interface{ Unwrap() error }); !ok || !strings.Contains(rpcErr.Unwrap().Error(), "unknown tool missing") {
+		t.Errorf("calling an unknown tool: err = %v", err)
+	}
+}
+
+func TestStdioClient(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := NewStdioTransport(executable, nil, map[string]string{stubServerEnv: "1"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exerciseClient(t, transport)
+}
+
+func TestStdioServerExit(t *testing.T) {
+	transport, err := NewStdioTransport("sh", []string{"-c", "echo boom >&2; exit 1"}, nil, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := Connect(ctx, transport); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("connecting to a dead server: err = %v, want its stderr", err)
+	}
+}
+
+func TestStdioClientExited(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := NewStdioTransport(executable, nil, map[string]string{stubServerEnv: "1"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := Connect(ctx, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Exited() {
+		t.Fatal("Exited() = true for a running server")
+	}
+	client.Close()
+	if !client.Exited() {
+		t.Error("Exited() = false once the server is gone")
+	}
+	if (&Client{transport: NewHTTPTransport("http://localhost", nil)}).Exited() {
+		t.Error("Exited() = true for an HTTP server")
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := serveStubHTTP(t)
+	defer server.Close()
+	exerciseClient(t, NewHTTPTransport(server.URL, map[string]string{"Authorization": "Bearer x"}))
+}
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	sessionIDHeader       = "Mcp-Session-Id"
+	protocolVersionHeader = "MCP-Protocol-Version"
+	// errorBodyLimit caps how much of a failed response's body is quoted.
+	errorBodyLimit = 512
+)
+
+// httpTransport speaks the streamable HTTP transport: every message is a
+// POST to one endpoint, answered with either a JSON body or a server-sent
+// event stream carrying the response.
+type httpTransport struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu sync.Mutex
+	// sessionID is assigned by the server on initialize, when it keeps
+	// sessions; every later request must carry it.
+	sessionID       string
+	protocolVersion string
+}
+
+// NewHTTPTransport returns a transport to the server at url, sending
+// headers (e.g. authorization) with every request.
+func NewHTTPTransport(url string, headers map[string]string) Transport {
+	return &httpTransport{url: url, headers: headers, client: &http.Client{}}
+}
+
+func (t *httpTransport) setProtocolVersion(protocolVersion string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.protocolVersion = protocolVersion
+}
+
+func (t *httpTransport) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(ctx, method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json, text/event-stream")
+	for key, value := range t.headers {
+		request.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		request.Header.Set(sessionIDHeader, t.sessionID)
+	}
+	if t.protocolVersion != "" {
+		request.Header.Set(protocolVersionHeader, t.protocolVersion)
+	}
+	return request, nil
+}
+
+// post sends one message, returning the response for the caller to read
+// and close.
+func (t *httpTransport) post(ctx context.Context, message *Message) (*http.Response, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	request, err := t.newRequest(ctx, http.MethodPost, body)
+	if err != nil {
+		return nil, err
+	}
+	response, err := t.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		defer response.Body.Close()
+		errorBody, _ := io.ReadAll(io.LimitReader(response.Body, errorBodyLimit))
+		if response.StatusCode == http.StatusNotFound && request.Header.Get(sessionIDHeader) != "" {
+			return nil, fmt.Errorf("MCP session expired (HTTP 404)")
+		}
+		return nil, fmt.Errorf("MCP server returned %s: %s", response.Status, strings.TrimSpace(string(errorBody)))
+	}
+	if sessionID := response.Header.Get(sessionIDHeader); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+	return response, nil
+}
+
+func (t *httpTransport) Call(ctx context.Context, request *Message) (*Message, error) {
+	response, err := t.post(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readEventStream(response.Body, request.ID)
+	}
+	message := &Message{}
+	if err := json.NewDecoder(response.Body).Decode(message); err != nil {
+		return nil, fmt.Errorf("parsing MCP response: %w", err)
+	}
+	return message, nil
+}
+
+func (t *httpTransport) Notify(ctx context.Context, notification *Message) error {
+	response, err := t.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, response.Body)
+	return response.Body.Close()
+}
+
+// Close ends the server-side session, if any; best effort.
+func (t *httpTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), closeGracePeriod)
+	defer cancel()
+	request, err := t.newRequest(ctx, http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	response, err := t.client.Do(request)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// readEventStream reads server-sent events until the response to the
+// request with the given ID. Other messages on the stream (progress
+// notifications, server requests) are skipped.
+func readEventStream(body io.Reader, id json.RawMessage) (*Message, error) {
+	reader := bufio.NewReader(body)
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteString("\n")
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// A blank line ends an event, as does the end of the stream.
+		if (line == "" || err != nil) && data.Len() > 0 {
+			message := &Message{}
+			if json.Unmarshal([]byte(data.String()), message) == nil && message.IsResponse() && sameID(message.ID, id) {
+				return message, nil
+			}
+			data.Reset()
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("MCP event stream ended without a response")
+			}
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package mcp implements the tools subset of the Model Context Protocol
+// (tools/list, tools/call): the JSON-RPC 2.0 messages, a client over the
+// stdio and streamable-HTTP transports, and a stdio server. Only the wire
+// format lives here; mapping MCP tools onto sgpt's tool registry, both ways,
+// is internal/tool/mcp's job.
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision sgpt speaks.
+const ProtocolVersion = "2025-06-18"
+
+// JSON-RPC error codes used by sgpt.
+const (
+	CodeParseError     = -32700
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Message is one JSON-RPC 2.0 message: a request (ID and Method), a
+// notification (Method only) or a response (ID and Result or Error).
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// IsRequest reports whether the message expects a response.
+func (m *Message) IsRequest() bool {
+	return m.Method != "" && len(m.ID) > 0
+}
+
+// IsResponse reports whether the message answers a request.
+func (m *Message) IsResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// idKey normalizes a JSON-RPC ID for comparison, tolerating whitespace
+// differences in how the peer re-encoded it.
+func idKey(id json.RawMessage) string {
+	return string(bytes.TrimSpace(id))
+}
+
+func sameID(a, b json.RawMessage) bool {
+	return idKey(a) == idKey(b)
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Implementation names a client or server in the initialize handshake.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Tool is a tool definition, as listed by tools/list.
+type Tool struct {
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// InputSchema is the JSON schema of the arguments object.
+	InputSchema json.RawMessage  `json:"inputSchema"`
+	Annotations *ToolAnnotations `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations are the server's hints about a tool's behavior. Hints,
+// not guarantees: they come from the server, which is trusted as far as the
+// user trusts it by configuring it.
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`
+}
+
+// ReadOnly reports whether the tool declares it does not modify its
+// environment. Unset means it may.
+func (t *Tool) ReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint != nil && *t.Annotations.ReadOnlyHint
+}
+
+// Content is one block of a tool result. Text blocks carry Text; image and
+// audio blocks carry base64 Data with its MimeType; resource blocks carry
+// the embedded Resource.
+type Content struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Data     string          `json:"data,omitempty"`
+	MimeType string          `json:"mimeType,omitempty"`
+	Resource json.RawMessage `json:"resource,omitempty"`
+}
+
+// CallToolResult is the result of tools/call. A tool failure is a result
+// with IsError set, not a JSON-RPC error: the model is meant to read it.
+type CallToolResult struct {
+	Content           []*Content      `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Text joins the result's text blocks, with a placeholder for each block
+// that is not text.
+func (r *CallToolResult) Text() string {
+	var b bytes.Buffer
+	for i, content := range r.Content {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch content.Type {
+		case "text":
+			b.WriteString(content.Text)
+		case "image", "audio":
+			fmt.Fprintf(&b, "[%s content (%s) omitted]", content.Type, content.MimeType)
+		default:
+			fmt.Fprintf(&b, "[%s content omitted]", content.Type)
+		}
+	}
+	return b.String()
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type listToolsResult struct {
+	Tools      []*Tool `json:"tools"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
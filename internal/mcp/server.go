@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ToolHandler provides the tools a Server exposes.
+type ToolHandler interface {
+	// ListTools returns the exposed tools.
+	ListTools(ctx context.Context) ([]*Tool, error)
+	// CallTool runs a tool. A tool failure is a result with IsError set; a
+	// returned *Error (e.g. an unknown tool) is sent as a JSON-RPC error.
+	CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error)
+}
+
+// Server serves a ToolHandler's tools over the stdio transport.
+type Server struct {
+	info         Implementation
+	instructions string
+	handler      ToolHandler
+}
+
+// NewServer returns a server introducing itself as info, with optional
+// usage instructions for the client's model.
+func NewServer(info Implementation, instructions string, handler ToolHandler) *Server {
+	return &Server{info: info, instructions: instructions, handler: handler}
+}
+
+// Serve answers the newline-delimited JSON-RPC messages read from r on w,
+// until r ends. Requests are served concurrently; one the client cancels
+// (notifications/cancelled) gets no response.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var writeMu sync.Mutex
+	write := func(message *Message) {
+		bytes, err := json.Marshal(message)
+		if err != nil {
+			return
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		w.Write(append(bytes, '\n'))
+	}
+
+	var mu sync.Mutex
+	// idToCancel cancels in-flight requests, keyed by their raw ID.
+	idToCancel := map[string]context.CancelFunc{}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			message := &Message{}
+			switch {
+			case json.Unmarshal(line, message) != nil:
+				write(&Message{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: "invalid JSON"}})
+			case message.IsRequest():
+				key := idKey(message.ID)
+				requestCtx, requestCancel := context.WithCancel(ctx)
+				mu.Lock()
+				idToCancel[key] = requestCancel
+				mu.Unlock()
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					response := s.respond(requestCtx, message)
+					mu.Lock()
+					delete(idToCancel, key)
+					mu.Unlock()
+					if requestCtx.Err() == nil {
+						write(response)
+					}
+					requestCancel()
+				}()
+			case message.Method == "notifications/cancelled":
+				params := &cancelledParams{}
+				if json.Unmarshal(message.Params, params) == nil {
+					mu.Lock()
+					if requestCancel, ok := idToCancel[idKey(params.RequestID)]; ok {
+						requestCancel()
+					}
+					mu.Unlock()
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// respond computes the response to one request.
+func (s *Server) respond(ctx context.Context, request *Message) *Message {
+	response := &Message{JSONRPC: "2.0", ID: request.ID}
+	result, err := s.dispatch(ctx, request)
+	if err == nil {
+		response.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		rpcErr := &Error{}
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: CodeInternalError, Message: err.Error()}
+		}
+		response.Result = nil
+		response.Error = rpcErr
+	}
+	return response
+}
+
+func (s *Server) dispatch(ctx context.Context, request *Message) (any, error) {
+	switch request.Method {
+	case "initialize":
+		params := &initializeParams{}
+		if err := json.Unmarshal(request.Params, params); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("parsing initialize params: %v", err)}
+		}
+		// A single revision is spoken: the client decides whether it can
+		// live with it.
+		return &initializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      s.info,
+			Instructions:    s.instructions,
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		tools, err := s.handler.ListTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &listToolsResult{Tools: tools}, nil
+	case "tools/call":
+		params := &callToolParams{}
+		if err := json.Unmarshal(request.Params, params); err != nil || params.Name == "" {
+			return nil, &Error{Code: CodeInvalidParams, Message: "tools/call wants a tool name"}
+		}
+		return s.handler.CallTool(ctx, params.Name, params.Arguments)
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: "method not supported: " + request.Method}
+	}
+}
+
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+// stubToolHandler exposes "echo" and "wait", which blocks until cancelled.
+type stubToolHandler struct{}
+
+func (stubToolHandler) ListTools(context.Context) ([]*Tool, error) {
+	return []*Tool{
+		{Name: "echo", InputSchema: json.RawMessage(`{"type":"object"}`)},
+		{Name: "wait", InputSchema: json.RawMessage(`{"type":"object"}`)},
+	}, nil
+}
+
+func (stubToolHandler) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*CallToolResult, error) {
+	switch name {
+	case "echo":
+		return &CallToolResult{Content: []*Content{{Type: "text", Text: string(arguments)}}}, nil
+	case "wait":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: "unknown tool " + name}
+	}
+}
+
+func TestServer(t *testing.T) {
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+	server := NewServer(Implementation{Name: "sgpt"}, "Be nice.", stubToolHandler{})
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(context.Background(), requestReader, responseWriter)
+		responseWriter.Close()
+	}()
+
+	responses := bufio.NewScanner(responseReader)
+	send := func(line string) {
+		t.Helper()
+		if _, err := io.WriteString(requestWriter, line+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive := func() *Message {
+		t.Helper()
+		if !responses.Scan() {
+			t.Fatalf("no response: %v", responses.Err())
+		}
+		message := &Message{}
+		if err := json.Unmarshal(responses.Bytes(), message); err != nil {
+			t.Fatal(err)
+		}
+		return message
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test"}}}`)
+	result := &initializeResult{}
+	if response := receive(); response.Error != nil || json.Unmarshal(response.Result, result) != nil {
+		t.Fatalf("initialize response = %+v", response)
+	}
+	if result.ServerInfo.Name != "sgpt" || result.Instructions != "Be nice." || result.Capabilities["tools"] == nil {
+		t.Errorf("initialize result = %+v", result)
+	}
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	listResult := &listToolsResult{}
+	if response := receive(); json.Unmarshal(response.Result, listResult) != nil || len(listResult.Tools) != 2 {
+		t.Fatalf("tools/list response = %+v", response)
+	}
+
+	send(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"echo","arguments":{"a":1}}}`)
+	callResult := &CallToolResult{}
+	response := receive()
+	if string(response.ID) != `"call"` || json.Unmarshal(response.Result, callResult) != nil || callResult.Text() != `{"a":1}` {
+		t.Errorf("tools/call response = %+v", response)
+	}
+
+	send(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"missing"}}`)
+	if response := receive(); response.Error == nil || response.Error.Code != CodeInvalidParams {
+		t.Errorf("unknown tool response = %+v", response)
+	}
+	send(`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`)
+	if response := receive(); response.Error == nil || response.Error.Code != CodeMethodNotFound {
+		t.Errorf("unknown method response = %+v", response)
+	}
+	send(`not json`)
+	if response := receive(); response.Error == nil || response.Error.Code != CodeParseError {
+		t.Errorf("invalid JSON response = %+v", response)
+	}
+
+	// A cancelled call is never answered: the next response is the ping's.
+	send(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"wait"}}`)
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":5}}`)
+	send(`{"jsonrpc":"2.0","id":6,"method":"ping"}`)
+	if response := receive(); string(response.ID) != "6" || response.Error != nil {
+		t.Errorf("ping response = %+v", response)
+	}
+
+	requestWriter.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Serve() = %v", err)
+	}
+	if responses.Scan() {
+		t.Errorf("unexpected response after the input ended: %s", responses.Text())
+	}
+}
@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// stderrTailSize is how much of a server's stderr is kept to explain
+	// its death.
+	stderrTailSize = 4096
+	// closeGracePeriod is how long a server gets to exit once its stdin is
+	// closed, before it is killed.
+	closeGracePeriod = 2 * time.Second
+	// exitReasonWait is how long a failed write waits for the server to be
+	// reaped: a broken pipe means it is dying, and its stderr says why.
+	exitReasonWait = time.Second
+)
+
+// stdioTransport speaks to a subprocess server: newline-delimited JSON-RPC
+// over its stdin and stdout.
+type stdioTransport struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *tailBuffer
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	// idToResponseCh routes responses to the in-flight Call awaiting them,
+	// keyed by the request's raw ID.
+	idToResponseCh map[string]chan *Message
+	// readerDone is closed when the server's stdout ends.
+	readerDone chan struct{}
+	// waitDone is closed once the process has been reaped; exitErr then
+	// says why it ended.
+	waitDone chan struct{}
+	exitErr  error
+}
+
+// NewStdioTransport launches command as a server subprocess in dir, with
+// env set on top of the current environment. Nothing here is MCP-specific:
+// plugins speak their own protocol over it.
+func NewStdioTransport(command string, args []string, env map[string]string, dir string) (Transport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		cmd:            cmd,
+		stdin:          stdin,
+		stderr:         &tailBuffer{},
+		idToResponseCh: map[string]chan *Message{},
+		readerDone:     make(chan struct{}),
+		waitDone:       make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", command, err)
+	}
+	go t.read(stdout)
+	go func() {
+		// Reaped only after stdout drained: Wait closes the pipes. Wait
+		// also flushes stderr, so the exit reason is complete.
+		<-t.readerDone
+		err := cmd.Wait()
+		t.exitErr = fmt.Errorf("%s exited: %v", filepath.Base(command), err)
+		if tail := strings.TrimSpace(t.stderr.String()); tail != "" {
+			t.exitErr = fmt.Errorf("%s exited: %s", filepath.Base(command), tail)
+		}
+		close(t.waitDone)
+	}()
+	return t, nil
+}
+
+// read dispatches the server's messages until its stdout ends.
+func (t *stdioTransport) read(stdout io.Reader) {
+	defer close(t.readerDone)
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			message := &Message{}
+			if json.Unmarshal(line, message) == nil {
+				t.dispatch(message)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (t *stdioTransport) dispatch(message *Message) {
+	switch {
+	case message.IsResponse():
+		key := idKey(message.ID)
+		t.mu.Lock()
+		responseCh, ok := t.idToResponseCh[key]
+		delete(t.idToResponseCh, key)
+		t.mu.Unlock()
+		if ok {
+			responseCh <- message
+		}
+	case message.IsRequest():
+		// sgpt declares no client capabilities: only pings are answered.
+		response := &Message{JSONRPC: "2.0", ID: message.ID, Result: json.RawMessage("{}")}
+		if message.Method != "ping" {
+			response = &Message{JSONRPC: "2.0", ID: message.ID, Error: &Error{Code: CodeMethodNotFound, Message: "method not supported: " + message.Method}}
+		}
+		t.write(response)
+	}
+}
+
+func (t *stdioTransport) write(message *Message) error {
+	bytes, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.stdin.Write(append(bytes, '\n'))
+	return err
+}
+
+func (t *stdioTransport) Call(ctx context.Context, request *Message) (*Message, error) {
+	// Buffered: the reader never blocks on a caller that gave up.
+	responseCh := make(chan *Message, 1)
+	key := idKey(request.ID)
+	t.mu.Lock()
+	t.idToResponseCh[key] = responseCh
+	t.mu.Unlock()
+	forget := func() {
+		t.mu.Lock()
+		delete(t.idToResponseCh, key)
+		t.mu.Unlock()
+	}
+
+	if err := t.write(request); err != nil {
+		forget()
+		return nil, t.deadOr(err)
+	}
+	select {
+	case response := <-responseCh:
+		return response, nil
+	case <-t.waitDone:
+		forget()
+		return nil, t.exitErr
+	case <-ctx.Done():
+		forget()
+		// Tell the server to stop working on it; best effort.
+		params, _ := json.Marshal(map[string]any{"requestId": request.ID, "reason": ctx.Err().Error()})
+		t.write(&Message{JSONRPC: "2.0", Method: "notifications/cancelled", Params: params})
+		return nil, ctx.Err()
+	}
+}
+
+func (t *stdioTransport) Notify(_ context.Context, notification *Message) error {
+	if err := t.write(notification); err != nil {
+		return t.deadOr(err)
+	}
+	return nil
+}
+
+// deadOr prefers the server's exit reason over the symptom it caused.
+func (t *stdioTransport) deadOr(err error) error {
+	select {
+	case <-t.waitDone:
+		return t.exitErr
+	case <-time.After(exitReasonWait):
+		return err
+	}
+}
+
+// Close closes the server's stdin — the protocol's shutdown signal — and
+// kills it if it outlives the grace period.
+func (t *stdioTransport) Close() error {
+	t.stdin.Close()
+	select {
+	case <-t.waitDone:
+	case <-time.After(closeGracePeriod):
+		t.cmd.Process.Kill()
+		<-t.waitDone
+	}
+	return nil
+}
+
+// tailBuffer keeps the last stderrTailSize bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > stderrTailSize {
+		b.data = b.data[len(b.data)-stderrTailSize:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
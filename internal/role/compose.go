@@ -0,0 +1,262 @@
+package role
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/pkg/errors"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// Piece is one value of an expanded role and the role it came from.
+type Piece struct {
+	Value  string
+	Source string
+}
+
+// Section is a part of an expanded role's prompt. Unnamed sections are
+// roles' prompts before their first @section; they are never replaced.
+type Section struct {
+	Name    string
+	Content string
+	Source  string
+	// Replaced lists the roles whose same-named section this one replaced,
+	// innermost first.
+	Replaced []string
+}
+
+// Parameter is a parameter of an expanded role and the role declaring it.
+type Parameter struct {
+	*sgptpb.Role_Parameter
+	Source string
+}
+
+// Composition is a fully expanded role, with where every piece came from.
+type Composition struct {
+	Name  string
+	Alias string
+	// Includes lists the included roles, depth-first, each once.
+	Includes []string
+	Model    Piece
+	// ModelOverridden is set when Model comes from an @override_model. The
+	// outermost role's own @model or @override_model always wins; among
+	// included roles, an @override_model beats a plain @model.
+	ModelOverridden bool
+	// FallbackModels is the outermost role's @fallback_model chain.
+	FallbackModels []Piece
+	Tools          []Piece
+	Files          []Piece
+	// ExcludedTools and ExcludedFiles are what exclusions removed; their
+	// source is the excluding role.
+	ExcludedTools []Piece
+	ExcludedFiles []Piece
+	Sections      []*Section
+	Parameters    []*Parameter
+}
+
+// Role flattens the composition into the role it expands to.
+func (c *Composition) Role() *sgptpb.Role {
+	role := &sgptpb.Role{Name: c.Name, Alias: c.Alias, Model: c.Model.Value}
+	var prompts []string
+	for _, section := range c.Sections {
+		if section.Content != "" {
+			prompts = append(prompts, section.Content)
+		}
+	}
+	role.Prompt = strings.Join(prompts, "\n\n")
+	for _, fallbackModel := range c.FallbackModels {
+		role.FallbackModels = append(role.FallbackModels, fallbackModel.Value)
+	}
+	for _, tool := range c.Tools {
+		role.Tools = append(role.Tools, tool.Value)
+	}
+	for _, file := range c.Files {
+		role.Files = append(role.Files, file.Value)
+	}
+	return role
+}
+
+// Explain expands the named role without rendering its templates, for
+// inspection.
+func (o *Opts) Explain(name string) (*Composition, error) {
+	return o.compose(name, map[string]bool{}, nil)
+}
+
+// compose resolves a role and merges its included roles depth-first:
+//   - prompts: included roles' sections come first, so the outermost role has
+//     the last word; a named section replaces the same-named one in place.
+//   - tools and files: unioned, minus the role's own exclusions, which apply
+//     to what its included roles brought.
+//   - model: the role's own @override_model or @model, so the including role
+//     has the last word; failing both, its included roles': an
+//     @override_model among them beats a plain @model, which otherwise the
+//     first included role with one provides.
+//   - fallback models: the outermost role's chain, as a whole; chains are
+//     never concatenated.
+//
+// visitedNameSet both breaks inclusion cycles and dedupes diamond includes:
+// a role already visited composes to nil. The prompts of a role declaring or
+// including parameters are rendered as templates with data; other roles'
+// prompts, and all of them when data is nil, are left raw.
+func (o *Opts) compose(name string, visitedNameSet map[string]bool, data *RoleTemplateData) (*Composition, error) {
+	role, ok := o.roleNameToRole[name]
+	if !ok {
+		return nil, errors.Errorf("unknown role (%s)", name)
+	}
+	if visitedNameSet[role.Name] {
+		return nil, nil
+	}
+	visitedNameSet[role.Name] = true
+
+	composition := &Composition{Name: role.Name, Alias: role.Alias}
+	for _, includedName := range role.GetRoles() {
+		included, err := o.compose(includedName, visitedNameSet, data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "expanding role (%s)", name)
+		}
+		if included != nil {
+			composition.merge(included)
+		}
+	}
+
+	for _, toolName := range role.GetExcludedTools() {
+		composition.Tools, composition.ExcludedTools = exclude(composition.Tools, composition.ExcludedTools, toolName, role.Name)
+	}
+	for _, filePath := range role.GetExcludedFiles() {
+		composition.Files, composition.ExcludedFiles = exclude(composition.Files, composition.ExcludedFiles, filePath, role.Name)
+	}
+	for _, toolName := range role.GetTools() {
+		composition.Tools = addPiece(composition.Tools, Piece{Value: toolName, Source: role.Name})
+	}
+	for _, filePath := range role.GetFiles() {
+		composition.Files = addPiece(composition.Files, Piece{Value: filePath, Source: role.Name})
+	}
+
+	for _, parameter := range role.GetParameters() {
+		// The including role's declaration wins.
+		composition.Parameters = slices.DeleteFunc(composition.Parameters, func(existing *Parameter) bool {
+			return existing.GetName() == parameter.GetName()
+		})
+		composition.Parameters = append(composition.Parameters, &Parameter{Role_Parameter: parameter, Source: role.Name})
+	}
+
+	// Templating is opt-in: a role without parameters may quote "{{" freely.
+	promptData := data
+	if len(composition.Parameters) == 0 {
+		promptData = nil
+	}
+	prompt, err := renderPrompt(role.Name, role.GetPrompt(), promptData)
+	if err != nil {
+		return nil, err
+	}
+	composition.addSection(&Section{Content: prompt, Source: role.Name})
+	for _, section := range role.GetSections() {
+		content, err := renderPrompt(role.Name+"#"+section.GetName(), section.GetContent(), promptData)
+		if err != nil {
+			return nil, err
+		}
+		composition.addSection(&Section{Name: section.GetName(), Content: content, Source: role.Name})
+	}
+
+	switch {
+	case role.GetOverrideModel() != "":
+		composition.Model = Piece{Value: role.GetOverrideModel(), Source: role.Name}
+		composition.ModelOverridden = true
+	case role.GetModel() != "":
+		composition.Model = Piece{Value: role.GetModel(), Source: role.Name}
+		composition.ModelOverridden = false
+	}
+	if len(role.GetFallbackModels()) > 0 {
+		composition.FallbackModels = nil
+		for _, fallbackModel := range role.GetFallbackModels() {
+			composition.FallbackModels = append(composition.FallbackModels, Piece{Value: fallbackModel, Source: role.Name})
+		}
+	}
+	return composition, nil
+}
+
+// merge folds an included role's composition into the including one's.
+func (c *Composition) merge(included *Composition) {
+	c.Includes = append(c.Includes, included.Name)
+	c.Includes = append(c.Includes, included.Includes...)
+	for _, tool := range included.Tools {
+		c.Tools = addPiece(c.Tools, tool)
+	}
+	for _, file := range included.Files {
+		c.Files = addPiece(c.Files, file)
+	}
+	c.ExcludedTools = append(c.ExcludedTools, included.ExcludedTools...)
+	c.ExcludedFiles = append(c.ExcludedFiles, included.ExcludedFiles...)
+	for _, section := range included.Sections {
+		c.addSection(section)
+	}
+	for _, parameter := range included.Parameters {
+		if !slices.ContainsFunc(c.Parameters, func(existing *Parameter) bool { return existing.GetName() == parameter.GetName() }) {
+			c.Parameters = append(c.Parameters, parameter)
+		}
+	}
+	// Among included roles, an override beats any plain model; otherwise
+	// the first included role with a model provides it. The including
+	// role's own model, set after merging, beats both.
+	if (included.ModelOverridden && !c.ModelOverridden) || c.Model.Value == "" {
+		c.Model = included.Model
+		c.ModelOverridden = included.ModelOverridden
+	}
+	if len(c.FallbackModels) == 0 {
+		c.FallbackModels = included.FallbackModels
+	}
+}
+
+// addSection appends a section, or replaces the same-named one in place.
+func (c *Composition) addSection(section *Section) {
+	if section.Name == "" {
+		if section.Content != "" {
+			c.Sections = append(c.Sections, section)
+		}
+		return
+	}
+	for i, existing := range c.Sections {
+		if existing.Name == section.Name {
+			replacement := *section
+			replacement.Replaced = append(append(slices.Clone(existing.Replaced), existing.Source), section.Replaced...)
+			c.Sections[i] = &replacement
+			return
+		}
+	}
+	c.Sections = append(c.Sections, section)
+}
+
+func addPiece(pieces []Piece, piece Piece) []Piece {
+	if slices.ContainsFunc(pieces, func(existing Piece) bool { return existing.Value == piece.Value }) {
+		return pieces
+	}
+	return append(pieces, piece)
+}
+
+// exclude removes value from pieces, recording the exclusion.
+func exclude(pieces, excluded []Piece, value, source string) ([]Piece, []Piece) {
+	excluded = append(excluded, Piece{Value: value, Source: source})
+	return slices.DeleteFunc(pieces, func(piece Piece) bool { return piece.Value == value }), excluded
+}
+
+// renderPrompt renders a role's prompt as a text/template with sprig, like
+// the system prompt; a nil data leaves it raw. Referencing an undeclared parameter is an error rather
+// than an empty string.
+func renderPrompt(name, prompt string, data *RoleTemplateData) (string, error) {
+	if data == nil || prompt == "" {
+		return prompt, nil
+	}
+	tmpl, err := template.New(name).Funcs(sprig.FuncMap()).Option("missingkey=error").Parse(prompt)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing role (%s) prompt template", name)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Wrapf(err, "executing role (%s) prompt template", name)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,168 @@
+package role
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+func pieceValues(pieces []Piece) []string {
+	values := make([]string, 0, len(pieces))
+	for _, piece := range pieces {
+		values = append(values, piece.Value)
+	}
+	return values
+}
+
+func TestCompose(t *testing.T) {
+	opts := NewOpts([]*sgptpb.Role{
+		{
+			Name:   "//:base",
+			Model:  "providers/a/models/base",
+			Prompt: "You are helpful.",
+			Sections: []*sgptpb.Role_Section{
+				{Name: "style", Content: "Be verbose."},
+				{Name: "rules", Content: "Never guess."},
+			},
+			Tools:          []string{"exec_shell", "diff"},
+			Files:          []string{"/repo/README.md"},
+			FallbackModels: []string{"providers/b/models/base"},
+		},
+		{
+			Name:           "//:pinned",
+			OverrideModel:  "providers/a/models/pinned",
+			FallbackModels: []string{"providers/b/models/pinned", "providers/c/models/pinned"},
+		},
+		{
+			Name:          "//:reviewer",
+			Roles:         []string{"//:base", "//:pinned", "//:base"},
+			Model:         "providers/a/models/reviewer",
+			Prompt:        "Review code.",
+			Sections:      []*sgptpb.Role_Section{{Name: "style", Content: "Be terse."}},
+			Tools:         []string{"read_files"},
+			ExcludedTools: []string{"exec_shell"},
+		},
+	})
+
+	composition, err := opts.Explain("//:reviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := composition.Includes, []string{"//:base", "//:pinned"}; !slices.Equal(got, want) {
+		t.Errorf("Includes = %v, want %v", got, want)
+	}
+	if got, want := pieceValues(composition.Tools), []string{"diff", "read_files"}; !slices.Equal(got, want) {
+		t.Errorf("Tools = %v, want %v", got, want)
+	}
+	if len(composition.ExcludedTools) != 1 || composition.ExcludedTools[0] != (Piece{Value: "exec_shell", Source: "//:reviewer"}) {
+		t.Errorf("ExcludedTools = %v", composition.ExcludedTools)
+	}
+	// The including role's own model beats what it includes, overrides too.
+	if composition.Model != (Piece{Value: "providers/a/models/reviewer", Source: "//:reviewer"}) || composition.ModelOverridden {
+		t.Errorf("Model = %v (overridden %t)", composition.Model, composition.ModelOverridden)
+	}
+
+	// The first included chain wins, whole, when the including role has none.
+	if got, want := pieceValues(composition.FallbackModels), []string{"providers/b/models/base"}; !slices.Equal(got, want) {
+		t.Errorf("FallbackModels = %v, want %v", got, want)
+	}
+
+	role := composition.Role()
+	if got, want := role.GetFallbackModels(), []string{"providers/b/models/base"}; !slices.Equal(got, want) {
+		t.Errorf("Role().FallbackModels = %v, want %v", got, want)
+	}
+	if want := "You are helpful.\n\nBe terse.\n\nNever guess.\n\nReview code."; role.GetPrompt() != want {
+		t.Errorf("Prompt = %q, want %q", role.GetPrompt(), want)
+	}
+	style := composition.Sections[1]
+	if style.Name != "style" || style.Source != "//:reviewer" || !slices.Equal(style.Replaced, []string{"//:base"}) {
+		t.Errorf("style section = %+v", style)
+	}
+}
+
+func TestComposeRendersTemplates(t *testing.T) {
+	opts := NewOpts([]*sgptpb.Role{{
+		Name:       "//:greeter",
+		Prompt:     "Hello {{ .Username }}.",
+		Sections:   []*sgptpb.Role_Section{{Name: "language", Content: "Answer in {{ .Params.language | upper }}."}},
+		Parameters: []*sgptpb.Role_Parameter{{Name: "language", Required: true}},
+	}})
+	data := &RoleTemplateData{TemplateData: TemplateData{Username: "ada"}, Params: map[string]string{"language": "french"}}
+	composition, err := opts.compose("//:greeter", map[string]bool{}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := composition.Role().GetPrompt(), "Hello ada.\n\nAnswer in FRENCH."; got != want {
+		t.Errorf("Prompt = %q, want %q", got, want)
+	}
+
+	opts.RoleName = "//:greeter"
+	missingParameters, err := opts.MissingParameters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missingParameters) != 1 || missingParameters[0].GetName() != "language" {
+		t.Errorf("MissingParameters = %v, want [language]", missingParameters)
+	}
+	opts.SetArg("language", "german")
+	if missingParameters, err := opts.MissingParameters(); err != nil || len(missingParameters) != 0 {
+		t.Errorf("MissingParameters after SetArg = %v, %v", missingParameters, err)
+	}
+	opts.SetArg("typo", "x")
+	if _, err := opts.MissingParameters(); err == nil {
+		t.Errorf("MissingParameters accepted an undeclared parameter")
+	}
+}
+
+func TestComposeLeavesPlainRolesRaw(t *testing.T) {
+	prompt := "Helm values look like {{ .Values.image }}; Go templates like {{ .Foo }}."
+	opts := NewOpts([]*sgptpb.Role{
+		{Name: "//:helm", Prompt: prompt, Sections: []*sgptpb.Role_Section{{Name: "jinja", Content: "{% if x %}{{ x }}{% endif %}"}}},
+		{Name: "//:greeter", Roles: []string{"//:helm"}, Prompt: "Hello {{ .Username }}.", Parameters: []*sgptpb.Role_Parameter{{Name: "language"}}},
+	})
+	data := &RoleTemplateData{TemplateData: TemplateData{Username: "ada"}, Params: map[string]string{}}
+	composition, err := opts.compose("//:helm", map[string]bool{}, data)
+	if err != nil {
+		t.Fatalf("composing a role without parameters: %v", err)
+	}
+	if got, want := composition.Role().GetPrompt(), prompt+"\n\n{% if x %}{{ x }}{% endif %}"; got != want {
+		t.Errorf("Prompt = %q, want it verbatim %q", got, want)
+	}
+
+	// An included plain role stays raw; the parameterized one renders.
+	composition, err = opts.compose("//:greeter", map[string]bool{}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := composition.Role().GetPrompt(); !strings.HasPrefix(got, prompt) || !strings.HasSuffix(got, "Hello ada.") {
+		t.Errorf("Prompt = %q", got)
+	}
+}
+
+func TestComposeModel(t *testing.T) {
+	opts := NewOpts([]*sgptpb.Role{
+		{Name: "//:base", Model: "providers/a/models/base"},
+		{Name: "//:pinned", OverrideModel: "providers/a/models/pinned"},
+		{Name: "//:plain", Roles: []string{"//:base", "//:pinned"}},
+		{Name: "//:outer", Roles: []string{"//:plain"}, OverrideModel: "providers/a/models/outer"},
+	})
+	for name, want := range map[string]struct {
+		model      Piece
+		overridden bool
+	}{
+		// Among included roles, the override beats the plain model.
+		"//:plain": {model: Piece{Value: "providers/a/models/pinned", Source: "//:pinned"}, overridden: true},
+		// The outermost override beats the included one.
+		"//:outer": {model: Piece{Value: "providers/a/models/outer", Source: "//:outer"}, overridden: true},
+	} {
+		composition, err := opts.Explain(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if composition.Model != want.model || composition.ModelOverridden != want.overridden {
+			t.Errorf("%s: Model = %v (overridden %t), want %v (overridden %t)", name, composition.Model, composition.ModelOverridden, want.model, want.overridden)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package role
 import (
 	"bytes"
 	_ "embed"
+	"fmt"
 	"os"
 	"os/user"
 	"runtime"
@@ -36,9 +37,32 @@ type TemplateData struct {
 	ToolDiscovery bool
 }
 
+// RoleTemplateData for rendering role prompts: the environment, plus the
+// role's parameters as {{ .Params.name }}.
+type RoleTemplateData struct {
+	TemplateData
+	Params map[string]string
+}
+
+// MissingParametersError lists required role parameters no value was passed
+// for.
+type MissingParametersError struct {
+	Parameters []*sgptpb.Role_Parameter
+}
+
+func (e *MissingParametersError) Error() string {
+	names := make([]string, 0, len(e.Parameters))
+	for _, parameter := range e.Parameters {
+		names = append(names, parameter.GetName())
+	}
+	return fmt.Sprintf("missing role parameter(s) %s: pass --role-arg name=value", strings.Join(names, ", "))
+}
+
 // Opts for a role.
 type Opts struct {
 	RoleName string
+	// RoleArgs are the role parameters' values, as name=value.
+	RoleArgs []string
 	// ToolDiscovery indicates discoverable tool engines are present; the
 	// system prompt then explains the discovery protocol.
 	ToolDiscovery  bool
@@ -47,6 +71,15 @@ type Opts struct {
 
 // GetOpts on the given command.
 func GetOpts(cmd *cobra.Command, defaultRole string, roles []*sgptpb.Role) *Opts {
+	opts := NewOpts(roles)
+	cmd.Flags().StringVarP(&opts.RoleName, "role", "r", defaultRole, "specify a role")
+	cmd.Flags().StringArrayVar(&opts.RoleArgs, "role-arg", nil, "set a role parameter, as name=value (repeatable)")
+	return opts
+}
+
+// NewOpts resolves roles by name or alias among the given ones, without
+// binding flags.
+func NewOpts(roles []*sgptpb.Role) *Opts {
 	// Names are selectors (unique by construction); aliases are a
 	// convenience and may collide across directories — first one wins.
 	roleNameToRole := map[string]*sgptpb.Role{}
@@ -60,43 +93,86 @@ func GetOpts(cmd *cobra.Command, defaultRole string, roles []*sgptpb.Role) *Opts
 			}
 		}
 	}
-	opts := &Opts{roleNameToRole: roleNameToRole}
-	cmd.Flags().StringVarP(&opts.RoleName, "role", "r", defaultRole, "specify a role")
-	return opts
+	return &Opts{roleNameToRole: roleNameToRole}
 }
 
-// Parse role. Returns a role with the system prompt wrapper applied.
-func (o *Opts) Parse() (*sgptpb.Role, error) {
-	// Gather template data.
-	u, err := user.Current()
+// SetArg sets a role parameter's value, overriding any passed before.
+func (o *Opts) SetArg(name, value string) {
+	o.RoleArgs = append(o.RoleArgs, name+"="+value)
+}
+
+// Parameters returns the parameters declared by the selected role and the
+// roles it includes. A parameter declared by several roles takes the
+// outermost declaration.
+func (o *Opts) Parameters() ([]*sgptpb.Role_Parameter, error) {
+	if o.RoleName == "" {
+		return nil, nil
+	}
+	composition, err := o.Explain(o.RoleName)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting current user")
+		return nil, err
 	}
-	username := u.Username
-	if username == "" {
-		username = u.Name
+	parameters := make([]*sgptpb.Role_Parameter, 0, len(composition.Parameters))
+	for _, parameter := range composition.Parameters {
+		parameters = append(parameters, parameter.Role_Parameter)
 	}
+	return parameters, nil
+}
 
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, errors.Wrap(err, "getting home directory")
+// MissingParameters returns the required parameters no value was passed
+// for, in declaration order.
+func (o *Opts) MissingParameters() ([]*sgptpb.Role_Parameter, error) {
+	_, err := o.parameterValues()
+	if missingParametersError, ok := err.(*MissingParametersError); ok {
+		return missingParametersError.Parameters, nil
 	}
+	return nil, err
+}
 
-	cwd, err := os.Getwd()
+// parameterValues resolves every parameter to its passed value or default.
+// Passing a value for a parameter the role does not declare is an error: it
+// is most likely a typo.
+func (o *Opts) parameterValues() (map[string]string, error) {
+	parameters, err := o.Parameters()
 	if err != nil {
-		return nil, errors.Wrap(err, "getting current working directory")
+		return nil, err
 	}
+	nameToArg := map[string]string{}
+	for _, roleArg := range o.RoleArgs {
+		name, value, ok := strings.Cut(roleArg, "=")
+		if !ok || name == "" {
+			return nil, errors.Errorf("invalid --role-arg %q: want name=value", roleArg)
+		}
+		nameToArg[name] = value
+	}
+	values := make(map[string]string, len(parameters))
+	missingParametersError := &MissingParametersError{}
+	for _, parameter := range parameters {
+		value, ok := nameToArg[parameter.GetName()]
+		delete(nameToArg, parameter.GetName())
+		switch {
+		case ok:
+			values[parameter.GetName()] = value
+		case parameter.GetRequired():
+			missingParametersError.Parameters = append(missingParametersError.Parameters, parameter)
+		default:
+			values[parameter.GetName()] = parameter.GetDefaultValue()
+		}
+	}
+	for name := range nameToArg {
+		return nil, errors.Errorf("role (%s) has no parameter %q", o.RoleName, name)
+	}
+	if len(missingParametersError.Parameters) > 0 {
+		return nil, missingParametersError
+	}
+	return values, nil
+}
 
-	data := TemplateData{
-		Username:      username,
-		OS:            runtime.GOOS,
-		Arch:          runtime.GOARCH,
-		Shell:         os.Getenv("SHELL"),
-		Home:          home,
-		CWD:           cwd,
-		Term:          os.Getenv("TERM"),
-		Time:          time.Now().Format("Mon Jan 2 3PM MST 2006"), // Hour resolution to allow for prompt caching.
-		ToolDiscovery: o.ToolDiscovery,
+// Parse role. Returns a role with the system prompt wrapper applied.
+func (o *Opts) Parse() (*sgptpb.Role, error) {
+	data, err := o.templateData()
+	if err != nil {
+		return nil, err
 	}
 
 	// Build the result role.
@@ -104,7 +180,7 @@ func (o *Opts) Parse() (*sgptpb.Role, error) {
 
 	// If a role is specified, inject its prompt and copy other fields.
 	if o.RoleName != "" {
-		role, err := o.expand(o.RoleName, map[string]bool{})
+		role, err := o.expand(data)
 		if err != nil {
 			return nil, err
 		}
@@ -131,60 +207,58 @@ func (o *Opts) Parse() (*sgptpb.Role, error) {
 	return result, nil
 }
 
-// expand resolves a role and merges its included roles depth-first. Included
-// prompts are prepended so the outermost role's prompt has the last word.
-// visitedNameSet both breaks inclusion cycles and dedupes diamond includes.
-func (o *Opts) expand(name string, visitedNameSet map[string]bool) (*sgptpb.Role, error) {
-	role, ok := o.roleNameToRole[name]
-	if !ok {
-		return nil, errors.Errorf("unknown role (%s)", name)
+// Expand returns the selected role fully expanded, its prompt rendered but
+// not wrapped in the system prompt.
+func (o *Opts) Expand() (*sgptpb.Role, error) {
+	data, err := o.templateData()
+	if err != nil {
+		return nil, err
+	}
+	return o.expand(data)
+}
+
+func (o *Opts) expand(data *TemplateData) (*sgptpb.Role, error) {
+	params, err := o.parameterValues()
+	if err != nil {
+		return nil, err
 	}
-	if visitedNameSet[role.Name] {
-		return &sgptpb.Role{Name: role.Name, Alias: role.Alias}, nil
+	composition, err := o.compose(o.RoleName, map[string]bool{}, &RoleTemplateData{TemplateData: *data, Params: params})
+	if err != nil {
+		return nil, err
 	}
-	visitedNameSet[role.Name] = true
+	return composition.Role(), nil
+}
 
-	result := &sgptpb.Role{
-		Name:  role.Name,
-		Alias: role.Alias,
-		Model: role.Model,
+// templateData gathers what prompts may reference about the environment.
+func (o *Opts) templateData() (*TemplateData, error) {
+	u, err := user.Current()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current user")
 	}
-	var prompts []string
-	for _, includedName := range role.GetRoles() {
-		includedRole, err := o.expand(includedName, visitedNameSet)
-		if err != nil {
-			return nil, errors.Wrapf(err, "expanding role (%s)", name)
-		}
-		if includedRole.Prompt != "" {
-			prompts = append(prompts, includedRole.Prompt)
-		}
-		result.Files = append(result.Files, includedRole.Files...)
-		result.Tools = append(result.Tools, includedRole.Tools...)
-		// The outermost model wins; fall back to included roles' models.
-		if result.Model == "" {
-			result.Model = includedRole.Model
-		}
+	username := u.Username
+	if username == "" {
+		username = u.Name
 	}
-	if role.Prompt != "" {
-		prompts = append(prompts, role.Prompt)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting home directory")
 	}
-	result.Prompt = strings.Join(prompts, "\n\n")
-	result.Files = append(result.Files, role.Files...)
-	result.Tools = append(result.Tools, role.Tools...)
-	result.Files = dedupe(result.Files)
-	result.Tools = dedupe(result.Tools)
-	return result, nil
-}
 
-func dedupe(values []string) []string {
-	valueSet := map[string]bool{}
-	result := make([]string, 0, len(values))
-	for _, value := range values {
-		if valueSet[value] {
-			continue
-		}
-		valueSet[value] = true
-		result = append(result, value)
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current working directory")
 	}
-	return result
+
+	return &TemplateData{
+		Username:      username,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Shell:         os.Getenv("SHELL"),
+		Home:          home,
+		CWD:           cwd,
+		Term:          os.Getenv("TERM"),
+		Time:          time.Now().Format("Mon Jan 2 3PM MST 2006"), // Hour resolution to allow for prompt caching.
+		ToolDiscovery: o.ToolDiscovery,
+	}, nil
 }
@@ -0,0 +1,373 @@
+// Package search is a local full-text index over chat messages. The index is
+// an inverted index persisted in the cache and maintained incrementally:
+// callers feed it the chats they list and the histories they load, and only
+// chats whose update time moved are re-indexed.
+package search
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/cache"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+const (
+	indexCacheKey = "search_index"
+	// indexCacheMaxAge only bounds an abandoned index: entries are kept
+	// fresh by update time, not by age.
+	indexCacheMaxAge = 90 * 24 * time.Hour
+	// maxTermLength drops tokens that are noise rather than words (hashes,
+	// base64 blobs).
+	maxTermLength = 40
+	// snippetRadius is the context kept on each side of a match in snippets.
+	snippetRadius = 60
+)
+
+// Hit is a message matching a query.
+type Hit struct {
+	// Chat is the resource name of the chat.
+	Chat string
+	// Title is the chat's title when it was indexed.
+	Title string
+	// Message is the resource name of the matching message.
+	Message string
+	// Snippet is the text around the first match, on one line.
+	Snippet string
+	Score   float64
+}
+
+// Index is the full-text index. Safe for concurrent use.
+type Index struct {
+	mu    sync.Mutex
+	index *sgptpb.SearchIndex
+	dirty bool
+}
+
+// Load returns the cached index, or an empty one.
+func Load() *Index {
+	index, ok := cache.Get(indexCacheKey, indexCacheMaxAge, &sgptpb.SearchIndex{})
+	if !ok {
+		index = &sgptpb.SearchIndex{}
+	}
+	return newIndex(index)
+}
+
+func newIndex(index *sgptpb.SearchIndex) *Index {
+	if index.Chats == nil {
+		index.Chats = map[string]*sgptpb.IndexedChat{}
+	}
+	if index.Postings == nil {
+		index.Postings = map[string]*sgptpb.SearchPostings{}
+	}
+	return &Index{index: index}
+}
+
+// Save persists the index if it changed since it was loaded or last saved.
+func (i *Index) Save() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.dirty {
+		return nil
+	}
+	if err := cache.Store(indexCacheKey, i.index); err != nil {
+		return err
+	}
+	i.dirty = false
+	return nil
+}
+
+// Stale reports whether a chat must be (re-)indexed.
+func (i *Index) Stale(chat *aipb.Chat) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	indexedChat, ok := i.index.Chats[chat.GetName()]
+	return !ok || !indexedChat.GetUpdateTime().AsTime().Equal(chat.GetUpdateTime().AsTime())
+}
+
+// Update (re-)indexes a chat from its full history.
+func (i *Index) Update(chat *aipb.Chat, messages []*aipb.Message) {
+	indexedChat := &sgptpb.IndexedChat{
+		UpdateTime: chat.GetUpdateTime(),
+		Title:      chat.GetTitle(),
+	}
+	termToPostings := map[string]*sgptpb.SearchPosting{}
+	var orderedTerms []string
+	for _, message := range messages {
+		text := searchableText(message)
+		if text == "" {
+			continue
+		}
+		messageIndex := int32(len(indexedChat.Messages))
+		indexedChat.Messages = append(indexedChat.Messages, &sgptpb.IndexedMessage{Name: message.GetName(), Text: text})
+		for _, term := range tokenize(text) {
+			key := term + "\x00" + message.GetName()
+			posting, ok := termToPostings[key]
+			if !ok {
+				posting = &sgptpb.SearchPosting{Chat: chat.GetName(), Message: messageIndex}
+				termToPostings[key] = posting
+				orderedTerms = append(orderedTerms, key)
+			}
+			posting.Count++
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.removeLocked(chat.GetName())
+	termSet := map[string]bool{}
+	for _, key := range orderedTerms {
+		term, _, _ := strings.Cut(key, "\x00")
+		postings, ok := i.index.Postings[term]
+		if !ok {
+			postings = &sgptpb.SearchPostings{}
+			i.index.Postings[term] = postings
+		}
+		postings.Postings = append(postings.Postings, termToPostings[key])
+		if !termSet[term] {
+			termSet[term] = true
+			indexedChat.Terms = append(indexedChat.Terms, term)
+		}
+	}
+	i.index.Chats[chat.GetName()] = indexedChat
+	i.dirty = true
+}
+
+// Remove drops a chat from the index.
+func (i *Index) Remove(chatName string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.removeLocked(chatName)
+}
+
+func (i *Index) removeLocked(chatName string) {
+	indexedChat, ok := i.index.Chats[chatName]
+	if !ok {
+		return
+	}
+	for _, term := range indexedChat.GetTerms() {
+		postings := i.index.Postings[term]
+		kept := postings.GetPostings()[:0]
+		for _, posting := range postings.GetPostings() {
+			if posting.GetChat() != chatName {
+				kept = append(kept, posting)
+			}
+		}
+		if len(kept) == 0 {
+			delete(i.index.Postings, term)
+			continue
+		}
+		postings.Postings = kept
+	}
+	delete(i.index.Chats, chatName)
+	i.dirty = true
+}
+
+// Sync brings the index in line with the store: every stale chat is
+// re-indexed and chats no longer listed are dropped. progress, when set, is
+// called after each re-indexed chat.
+func (i *Index) Sync(ctx context.Context, chatStore *store.Store, progress func(chat *aipb.Chat)) error {
+	listedChatNameSet := map[string]bool{}
+	pageToken := ""
+	for {
+		chats, nextPageToken, err := chatStore.ListChats(ctx, 100, pageToken, "")
+		if err != nil {
+			return err
+		}
+		for _, chat := range chats {
+			listedChatNameSet[chat.GetName()] = true
+			if !i.Stale(chat) {
+				continue
+			}
+			messages, err := chatStore.ListMessages(ctx, chat.GetName())
+			if err != nil {
+				return err
+			}
+			i.Update(chat, messages)
+			if progress != nil {
+				progress(chat)
+			}
+		}
+		if nextPageToken == "" {
+			break
+		}
+		pageToken = nextPageToken
+	}
+
+	i.mu.Lock()
+	var deletedChatNames []string
+	for chatName := range i.index.Chats {
+		if !listedChatNameSet[chatName] {
+			deletedChatNames = append(deletedChatNames, chatName)
+		}
+	}
+	for _, chatName := range deletedChatNames {
+		i.removeLocked(chatName)
+	}
+	i.mu.Unlock()
+	return nil
+}
+
+// Search returns the messages containing every term of the query, best
+// match first: terms score by their frequency in the message, weighted by
+// their rarity across the index.
+func (i *Index) Search(query string, limit int) []Hit {
+	terms := dedupe(tokenize(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var messagesCount int
+	for _, indexedChat := range i.index.Chats {
+		messagesCount += len(indexedChat.GetMessages())
+	}
+	type messageRef struct {
+		chat    string
+		message int32
+	}
+	refToScore := map[messageRef]float64{}
+	refToMatchedTerms := map[messageRef]int{}
+	for _, term := range terms {
+		postings := i.index.Postings[term].GetPostings()
+		if len(postings) == 0 {
+			// Every term must match: one missing term empties the result.
+			return nil
+		}
+		idf := math.Log(1 + float64(messagesCount)/float64(len(postings)))
+		for _, posting := range postings {
+			ref := messageRef{chat: posting.GetChat(), message: posting.GetMessage()}
+			refToScore[ref] += float64(posting.GetCount()) * idf
+			refToMatchedTerms[ref]++
+		}
+	}
+
+	var hits []Hit
+	for ref, matchedTerms := range refToMatchedTerms {
+		if matchedTerms < len(terms) {
+			continue
+		}
+		indexedChat := i.index.Chats[ref.chat]
+		messages := indexedChat.GetMessages()
+		if int(ref.message) >= len(messages) {
+			continue
+		}
+		message := messages[ref.message]
+		hits = append(hits, Hit{
+			Chat:    ref.chat,
+			Title:   indexedChat.GetTitle(),
+			Message: message.GetName(),
+			Snippet: snippet(message.GetText(), terms),
+			Score:   refToScore[ref],
+		})
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		// Stable order among ties: most recently updated chat first.
+		updateTimeA := i.index.Chats[hits[a].Chat].GetUpdateTime().AsTime()
+		updateTimeB := i.index.Chats[hits[b].Chat].GetUpdateTime().AsTime()
+		if !updateTimeA.Equal(updateTimeB) {
+			return updateTimeA.After(updateTimeB)
+		}
+		return hits[a].Message < hits[b].Message
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// searchableText is the text a message is found by: what the user typed and
+// the assistant answered. Context (files, system prompt), thoughts and tool
+// payloads are left out — they would drown every query in noise.
+func searchableText(message *aipb.Message) string {
+	if message.GetDeleteTime() != nil || store.IsContextMessage(message) {
+		return ""
+	}
+	if role := message.GetRole(); role != aipb.Role_ROLE_USER && role != aipb.Role_ROLE_ASSISTANT {
+		return ""
+	}
+	var texts []string
+	for _, block := range message.GetBlocks() {
+		if text := block.GetText(); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// tokenize splits text into lowercase terms of letters and digits.
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, field := range fields {
+		if len(field) < 2 || len(field) > maxTermLength {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+func dedupe(terms []string) []string {
+	seen := map[string]bool{}
+	unique := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+// snippet flattens the text around the first occurrence of any term. Terms
+// are matched case-insensitively in text itself: lowercasing the text first
+// would shift offsets wherever a rune's lowercase has another length.
+func snippet(text string, terms []string) string {
+	start := 0
+	if len(terms) > 0 {
+		patterns := make([]string, 0, len(terms))
+		for _, term := range terms {
+			patterns = append(patterns, regexp.QuoteMeta(term))
+		}
+		if match := regexp.MustCompile("(?i)" + strings.Join(patterns, "|")).FindStringIndex(text); match != nil {
+			start = match[0]
+		}
+	}
+	from := max(0, start-snippetRadius)
+	to := min(len(text), start+snippetRadius)
+	// Never cut a multi-byte rune in half.
+	for from > 0 && !isRuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !isRuneStart(text[to]) {
+		to++
+	}
+	excerpt := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(text) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+func testChat(id, title string, updateTime time.Time) *aipb.Chat {
+	return &aipb.Chat{Name: "chats/" + id, Title: title, UpdateTime: timestamppb.New(updateTime)}
+}
+
+func testMessage(chat *aipb.Chat, id string, message *aipb.Message) *aipb.Message {
+	message.Name = chat.GetName() + "/messages/" + id
+	return message
+}
+
+func TestSearch(t *testing.T) {
+	index := newIndex(&sgptpb.SearchIndex{})
+	now := time.Now()
+	goChat := testChat("go", "Go channels", now)
+	index.Update(goChat, []*aipb.Message{
+		testMessage(goChat, "1", ai.NewUserMessage(ai.NewTextBlock("How do buffered channels work?"))),
+		testMessage(goChat, "2", ai.NewAssistantMessage(ai.NewTextBlock("A buffered channel blocks only when full. Channels channels."))),
+		testMessage(goChat, "3", store.NewInjectedFileMessage("main.go", "package main // buffered")),
+	})
+	rustChat := testChat("rust", "Rust", now.Add(-time.Hour))
+	index.Update(rustChat, []*aipb.Message{
+		testMessage(rustChat, "1", ai.NewUserMessage(ai.NewTextBlock("Are Rust channels buffered?"))),
+	})
+
+	hits := index.Search("Buffered channels", 10)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2: %v", len(hits), hits)
+	}
+	if hits[0].Message != "chats/go/messages/1" || hits[0].Title != "Go channels" {
+		t.Errorf("best hit = %+v, want the Go question", hits[0])
+	}
+	if !strings.Contains(strings.ToLower(hits[0].Snippet), "buffered") {
+		t.Errorf("snippet = %q, want it around the match", hits[0].Snippet)
+	}
+
+	// Context messages are not indexed.
+	if hits := index.Search("package", 10); len(hits) != 0 {
+		t.Errorf("Search(package) = %v, want no hits", hits)
+	}
+	// Every term must match.
+	if hits := index.Search("rust full", 10); len(hits) != 0 {
+		t.Errorf("Search(rust full) = %v, want no hits", hits)
+	}
+}
+
+func TestUpdateReplacesAndRemove(t *testing.T) {
+	index := newIndex(&sgptpb.SearchIndex{})
+	now := time.Now()
+	chat := testChat("c", "", now)
+	index.Update(chat, []*aipb.Message{testMessage(chat, "1", ai.NewUserMessage(ai.NewTextBlock("alpha beta")))})
+	if index.Stale(chat) {
+		t.Error("Stale = true right after Update")
+	}
+
+	chat = testChat("c", "", now.Add(time.Minute))
+	if !index.Stale(chat) {
+		t.Error("Stale = false after the chat moved")
+	}
+	index.Update(chat, []*aipb.Message{testMessage(chat, "1", ai.NewUserMessage(ai.NewTextBlock("gamma")))})
+	if hits := index.Search("alpha", 10); len(hits) != 0 {
+		t.Errorf("Search(alpha) = %v, want the old text gone", hits)
+	}
+	if hits := index.Search("gamma", 10); len(hits) != 1 {
+		t.Errorf("Search(gamma) = %v, want 1 hit", hits)
+	}
+
+	index.Remove(chat.GetName())
+	if hits := index.Search("gamma", 10); len(hits) != 0 {
+		t.Errorf("Search(gamma) = %v after Remove, want no hits", hits)
+	}
+	if len(index.index.GetPostings()) != 0 {
+		t.Errorf("postings = %v after Remove, want none", index.index.GetPostings())
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("é", 100) + " needle\nin the\thaystack " + strings.Repeat("x", 100)
+	got := snippet(text, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "needle in the haystack") {
+		t.Errorf("snippet = %q", got)
+	}
+}
+
+func TestSnippetNonASCII(t *testing.T) {
+	// "İ" lowercases to three bytes from two: offsets into a lowercased copy
+	// run past the text.
+	text := strings.Repeat("İ", 200) + " Needle"
+	got := snippet(text, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "Needle") {
+		t.Errorf("snippet = %q, want it to end at the match", got)
+	}
+	if got := snippet("ΣΊΣΥΦΟΣ rolls", []string{"σίσυφος"}); !strings.Contains(got, "ΣΊΣΥΦΟΣ") {
+		t.Errorf("snippet = %q, want the case-folded match", got)
+	}
+}
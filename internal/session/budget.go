@@ -0,0 +1,302 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// dailySpendMaxAge bounds how stale the day's spend may get between listings:
+// summing every chat created today is far too expensive to repeat on each
+// tool iteration, and the local tree's spend is added on top in the meantime.
+const dailySpendMaxAge = time.Minute
+
+// ErrBudgetExceeded ends a turn the user stopped at a budget cap.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// budgetCap identifies which configured guardrail a turn ran into.
+type budgetCap int
+
+const (
+	budgetCapNone budgetCap = iota
+	budgetCapToolIterations
+	budgetCapChat
+	budgetCapAgentTree
+	budgetCapDaily
+)
+
+// budgetPause is a turn parked at a reached cap, awaiting the user's call.
+type budgetPause struct {
+	reason   string
+	answerCh chan bool
+}
+
+// BudgetStatus is the spend left before the tightest configured cap pauses
+// the turn. Limited is false when no spend cap is configured.
+type BudgetStatus struct {
+	Remaining float64
+	Limited   bool
+}
+
+// spendTree pools the spend of a chat and every sub-agent launched under it,
+// so a single cap bounds the whole tree. The day's spend is cached here too:
+// every session of the tree adds to it.
+type spendTree struct {
+	mu       sync.Mutex
+	sessions []*Session
+	// treeGrants and dailyGrants count the confirmations past each cap; every
+	// confirmation grants one more allowance of the configured size.
+	treeGrants  int
+	dailyGrants int
+
+	// dailySpend is the day's spend as of dailyFetchTime, when the tree had
+	// spent dailyTreePrice: the estimate is the listing plus the tree's
+	// spend since.
+	dailySpend     float64
+	dailyTreePrice float64
+	dailyFetchTime time.Time
+}
+
+func newSpendTree(s *Session) *spendTree {
+	return &spendTree{sessions: []*Session{s}}
+}
+
+// price sums the spend of every session of the tree. Takes each session's
+// lock in turn — never call it holding one.
+func (t *spendTree) price() float64 {
+	t.mu.Lock()
+	sessions := append([]*Session(nil), t.sessions...)
+	t.mu.Unlock()
+	var price float64
+	for _, s := range sessions {
+		price += s.Price()
+	}
+	return price
+}
+
+// SetParent makes the session a sub-agent of parent for budgeting: its spend
+// counts toward the parent's agent-tree cap (and vice versa). Call it before
+// the session's first turn.
+func (s *Session) SetParent(parent *Session) {
+	tree := parent.spendTree()
+	tree.mu.Lock()
+	tree.sessions = append(tree.sessions, s)
+	tree.mu.Unlock()
+	s.mu.Lock()
+	s.tree = tree
+	s.mu.Unlock()
+}
+
+func (s *Session) spendTree() *spendTree {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tree
+}
+
+// dailySpend estimates the day's spend: a cached listing of today's chats
+// plus whatever the tree spent since. Listing failures surface as errors but
+// never block the turn — a guardrail that breaks the chat is worse than one
+// that is briefly blind.
+func (s *Session) dailySpend() (float64, bool) {
+	tree := s.spendTree()
+	treePrice := tree.price()
+
+	tree.mu.Lock()
+	fresh := time.Since(tree.dailyFetchTime) < dailySpendMaxAge
+	dailySpend, dailyTreePrice := tree.dailySpend, tree.dailyTreePrice
+	tree.mu.Unlock()
+	if fresh {
+		return dailySpend + treePrice - dailyTreePrice, true
+	}
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dailySpend, err := s.store.SpendSince(s.turnContext(), midnight)
+	if err != nil {
+		s.emitError(fmt.Errorf("computing today's spend: %w", err))
+		return 0, false
+	}
+	tree.mu.Lock()
+	tree.dailySpend, tree.dailyTreePrice, tree.dailyFetchTime = dailySpend, treePrice, now
+	tree.mu.Unlock()
+	return dailySpend, true
+}
+
+// reachedBudget reports the first configured cap the turn has reached, with
+// a user-facing explanation.
+func (t *turn) reachedBudget() (budgetCap, string) {
+	s := t.session
+	budget := s.Params().Budget
+
+	if limit := int(budget.GetMaxToolIterations()); limit > 0 && t.toolIterations >= limit*(1+t.toolIterationGrants) {
+		return budgetCapToolIterations, fmt.Sprintf("%d tool iterations this turn", t.toolIterations)
+	}
+	if limit := budget.GetMaxChatPrice(); limit > 0 {
+		s.mu.Lock()
+		grants := s.chatBudgetGrants
+		s.mu.Unlock()
+		if price := s.Price(); price >= limit*float64(1+grants) {
+			return budgetCapChat, fmt.Sprintf("chat spend $%.2f (cap $%.2f)", price, limit*float64(1+grants))
+		}
+	}
+	tree := s.spendTree()
+	if limit := budget.GetMaxAgentTreePrice(); limit > 0 {
+		tree.mu.Lock()
+		grants := tree.treeGrants
+		tree.mu.Unlock()
+		if price := tree.price(); price >= limit*float64(1+grants) {
+			return budgetCapAgentTree, fmt.Sprintf("agent tree spend $%.2f (cap $%.2f)", price, limit*float64(1+grants))
+		}
+	}
+	if limit := budget.GetMaxDailyPrice(); limit > 0 {
+		tree.mu.Lock()
+		grants := tree.dailyGrants
+		tree.mu.Unlock()
+		if spend, ok := s.dailySpend(); ok && spend >= limit*float64(1+grants) {
+			return budgetCapDaily, fmt.Sprintf("today's spend $%.2f (cap $%.2f)", spend, limit*float64(1+grants))
+		}
+	}
+	return budgetCapNone, ""
+}
+
+// enforceBudget parks the turn at a reached cap until the user decides.
+// Confirming grants one more allowance of the reached cap and re-checks (the
+// next cap may be reached too); stopping returns an error wrapping
+// ErrBudgetExceeded, cancelling the turn its context error.
+func (t *turn) enforceBudget() error {
+	s := t.session
+	for {
+		reachedCap, reason := t.reachedBudget()
+		if reachedCap == budgetCapNone {
+			return nil
+		}
+		if !s.awaitBudget(t.ctx, reason) {
+			if err := t.ctx.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %s", ErrBudgetExceeded, reason)
+		}
+		switch reachedCap {
+		case budgetCapToolIterations:
+			t.toolIterationGrants++
+		case budgetCapChat:
+			s.mu.Lock()
+			s.chatBudgetGrants++
+			s.mu.Unlock()
+		case budgetCapAgentTree, budgetCapDaily:
+			tree := s.spendTree()
+			tree.mu.Lock()
+			if reachedCap == budgetCapAgentTree {
+				tree.treeGrants++
+			} else {
+				tree.dailyGrants++
+			}
+			tree.mu.Unlock()
+		}
+	}
+}
+
+// awaitBudget blocks the turn goroutine until the user continues past the
+// cap or stops; a cancelled turn counts as stopping.
+func (s *Session) awaitBudget(ctx context.Context, reason string) bool {
+	pause := &budgetPause{reason: reason, answerCh: make(chan bool, 1)}
+	s.mu.Lock()
+	s.budgetPause = pause
+	s.mu.Unlock()
+	s.refresh()
+
+	defer func() {
+		s.mu.Lock()
+		if s.budgetPause == pause {
+			s.budgetPause = nil
+		}
+		s.mu.Unlock()
+		s.refresh()
+	}()
+
+	select {
+	case answer := <-pause.answerCh:
+		return answer
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// answerBudget delivers the user's decision to the paused turn. Reports
+// whether a turn was actually paused.
+func (s *Session) answerBudget(answer bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.budgetPause == nil {
+		return false
+	}
+	// Buffered, single receiver: never blocks the UI goroutine.
+	s.budgetPause.answerCh <- answer
+	s.budgetPause = nil
+	return true
+}
+
+// ---- Budget API (called from the UI goroutine) ----
+
+// BudgetPauseReason explains the cap the running turn is paused at; empty
+// when the turn is not paused.
+func (s *Session) BudgetPauseReason() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.budgetPause == nil {
+		return ""
+	}
+	return s.budgetPause.reason
+}
+
+// ContinuePastBudget resumes a turn paused at a cap, granting one more
+// allowance of that cap.
+func (s *Session) ContinuePastBudget() {
+	s.answerBudget(true)
+}
+
+// StopAtBudget ends a turn paused at a cap. Pending tool results stay queued,
+// so the next message resumes the conversation where it stopped.
+func (s *Session) StopAtBudget() {
+	s.answerBudget(false)
+}
+
+// Budget reports the spend left before the tightest configured spend cap.
+// The daily figure is the cached estimate — rendering never lists chats.
+func (s *Session) Budget() BudgetStatus {
+	budget := s.Params().Budget
+	remaining := math.Inf(1)
+	if limit := budget.GetMaxChatPrice(); limit > 0 {
+		s.mu.Lock()
+		grants := s.chatBudgetGrants
+		s.mu.Unlock()
+		remaining = math.Min(remaining, limit*float64(1+grants)-s.Price())
+	}
+	tree := s.spendTree()
+	if limit := budget.GetMaxAgentTreePrice(); limit > 0 {
+		tree.mu.Lock()
+		grants := tree.treeGrants
+		tree.mu.Unlock()
+		remaining = math.Min(remaining, limit*float64(1+grants)-tree.price())
+	}
+	if limit := budget.GetMaxDailyPrice(); limit > 0 {
+		treePrice := tree.price()
+		tree.mu.Lock()
+		grants, fetched := tree.dailyGrants, !tree.dailyFetchTime.IsZero()
+		spend := tree.dailySpend + treePrice - tree.dailyTreePrice
+		tree.mu.Unlock()
+		// Until the first turn lists today's chats, only the tree's spend is
+		// known.
+		if !fetched {
+			spend = treePrice
+		}
+		remaining = math.Min(remaining, limit*float64(1+grants)-spend)
+	}
+	if math.IsInf(remaining, 1) {
+		return BudgetStatus{}
+	}
+	return BudgetStatus{Remaining: math.Max(remaining, 0), Limited: true}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// newBudgetTurn builds a turn over a minimal session capped at the given
+// number of tool iterations.
+func newBudgetTurn(ctx context.Context, maxToolIterations int32) *turn {
+	s := newReviewSession(ctx)
+	s.params.Budget = &sgptpb.Budget{MaxToolIterations: maxToolIterations}
+	s.tree = newSpendTree(s)
+	turnCtx, cancel := context.WithCancel(ctx)
+	return &turn{session: s, ctx: turnCtx, cancel: cancel}
+}
+
+// awaitBudgetPause spins until the turn parks at a cap.
+func awaitBudgetPause(t *testing.T, s *Session) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s.BudgetPauseReason() != "" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("turn never paused at the budget cap")
+}
+
+func TestEnforceBudgetUnderCap(t *testing.T) {
+	currentTurn := newBudgetTurn(context.Background(), 3)
+	currentTurn.toolIterations = 2
+	if err := currentTurn.enforceBudget(); err != nil {
+		t.Fatalf("enforceBudget = %v, want nil under the cap", err)
+	}
+}
+
+func TestEnforceBudgetContinueGrantsAllowance(t *testing.T) {
+	currentTurn := newBudgetTurn(context.Background(), 2)
+	s := currentTurn.session
+	currentTurn.toolIterations = 2
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- currentTurn.enforceBudget() }()
+
+	awaitBudgetPause(t, s)
+	if got := s.State(); got != StateAwaitingBudget {
+		t.Fatalf("state = %v, want StateAwaitingBudget", got)
+	}
+	s.ContinuePastBudget()
+	if err := <-errCh; err != nil {
+		t.Fatalf("enforceBudget = %v, want nil after continuing", err)
+	}
+	// One more allowance of the same size: the next pause is at 4.
+	currentTurn.toolIterations = 3
+	if err := currentTurn.enforceBudget(); err != nil {
+		t.Fatalf("enforceBudget = %v, want nil within the granted allowance", err)
+	}
+}
+
+func TestEnforceBudgetStop(t *testing.T) {
+	currentTurn := newBudgetTurn(context.Background(), 1)
+	s := currentTurn.session
+	currentTurn.toolIterations = 1
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- currentTurn.enforceBudget() }()
+
+	awaitBudgetPause(t, s)
+	s.StopAtBudget()
+	if err := <-errCh; !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("enforceBudget = %v, want ErrBudgetExceeded", err)
+	}
+	if reason := s.BudgetPauseReason(); reason != "" {
+		t.Fatalf("pause reason = %q, want empty after stopping", reason)
+	}
+}
+
+func TestEnforceBudgetCancel(t *testing.T) {
+	currentTurn := newBudgetTurn(context.Background(), 1)
+	s := currentTurn.session
+	currentTurn.toolIterations = 1
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- currentTurn.enforceBudget() }()
+
+	awaitBudgetPause(t, s)
+	currentTurn.cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("enforceBudget = %v, want context.Canceled", err)
+	}
+}
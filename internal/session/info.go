@@ -40,6 +40,8 @@ type Info struct {
 	ContextLimit int32
 	OutputLimit  int32
 	Price        float64
+	// Budget is the spend left before the tightest configured cap.
+	Budget BudgetStatus
 
 	// Lores and Files partition the injected context by origin: a lore is an
 	// injected file that resolves to a lore library.
@@ -58,8 +60,8 @@ type Info struct {
 
 // Info computes the snapshot. Blocking only on the session mutex.
 func (s *Session) Info() *Info {
-	// Price() and LastModelUsage() take the lock themselves.
-	price, contextUsage := s.Price(), s.LastModelUsage()
+	// Price(), LastModelUsage() and Budget() take the lock themselves.
+	price, contextUsage, budget := s.Price(), s.LastModelUsage(), s.Budget()
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -76,6 +78,7 @@ func (s *Session) Info() *Info {
 		ContextLimit:    s.params.Model.GetTtt().GetContextTokenLimit(),
 		OutputLimit:     s.params.Model.GetTtt().GetOutputTokenLimit(),
 		Price:           price,
+		Budget:          budget,
 		QueuedMessages:  len(s.queuedMessages),
 		AvailableTools:  append([]string(nil), s.params.AvailableToolNames...),
 	}
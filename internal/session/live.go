@@ -0,0 +1,395 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/malonaz/sgpt/internal/file"
+	"github.com/malonaz/sgpt/internal/gitcontext"
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+const (
+	// liveFileDiffContext is the unchanged lines framing each hunk of a live
+	// file's diff.
+	liveFileDiffContext = 3
+	// maxLiveFileDiffRatio caps the diffs accumulated on a live file, as a
+	// fraction of its size: past it, re-injecting the whole file is cheaper
+	// than making the model replay the diffs.
+	maxLiveFileDiffRatio = 0.5
+)
+
+// liveFile tracks what the model has seen of a live injected file, so the
+// changes made since reach it before the next turn.
+type liveFile struct {
+	// content is the file as the model sees it: the injected content with
+	// the diffs since applied. Unknown (known is false) until the injection
+	// is persisted, or when it was not injected as text.
+	content []byte
+	known   bool
+	// modTime and size are the file's stat when content was last checked;
+	// an unchanged stat skips reading the file.
+	modTime time.Time
+	size    int64
+	// diffMessageNames are the diff messages persisted since the injection,
+	// and diffSize the length of their diffs.
+	diffMessageNames []string
+	diffSize         int
+}
+
+// liveFileRefresh is the change of a live file to send before a turn.
+type liveFileRefresh struct {
+	path    string
+	content []byte
+	modTime time.Time
+	size    int64
+	// diff is empty when the file must be re-injected whole.
+	diff string
+	// touched is set when the file's stat changed but not its content:
+	// only the stat is recorded, nothing is sent.
+	touched bool
+}
+
+// seedLiveFiles marks the live files of a new or resumed session. A resumed
+// live file that had diffs cannot replay them onto its injected content, so
+// the first turn after resuming re-injects it whole. Caller holds the lock.
+func (s *Session) seedLiveFiles(messages []*aipb.Message) {
+	for _, path := range s.normalizeInjectedPaths(s.params.LiveFiles) {
+		if _, ok := s.params.InjectedFileContents[path]; !ok && slices.Contains(s.injectedFilePaths, path) {
+			s.pathToLiveFile[path] = &liveFile{}
+		}
+	}
+	for _, message := range messages {
+		if message.GetDeleteTime() != nil {
+			continue
+		}
+		if path := store.InjectedFilePath(message); path != "" && store.IsLiveFile(message) {
+			tracked := &liveFile{}
+			tracked.content, tracked.known = injectedContent(message, path)
+			s.pathToLiveFile[path] = tracked
+		}
+	}
+	for _, message := range messages {
+		if message.GetDeleteTime() != nil {
+			continue
+		}
+		if tracked, ok := s.pathToLiveFile[store.FileDiffPath(message)]; ok {
+			tracked.diffMessageNames = append(tracked.diffMessageNames, message.GetName())
+			tracked.content, tracked.known = nil, false
+		}
+	}
+}
+
+// injectedContent recovers the file content an injected-file message
+// carries: its media, or its text as injectedFileMessage formats it. Notes
+// (unreadable files, attachments for text-only models) carry none.
+func injectedContent(message *aipb.Message, path string) ([]byte, bool) {
+	if _, content, ok := store.Attachment(message); ok {
+		return content, true
+	}
+	// A git source is compared whole: its text is all there is.
+	if gitcontext.IsSelector(path) {
+		if blocks := message.GetBlocks(); len(blocks) > 0 {
+			return []byte(blocks[0].GetText()), true
+		}
+		return nil, false
+	}
+	for _, block := range message.GetBlocks() {
+		text := block.GetText()
+		prefix := fmt.Sprintf("file %s: `", path)
+		if strings.HasPrefix(text, prefix) && strings.HasSuffix(text, "`") && len(text) > len(prefix) {
+			return []byte(text[len(prefix) : len(text)-1]), true
+		}
+		break
+	}
+	return nil, false
+}
+
+// markLiveFile annotates an injected-file message with its path's liveness.
+// Caller holds the lock.
+func (s *Session) markLiveFile(message *aipb.Message, path string) *aipb.Message {
+	if _, ok := s.pathToLiveFile[path]; ok {
+		store.SetLiveFile(message, true)
+	}
+	return message
+}
+
+// settleLiveFile records the content of a live file's persisted injection as
+// what the model sees, dropping its diffs. Caller holds the lock.
+func (s *Session) settleLiveFile(message *aipb.Message, path string, info os.FileInfo) {
+	tracked, ok := s.pathToLiveFile[path]
+	if !ok {
+		return
+	}
+	tracked.content, tracked.known = injectedContent(message, path)
+	tracked.modTime, tracked.size = time.Time{}, 0
+	if info != nil {
+		tracked.modTime, tracked.size = info.ModTime(), info.Size()
+	}
+	tracked.diffMessageNames, tracked.diffSize = nil, 0
+}
+
+// statFile stats an injected path; nil when it cannot be.
+func statFile(path string) os.FileInfo {
+	expandedPath, err := file.ExpandPath(path)
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(expandedPath)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// refreshLiveFiles sends the live files' changes ahead of a turn. A text
+// change small enough is appended as a diff, which keeps the cached prompt
+// prefix; anything else re-injects the file, soft-deleting its previous
+// injection and diffs. Best effort: a failure is reported, never fatal to
+// the turn.
+func (s *Session) refreshLiveFiles() {
+	s.mu.Lock()
+	// Files are read and git sources loaded outside the lock, against a copy
+	// of what the model sees: disks and git may take their time.
+	var filePaths, sourcePaths []string
+	pathToTracked := map[string]liveFile{}
+	for _, path := range s.injectedFilePaths {
+		tracked, ok := s.pathToLiveFile[path]
+		if !ok {
+			continue
+		}
+		// Not persisted yet: ensureContext reads the file as it is now.
+		if _, ok := s.injectedFilePathToMessageName[path]; !ok {
+			continue
+		}
+		pathToTracked[path] = *tracked
+		if gitcontext.IsSelector(path) {
+			sourcePaths = append(sourcePaths, path)
+		} else {
+			filePaths = append(filePaths, path)
+		}
+	}
+	chatName := s.chat.GetName()
+	s.mu.Unlock()
+	var refreshes, touchedRefreshes []liveFileRefresh
+	for _, path := range filePaths {
+		refresh, ok := checkLiveFile(path, pathToTracked[path])
+		switch {
+		case ok && refresh.touched:
+			touchedRefreshes = append(touchedRefreshes, refresh)
+		case ok:
+			refreshes = append(refreshes, refresh)
+		}
+	}
+	for _, path := range sourcePaths {
+		if refresh, ok := s.checkLiveSource(path, pathToTracked[path]); ok {
+			refreshes = append(refreshes, refresh)
+		}
+	}
+	if len(touchedRefreshes) > 0 {
+		s.mu.Lock()
+		for _, refresh := range touchedRefreshes {
+			// Recorded unless the model was sent another version meanwhile.
+			if tracked, ok := s.pathToLiveFile[refresh.path]; ok && tracked.known && bytes.Equal(tracked.content, refresh.content) {
+				tracked.modTime, tracked.size = refresh.modTime, refresh.size
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	var reinjectedPaths []string
+	for _, refresh := range refreshes {
+		if refresh.diff == "" {
+			reinjectedPaths = append(reinjectedPaths, refresh.path)
+			continue
+		}
+		createdMessage, err := s.store.CreateMessage(s.turnContext(), chatName, store.NewFileDiffMessage(refresh.path, refresh.diff))
+		if err != nil {
+			s.emitError(fmt.Errorf("sending live file %s changes: %w", refresh.path, err))
+			continue
+		}
+		s.mu.Lock()
+		if tracked, ok := s.pathToLiveFile[refresh.path]; ok {
+			tracked.content, tracked.known = refresh.content, true
+			tracked.modTime, tracked.size = refresh.modTime, refresh.size
+			tracked.diffMessageNames = append(tracked.diffMessageNames, createdMessage.GetName())
+			tracked.diffSize += len(refresh.diff)
+		}
+		s.messages = append(s.messages, createdMessage)
+		s.invalidatePrice()
+		s.mu.Unlock()
+	}
+	if len(reinjectedPaths) > 0 {
+		s.reinjectLiveFiles(reinjectedPaths)
+	}
+}
+
+// checkLiveFile compares a live file against what the model sees, returning
+// the refresh to send when it changed, or a touched one when only its stat
+// did. Reads the file: caller must not hold the lock.
+func checkLiveFile(path string, tracked liveFile) (liveFileRefresh, bool) {
+	info := statFile(path)
+	if info == nil {
+		// Vanished: the injection stands until the user removes it.
+		return liveFileRefresh{}, false
+	}
+	if info.ModTime().Equal(tracked.modTime) && info.Size() == tracked.size {
+		return liveFileRefresh{}, false
+	}
+	changedFile, err := file.Read(path)
+	if err != nil {
+		return liveFileRefresh{}, false
+	}
+	refresh := liveFileRefresh{path: path, content: changedFile.Content, modTime: info.ModTime(), size: info.Size()}
+	if tracked.known && bytes.Equal(tracked.content, changedFile.Content) {
+		// Touched, not changed.
+		refresh.touched = true
+		return refresh, true
+	}
+	if tracked.known && file.AttachmentMediaType(changedFile.Content) == "" && file.AttachmentMediaType(tracked.content) == "" {
+		diff := liveFileDiff(path, tracked.content, changedFile.Content)
+		if float64(tracked.diffSize+len(diff)) <= maxLiveFileDiffRatio*float64(len(changedFile.Content)) {
+			refresh.diff = diff
+		}
+	}
+	return refresh, true
+}
+
+// checkLiveSource reloads a live git source, returning its re-injection when
+// the output changed: a diff of a diff would only confuse the model. Runs
+// git: caller must not hold the lock.
+func (s *Session) checkLiveSource(path string, tracked liveFile) (liveFileRefresh, bool) {
+	text := s.gitSourceText(path)
+	if tracked.known && string(tracked.content) == text {
+		return liveFileRefresh{}, false
+	}
+	return liveFileRefresh{path: path}, true
+}
+
+// gitSourceText loads a git selector's content as context message text. A
+// failing git degrades to an inline note, as an unreadable file does.
+// Caller must not hold the lock.
+func (s *Session) gitSourceText(path string) string {
+	source, err := gitcontext.Parse(path)
+	if err != nil {
+		return fmt.Sprintf("%s: [invalid: %v]", path, err)
+	}
+	content, err := source.Load(s.ctx)
+	if err != nil {
+		return fmt.Sprintf("%s: [unavailable: %v]", path, err)
+	}
+	return source.Format(content)
+}
+
+// liveFileDiff renders the unified diff between two versions of a file.
+func liveFileDiff(path string, before, after []byte) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(before)),
+		B:        difflib.SplitLines(string(after)),
+		FromFile: path,
+		ToFile:   path,
+		Context:  liveFileDiffContext,
+	})
+	return diff
+}
+
+// reinjectLiveFiles re-injects live files whole through SetInjectedFiles:
+// removing them soft-deletes their injections and diffs, adding them back
+// queues fresh injections for ensureContext, at the end of the history.
+func (s *Session) reinjectLiveFiles(paths []string) {
+	injectedFilePaths := s.InjectedFiles()
+	remainingPaths := slices.DeleteFunc(slices.Clone(injectedFilePaths), func(path string) bool {
+		return slices.Contains(paths, path)
+	})
+	s.SetInjectedFiles(remainingPaths)
+	s.mu.Lock()
+	for _, path := range paths {
+		s.pathToLiveFile[path] = &liveFile{}
+	}
+	s.mu.Unlock()
+	s.SetInjectedFiles(injectedFilePaths)
+}
+
+// ---- Live file API (called from the UI goroutine) ----
+
+// IsLiveFile reports whether an injected file is live.
+func (s *Session) IsLiveFile(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.pathToLiveFile[path]
+	return ok
+}
+
+// SetLiveFile makes an injected file live, or static again. Virtual
+// injections have no file to watch. Blocking when the injection is already
+// persisted (its annotation is updated) — call it off the UI loop.
+func (s *Session) SetLiveFile(path string, live bool) error {
+	s.mu.Lock()
+	if _, ok := s.params.InjectedFileContents[path]; ok {
+		s.mu.Unlock()
+		return fmt.Errorf("%s is not a file", path)
+	}
+	if !slices.Contains(s.injectedFilePaths, path) {
+		s.mu.Unlock()
+		return fmt.Errorf("%s is not injected", path)
+	}
+	if _, ok := s.pathToLiveFile[path]; ok == live {
+		s.mu.Unlock()
+		return nil
+	}
+	var injectedMessage *aipb.Message
+	messageName := s.injectedFilePathToMessageName[path]
+	for _, message := range s.messages {
+		if store.InjectedFilePath(message) == path && message.GetName() == messageName {
+			injectedMessage = message
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if injectedMessage != nil && messageName != "" {
+		updatedMessage := proto.Clone(injectedMessage).(*aipb.Message)
+		store.SetLiveFile(updatedMessage, live)
+		persistedMessage, err := s.store.UpdateMessage(s.ctx, updatedMessage, "annotations")
+		if err != nil {
+			return fmt.Errorf("updating injected file %s: %w", path, err)
+		}
+		injectedMessage = persistedMessage
+	}
+
+	s.mu.Lock()
+	if !live {
+		delete(s.pathToLiveFile, path)
+	} else {
+		s.pathToLiveFile[path] = &liveFile{}
+	}
+	for i, message := range s.messages {
+		if store.InjectedFilePath(message) != path || message.GetName() != messageName {
+			continue
+		}
+		if messageName == "" {
+			// Replaced, not mutated: the timeline caches items by message.
+			optimisticMessage := proto.Clone(message).(*aipb.Message)
+			store.SetLiveFile(optimisticMessage, live)
+			s.messages[i] = optimisticMessage
+		} else {
+			s.messages[i] = injectedMessage
+			if live {
+				// What the model sees from here is the injected content.
+				s.settleLiveFile(injectedMessage, path, nil)
+			}
+		}
+		break
+	}
+	s.mu.Unlock()
+	s.refresh()
+	return nil
+}
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/malonaz/sgpt/internal/store"
+)
+
+// writeLiveFile writes a version of a live file. Versions differ in size, so
+// the stat check sees every change despite coarse modification times.
+func writeLiveFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInjectedContent(t *testing.T) {
+	path := "/src/main.go"
+	content, ok := injectedContent(store.NewInjectedFileMessage(path, "file /src/main.go: `package main\n`"), path)
+	if !ok || string(content) != "package main\n" {
+		t.Errorf("injectedContent = %q (%t), want the file content", content, ok)
+	}
+	if _, ok := injectedContent(store.NewInjectedFileMessage(path, "file /src/main.go: [unreadable: gone]"), path); ok {
+		t.Error("injectedContent recovered content from an unreadable-file note")
+	}
+}
+
+func TestCheckLiveFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	before := strings.Repeat("line\n", 200)
+	writeLiveFile(t, path, before)
+	tracked := liveFile{content: []byte(before), known: true}
+
+	// Unchanged content: only the stat is to be recorded.
+	refresh, ok := checkLiveFile(path, tracked)
+	if !ok || !refresh.touched {
+		t.Fatalf("checkLiveFile = %+v (%t), want a touched file", refresh, ok)
+	}
+	if refresh.size != int64(len(before)) {
+		t.Errorf("size = %d, want %d", refresh.size, len(before))
+	}
+	tracked.modTime, tracked.size = refresh.modTime, refresh.size
+	if _, ok := checkLiveFile(path, tracked); ok {
+		t.Fatal("checkLiveFile reported an unchanged file")
+	}
+
+	// A small edit travels as a diff.
+	after := strings.Replace(before, "line\n", "edited\n", 1)
+	writeLiveFile(t, path, after)
+	refresh, ok = checkLiveFile(path, tracked)
+	if !ok || refresh.diff == "" {
+		t.Fatalf("checkLiveFile = %+v (%t), want a diff", refresh, ok)
+	}
+	if !strings.Contains(refresh.diff, "-line\n") || !strings.Contains(refresh.diff, "+edited\n") {
+		t.Errorf("diff = %q", refresh.diff)
+	}
+
+	// A rewrite re-injects the file.
+	writeLiveFile(t, path, "rewritten\n")
+	if refresh, ok := checkLiveFile(path, tracked); !ok || refresh.diff != "" {
+		t.Errorf("checkLiveFile = %+v (%t), want a re-injection", refresh, ok)
+	}
+
+	// Without a known baseline, any change re-injects the file.
+	tracked = liveFile{}
+	if refresh, ok := checkLiveFile(path, tracked); !ok || refresh.diff != "" {
+		t.Errorf("checkLiveFile = %+v (%t), want a re-injection", refresh, ok)
+	}
+}
+
+func TestCheckLiveFileAccumulatedDiffs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	before := strings.Repeat("line\n", 200)
+	after := strings.Replace(before, "line\n", "edited\n", 1)
+	writeLiveFile(t, path, after)
+	// The diffs already sent nearly outweigh the file: re-inject instead.
+	tracked := liveFile{content: []byte(before), known: true, diffSize: len(after) / 2}
+	if refresh, ok := checkLiveFile(path, tracked); !ok || refresh.diff != "" {
+		t.Errorf("checkLiveFile = %+v (%t), want a re-injection", refresh, ok)
+	}
+}
+
+func TestCheckLiveSource(t *testing.T) {
+	s := newReviewSession(context.Background())
+	// Invalid selectors degrade to a note without running git.
+	path := "git:bogus"
+	text := s.gitSourceText(path)
+	if !strings.Contains(text, "[invalid:") {
+		t.Fatalf("gitSourceText = %q, want an invalid-selector note", text)
+	}
+	if _, ok := s.checkLiveSource(path, liveFile{content: []byte(text), known: true}); ok {
+		t.Error("checkLiveSource reported an unchanged source")
+	}
+	if refresh, ok := s.checkLiveSource(path, liveFile{content: []byte("older output"), known: true}); !ok || refresh.diff != "" {
+		t.Errorf("checkLiveSource = %+v (%t), want a re-injection", refresh, ok)
+	}
+}
@@ -0,0 +1,210 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+const (
+	// defaultGenerationInitialBackoff and defaultGenerationMaxBackoff apply
+	// when the generation retry configuration leaves them unset.
+	defaultGenerationInitialBackoff = time.Second
+	defaultGenerationMaxBackoff     = 30 * time.Second
+	// retryCountdownInterval paces the refreshes of a retry's countdown.
+	retryCountdownInterval = time.Second
+)
+
+// retryPause is a turn waiting out the backoff before its next generation
+// attempt.
+type retryPause struct {
+	status   RetryStatus
+	deadline time.Time
+	answerCh chan bool
+}
+
+// RetryStatus describes the generation attempt a paused turn is about to
+// make.
+type RetryStatus struct {
+	// Model is the resource name of the model the attempt uses.
+	Model string
+	// Attempt counts the attempts with Model, this one included, out of
+	// MaxAttempts.
+	Attempt     int
+	MaxAttempts int
+	// Fallback is set when the attempt is the first with Model after the
+	// previous model exhausted its retries.
+	Fallback bool
+	// Err is the transient error of the failed attempt.
+	Err error
+	// Remaining is the wait left before the attempt.
+	Remaining time.Duration
+}
+
+// retryableGenerationError reports whether a generation failed transiently:
+// the provider was rate limited, overloaded or unreachable.
+func retryableGenerationError(err error) bool {
+	switch grpcstatus.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded:
+		return !IsCancelError(err)
+	default:
+		return false
+	}
+}
+
+// generate runs one generation, retrying transient failures with
+// exponential backoff, then falling back along the model chain once a model
+// exhausts its retries. The turn keeps the model that answered: its next
+// generations start there. A failed stream whose tool calls already ran
+// eagerly is never regenerated, as that would run them again.
+func (t *turn) generate(inputMessages []*aipb.Message) (*aipb.Message, error) {
+	s := t.session
+	params := s.Params()
+	models := generationModels(params)
+	generationRetry := params.GenerationRetry
+	maxAttempts := 1 + int(generationRetry.GetMaxRetries())
+	initialBackoff := defaultGenerationInitialBackoff
+	if generationRetry.GetInitialBackoff() != nil {
+		initialBackoff = generationRetry.GetInitialBackoff().AsDuration()
+	}
+	maxBackoff := defaultGenerationMaxBackoff
+	if generationRetry.GetMaxBackoff() != nil {
+		maxBackoff = generationRetry.GetMaxBackoff().AsDuration()
+	}
+	initialBackoff = min(initialBackoff, maxBackoff)
+
+	attempt, backoff := 1, initialBackoff
+	for {
+		eagerToolCalls := t.eagerToolCalls
+		generatedMessage, err := t.stream(inputMessages, models[t.modelIndex])
+		if err == nil || !retryableGenerationError(err) || t.eagerToolCalls != eagerToolCalls {
+			return generatedMessage, err
+		}
+		// The failed attempt's usage is settled before the next one streams
+		// over it.
+		s.settleModelUsage()
+
+		status := RetryStatus{Err: err}
+		var wait time.Duration
+		switch {
+		case attempt < maxAttempts:
+			attempt++
+			wait, backoff = backoff, min(2*backoff, maxBackoff)
+		case t.modelIndex+1 < len(models):
+			t.modelIndex++
+			attempt, wait, backoff = 1, initialBackoff, initialBackoff
+			status.Fallback = true
+		default:
+			return nil, err
+		}
+		status.Model = models[t.modelIndex].GetName()
+		status.Attempt, status.MaxAttempts = attempt, maxAttempts
+		if !s.awaitRetry(t.ctx, status, wait) {
+			if ctxErr := t.ctx.Err(); ctxErr != nil {
+				err = fmt.Errorf("stream cancelled: %w", ctxErr)
+				s.mu.Lock()
+				s.streamError = err
+				s.mu.Unlock()
+			}
+			return nil, err
+		}
+	}
+}
+
+// generationModels returns the chain of models a turn generates with: the
+// session's model, then its fallbacks. Fallback is part of retrying, so a
+// session without a retry configuration keeps to its model.
+func generationModels(params Params) []*aipb.Model {
+	models := []*aipb.Model{params.Model}
+	if params.GenerationRetry == nil {
+		return models
+	}
+	for _, fallbackModel := range params.FallbackModels {
+		// A sub-agent may run on one of its parent's fallbacks.
+		if fallbackModel.GetName() != params.Model.GetName() {
+			models = append(models, fallbackModel)
+		}
+	}
+	return models
+}
+
+// awaitRetry blocks the turn goroutine for wait, refreshing the countdown,
+// and reports whether the attempt should be made: the user may retry at
+// once, or give up; a cancelled turn gives up.
+func (s *Session) awaitRetry(ctx context.Context, status RetryStatus, wait time.Duration) bool {
+	pause := &retryPause{status: status, deadline: time.Now().Add(wait), answerCh: make(chan bool, 1)}
+	s.mu.Lock()
+	s.retryPause = pause
+	s.mu.Unlock()
+	s.refresh()
+
+	defer func() {
+		s.mu.Lock()
+		if s.retryPause == pause {
+			s.retryPause = nil
+		}
+		s.mu.Unlock()
+		s.refresh()
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	ticker := time.NewTicker(retryCountdownInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case answer := <-pause.answerCh:
+			return answer
+		case <-timer.C:
+			return true
+		case <-ticker.C:
+			s.refresh()
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// answerRetry delivers the user's decision to the waiting turn. Reports
+// whether a turn was actually waiting.
+func (s *Session) answerRetry(answer bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.retryPause == nil {
+		return false
+	}
+	// Buffered, single receiver: never blocks the UI goroutine.
+	s.retryPause.answerCh <- answer
+	s.retryPause = nil
+	return true
+}
+
+// ---- Retry API (called from the UI goroutine) ----
+
+// RetryStatus describes the attempt the running turn is waiting to make; nil
+// when it is not waiting.
+func (s *Session) RetryStatus() *RetryStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.retryPause == nil {
+		return nil
+	}
+	status := s.retryPause.status
+	status.Remaining = max(time.Until(s.retryPause.deadline), 0)
+	return &status
+}
+
+// RetryNow makes the waiting attempt without waiting out the backoff.
+func (s *Session) RetryNow() {
+	s.answerRetry(true)
+}
+
+// StopRetrying ends a turn waiting to retry with the transient error. Its
+// inputs are re-queued, so the next message resends them.
+func (s *Session) StopRetrying() {
+	s.answerRetry(false)
+}
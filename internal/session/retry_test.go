@@ -0,0 +1,113 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// awaitRetryPause spins until the turn waits to retry.
+func awaitRetryPause(t *testing.T, s *Session) *RetryStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if status := s.RetryStatus(); status != nil {
+			return status
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("turn never waited to retry")
+	return nil
+}
+
+func TestRetryableGenerationError(t *testing.T) {
+	for err, want := range map[error]bool{
+		grpcstatus.Error(codes.ResourceExhausted, "rate limited"):                           true,
+		fmt.Errorf("opening stream: %w", grpcstatus.Error(codes.Unavailable, "overloaded")): true,
+		grpcstatus.Error(codes.InvalidArgument, "bad request"):                              false,
+		fmt.Errorf("stream cancelled: %w", context.Canceled):                                false,
+		errors.New("accumulating stream response"):                                          false,
+	} {
+		if got := retryableGenerationError(err); got != want {
+			t.Errorf("retryableGenerationError(%v) = %t, want %t", err, got, want)
+		}
+	}
+}
+
+func TestGenerationModels(t *testing.T) {
+	model := &aipb.Model{Name: "providers/a/models/m"}
+	fallbackModels := []*aipb.Model{{Name: "providers/b/models/m"}, model}
+	for name, test := range map[string]struct {
+		generationRetry *sgptpb.GenerationRetry
+		want            []string
+	}{
+		"no retry configuration": {want: []string{"providers/a/models/m"}},
+		"retry configured": {
+			generationRetry: &sgptpb.GenerationRetry{MaxRetries: 1},
+			want:            []string{"providers/a/models/m", "providers/b/models/m"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			models := generationModels(Params{Model: model, FallbackModels: fallbackModels, GenerationRetry: test.generationRetry})
+			var got []string
+			for _, model := range models {
+				got = append(got, model.GetName())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("generationModels = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAwaitRetryElapses(t *testing.T) {
+	s := newReviewSession(context.Background())
+	if !s.awaitRetry(context.Background(), RetryStatus{Model: "providers/a/models/m"}, time.Millisecond) {
+		t.Fatal("awaitRetry = false, want true once the backoff elapsed")
+	}
+	if status := s.RetryStatus(); status != nil {
+		t.Errorf("RetryStatus = %+v after the attempt, want nil", status)
+	}
+}
+
+func TestAwaitRetryAnswers(t *testing.T) {
+	for name, test := range map[string]struct {
+		answer func(s *Session)
+		want   bool
+	}{
+		"retry now":      {answer: (*Session).RetryNow, want: true},
+		"stop retrying":  {answer: (*Session).StopRetrying, want: false},
+		"cancelled turn": {want: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := newReviewSession(ctx)
+			answerCh := make(chan bool, 1)
+			go func() {
+				answerCh <- s.awaitRetry(ctx, RetryStatus{Model: "providers/a/models/m", Attempt: 2, MaxAttempts: 3}, time.Hour)
+			}()
+
+			status := awaitRetryPause(t, s)
+			if status.Attempt != 2 || status.Remaining <= 0 || status.Remaining > time.Hour {
+				t.Errorf("RetryStatus = %+v", status)
+			}
+			if test.answer != nil {
+				test.answer(s)
+			} else {
+				cancel()
+			}
+			if got := <-answerCh; got != test.want {
+				t.Errorf("awaitRetry = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
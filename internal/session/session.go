@@ -10,6 +10,8 @@ package session
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -19,6 +21,7 @@ import (
 
 	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
 	"github.com/malonaz/sgpt/internal/file"
+	"github.com/malonaz/sgpt/internal/gitcontext"
 	"github.com/malonaz/sgpt/internal/store"
 	"github.com/malonaz/sgpt/internal/tool"
 )
@@ -32,6 +35,9 @@ const (
 	StateStreaming
 	StateExecutingTools
 	StateAwaitingReview
+	// StateAwaitingBudget: the turn reached a configured budget cap and is
+	// paused until the user continues or stops it.
+	StateAwaitingBudget
 )
 
 // verdict is the user's answer to a tool call review.
@@ -69,10 +75,24 @@ type Params struct {
 	// (virtual injections, e.g. knowledge-graph nodes). Paths present here
 	// are used verbatim — never normalized, never read from disk.
 	InjectedFileContents map[string]string
+	// LiveFiles are the injected files whose changes are sent to the model
+	// before each turn (see refreshLiveFiles).
+	LiveFiles []string
 	// LoreNameForPath recognizes an injected file as a lore, returning its
 	// canonical name. Lores enter the context as plain files, so this is the
 	// only way to tell them apart when reporting what the context holds.
 	LoreNameForPath func(path string) (string, bool)
+	// RetrieveLores, when set, picks the lores relevant to a user message
+	// that starts a turn, given the history so far; the returned paths join
+	// the injected files ahead of the message.
+	RetrieveLores func(query string, history []*aipb.Message) ([]string, error)
+	// Budget caps the session's spend and tool loops; nil is unlimited.
+	Budget *sgptpb.Budget
+	// FallbackModels are tried in order once Model keeps failing
+	// transiently, under GenerationRetry; nil retries nothing and falls
+	// back to nothing.
+	FallbackModels  []*aipb.Model
+	GenerationRetry *sgptpb.GenerationRetry
 }
 
 // Session drives a single chat conversation.
@@ -91,6 +111,8 @@ type Session struct {
 	streamingMessage  *aipb.Message
 	streamError       error
 	executingToolCall string
+	// toolCallProgress is the executing tool call's last reported progress.
+	toolCallProgress string
 	// currentTurn is the turn in flight, nil when idle. It scopes one full
 	// exchange (context RPCs, streams, tool loops); cancelling it aborts the
 	// turn wherever it is — including before the first stream opens.
@@ -109,6 +131,15 @@ type Session struct {
 	// terminal.
 	pendingReviews map[string]pendingReview
 
+	// budgetPause is set while the turn is parked at a reached budget cap.
+	budgetPause *budgetPause
+	// retryPause is set while the turn waits to retry a failed generation.
+	retryPause *retryPause
+	// chatBudgetGrants counts the confirmations past the per-chat cap.
+	chatBudgetGrants int
+	// tree pools this session's spend with its sub-agents' (see SetParent).
+	tree *spendTree
+
 	// injectedFilePaths are the files in the model context, mutable at
 	// runtime via SetInjectedFiles. Each path is persisted exactly once as a
 	// labeled user message; injectedFilePathToMessageName tracks which paths
@@ -116,6 +147,8 @@ type Session struct {
 	// provider prompt cache stays stable.
 	injectedFilePaths             []string
 	injectedFilePathToMessageName map[string]string
+	// pathToLiveFile tracks the live injected files, by path.
+	pathToLiveFile map[string]*liveFile
 	// systemPromptSent guards the one-time persistence of the system message.
 	systemPromptSent bool
 
@@ -176,6 +209,7 @@ func New(
 		autoAcceptedToolNameSet:       map[string]bool{},
 		pendingReviews:                map[string]pendingReview{},
 		injectedFilePathToMessageName: map[string]string{},
+		pathToLiveFile:                map[string]*liveFile{},
 		totalModelUsage:               &aipb.ModelUsage{},
 		lastModelUsage:                &aipb.ModelUsage{},
 		renderThrottle:                throttle{interval: renderInterval},
@@ -183,8 +217,12 @@ func New(
 	}
 	// Tools execute against this session's history (the registry is one
 	// instance shared across main chat and sub-agents): stamped on the
-	// context so tools can derive what the model has already seen.
+	// context so tools can derive what the model has already seen, along
+	// with the circuit breakers of this session's tools.
 	s.ctx = tool.WithHistory(s.ctx, s.historyForTools)
+	s.ctx = tool.WithChat(s.ctx, s.Chat)
+	s.ctx = tool.WithCircuitBreakers(s.ctx, tool.NewCircuitBreakers())
+	s.tree = newSpendTree(s)
 	s.injectedFilePaths = s.normalizeInjectedPaths(params.InjectedFiles)
 	// Sort once (on a copy — the slice is shared across sessions via the
 	// app's default params): the tool picker reads this on every open.
@@ -199,6 +237,7 @@ func New(
 			s.systemPromptSent = true
 		}
 	}
+	s.seedLiveFiles(messages)
 	s.enabledUserToolNameSet = map[string]bool{}
 	s.enabledAdvertisedNameSet = map[string]bool{}
 	// Seed from --tool/role config. The CLI already resolved these names at
@@ -276,31 +315,53 @@ func (s *Session) seedOptimisticContext() {
 		if _, ok := s.injectedFilePathToMessageName[path]; ok {
 			continue
 		}
-		s.messages = append(s.messages, store.NewInjectedFileMessage(path, s.injectedFileContent(path)))
+		s.messages = append(s.messages, s.markLiveFile(s.injectedFileMessage(path), path))
 	}
 }
 
-// injectedFileContent formats a file for injection; a vanished file degrades
-// to an inline note instead of killing the turn.
-func (s *Session) injectedFileContent(path string) string {
+// injectedFileMessage builds the message injecting a file: images and PDFs
+// travel as media blocks, anything else as text. A vanished file degrades to
+// an inline note instead of killing the turn. Reads the file or runs git:
+// caller must not hold the lock.
+func (s *Session) injectedFileMessage(path string) *aipb.Message {
 	// Virtual injections carry their content; there is no file behind them.
 	if content, ok := s.params.InjectedFileContents[path]; ok {
-		return content
+		return store.NewInjectedFileMessage(path, content)
+	}
+	if gitcontext.IsSelector(path) {
+		return store.NewInjectedFileMessage(path, s.gitSourceText(path))
 	}
 	injectedFile, err := file.Read(path)
 	if err != nil {
-		return fmt.Sprintf("file %s: [unreadable: %v]", path, err)
+		return store.NewInjectedFileMessage(path, fmt.Sprintf("file %s: [unreadable: %v]", path, err))
+	}
+	if mediaType := injectedFile.MediaType(); mediaType != "" {
+		// Sending media to a text-only model fails the whole generation.
+		if !s.params.Model.GetTtt().GetVision() {
+			note := fmt.Sprintf("file %s: [%s not attached: %s does not accept images or documents]",
+				path, file.DescribeAttachment(mediaType, injectedFile.Content), s.params.Model.GetName())
+			return store.NewInjectedFileMessage(path, note)
+		}
+		return store.NewInjectedAttachmentMessage(path, mediaType, injectedFile.Content)
 	}
-	return fmt.Sprintf("file %s: `%s`", path, injectedFile.Content)
+	return store.NewInjectedFileMessage(path, fmt.Sprintf("file %s: `%s`", path, injectedFile.Content))
 }
 
 // normalizeInjectedPaths normalizes real file paths but keeps virtual
 // (content-overridden) paths verbatim, so they keep matching their override.
+// Git selectors are canonicalized instead.
 func (s *Session) normalizeInjectedPaths(paths []string) []string {
 	normalized := make([]string, 0, len(paths))
 	seen := map[string]bool{}
 	for _, path := range paths {
-		if _, ok := s.params.InjectedFileContents[path]; !ok {
+		_, virtual := s.params.InjectedFileContents[path]
+		switch {
+		case virtual:
+		case gitcontext.IsSelector(path):
+			if source, err := gitcontext.Parse(path); err == nil {
+				path = source.String()
+			}
+		default:
 			path = file.Normalize([]string{path})[0]
 		}
 		if !seen[path] {
@@ -385,11 +446,14 @@ func (s *Session) StreamError() error {
 
 // State derives the lifecycle phase from the session's facts — nothing ever
 // stores it, so it can never go stale. A pending review outranks execution,
-// which outranks a turn merely being in flight.
+// which outranks a turn merely being in flight. A budget pause outranks them
+// all: nothing else progresses until the user answers it.
 func (s *Session) State() State {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	switch {
+	case s.budgetPause != nil:
+		return StateAwaitingBudget
 	case len(s.pendingReviews) > 0:
 		return StateAwaitingReview
 	case s.executingToolCall != "":
@@ -422,6 +486,21 @@ func (s *Session) setExecutingToolCall(id string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.executingToolCall = id
+	s.toolCallProgress = ""
+}
+
+// ToolCallProgress is the markdown progress the executing tool call last
+// reported; empty if none.
+func (s *Session) ToolCallProgress() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.toolCallProgress
+}
+
+func (s *Session) setToolCallProgress(markdown string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolCallProgress = markdown
 }
 
 func (s *Session) Params() Params {
@@ -498,6 +577,21 @@ func (s *Session) InjectedFiles() []string {
 func (s *Session) SetInjectedFiles(paths []string) {
 	paths = s.normalizeInjectedPaths(paths)
 
+	// Added paths are loaded before taking the lock to swap them in: git
+	// sources may take their time.
+	s.mu.Lock()
+	var addedPaths []string
+	for _, path := range paths {
+		if _, ok := s.injectedFilePathToMessageName[path]; !ok && s.optimisticInjectedFileIndex(path) < 0 {
+			addedPaths = append(addedPaths, path)
+		}
+	}
+	s.mu.Unlock()
+	pathToAddedMessage := make(map[string]*aipb.Message, len(addedPaths))
+	for _, path := range addedPaths {
+		pathToAddedMessage[path] = s.injectedFileMessage(path)
+	}
+
 	s.mu.Lock()
 	removedPathToMessageName := map[string]string{}
 	pathSet := make(map[string]bool, len(paths))
@@ -509,6 +603,15 @@ func (s *Session) SetInjectedFiles(paths []string) {
 			removedPathToMessageName[path] = messageName
 		}
 	}
+	// A removed live file takes its diffs along: they patch an injection the
+	// model no longer sees.
+	var removedDiffMessageNames []string
+	for path, tracked := range s.pathToLiveFile {
+		if !pathSet[path] {
+			removedDiffMessageNames = append(removedDiffMessageNames, tracked.diffMessageNames...)
+			delete(s.pathToLiveFile, path)
+		}
+	}
 	// Drop optimistic (never persisted) file messages outright — there is
 	// nothing server-side to soft-delete.
 	for _, path := range s.injectedFilePaths {
@@ -530,7 +633,13 @@ func (s *Session) SetInjectedFiles(paths []string) {
 		if s.optimisticInjectedFileIndex(path) >= 0 {
 			continue
 		}
-		s.messages = append(s.messages, store.NewInjectedFileMessage(path, s.injectedFileContent(path)))
+		// Lost to a concurrent change since loaded: ensureContext still
+		// persists it on the next turn.
+		addedMessage, ok := pathToAddedMessage[path]
+		if !ok {
+			continue
+		}
+		s.messages = append(s.messages, s.markLiveFile(addedMessage, path))
 		s.invalidatePrice()
 	}
 	s.mu.Unlock()
@@ -554,6 +663,18 @@ func (s *Session) SetInjectedFiles(paths []string) {
 		}
 		s.mu.Unlock()
 	}
+	for _, messageName := range removedDiffMessageNames {
+		if err := s.store.DeleteMessage(s.ctx, messageName); err != nil {
+			s.emitError(fmt.Errorf("removing live file changes: %w", err))
+			continue
+		}
+		s.mu.Lock()
+		s.messages = slices.DeleteFunc(s.messages, func(message *aipb.Message) bool {
+			return message.GetName() == messageName
+		})
+		s.invalidatePrice()
+		s.mu.Unlock()
+	}
 
 	s.refresh()
 }
@@ -609,8 +730,15 @@ func (s *Session) DeleteMessage(messageName string) error {
 			// path re-injects it instead of silently doing nothing.
 			if path := store.InjectedFilePath(message); path != "" {
 				delete(s.injectedFilePathToMessageName, path)
+				delete(s.pathToLiveFile, path)
 				s.injectedFilePaths = removePath(s.injectedFilePaths, path)
 			}
+			// Without one of its diffs, the model's view of a live file is
+			// unknown: its next change re-injects it whole.
+			if tracked, ok := s.pathToLiveFile[store.FileDiffPath(message)]; ok {
+				tracked.diffMessageNames = removePath(tracked.diffMessageNames, message.GetName())
+				tracked.content, tracked.known = nil, false
+			}
 			continue
 		}
 		remainingMessages = append(remainingMessages, message)
@@ -710,9 +838,13 @@ func (s *Session) ensureContext() error {
 	systemPrompt := s.params.SystemPrompt
 	systemPromptPending := systemPrompt != "" && !s.systemPromptSent
 	var newFilePaths []string
+	newLiveFilePathSet := map[string]bool{}
 	for _, path := range s.injectedFilePaths {
 		if _, ok := s.injectedFilePathToMessageName[path]; !ok {
 			newFilePaths = append(newFilePaths, path)
+			if _, ok := s.pathToLiveFile[path]; ok {
+				newLiveFilePathSet[path] = true
+			}
 		}
 	}
 	s.mu.Unlock()
@@ -737,12 +869,22 @@ func (s *Session) ensureContext() error {
 	}
 
 	for _, path := range newFilePaths {
-		createdMessage, err := s.store.CreateMessage(s.turnContext(), chatName, store.NewInjectedFileMessage(path, s.injectedFileContent(path)))
+		// Stat before reading: a change racing the read shows on the next
+		// refresh instead of going unnoticed.
+		live := newLiveFilePathSet[path]
+		var info os.FileInfo
+		if live {
+			info = statFile(path)
+		}
+		fileMessage := s.injectedFileMessage(path)
+		store.SetLiveFile(fileMessage, live)
+		createdMessage, err := s.store.CreateMessage(s.turnContext(), chatName, fileMessage)
 		if err != nil {
 			return fmt.Errorf("persisting injected file %s: %w", path, err)
 		}
 		s.mu.Lock()
 		s.injectedFilePathToMessageName[path] = createdMessage.GetName()
+		s.settleLiveFile(fileMessage, path, info)
 		if index := s.optimisticInjectedFileIndex(path); index >= 0 {
 			s.messages[index] = createdMessage
 		} else {
@@ -903,6 +1045,8 @@ func (s *Session) SendMessage(text string) {
 	defer s.endTurn(currentTurn)
 	s.refresh()
 
+	s.retrieveLores(userMessage, text)
+	s.refreshLiveFiles()
 	if err := s.ensureContext(); err != nil {
 		// The turn never ran: drop the optimistic message from the queue so a
 		// retry doesn't send it twice.
@@ -916,6 +1060,56 @@ func (s *Session) SendMessage(text string) {
 	currentTurn.run()
 }
 
+// retrieveLores runs the pre-turn lore retrieval for a turn's opening
+// message. Retrieved lores render ahead of the message, where ensureContext
+// persists them, so the model reads them before the question. Best effort:
+// a failed retrieval is reported and the turn runs without it.
+func (s *Session) retrieveLores(userMessage *aipb.Message, text string) {
+	if s.params.RetrieveLores == nil {
+		return
+	}
+	s.mu.Lock()
+	history := append([]*aipb.Message(nil), s.messages...)
+	s.mu.Unlock()
+	paths, err := s.params.RetrieveLores(text, history)
+	if err != nil {
+		s.emitError(fmt.Errorf("retrieving lores: %w", err))
+		return
+	}
+	paths = s.normalizeInjectedPaths(paths)
+	if len(paths) == 0 {
+		return
+	}
+	pathToMessage := make(map[string]*aipb.Message, len(paths))
+	for _, path := range paths {
+		pathToMessage[path] = s.injectedFileMessage(path)
+	}
+
+	s.mu.Lock()
+	injectedPathSet := make(map[string]bool, len(s.injectedFilePaths))
+	for _, path := range s.injectedFilePaths {
+		injectedPathSet[path] = true
+	}
+	index := len(s.messages)
+	for i, message := range s.messages {
+		if message == userMessage {
+			index = i
+			break
+		}
+	}
+	for _, path := range paths {
+		if injectedPathSet[path] {
+			continue
+		}
+		s.injectedFilePaths = append(s.injectedFilePaths, path)
+		s.messages = append(s.messages[:index], append([]*aipb.Message{pathToMessage[path]}, s.messages[index:]...)...)
+		index++
+	}
+	s.invalidatePrice()
+	s.mu.Unlock()
+	s.refresh()
+}
+
 // rollbackPendingInput removes an optimistically appended message after the
 // turn failed before reaching the model.
 func (s *Session) rollbackPendingInput(message *aipb.Message) {
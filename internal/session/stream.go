@@ -25,7 +25,7 @@ const renderInterval = 66 * time.Millisecond
 // (user text, tool results) are persisted server-side along with the
 // generated assistant message. Blocks until the stream completes or errors.
 // Returns the assistant message.
-func (t *turn) stream(inputMessages []*aipb.Message) (*aipb.Message, error) {
+func (t *turn) stream(inputMessages []*aipb.Message, model *aipb.Model) (*aipb.Message, error) {
 	s := t.session
 
 	// Snapshot once: the UI goroutine mutates params (reasoning effort, tool
@@ -33,7 +33,7 @@ func (t *turn) stream(inputMessages []*aipb.Message) (*aipb.Message, error) {
 	params := s.Params()
 	generateMessageRequest := &aiservicepb.GenerateMessageRequest{
 		Parent:   s.Chat().GetName(),
-		Model:    params.Model.GetName(),
+		Model:    model.GetName(),
 		Messages: inputMessages,
 		// Filtered by the user's runtime tool selection (SetEnabledTools).
 		Tools:    s.advertisedTools(),
@@ -110,6 +110,7 @@ func (t *turn) stream(inputMessages []*aipb.Message) (*aipb.Message, error) {
 			debug.LogProto("eager", toolCall)
 			s.resolveToolCall(t.ctx, toolCall, true)
 			resolvedToolCallCount++
+			t.eagerToolCalls++
 		}
 
 		s.refresh()
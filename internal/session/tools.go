@@ -86,6 +86,10 @@ func (s *Session) executeToolCall(ctx context.Context, toolCall *aipb.ToolCall)
 		s.refresh()
 	}()
 
+	ctx = tool.WithProgress(ctx, func(markdown string) {
+		s.setToolCallProgress(markdown)
+		s.refresh()
+	})
 	toolResult, err := s.registry.Execute(ctx, toolCall)
 	if err != nil {
 		toolResult = ai.NewErrorToolResult(toolCall.Name, toolCall.Id, err)
@@ -20,6 +20,15 @@ type turn struct {
 	// executions, review waits — so one cancel aborts it wherever it is.
 	ctx    context.Context
 	cancel context.CancelFunc
+	// toolIterations counts the tool round trips of the turn so far;
+	// toolIterationGrants the confirmations past the configured cap.
+	toolIterations      int
+	toolIterationGrants int
+	// modelIndex selects the model generating, in the chain of the session's
+	// model and its fallbacks; eagerToolCalls counts the tool calls resolved
+	// mid-stream so far.
+	modelIndex     int
+	eagerToolCalls int
 }
 
 func newTurn(s *Session) *turn {
@@ -30,19 +39,26 @@ func newTurn(s *Session) *turn {
 // run executes the turn to completion: generate → resolve tool calls → loop.
 // Every loop iteration flushes chat state before streaming, and the turn
 // flushes once more on exit, so a turn never leaves unpersisted chat state
-// behind.
+// behind. Budgets are enforced before each generation: a reached cap pauses
+// the turn until the user continues or stops it.
 func (t *turn) run() {
 	s := t.session
 	defer s.flushChat()
 	for {
 		s.flushChat()
+		if err := t.enforceBudget(); err != nil {
+			// Inputs stay queued (tool results included), so the next turn
+			// picks the conversation up where the cap stopped it.
+			s.mu.Lock()
+			s.streamError = err
+			s.mu.Unlock()
+			s.refresh()
+			s.notifyTurnComplete("", err)
+			return
+		}
 		inputMessages := s.takeInputMessages()
-		generatedMessage, err := t.stream(inputMessages)
-
-		s.mu.Lock()
-		ai.AggregateModelUsage(s.totalModelUsage, s.lastModelUsage)
-		*s.lastModelUsage = aipb.ModelUsage{}
-		s.mu.Unlock()
+		generatedMessage, err := t.generate(inputMessages)
+		s.settleModelUsage()
 
 		if err != nil {
 			// The failed inputs are re-queued (the server excluded them from
@@ -71,6 +87,7 @@ func (t *turn) run() {
 		}
 
 		t.executeToolCalls(generatedMessage, toolCalls)
+		t.toolIterations++
 
 		// Cancelled mid-review or mid-execution: the remaining calls were
 		// resolved as cancelled and their results queued for the next turn —
@@ -83,6 +100,14 @@ func (t *turn) run() {
 	}
 }
 
+// settleModelUsage adds the last generation's usage to the session's total.
+func (s *Session) settleModelUsage() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ai.AggregateModelUsage(s.totalModelUsage, s.lastModelUsage)
+	*s.lastModelUsage = aipb.ModelUsage{}
+}
+
 // executeToolCalls resolves every tool call of the assistant message strictly
 // sequentially, then queues the results as input for the next generation.
 //
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	aiservicepb "github.com/malonaz/core/genproto/ai/ai_service/v1"
 	aipb "github.com/malonaz/core/genproto/ai/v1"
@@ -23,6 +24,39 @@ import (
 // be quoted as string literals: labels."sgpt.com/favorite" = "true".
 var FavoriteFilter = fmt.Sprintf("labels.%q = %q", sgptpb.Labels.Favorite.GetKey(), aip.LabelValueTrue)
 
+// ArchivedFilter is the server-side filter matching archived chats.
+var ArchivedFilter = fmt.Sprintf("labels.%q = %q", sgptpb.Labels.Archived.GetKey(), aip.LabelValueTrue)
+
+// ParentChatFilter returns the server-side filter matching the sub-agent
+// chats launched by the chat with the given ID.
+func ParentChatFilter(parentChatID string) string {
+	return fmt.Sprintf("labels.%q = %q", sgptpb.Labels.ParentChat.GetKey(), parentChatID)
+}
+
+// TimeRangeFilter returns the server-side filter matching resources whose
+// timestamp field falls in [start, end). A zero bound is open.
+func TimeRangeFilter(field string, start, end time.Time) string {
+	var filters []string
+	if !start.IsZero() {
+		filters = append(filters, fmt.Sprintf("%s >= %q", field, start.UTC().Format(time.RFC3339)))
+	}
+	if !end.IsZero() {
+		filters = append(filters, fmt.Sprintf("%s < %q", field, end.UTC().Format(time.RFC3339)))
+	}
+	return AndFilters(filters...)
+}
+
+// AndFilters conjoins filters, skipping empty ones.
+func AndFilters(filters ...string) string {
+	var nonEmpty []string
+	for _, filter := range filters {
+		if filter != "" {
+			nonEmpty = append(nonEmpty, filter)
+		}
+	}
+	return strings.Join(nonEmpty, " AND ")
+}
+
 const (
 	// TagsAnnotation stores chat tags, comma-separated. Tags such as GitHub
 	// repos ("owner/repo") don't fit the label value pattern, hence annotations.
@@ -31,9 +65,18 @@ const (
 	FilesAnnotation = "sgpt.com/files"
 	// CurrentModelAnnotation stores the model resource name in use by the chat.
 	CurrentModelAnnotation = "sgpt.com/current-model"
+	// RoleAnnotation stores the selector of the role the chat was launched
+	// with, so spend can be attributed per role.
+	RoleAnnotation = "sgpt.com/role"
 	// FilePathAnnotation stores, on an injected-file message, the path of the
 	// file whose content the message carries.
 	FilePathAnnotation = "sgpt.com/file-path"
+	// LiveFileAnnotation marks an injected-file message as live: the file's
+	// changes are sent to the model before each turn.
+	LiveFileAnnotation = "sgpt.com/live-file"
+	// FileDiffPathAnnotation stores, on a file-diff message, the path of the
+	// live file whose change the message carries.
+	FileDiffPathAnnotation = "sgpt.com/file-diff-path"
 )
 
 // Store wraps the ai service client, which owns the chat data layer.
@@ -113,6 +156,15 @@ func (s *Store) GetChat(ctx context.Context, name string) (*aipb.Chat, error) {
 	return chat, nil
 }
 
+// ChatName resolves a chat reference — a full resource name, or the bare
+// chat ID (its last segment) — to a resource name.
+func (s *Store) ChatName(reference string) string {
+	if strings.Contains(reference, "/") {
+		return reference
+	}
+	return s.parent() + "/chats/" + reference
+}
+
 // DeleteChat deletes a chat by resource name.
 func (s *Store) DeleteChat(ctx context.Context, name string) error {
 	deleteChatRequest := &aiservicepb.DeleteChatRequest{Name: name}
@@ -156,6 +208,30 @@ func (s *Store) LatestChat(ctx context.Context) (*aipb.Chat, error) {
 	return chats[0], nil
 }
 
+// SpendSince sums the price of the chats created at or after since. A chat's
+// price is its lifetime total, so a chat resumed later still counts toward the
+// day it was created. Pages newest-first and stops at the first older chat.
+func (s *Store) SpendSince(ctx context.Context, since time.Time) (float64, error) {
+	var spend float64
+	pageToken := ""
+	for {
+		chats, nextPageToken, err := s.ListChats(ctx, 100, pageToken, "")
+		if err != nil {
+			return 0, err
+		}
+		for _, chat := range chats {
+			if chat.GetCreateTime().AsTime().Before(since) {
+				return spend, nil
+			}
+			spend += chat.GetPrice()
+		}
+		if nextPageToken == "" {
+			return spend, nil
+		}
+		pageToken = nextPageToken
+	}
+}
+
 // SetFavorite sets or clears the favorite label on a chat and persists it.
 func (s *Store) SetFavorite(ctx context.Context, chat *aipb.Chat, favorite bool) (*aipb.Chat, error) {
 	SetFavoriteLabel(chat, favorite)
@@ -247,6 +323,21 @@ func SetFavoriteLabel(chat *aipb.Chat, favorite bool) {
 	aip.SetLabel(chat, sgptpb.Labels.Favorite.GetKey(), aip.LabelValueTrue)
 }
 
+// IsArchived reports whether a chat is archived.
+func IsArchived(chat *aipb.Chat) bool {
+	value, _ := aip.GetLabel(chat, sgptpb.Labels.Archived.GetKey())
+	return value == aip.LabelValueTrue
+}
+
+// SetArchivedLabel sets or clears the archived label on a chat in place.
+func SetArchivedLabel(chat *aipb.Chat, archived bool) {
+	if !archived {
+		aip.DeleteLabel(chat, sgptpb.Labels.Archived.GetKey())
+		return
+	}
+	aip.SetLabel(chat, sgptpb.Labels.Archived.GetKey(), aip.LabelValueTrue)
+}
+
 // ParentChatID returns the ID of the chat that launched this sub-agent chat.
 func ParentChatID(chat *aipb.Chat) string {
 	value, _ := aip.GetLabel(chat, sgptpb.Labels.ParentChat.GetKey())
@@ -301,6 +392,16 @@ func SetCurrentModel(chat *aipb.Chat, model string) {
 	setChatAnnotation(chat, CurrentModelAnnotation, model)
 }
 
+// Role returns the selector of the role the chat was launched with.
+func Role(chat *aipb.Chat) string {
+	return chat.GetAnnotations()[RoleAnnotation]
+}
+
+// SetRole records the role the chat was launched with in place.
+func SetRole(chat *aipb.Chat, role string) {
+	setChatAnnotation(chat, RoleAnnotation, role)
+}
+
 func setChatAnnotation(chat *aipb.Chat, key, value string) {
 	if value == "" {
 		delete(chat.GetAnnotations(), key)
@@ -329,6 +430,76 @@ func NewInjectedFileMessage(path, content string) *aipb.Message {
 	return message
 }
 
+// NewInjectedAttachmentMessage builds a user message carrying an image or a
+// PDF as a media block, after a text block naming the file: labeled like any
+// injected file, so it is managed the same way.
+func NewInjectedAttachmentMessage(path, mediaType string, content []byte) *aipb.Message {
+	message := NewInjectedFileMessage(path, fmt.Sprintf("file %s:", path))
+	message.Blocks = append(message.Blocks, newMediaBlock(mediaType, content))
+	return message
+}
+
+// newMediaBlock wraps attachment content in the block of its kind: images
+// and documents (PDFs) are distinct blocks to providers.
+func newMediaBlock(mediaType string, content []byte) *aipb.Block {
+	if strings.HasPrefix(mediaType, "image/") {
+		return &aipb.Block{Content: &aipb.Block_Image{Image: &aipb.Image{Data: content, MediaType: mediaType}}}
+	}
+	return &aipb.Block{Content: &aipb.Block_Document{Document: &aipb.Document{Data: content, MediaType: mediaType}}}
+}
+
+// IsLiveFile reports whether an injected-file message is live.
+func IsLiveFile(message *aipb.Message) bool {
+	return message.GetAnnotations()[LiveFileAnnotation] == aip.LabelValueTrue
+}
+
+// SetLiveFile marks an injected-file message as live, or not.
+func SetLiveFile(message *aipb.Message, live bool) {
+	if !live {
+		delete(message.Annotations, LiveFileAnnotation)
+		return
+	}
+	if message.Annotations == nil {
+		message.Annotations = map[string]string{}
+	}
+	message.Annotations[LiveFileAnnotation] = aip.LabelValueTrue
+}
+
+// NewFileDiffMessage builds a context message carrying the unified diff of
+// a live file's change: cheaper than re-injecting the file, and it leaves
+// the cached prompt prefix intact.
+func NewFileDiffMessage(path, diff string) *aipb.Message {
+	message := &aipb.Message{
+		Role:   aipb.Role_ROLE_USER,
+		Blocks: []*aipb.Block{{Content: &aipb.Block_Text{Text: fmt.Sprintf("file %s changed:\n```diff\n%s```", path, diff)}}},
+		Annotations: map[string]string{
+			FileDiffPathAnnotation: path,
+		},
+	}
+	aip.SetLabel(message, sgptpb.Labels.Context.GetKey(), aip.LabelValueTrue)
+	return message
+}
+
+// FileDiffPath returns the live file path of a file-diff message, or ""
+// when the message is not one.
+func FileDiffPath(message *aipb.Message) string {
+	return message.GetAnnotations()[FileDiffPathAnnotation]
+}
+
+// Attachment returns the media type and content of a message's first media
+// block; ok is false when the message carries none.
+func Attachment(message *aipb.Message) (mediaType string, content []byte, ok bool) {
+	for _, block := range message.GetBlocks() {
+		if image := block.GetImage(); image != nil {
+			return image.GetMediaType(), image.GetData(), true
+		}
+		if document := block.GetDocument(); document != nil {
+			return document.GetMediaType(), document.GetData(), true
+		}
+	}
+	return "", nil, false
+}
+
 // InjectedFilePath returns the injected file path of a message, or "" when
 // the message is not an injected-file message.
 func InjectedFilePath(message *aipb.Message) string {
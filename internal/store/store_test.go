@@ -2,6 +2,7 @@ package store
 
 import (
 	"testing"
+	"time"
 
 	aipb "github.com/malonaz/core/genproto/ai/v1"
 	"github.com/malonaz/core/go/ai"
@@ -106,3 +107,63 @@ func TestOrphanedToolCallsEmptyHistory(t *testing.T) {
 		t.Fatalf("orphans = %v, want none", toolCallIDs(orphans))
 	}
 }
+
+func TestTimeRangeFilter(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	got := AndFilters(FavoriteFilter, TimeRangeFilter("create_time", start, end))
+	want := `labels."sgpt.com/favorite" = "true" AND create_time >= "2025-03-01T00:00:00Z" AND create_time < "2025-04-01T00:00:00Z"`
+	if got != want {
+		t.Errorf("filter = %s, want %s", got, want)
+	}
+	if got := TimeRangeFilter("create_time", time.Time{}, time.Time{}); got != "" {
+		t.Errorf("open range filter = %q, want empty", got)
+	}
+}
+
+func TestInjectedAttachmentMessage(t *testing.T) {
+	message := NewInjectedAttachmentMessage("/tmp/shot.png", "image/png", []byte("png bytes"))
+	if got := InjectedFilePath(message); got != "/tmp/shot.png" {
+		t.Errorf("InjectedFilePath = %q", got)
+	}
+	if !IsContextMessage(message) {
+		t.Error("attachment message is not labeled as context")
+	}
+	mediaType, content, ok := Attachment(message)
+	if !ok || mediaType != "image/png" || string(content) != "png bytes" {
+		t.Errorf("Attachment = %q, %q, %t", mediaType, content, ok)
+	}
+
+	mediaType, _, ok = Attachment(NewInjectedAttachmentMessage("/tmp/spec.pdf", "application/pdf", []byte("%PDF")))
+	if !ok || mediaType != "application/pdf" {
+		t.Errorf("Attachment(pdf) = %q, %t", mediaType, ok)
+	}
+	if _, _, ok := Attachment(NewInjectedFileMessage("/tmp/main.go", "package main")); ok {
+		t.Error("Attachment found media in a text file message")
+	}
+}
+
+func TestLiveFileAnnotations(t *testing.T) {
+	message := NewInjectedFileMessage("/src/main.go", "file /src/main.go: `package main`")
+	if IsLiveFile(message) {
+		t.Error("injected file is live by default")
+	}
+	SetLiveFile(message, true)
+	if !IsLiveFile(message) || InjectedFilePath(message) != "/src/main.go" {
+		t.Errorf("after SetLiveFile(true): live %t, path %q", IsLiveFile(message), InjectedFilePath(message))
+	}
+	SetLiveFile(message, false)
+	if IsLiveFile(message) {
+		t.Error("file still live after SetLiveFile(false)")
+	}
+
+	diffMessage := NewFileDiffMessage("/src/main.go", "-a\n+b\n")
+	if got := FileDiffPath(diffMessage); got != "/src/main.go" {
+		t.Errorf("FileDiffPath = %q", got)
+	}
+	// A diff is context, but not an injection: toggling the file never
+	// mistakes it for the file itself.
+	if !IsContextMessage(diffMessage) || InjectedFilePath(diffMessage) != "" {
+		t.Errorf("diff message: context %t, injected path %q", IsContextMessage(diffMessage), InjectedFilePath(diffMessage))
+	}
+}
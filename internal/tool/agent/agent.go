@@ -7,8 +7,10 @@ import (
 	"sync"
 
 	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/jsonschema"
 	"github.com/malonaz/sgpt/internal/tool"
 )
 
@@ -23,13 +25,28 @@ type LaunchRequest struct {
 	Files []string
 	Tools []string
 	Model string
+	// ParentChat is the resource name of the launching chat.
+	ParentChat string
+	// ResponseSchema, when set, is what the answer must validate against:
+	// the launcher checks it with ParseResponse.
+	ResponseSchema *jsonschema.Schema
+}
+
+// LaunchResult is a sub-agent's final answer and what it cost.
+type LaunchResult struct {
+	Response string
+	// StructuredResponse is the validated JSON answer, for requests with a
+	// response schema.
+	StructuredResponse *structpb.Value
+	Price              float64
 }
 
 // Launcher runs a sub-agent chat and blocks until it produces a final answer.
 // Implemented by the TUI App (it owns tabs); injected late via SetLauncher
-// because the registry is built before the App exists.
+// because the registry is built before the App exists. A sub-agent that
+// started but failed still returns its result, for the spend.
 type Launcher interface {
-	LaunchAgent(ctx context.Context, request *LaunchRequest) (string, error)
+	LaunchAgent(ctx context.Context, request *LaunchRequest) (*LaunchResult, error)
 }
 
 func parseArguments(toolCall *aipb.ToolCall) (*sgptpb.AgentRequest, error) {
@@ -37,12 +54,20 @@ func parseArguments(toolCall *aipb.ToolCall) (*sgptpb.AgentRequest, error) {
 	if err := tool.UnmarshalArguments(toolCall, agentRequest); err != nil {
 		return nil, err
 	}
-	if strings.TrimSpace(agentRequest.GetQuery()) == "" {
-		return nil, fmt.Errorf("no query specified")
+	if err := validateAgentRequest(agentRequest); err != nil {
+		return nil, err
 	}
 	return agentRequest, nil
 }
 
+func validateAgentRequest(agentRequest *sgptpb.AgentRequest) error {
+	if strings.TrimSpace(agentRequest.GetQuery()) == "" {
+		return fmt.Errorf("no query specified")
+	}
+	_, err := compileResponseSchema(agentRequest.GetResponseSchema())
+	return err
+}
+
 // Tool launches sub-agents in new chat tabs.
 type Tool struct {
 	mu       sync.Mutex
@@ -71,6 +96,13 @@ func (t *Tool) Review(_ context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolC
 	}
 	// Sub-agents spend tokens and may be granted mutating tools: never
 	// auto-execute. Summarize the grant so the user reviews scope, not JSON.
+	return &sgptpb.ToolCallMetadata{
+		DisplayMessage: &sgptpb.DisplayMessage{Content: summarizeGrant(agentRequest)},
+	}, nil
+}
+
+// summarizeGrant lists the tools, files and model a sub-agent is given.
+func summarizeGrant(agentRequest *sgptpb.AgentRequest) string {
 	var parts []string
 	if len(agentRequest.GetTools()) > 0 {
 		parts = append(parts, "tools: "+strings.Join(agentRequest.GetTools(), ", "))
@@ -81,9 +113,10 @@ func (t *Tool) Review(_ context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolC
 	if agentRequest.GetModel() != "" {
 		parts = append(parts, "model: "+agentRequest.GetModel())
 	}
-	return &sgptpb.ToolCallMetadata{
-		DisplayMessage: &sgptpb.DisplayMessage{Content: strings.Join(parts, " | ")},
-	}, nil
+	if agentRequest.GetResponseSchema() != "" {
+		parts = append(parts, "structured answer")
+	}
+	return strings.Join(parts, " | ")
 }
 
 func (t *Tool) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
@@ -95,26 +128,55 @@ func (t *Tool) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.Tool
 	if err != nil {
 		return nil, err
 	}
-	launchRequest := &LaunchRequest{
-		Query: agentRequest.GetQuery(),
-		Title: agentRequest.GetTitle(),
-		Files: agentRequest.GetFiles(),
-		Tools: agentRequest.GetTools(),
-		Model: agentRequest.GetModel(),
+	launchRequest, err := newLaunchRequest(ctx, agentRequest)
+	if err != nil {
+		return nil, err
 	}
 	// Blocks until the sub-agent's turn fully completes (including any tool
 	// calls the user reviews in the sub-agent's tab).
-	response, err := launcher.LaunchAgent(ctx, launchRequest)
+	launchResult, err := launcher.LaunchAgent(ctx, launchRequest)
 	if err != nil {
 		return nil, err
 	}
-	if response == "" {
-		response = "sub-agent finished without a text response"
+	agentResponse := &sgptpb.AgentResponse{
+		Response:           responseOrPlaceholder(launchResult.Response),
+		StructuredResponse: launchResult.StructuredResponse,
 	}
-	agentResponse := &sgptpb.AgentResponse{Response: response}
 	return tool.NewStructuredToolResult(toolCall, agentResponse)
 }
 
+// newLaunchRequest builds the launch of one requested sub-agent, parented to
+// the executing session's chat. A response schema is compiled and spelled
+// out at the end of the query.
+func newLaunchRequest(ctx context.Context, agentRequest *sgptpb.AgentRequest) (*LaunchRequest, error) {
+	responseSchema, err := compileResponseSchema(agentRequest.GetResponseSchema())
+	if err != nil {
+		return nil, err
+	}
+	launchRequest := &LaunchRequest{
+		Query:          agentRequest.GetQuery(),
+		Title:          agentRequest.GetTitle(),
+		Files:          agentRequest.GetFiles(),
+		Tools:          agentRequest.GetTools(),
+		Model:          agentRequest.GetModel(),
+		ResponseSchema: responseSchema,
+	}
+	if responseSchema != nil {
+		launchRequest.Query += responseInstruction(agentRequest.GetResponseSchema())
+	}
+	if parentChat := tool.Chat(ctx); parentChat != nil {
+		launchRequest.ParentChat = parentChat.GetName()
+	}
+	return launchRequest, nil
+}
+
+func responseOrPlaceholder(response string) string {
+	if response == "" {
+		return "sub-agent finished without a text response"
+	}
+	return response
+}
+
 // RenderRequest renders the query as markdown instead of raw JSON. Tolerates
 // partial arguments so the query is readable as it streams in.
 func (t *Tool) RenderRequest(toolCall *aipb.ToolCall) (string, bool) {
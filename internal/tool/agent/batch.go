@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+const (
+	defaultMaxConcurrency = 3
+	maxMaxConcurrency     = 8
+)
+
+// BatchDefinition is the tool definition for launching sub-agents
+// concurrently, built from the ToolService.AgentBatch method.
+var BatchDefinition = tool.MustBuildTool("agent_batch", tool.HandlerIDAgentBatch, "sgpt.v1.ToolService.AgentBatch")
+
+func parseBatchArguments(toolCall *aipb.ToolCall) (*sgptpb.AgentBatchRequest, error) {
+	agentBatchRequest := &sgptpb.AgentBatchRequest{}
+	if err := tool.UnmarshalArguments(toolCall, agentBatchRequest); err != nil {
+		return nil, err
+	}
+	if len(agentBatchRequest.GetAgents()) == 0 {
+		return nil, fmt.Errorf("no agents specified")
+	}
+	for i, agentRequest := range agentBatchRequest.GetAgents() {
+		if err := validateAgentRequest(agentRequest); err != nil {
+			return nil, fmt.Errorf("agent %d: %w", i, err)
+		}
+	}
+	return agentBatchRequest, nil
+}
+
+// maxConcurrency clamps the requested concurrency to [1, maxMaxConcurrency].
+func maxConcurrency(agentBatchRequest *sgptpb.AgentBatchRequest) int {
+	concurrency := int(agentBatchRequest.GetMaxConcurrency())
+	if concurrency <= 0 {
+		concurrency = defaultMaxConcurrency
+	}
+	return min(concurrency, maxMaxConcurrency)
+}
+
+// BatchTool launches several sub-agents at once, each in its own tab, through
+// the agent tool's launcher.
+type BatchTool struct {
+	agentTool *Tool
+}
+
+// NewBatchTool instantiates a batch tool sharing agentTool's launcher.
+func NewBatchTool(agentTool *Tool) *BatchTool {
+	return &BatchTool{agentTool: agentTool}
+}
+
+func (t *BatchTool) Review(_ context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	agentBatchRequest, err := parseBatchArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	// Same scope review as the agent tool, one line per sub-agent.
+	lines := []string{fmt.Sprintf("%d sub-agents, %d at a time", len(agentBatchRequest.GetAgents()), maxConcurrency(agentBatchRequest))}
+	for _, agentRequest := range agentBatchRequest.GetAgents() {
+		line := "• " + agentRequest.GetTitle()
+		if grant := summarizeGrant(agentRequest); grant != "" {
+			line += " | " + grant
+		}
+		lines = append(lines, line)
+	}
+	return &sgptpb.ToolCallMetadata{
+		DisplayMessage: &sgptpb.DisplayMessage{Content: strings.Join(lines, "\n")},
+	}, nil
+}
+
+func (t *BatchTool) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	agentBatchRequest, err := parseBatchArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	launcher, err := t.agentTool.getLauncher()
+	if err != nil {
+		return nil, err
+	}
+
+	agentRequests := agentBatchRequest.GetAgents()
+	progress := newBatchProgress(agentRequests)
+	progress.report(ctx)
+
+	results := make([]*sgptpb.AgentBatchResponse_Result, len(agentRequests))
+	semaphore := make(chan struct{}, maxConcurrency(agentBatchRequest))
+	var wg sync.WaitGroup
+	for i, agentRequest := range agentRequests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+			}
+			// Cancelling the batch frees the running agents' slots: queued
+			// agents must not take them and launch anyway.
+			if ctx.Err() != nil {
+				results[i] = &sgptpb.AgentBatchResponse_Result{Title: agentRequest.GetTitle(), Error: ctx.Err().Error()}
+				progress.finish(ctx, i, results[i])
+				return
+			}
+
+			progress.start(ctx, i)
+			result := &sgptpb.AgentBatchResponse_Result{Title: agentRequest.GetTitle()}
+			launchRequest, err := newLaunchRequest(ctx, agentRequest)
+			var launchResult *LaunchResult
+			if err == nil {
+				launchResult, err = launcher.LaunchAgent(ctx, launchRequest)
+			}
+			if launchResult != nil {
+				result.Price = launchResult.Price
+			}
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Response = responseOrPlaceholder(launchResult.Response)
+				result.StructuredResponse = launchResult.StructuredResponse
+			}
+			results[i] = result
+			progress.finish(ctx, i, result)
+		}()
+	}
+	wg.Wait()
+
+	agentBatchResponse := &sgptpb.AgentBatchResponse{Results: results}
+	return tool.NewStructuredToolResult(toolCall, agentBatchResponse)
+}
+
+// RenderRequest lists the sub-agents' titles and queries instead of raw
+// JSON. Tolerates partial arguments so the batch is readable as it streams
+// in.
+func (t *BatchTool) RenderRequest(toolCall *aipb.ToolCall) (string, bool) {
+	agentBatchRequest := &sgptpb.AgentBatchRequest{}
+	if tool.UnmarshalArguments(toolCall, agentBatchRequest) != nil || len(agentBatchRequest.GetAgents()) == 0 {
+		return "", false
+	}
+	sections := make([]string, 0, len(agentBatchRequest.GetAgents()))
+	for _, agentRequest := range agentBatchRequest.GetAgents() {
+		sections = append(sections, fmt.Sprintf("### 🤖 %s\n%s", agentRequest.GetTitle(), agentRequest.GetQuery()))
+	}
+	return strings.Join(sections, "\n\n"), true
+}
+
+func (t *BatchTool) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
+	agentBatchRequest := &sgptpb.AgentBatchRequest{}
+	if tool.UnmarshalArguments(toolCall, agentBatchRequest) != nil || len(agentBatchRequest.GetAgents()) == 0 {
+		return "🤖 sub-agents", true
+	}
+	return fmt.Sprintf("🤖 %d sub-agents", len(agentBatchRequest.GetAgents())), true
+}
+
+// RenderResult renders each sub-agent's answer under its title, with its
+// cost and any error.
+func (t *BatchTool) RenderResult(_ *aipb.ToolCall, toolResult *aipb.ToolResult) (string, bool) {
+	structured := toolResult.GetStructuredContent().GetStructValue()
+	if structured == nil {
+		return "", false
+	}
+	agentBatchResponse := &sgptpb.AgentBatchResponse{}
+	if err := pbutil.UnmarshalFromStruct(agentBatchResponse, structured); err != nil {
+		return "", false
+	}
+	sections := make([]string, 0, len(agentBatchResponse.GetResults()))
+	total := 0.0
+	for _, result := range agentBatchResponse.GetResults() {
+		total += result.GetPrice()
+		if result.GetError() != "" {
+			sections = append(sections, fmt.Sprintf("### ✗ %s · $%.4f\n**error:** %s", result.GetTitle(), result.GetPrice(), result.GetError()))
+			continue
+		}
+		response := result.GetResponse()
+		if structuredResponse := result.GetStructuredResponse(); structuredResponse != nil {
+			bytes, _ := pbutil.JSONMarshalPretty(structuredResponse)
+			response = fmt.Sprintf("```json\n%s\n```", bytes)
+		}
+		sections = append(sections, fmt.Sprintf("### ✓ %s · $%.4f\n%s", result.GetTitle(), result.GetPrice(), response))
+	}
+	sections = append(sections, fmt.Sprintf("_total: $%.4f_", total))
+	return strings.Join(sections, "\n\n"), true
+}
+
+// batchProgress tracks each sub-agent's status for the progress report.
+// Sub-agents finish concurrently: reports go out under the lock so a stale
+// render never overwrites a newer one.
+type batchProgress struct {
+	mu      sync.Mutex
+	titles  []string
+	results []*sgptpb.AgentBatchResponse_Result
+	started []bool
+}
+
+func newBatchProgress(agentRequests []*sgptpb.AgentRequest) *batchProgress {
+	titles := make([]string, len(agentRequests))
+	for i, agentRequest := range agentRequests {
+		titles[i] = agentRequest.GetTitle()
+	}
+	return &batchProgress{
+		titles:  titles,
+		results: make([]*sgptpb.AgentBatchResponse_Result, len(agentRequests)),
+		started: make([]bool, len(agentRequests)),
+	}
+}
+
+func (p *batchProgress) start(ctx context.Context, i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started[i] = true
+	tool.ReportProgress(ctx, p.renderLocked())
+}
+
+func (p *batchProgress) finish(ctx context.Context, i int, result *sgptpb.AgentBatchResponse_Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results[i] = result
+	tool.ReportProgress(ctx, p.renderLocked())
+}
+
+func (p *batchProgress) report(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tool.ReportProgress(ctx, p.renderLocked())
+}
+
+func (p *batchProgress) renderLocked() string {
+	lines := make([]string, len(p.titles))
+	done := 0
+	for i, title := range p.titles {
+		switch result := p.results[i]; {
+		case result != nil && result.GetError() != "":
+			done++
+			lines[i] = fmt.Sprintf("- ✗ **%s** · $%.4f · %s", title, result.GetPrice(), result.GetError())
+		case result != nil:
+			done++
+			lines[i] = fmt.Sprintf("- ✓ **%s** · $%.4f", title, result.GetPrice())
+		case p.started[i]:
+			lines[i] = fmt.Sprintf("- ● **%s** · running", title)
+		default:
+			lines[i] = fmt.Sprintf("- ○ **%s** · queued", title)
+		}
+	}
+	return fmt.Sprintf("%d/%d sub-agents done\n\n%s", done, len(p.titles), strings.Join(lines, "\n"))
+}
+
+var (
+	_ tool.Tool            = (*BatchTool)(nil)
+	_ tool.RequestRenderer = (*BatchTool)(nil)
+	_ tool.HeaderRenderer  = (*BatchTool)(nil)
+	_ tool.ResultRenderer  = (*BatchTool)(nil)
+)
+
+func init() { tool.RegisterBuiltin(BatchDefinition) }
@@ -0,0 +1,232 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// fakeLauncher answers sub-agents by title: titles starting with "fail" fail
+// after spending failedLaunchPrice, others answer for launchPrice. With block set, each
+// launch waits for release or cancellation.
+type fakeLauncher struct {
+	block   bool
+	release chan struct{}
+
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	launched   []string
+}
+
+const (
+	launchPrice       = 0.25
+	failedLaunchPrice = 0.5
+)
+
+func newFakeLauncher(block bool) *fakeLauncher {
+	return &fakeLauncher{block: block, release: make(chan struct{})}
+}
+
+func (l *fakeLauncher) LaunchAgent(ctx context.Context, request *LaunchRequest) (*LaunchResult, error) {
+	l.mu.Lock()
+	l.running++
+	l.maxRunning = max(l.maxRunning, l.running)
+	l.launched = append(l.launched, request.Title)
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.running--
+		l.mu.Unlock()
+	}()
+
+	if l.block {
+		select {
+		case <-l.release:
+		case <-ctx.Done():
+			return &LaunchResult{}, ctx.Err()
+		}
+	}
+	if strings.HasPrefix(request.Title, "fail") {
+		return &LaunchResult{Price: failedLaunchPrice}, errors.New("boom")
+	}
+	return &LaunchResult{Response: "answer from " + request.Title, Price: launchPrice}, nil
+}
+
+func (l *fakeLauncher) runningCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.running
+}
+
+func newBatchToolCall(t *testing.T, maxConcurrency int, titles ...string) *aipb.ToolCall {
+	t.Helper()
+	agents := make([]any, 0, len(titles))
+	for _, title := range titles {
+		agents = append(agents, map[string]any{"title": title, "query": "do " + title})
+	}
+	arguments, err := structpb.NewStruct(map[string]any{"agents": agents, "max_concurrency": maxConcurrency})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &aipb.ToolCall{Id: "1", Name: BatchDefinition.GetName(), Arguments: arguments}
+}
+
+func newBatchTool(launcher Launcher) *BatchTool {
+	agentTool := &Tool{}
+	agentTool.SetLauncher(launcher)
+	return NewBatchTool(agentTool)
+}
+
+func parseBatchResponse(t *testing.T, toolResult *aipb.ToolResult) *sgptpb.AgentBatchResponse {
+	t.Helper()
+	agentBatchResponse := &sgptpb.AgentBatchResponse{}
+	if err := pbutil.UnmarshalFromStruct(agentBatchResponse, toolResult.GetStructuredContent().GetStructValue()); err != nil {
+		t.Fatal(err)
+	}
+	return agentBatchResponse
+}
+
+// waitFor polls condition until it holds, failing the test after a second.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !condition(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+	}
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	for requested, want := range map[int32]int{-1: defaultMaxConcurrency, 0: defaultMaxConcurrency, 2: 2, 100: maxMaxConcurrency} {
+		if got := maxConcurrency(&sgptpb.AgentBatchRequest{MaxConcurrency: requested}); got != want {
+			t.Errorf("maxConcurrency(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
+
+func TestBatchRespectsConcurrency(t *testing.T) {
+	launcher := newFakeLauncher(true)
+	batchTool := newBatchTool(launcher)
+	toolCall := newBatchToolCall(t, 2, "a", "b", "c", "d", "e")
+	done := make(chan *aipb.ToolResult)
+	go func() {
+		toolResult, err := batchTool.Execute(context.Background(), toolCall)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- toolResult
+	}()
+
+	waitFor(t, func() bool { return launcher.runningCount() == 2 })
+	// Give a third agent the chance to overrun the limit.
+	time.Sleep(20 * time.Millisecond)
+	if running := launcher.runningCount(); running != 2 {
+		t.Errorf("%d agents running, want the limit of 2", running)
+	}
+	close(launcher.release)
+	agentBatchResponse := parseBatchResponse(t, <-done)
+	if len(agentBatchResponse.GetResults()) != 5 || len(launcher.launched) != 5 || launcher.maxRunning != 2 {
+		t.Errorf("%d results, %d launched, at most %d at once; want 5, 5, 2", len(agentBatchResponse.GetResults()), len(launcher.launched), launcher.maxRunning)
+	}
+}
+
+func TestBatchAggregatesResults(t *testing.T) {
+	batchTool := newBatchTool(newFakeLauncher(false))
+	toolCall := newBatchToolCall(t, 0, "first", "fail second", "third")
+	toolResult, err := batchTool.Execute(context.Background(), toolCall)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := parseBatchResponse(t, toolResult).GetResults()
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	for i, want := range []struct {
+		title, response, err string
+		price                float64
+	}{
+		{title: "first", response: "answer from first", price: launchPrice},
+		{title: "fail second", err: "boom", price: failedLaunchPrice},
+		{title: "third", response: "answer from third", price: launchPrice},
+	} {
+		result := results[i]
+		if result.GetTitle() != want.title || result.GetResponse() != want.response || result.GetError() != want.err || result.GetPrice() != want.price {
+			t.Errorf("result %d = %v, want %+v", i, result, want)
+		}
+	}
+	rendered, ok := batchTool.RenderResult(toolCall, toolResult)
+	if !ok || !strings.Contains(rendered, "### ✗ fail second · $0.5000\n**error:** boom") || !strings.HasSuffix(rendered, "_total: $1.0000_") {
+		t.Errorf("RenderResult = %q", rendered)
+	}
+}
+
+func TestBatchCancelledWhileQueued(t *testing.T) {
+	launcher := newFakeLauncher(true)
+	batchTool := newBatchTool(launcher)
+	ctx, cancel := context.WithCancel(context.Background())
+	toolCall := newBatchToolCall(t, 1, "running", "queued", "also queued")
+	done := make(chan *aipb.ToolResult)
+	go func() {
+		toolResult, err := batchTool.Execute(ctx, toolCall)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- toolResult
+	}()
+
+	waitFor(t, func() bool { return launcher.runningCount() == 1 })
+	cancel()
+	results := parseBatchResponse(t, <-done).GetResults()
+	if len(launcher.launched) != 1 {
+		t.Errorf("launched %v, want only the running agent", launcher.launched)
+	}
+	for _, result := range results {
+		if result.GetError() != context.Canceled.Error() {
+			t.Errorf("%s: error = %q, want %q", result.GetTitle(), result.GetError(), context.Canceled)
+		}
+	}
+}
+
+func TestBatchProgress(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []string
+	)
+	ctx := tool.WithProgress(context.Background(), func(markdown string) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, markdown)
+	})
+	if _, err := newBatchTool(newFakeLauncher(false)).Execute(ctx, newBatchToolCall(t, 1, "ok", "fail")); err != nil {
+		t.Fatal(err)
+	}
+
+	// One report up front, then one per start and per finish.
+	if len(reports) != 5 {
+		t.Fatalf("got %d reports, want 5: %q", len(reports), reports)
+	}
+	if want := "0/2 sub-agents done\n\n- ○ **ok** · queued\n- ○ **fail** · queued"; reports[0] != want {
+		t.Errorf("first report = %q, want %q", reports[0], want)
+	}
+	if want := "2/2 sub-agents done\n\n- ✓ **ok** · $0.2500\n- ✗ **fail** · $0.5000 · boom"; reports[4] != want {
+		t.Errorf("last report = %q, want %q", reports[4], want)
+	}
+
+	progress := newBatchProgress([]*sgptpb.AgentRequest{{Title: "a"}, {Title: "b"}})
+	progress.started[0] = true
+	if got, want := progress.renderLocked(), "0/2 sub-agents done\n\n- ● **a** · running\n- ○ **b** · queued"; got != want {
+		t.Errorf("renderLocked = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/malonaz/sgpt/internal/jsonschema"
+)
+
+// MaxResponseAttempts bounds how many answers a sub-agent gets to produce one
+// that validates against its response schema.
+const MaxResponseAttempts = 3
+
+// jsonFenceRegexp matches fenced code blocks, optionally tagged json.
+var jsonFenceRegexp = regexp.MustCompile("(?s)```(?:json)?[ \\t]*\\n(.*?)\\n[ \\t]*```")
+
+// compileResponseSchema compiles an AgentRequest's response_schema; nil when
+// the answer is free-form.
+func compileResponseSchema(document string) (*jsonschema.Schema, error) {
+	if strings.TrimSpace(document) == "" {
+		return nil, nil
+	}
+	schema, err := jsonschema.Compile(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_schema: %w", err)
+	}
+	return schema, nil
+}
+
+// responseInstruction is appended to a sub-agent's query when its answer
+// must follow a schema.
+func responseInstruction(document string) string {
+	return "\n\n---\nEnd your final message with your answer as a single ```json fenced code block " +
+		"that validates against this JSON schema. The block is parsed by a program: " +
+		"it must be the last one in the message and hold nothing but the JSON value.\n" +
+		"```json\n" + strings.TrimSpace(document) + "\n```"
+}
+
+// ParseResponse extracts the JSON answer from a sub-agent's final message and
+// validates it against the request's response schema. Returns nil for
+// free-form requests.
+func (r *LaunchRequest) ParseResponse(text string) (*structpb.Value, error) {
+	if r.ResponseSchema == nil {
+		return nil, nil
+	}
+	// The last fenced block is the answer; without one, the whole message
+	// may be bare JSON.
+	document := strings.TrimSpace(text)
+	if matches := jsonFenceRegexp.FindAllStringSubmatch(text, -1); len(matches) > 0 {
+		document = matches[len(matches)-1][1]
+	}
+	var answer any
+	if err := json.Unmarshal([]byte(document), &answer); err != nil {
+		return nil, fmt.Errorf("the answer is not valid JSON: %w", err)
+	}
+	if err := r.ResponseSchema.Validate(answer); err != nil {
+		return nil, fmt.Errorf("the answer does not match the schema: %w", err)
+	}
+	value, err := structpb.NewValue(answer)
+	if err != nil {
+		return nil, fmt.Errorf("converting the answer: %w", err)
+	}
+	return value, nil
+}
+
+// ResponseRetryMessage asks a sub-agent to fix an answer ParseResponse
+// rejected.
+func ResponseRetryMessage(err error) string {
+	return fmt.Sprintf("Your final answer was rejected: %v.\n\n"+
+		"Reply again with the corrected answer as a single ```json fenced code block matching the schema.", err)
+}
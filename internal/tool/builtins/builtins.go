@@ -0,0 +1,31 @@
+// Package builtins registers sgpt's built-in tools on a registry, for every
+// command that handles their calls: chats execute them, exports render them.
+package builtins
+
+import (
+	"github.com/malonaz/sgpt/internal/tool"
+	"github.com/malonaz/sgpt/internal/tool/agent"
+	"github.com/malonaz/sgpt/internal/tool/diff"
+	toolio "github.com/malonaz/sgpt/internal/tool/io"
+	"github.com/malonaz/sgpt/internal/tool/lores"
+	"github.com/malonaz/sgpt/internal/tool/shell"
+)
+
+// Register registers the handlers of every built-in tool. searchLoresTool
+// searches every reachable library; writes only ever land in its index's
+// enclosing repo. Returns the agent tool, for the caller to give a launcher:
+// without one, sub-agents cannot run.
+func Register(registry *tool.Registry, searchLoresTool *lores.Tool) *agent.Tool {
+	registry.Register(tool.HandlerIDShell, &shell.Tool{})
+	registry.Register(tool.HandlerIDReadFiles, &toolio.ReadFilesTool{})
+	registry.Register(tool.HandlerIDDiff, &diff.Tool{})
+	registry.Register(tool.HandlerIDReplace, &toolio.ReplaceTool{})
+	// Same instance everywhere: sub-agents can spawn sub-agents.
+	agentTool := &agent.Tool{}
+	registry.Register(tool.HandlerIDAgent, agentTool)
+	registry.Register(tool.HandlerIDAgentBatch, agent.NewBatchTool(agentTool))
+	registry.Register(tool.HandlerIDSearchLores, searchLoresTool)
+	registry.Register(tool.HandlerIDWriteLore, &lores.WriteTool{Index: searchLoresTool.Index})
+	registry.Register(tool.HandlerIDDeleteLore, &lores.DeleteTool{Index: searchLoresTool.Index})
+	return agentTool
+}
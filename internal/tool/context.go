@@ -10,6 +10,16 @@ import (
 // contexts.
 type historyKey struct{}
 
+// chatKey scopes the session-chat accessor carried on tool-execution contexts.
+type chatKey struct{}
+
+// progressKey scopes the progress reporter carried on tool-execution contexts.
+type progressKey struct{}
+
+// circuitBreakersKey scopes the session's circuit breakers carried on
+// tool-execution contexts.
+type circuitBreakersKey struct{}
+
 // WithHistory stamps a context with an accessor for the executing session's
 // message history. The registry is shared across sessions — main chat,
 // sub-agents, tabs — so tools that need to know what the model has already
@@ -27,3 +37,46 @@ func History(ctx context.Context) []*aipb.Message {
 	}
 	return history()
 }
+
+// WithChat stamps a context with an accessor for the executing session's
+// chat, so tools launching chats of their own (sub-agents) know their parent.
+func WithChat(ctx context.Context, chat func() *aipb.Chat) context.Context {
+	return context.WithValue(ctx, chatKey{}, chat)
+}
+
+// Chat returns the executing session's chat; nil when executing outside a
+// session.
+func Chat(ctx context.Context) *aipb.Chat {
+	chat, ok := ctx.Value(chatKey{}).(func() *aipb.Chat)
+	if !ok {
+		return nil
+	}
+	return chat()
+}
+
+// WithProgress stamps a context with a reporter for the executing tool call's
+// progress, which the TUI shows in the call's timeline item while it runs.
+func WithProgress(ctx context.Context, report func(markdown string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// ReportProgress replaces the executing tool call's progress with markdown;
+// a no-op when executing outside a session.
+func ReportProgress(ctx context.Context, markdown string) {
+	if report, ok := ctx.Value(progressKey{}).(func(markdown string)); ok {
+		report(markdown)
+	}
+}
+
+// WithCircuitBreakers stamps a context with the executing session's circuit
+// breakers: a tool failing in one session stays available to the others.
+func WithCircuitBreakers(ctx context.Context, circuitBreakers *CircuitBreakers) context.Context {
+	return context.WithValue(ctx, circuitBreakersKey{}, circuitBreakers)
+}
+
+// circuitBreakersFrom returns the executing session's circuit breakers; nil
+// when executing outside a session.
+func circuitBreakersFrom(ctx context.Context) *CircuitBreakers {
+	circuitBreakers, _ := ctx.Value(circuitBreakersKey{}).(*CircuitBreakers)
+	return circuitBreakers
+}
@@ -0,0 +1,104 @@
+package lores
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// DeleteLore is the tool definition, built from ToolService.DeleteLore.
+var DeleteLore = tool.MustBuildTool("delete_lore", tool.HandlerIDDeleteLore, "sgpt.v1.ToolService.DeleteLore")
+
+func parseDeleteLoreArguments(toolCall *aipb.ToolCall) (*sgptpb.DeleteLoreRequest, error) {
+	deleteLoreRequest := &sgptpb.DeleteLoreRequest{}
+	if err := tool.UnmarshalArguments(toolCall, deleteLoreRequest); err != nil {
+		return nil, err
+	}
+	if deleteLoreRequest.GetName() == "" {
+		return nil, fmt.Errorf("no name specified")
+	}
+	return deleteLoreRequest, nil
+}
+
+// DeleteTool deletes lores from the enclosing repo's library.
+type DeleteTool struct {
+	Index *lore.Index
+}
+
+func (t *DeleteTool) Review(_ context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	deleteLoreRequest, err := parseDeleteLoreArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	// The review names what goes: by execution time, the file is gone.
+	metadata := &sgptpb.ToolCallMetadata{DisplayMessage: &sgptpb.DisplayMessage{}}
+	library, id, err := t.Index.Writable(deleteLoreRequest.GetName())
+	if err != nil {
+		metadata.DisplayMessage.Content = fmt.Sprintf("Delete will fail: %v", err)
+		return metadata, nil
+	}
+	path := library.Path(id)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		metadata.DisplayMessage.Content = fmt.Sprintf("Delete will fail: %v", err)
+		return metadata, nil
+	}
+	metadata.DisplayMessage.Content = "Deletes " + path
+	if existing, err := lore.UnmarshalMarkdown(data); err == nil {
+		metadata.DisplayMessage.Content += fmt.Sprintf(" (%q)", existing.GetTitle())
+	}
+	return metadata, nil
+}
+
+func (t *DeleteTool) Execute(_ context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	deleteLoreRequest, err := parseDeleteLoreArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	library, id, err := t.Index.Writable(deleteLoreRequest.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if err := library.Delete(id); err != nil {
+		return nil, err
+	}
+	if err := syncLabels(library); err != nil {
+		return nil, err
+	}
+	return tool.NewStructuredToolResult(toolCall, &sgptpb.DeleteLoreResponse{
+		Name: library.QualifyName("lores/" + id),
+		Path: library.Path(id),
+	})
+}
+
+// RenderHeader shows the lore being deleted instead of the tool name.
+func (t *DeleteTool) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
+	deleteLoreRequest := &sgptpb.DeleteLoreRequest{}
+	if tool.UnmarshalArguments(toolCall, deleteLoreRequest) != nil || deleteLoreRequest.GetName() == "" {
+		return "", false
+	}
+	return fmt.Sprintf("🗑️ `%s`", deleteLoreRequest.GetName()), true
+}
+
+// RenderResult summarizes the deletion in one line.
+func (t *DeleteTool) RenderResult(_ *aipb.ToolCall, toolResult *aipb.ToolResult) (string, bool) {
+	deleteLoreResponse := &sgptpb.DeleteLoreResponse{}
+	if !unmarshalResult(toolResult, deleteLoreResponse) {
+		return "", false
+	}
+	return fmt.Sprintf("Deleted `%s`", deleteLoreResponse.GetPath()), true
+}
+
+var (
+	_ tool.Tool           = (*DeleteTool)(nil)
+	_ tool.HeaderRenderer = (*DeleteTool)(nil)
+	_ tool.ResultRenderer = (*DeleteTool)(nil)
+)
+
+func init() { tool.RegisterBuiltin(DeleteLore) }
@@ -0,0 +1,36 @@
+package lores
+
+import (
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	"github.com/malonaz/sgpt/internal/lore"
+)
+
+// Retriever returns a pre-turn lore retrieval hook (session.Params'
+// RetrieveLores): the file paths of the topK lores ranked most relevant to
+// the user's message. Lores already in the context — injected, or returned
+// by search_lores — are skipped, so each turn only ever adds new knowledge.
+func Retriever(index *lore.Index, ranker *lore.Ranker, topK int) func(query string, history []*aipb.Message) ([]string, error) {
+	return func(query string, history []*aipb.Message) ([]string, error) {
+		returnedNameSet := ReturnedNameSet(index, history)
+		// Over-fetch by what may be skipped, so the skips never starve the
+		// topK.
+		matches, err := ranker.Search(query, topK+len(returnedNameSet))
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for _, match := range matches {
+			if len(paths) == topK {
+				break
+			}
+			name, path, err := index.Resolve(match.Lore.GetName())
+			if err != nil || returnedNameSet[name] {
+				// Unresolvable: deleted since the ranker last looked.
+				continue
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	}
+}
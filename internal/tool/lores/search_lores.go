@@ -1,13 +1,12 @@
-// Package lores implements the search_lores tool: grep-style search over
-// the selected repos' lore libraries (.sgpt/lores), the agent-curated
-// knowledge base shared from repo to repo via imports.
+// Package lores implements the search_lores tool: grep-style or ranked
+// search over the selected repos' lore libraries (.sgpt/lores), the
+// agent-curated knowledge base shared from repo to repo via imports.
 package lores
 
 import (
 	"context"
 	"fmt"
 	"regexp"
-	"sort"
 	"strings"
 
 	aipb "github.com/malonaz/core/genproto/ai/v1"
@@ -41,19 +40,22 @@ type Tool struct {
 	// Index over the reachable libraries: the enclosing repo's and/or each
 	// "@{import}" repo's.
 	Index *lore.Index
+	// Ranker serves ranked searches over the same libraries; ranked
+	// searches fail without one.
+	Ranker *lore.Ranker
 }
 
-// returnedNameSet derives, from the session's message history, the canonical
+// ReturnedNameSet derives, from a session's message history, the canonical
 // names of every lore already in the model's context: lore files injected
-// directly (e.g. the configured default lores) and matches returned by
-// earlier searches. A lore is worth returning once — after that it is in
-// the context, and repeating it buries the hits that are not.
-func (t *Tool) returnedNameSet(messages []*aipb.Message) map[string]bool {
+// directly (the configured default lores, automatic retrieval) and matches
+// returned by earlier searches. A lore is worth returning once — after that
+// it is in the context, and repeating it buries the hits that are not.
+func ReturnedNameSet(index *lore.Index, messages []*aipb.Message) map[string]bool {
 	returned := map[string]bool{}
 	for _, message := range messages {
 		// Lore files injected as plain context files.
 		if path := store.InjectedFilePath(message); path != "" && message.GetDeleteTime() == nil {
-			if name, ok := t.Index.NameForPath(path); ok {
+			if name, ok := index.NameForPath(path); ok {
 				returned[name] = true
 			}
 			continue
@@ -115,11 +117,19 @@ func (t *Tool) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.Tool
 		}
 		lores = append(lores, libraryLores...)
 	}
-	matches, err := lore.Search(lores, searchLoresRequest.GetQuery(), int(searchLoresRequest.GetTopN()))
+	var matches []*lore.Match
+	if searchLoresRequest.GetRanked() {
+		if t.Ranker == nil {
+			return nil, fmt.Errorf("ranked search is unavailable")
+		}
+		matches, err = t.Ranker.Search(searchLoresRequest.GetQuery(), int(searchLoresRequest.GetTopN()))
+	} else {
+		matches, err = lore.Search(lores, searchLoresRequest.GetQuery(), int(searchLoresRequest.GetTopN()))
+	}
 	if err != nil {
 		return nil, err
 	}
-	returnedNameSet := t.returnedNameSet(tool.History(ctx))
+	returnedNameSet := ReturnedNameSet(t.Index, tool.History(ctx))
 	searchLoresResponse := &sgptpb.SearchLoresResponse{}
 	for _, match := range matches {
 		if returnedNameSet[match.Lore.GetName()] {
@@ -172,7 +182,7 @@ func (t *Tool) RenderResult(toolCall *aipb.ToolCall, toolResult *aipb.ToolResult
 	// bad query we still render, just without highlights.
 	var pattern *regexp.Regexp
 	if searchLoresRequest, err := parseSearchLoresArguments(toolCall); err == nil {
-		pattern, _ = regexp.Compile("(?i)" + searchLoresRequest.GetQuery())
+		pattern = highlightPattern(searchLoresRequest)
 	}
 	sections := make([]string, 0, len(searchLoresResponse.GetMatches()))
 	for _, match := range searchLoresResponse.GetMatches() {
@@ -184,14 +194,7 @@ func (t *Tool) RenderResult(toolCall *aipb.ToolCall, toolResult *aipb.ToolResult
 		if match.GetMatchCount() > 0 {
 			facts = append(facts, fmt.Sprintf("%d match(es)", match.GetMatchCount()))
 		}
-		keys := make([]string, 0, len(match.GetLabels()))
-		for key := range match.GetLabels() {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			facts = append(facts, fmt.Sprintf("`%s=%s`", key, match.GetLabels()[key]))
-		}
+		facts = append(facts, labelFacts(match.GetLabels())...)
 		b.WriteString(strings.Join(facts, " · ") + "\n")
 		if description := match.GetDescription(); description != "" {
 			fmt.Fprintf(&b, "*%s*\n", description)
@@ -223,6 +226,24 @@ func defuse(snippet string) string {
 	})
 }
 
+// highlightPattern matches what a search matched: the query itself for a
+// grep-style search, any of its terms for a ranked one. Nil when nothing
+// is worth highlighting.
+func highlightPattern(searchLoresRequest *sgptpb.SearchLoresRequest) *regexp.Regexp {
+	if !searchLoresRequest.GetRanked() {
+		pattern, _ := regexp.Compile("(?i)" + searchLoresRequest.GetQuery())
+		return pattern
+	}
+	terms := lore.Tokenize(searchLoresRequest.GetQuery())
+	if len(terms) == 0 {
+		return nil
+	}
+	for i, term := range terms {
+		terms[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(terms, "|") + `)\b`)
+}
+
 // highlight bolds every pattern match within a snippet line.
 func highlight(snippet string, pattern *regexp.Regexp) string {
 	if pattern == nil {
@@ -0,0 +1,200 @@
+package lores
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/pbutil"
+	"google.golang.org/protobuf/proto"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/lore"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// WriteLore is the tool definition, built from ToolService.WriteLore.
+var WriteLore = tool.MustBuildTool("write_lore", tool.HandlerIDWriteLore, "sgpt.v1.ToolService.WriteLore")
+
+func parseWriteLoreArguments(toolCall *aipb.ToolCall) (*sgptpb.WriteLoreRequest, error) {
+	writeLoreRequest := &sgptpb.WriteLoreRequest{}
+	if err := tool.UnmarshalArguments(toolCall, writeLoreRequest); err != nil {
+		return nil, err
+	}
+	switch {
+	case writeLoreRequest.GetName() == "":
+		return nil, fmt.Errorf("no name specified")
+	case strings.TrimSpace(writeLoreRequest.GetTitle()) == "":
+		return nil, fmt.Errorf("no title specified")
+	case strings.TrimSpace(writeLoreRequest.GetContent()) == "":
+		return nil, fmt.Errorf("no content specified")
+	}
+	return writeLoreRequest, nil
+}
+
+// WriteTool writes lores into the enclosing repo's library, the only
+// writable one. Every write goes through review: the user reads the lore as
+// prose before it lands.
+type WriteTool struct {
+	Index *lore.Index
+}
+
+// prepare resolves the target of a write and checks its labels against the
+// library's vocabulary, returning the labels it adds.
+func (t *WriteTool) prepare(writeLoreRequest *sgptpb.WriteLoreRequest) (lore.Library, string, []string, error) {
+	library, id, err := t.Index.Writable(writeLoreRequest.GetName())
+	if err != nil {
+		return lore.Library{}, "", nil, err
+	}
+	newLabels, err := library.CheckLabels(id, writeLoreRequest.GetLabels())
+	if err != nil {
+		return lore.Library{}, "", nil, err
+	}
+	return library, id, newLabels, nil
+}
+
+func (t *WriteTool) Review(_ context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	writeLoreRequest, err := parseWriteLoreArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	// Library mutation: never auto-execute. Failures are surfaced in the
+	// review; Execute produces the error result the model reacts to.
+	metadata := &sgptpb.ToolCallMetadata{DisplayMessage: &sgptpb.DisplayMessage{}}
+	library, id, newLabels, err := t.prepare(writeLoreRequest)
+	if err != nil {
+		metadata.DisplayMessage.Content = fmt.Sprintf("Write will fail: %v", err)
+		return metadata, nil
+	}
+	path := library.Path(id)
+	lines := []string{"Creates " + path}
+	if data, err := os.ReadFile(path); err == nil {
+		lines[0] = "Overwrites " + path
+		if existing, err := lore.UnmarshalMarkdown(data); err == nil {
+			lines[0] += fmt.Sprintf(" (%q)", existing.GetTitle())
+		}
+	}
+	if len(newLabels) > 0 {
+		lines = append(lines, "New labels: "+strings.Join(newLabels, ", "))
+	}
+	metadata.DisplayMessage.Content = strings.Join(lines, "\n")
+	return metadata, nil
+}
+
+func (t *WriteTool) Execute(_ context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	writeLoreRequest, err := parseWriteLoreArguments(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	// Re-checked at execution time: the library may have changed since
+	// review.
+	library, id, newLabels, err := t.prepare(writeLoreRequest)
+	if err != nil {
+		return nil, err
+	}
+	_, statErr := os.Stat(library.Path(id))
+	path, err := library.Save(id, &sgptpb.Lore{
+		Title:       writeLoreRequest.GetTitle(),
+		Description: writeLoreRequest.GetDescription(),
+		Labels:      writeLoreRequest.GetLabels(),
+		Content:     writeLoreRequest.GetContent(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := syncLabels(library); err != nil {
+		return nil, err
+	}
+	return tool.NewStructuredToolResult(toolCall, &sgptpb.WriteLoreResponse{
+		Name:      library.QualifyName("lores/" + id),
+		Path:      path,
+		Created:   os.IsNotExist(statErr),
+		NewLabels: newLabels,
+	})
+}
+
+// RenderHeader shows the lore being written instead of the tool name,
+// tolerating partial arguments so it appears as soon as the name streams in.
+func (t *WriteTool) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
+	writeLoreRequest := &sgptpb.WriteLoreRequest{}
+	if tool.UnmarshalArguments(toolCall, writeLoreRequest) != nil || writeLoreRequest.GetName() == "" {
+		return "", false
+	}
+	return fmt.Sprintf("📝 `%s`", writeLoreRequest.GetName()), true
+}
+
+// RenderRequest renders the lore as the reader will meet it — title,
+// labels, description, then the content as markdown — rather than as a JSON
+// payload with the content escaped on one line.
+func (t *WriteTool) RenderRequest(toolCall *aipb.ToolCall) (string, bool) {
+	writeLoreRequest := &sgptpb.WriteLoreRequest{}
+	// Partial arguments: render whatever has streamed in so far.
+	if tool.UnmarshalArguments(toolCall, writeLoreRequest) != nil || writeLoreRequest.GetTitle() == "" {
+		return "", false
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "### 📜 %s\n", writeLoreRequest.GetTitle())
+	if facts := labelFacts(writeLoreRequest.GetLabels()); len(facts) > 0 {
+		b.WriteString(strings.Join(facts, " · ") + "\n")
+	}
+	if description := writeLoreRequest.GetDescription(); description != "" {
+		fmt.Fprintf(&b, "*%s*\n", description)
+	}
+	b.WriteString("\n---\n\n")
+	b.WriteString(strings.TrimSpace(writeLoreRequest.GetContent()))
+	return b.String(), true
+}
+
+// RenderResult summarizes the write in one line.
+func (t *WriteTool) RenderResult(_ *aipb.ToolCall, toolResult *aipb.ToolResult) (string, bool) {
+	writeLoreResponse := &sgptpb.WriteLoreResponse{}
+	if !unmarshalResult(toolResult, writeLoreResponse) {
+		return "", false
+	}
+	verb := "Updated"
+	if writeLoreResponse.GetCreated() {
+		verb = "Created"
+	}
+	return fmt.Sprintf("%s `%s`", verb, writeLoreResponse.GetPath()), true
+}
+
+var (
+	_ tool.Tool            = (*WriteTool)(nil)
+	_ tool.HeaderRenderer  = (*WriteTool)(nil)
+	_ tool.RequestRenderer = (*WriteTool)(nil)
+	_ tool.ResultRenderer  = (*WriteTool)(nil)
+)
+
+func init() { tool.RegisterBuiltin(WriteLore) }
+
+// labelFacts renders labels as sorted inline-code "key=value" facts.
+func labelFacts(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	facts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		facts = append(facts, fmt.Sprintf("`%s=%s`", key, labels[key]))
+	}
+	return facts
+}
+
+// syncLabels refreshes the library's label vocabulary after a write.
+func syncLabels(library lore.Library) error {
+	lores, err := library.Load()
+	if err != nil {
+		return err
+	}
+	return library.SyncLabels(lores)
+}
+
+// unmarshalResult parses a tool result's structured content into response.
+func unmarshalResult(toolResult *aipb.ToolResult, response proto.Message) bool {
+	structured := toolResult.GetStructuredContent().GetStructValue()
+	return structured != nil && pbutil.UnmarshalFromStruct(response, structured) == nil
+}
@@ -0,0 +1,310 @@
+// Package mcp exposes the tools of Model Context Protocol servers
+// (`.sgpt/{title}.mcp` files) through the tool registry.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"github.com/malonaz/core/go/pbutil"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+const (
+	// ServerAnnotation names the MCP server (its selector) a tool belongs to.
+	ServerAnnotation = "sgpt.com/mcp-server"
+	// ToolAnnotation is the tool's name on its server, before prefixing.
+	ToolAnnotation = "sgpt.com/mcp-tool"
+)
+
+// Manager connects to MCP servers and implements tool.Tool for the tools
+// they expose.
+type Manager struct {
+	// serverNameToConfiguration indexes the discovered MCP server files
+	// (selector-named) this manager can connect to.
+	serverNameToConfiguration map[string]*sgptpb.McpServer
+
+	mu sync.Mutex
+	// serverNameToClient records connected servers: servers are started
+	// lazily, on the first EnsureServer call for their name.
+	serverNameToClient map[string]*gomcp.Client
+	serverNameToTools  map[string][]*aipb.Tool
+	// serverNameToConnection holds the connections in progress: callers
+	// asking for a server being connected wait for it instead of starting
+	// another.
+	serverNameToConnection map[string]*connection
+	closed                 bool
+}
+
+// connection is a server connection in progress; done is closed once it
+// settles.
+type connection struct {
+	done   chan struct{}
+	client *gomcp.Client
+	tools  []*aipb.Tool
+	err    error
+}
+
+// NewManager creates a lazy manager: no server is started until
+// EnsureServer is called for it.
+func NewManager(mcpServers []*sgptpb.McpServer) *Manager {
+	serverNameToConfiguration := map[string]*sgptpb.McpServer{}
+	for _, mcpServer := range mcpServers {
+		serverNameToConfiguration[mcpServer.GetName()] = mcpServer
+	}
+	return &Manager{
+		serverNameToConfiguration: serverNameToConfiguration,
+		serverNameToClient:        map[string]*gomcp.Client{},
+		serverNameToTools:         map[string][]*aipb.Tool{},
+		serverNameToConnection:    map[string]*connection{},
+	}
+}
+
+// Has reports whether name is a known MCP server.
+func (m *Manager) Has(name string) bool {
+	_, ok := m.serverNameToConfiguration[name]
+	return ok
+}
+
+// EnsureServer connects to the named server on first use and returns its
+// tools; later calls are served from memory, unless the server exited.
+func (m *Manager) EnsureServer(ctx context.Context, serverName string) ([]*aipb.Tool, error) {
+	_, tools, err := m.connect(ctx, serverName)
+	return tools, err
+}
+
+// connect returns the named server's client, connecting it unless it is
+// connected and alive. Connecting runs outside the lock: it starts a
+// subprocess or reaches the network, and calls to other servers must not
+// wait on it.
+func (m *Manager) connect(ctx context.Context, serverName string) (*gomcp.Client, []*aipb.Tool, error) {
+	mcpServer, ok := m.serverNameToConfiguration[serverName]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown MCP server %q", serverName)
+	}
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("MCP server %s: manager closed", serverName)
+	}
+	if client, ok := m.serverNameToClient[serverName]; ok {
+		if !client.Exited() {
+			tools := m.serverNameToTools[serverName]
+			m.mu.Unlock()
+			return client, tools, nil
+		}
+		// A dead subprocess server would fail every call with its exit:
+		// drop it, and start it anew.
+		delete(m.serverNameToClient, serverName)
+		delete(m.serverNameToTools, serverName)
+		client.Close()
+	}
+	if pending, ok := m.serverNameToConnection[serverName]; ok {
+		m.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.client, pending.tools, pending.err
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	pending := &connection{done: make(chan struct{})}
+	m.serverNameToConnection[serverName] = pending
+	m.mu.Unlock()
+
+	pending.client, pending.tools, pending.err = dial(ctx, mcpServer)
+
+	m.mu.Lock()
+	delete(m.serverNameToConnection, serverName)
+	if pending.err == nil && m.closed {
+		pending.client.Close()
+		pending.client, pending.tools, pending.err = nil, nil, fmt.Errorf("MCP server %s: manager closed", serverName)
+	}
+	if pending.err == nil {
+		m.serverNameToClient[serverName] = pending.client
+		m.serverNameToTools[serverName] = pending.tools
+	}
+	m.mu.Unlock()
+	close(pending.done)
+	return pending.client, pending.tools, pending.err
+}
+
+// dial starts or reaches a server and lists its tools.
+func dial(ctx context.Context, mcpServer *sgptpb.McpServer) (*gomcp.Client, []*aipb.Tool, error) {
+	serverName := mcpServer.GetName()
+	transport, err := newTransport(mcpServer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to MCP server %s: %w", serverName, err)
+	}
+	client, err := gomcp.Connect(ctx, transport)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to MCP server %s: %w", serverName, err)
+	}
+	mcpTools, err := client.ListTools(ctx)
+	if err != nil {
+		client.Close()
+		return nil, nil, fmt.Errorf("MCP server %s: %w", serverName, err)
+	}
+	tools := make([]*aipb.Tool, 0, len(mcpTools))
+	for _, mcpTool := range mcpTools {
+		aiTool, err := buildTool(mcpServer, mcpTool)
+		if err != nil {
+			client.Close()
+			return nil, nil, fmt.Errorf("MCP server %s: %w", serverName, err)
+		}
+		tools = append(tools, aiTool)
+	}
+	return client, tools, nil
+}
+
+func newTransport(mcpServer *sgptpb.McpServer) (gomcp.Transport, error) {
+	switch {
+	case mcpServer.GetStdio() != nil:
+		stdio := mcpServer.GetStdio()
+		return gomcp.NewStdioTransport(stdio.GetCommand(), stdio.GetArgs(), stdio.GetEnv(), stdio.GetDir())
+	case mcpServer.GetHttp() != nil:
+		return gomcp.NewHTTPTransport(mcpServer.GetHttp().GetUrl(), mcpServer.GetHttp().GetHeaders()), nil
+	default:
+		return nil, fmt.Errorf("no transport configured")
+	}
+}
+
+// buildTool maps an MCP tool definition onto the tool advertised to the
+// model. The input schema is carried over as is, through its JSON form.
+func buildTool(mcpServer *sgptpb.McpServer, mcpTool *gomcp.Tool) (*aipb.Tool, error) {
+	annotations := map[string]string{
+		tool.ToolHandlerIDAnnotation: tool.HandlerIDMCP,
+		ServerAnnotation:             mcpServer.GetName(),
+		ToolAnnotation:               mcpTool.Name,
+	}
+	// The server's read-only hint is the MCP equivalent of a side-effect
+	// free method: its calls run without review.
+	if mcpTool.ReadOnly() {
+		annotations[aitool.AnnotationKeyNoSideEffect] = "true"
+	}
+	inputSchema := mcpTool.InputSchema
+	if len(inputSchema) == 0 {
+		inputSchema = json.RawMessage(`{"type":"object"}`)
+	}
+	description := mcpTool.Description
+	if description == "" {
+		description = mcpTool.Title
+	}
+	bytes, err := json.Marshal(map[string]any{
+		"name":        tool.PrefixedName(mcpServer.GetToolPrefix(), mcpTool.Name),
+		"description": description,
+		"jsonSchema":  inputSchema,
+		"annotations": annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling tool %q: %w", mcpTool.Name, err)
+	}
+	aiTool := &aipb.Tool{}
+	if err := pbutil.JSONUnmarshal(bytes, aiTool); err != nil {
+		return nil, fmt.Errorf("converting tool %q: %w", mcpTool.Name, err)
+	}
+	return aiTool, nil
+}
+
+// clientFor returns the client of the server a tool call is for, restarting
+// the server if it exited since it was connected.
+func (m *Manager) clientFor(ctx context.Context, toolCall *aipb.ToolCall) (*gomcp.Client, string, error) {
+	serverName := tool.GetToolCallAnnotation(toolCall, ServerAnnotation)
+	toolName := tool.GetToolCallAnnotation(toolCall, ToolAnnotation)
+	// Locked: servers connect concurrently (lazy init off the UI loop).
+	m.mu.Lock()
+	client, ok := m.serverNameToClient[serverName]
+	m.mu.Unlock()
+	if !ok {
+		return nil, "", fmt.Errorf("MCP server %q is not connected", serverName)
+	}
+	if client.Exited() {
+		var err error
+		if client, _, err = m.connect(ctx, serverName); err != nil {
+			return nil, "", tool.Transient(err)
+		}
+	}
+	return client, toolName, nil
+}
+
+// Review implements tool.Tool.
+func (m *Manager) Review(ctx context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	if _, _, err := m.clientFor(ctx, toolCall); err != nil {
+		return nil, err
+	}
+	return &sgptpb.ToolCallMetadata{
+		DisplayMessage: &sgptpb.DisplayMessage{},
+		AutoExecute:    tool.NoSideEffects(toolCall),
+	}, nil
+}
+
+// Execute implements tool.Tool. Tool failures reported by the server are
+// results for the model to read; a failing connection is a transient error,
+// for the execution policy to retry.
+func (m *Manager) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	client, toolName, err := m.clientFor(ctx, toolCall)
+	if err != nil {
+		return nil, err
+	}
+	arguments, err := tool.ArgumentsJSON(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallTool(ctx, toolName, arguments)
+	if err != nil {
+		var rpcError *gomcp.Error
+		if errors.As(err, &rpcError) || ctx.Err() != nil {
+			return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, err), nil
+		}
+		return nil, tool.Transient(err)
+	}
+	if result.IsError {
+		return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, errors.New(result.Text())), nil
+	}
+	value := structpb.NewStringValue(result.Text())
+	if len(result.StructuredContent) > 0 {
+		value = &structpb.Value{}
+		if err := value.UnmarshalJSON(result.StructuredContent); err != nil {
+			return nil, fmt.Errorf("unmarshaling structured content into structpb.Value: %w", err)
+		}
+	}
+	return ai.NewStructuredToolResult(toolCall.Name, toolCall.Id, value), nil
+}
+
+// RenderHeader shows {server}/{tool} instead of the prefixed tool name.
+func (m *Manager) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
+	serverName := tool.GetToolCallAnnotation(toolCall, ServerAnnotation)
+	toolName := tool.GetToolCallAnnotation(toolCall, ToolAnnotation)
+	if serverName == "" || toolName == "" {
+		return "", false
+	}
+	return fmt.Sprintf("🔌 `%s/%s`", serverName, toolName), true
+}
+
+// Close ends every server session, terminating subprocess servers. Servers
+// still connecting are closed as they connect.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for serverName, client := range m.serverNameToClient {
+		client.Close()
+		delete(m.serverNameToClient, serverName)
+		delete(m.serverNameToTools, serverName)
+	}
+}
+
+var (
+	_ tool.Tool           = (*Manager)(nil)
+	_ tool.HeaderRenderer = (*Manager)(nil)
+)
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"github.com/malonaz/core/go/pbutil"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+const (
+	// stubServerEnv turns the test binary into a stdio stub server.
+	stubServerEnv = "SGPT_MCP_STUB_SERVER"
+	// stubStartsEnv names a directory the stub server records each of its
+	// starts in.
+	stubStartsEnv = "SGPT_MCP_STUB_STARTS"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(stubServerEnv) != "" {
+		if file, err := os.CreateTemp(os.Getenv(stubStartsEnv), "start"); err == nil {
+			file.Close()
+		}
+		gomcp.NewServer(gomcp.Implementation{Name: "stub"}, "", stubHandler{}).Serve(context.Background(), os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stubHandler serves a read-only "read" tool, a "write" tool, and an "exit"
+// tool killing the server.
+type stubHandler struct{}
+
+func (stubHandler) ListTools(context.Context) ([]*gomcp.Tool, error) {
+	readOnly := true
+	return []*gomcp.Tool{
+		{Name: "read", Description: "Reads.", Annotations: &gomcp.ToolAnnotations{ReadOnlyHint: &readOnly}},
+		{Name: "write", Title: "Write", InputSchema: json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}}}`)},
+		{Name: "exit"},
+	}, nil
+}
+
+func (stubHandler) CallTool(_ context.Context, name string, _ json.RawMessage) (*gomcp.CallToolResult, error) {
+	if name == "exit" {
+		os.Exit(1)
+	}
+	return &gomcp.CallToolResult{Content: []*gomcp.Content{{Type: "text", Text: name + " ok"}}}, nil
+}
+
+// newStubManager returns a manager for one stub server, "//:stub", and the
+// directory its starts are recorded in.
+func newStubManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	startsDir := t.TempDir()
+	manager := NewManager([]*sgptpb.McpServer{{
+		Name:       "//:stub",
+		ToolPrefix: "stub",
+		Transport: &sgptpb.McpServer_Stdio_{Stdio: &sgptpb.McpServer_Stdio{
+			Command: executable,
+			Env:     map[string]string{stubServerEnv: "1", stubStartsEnv: startsDir},
+			Dir:     t.TempDir(),
+		}},
+	}})
+	t.Cleanup(manager.Close)
+	return manager, startsDir
+}
+
+func starts(t *testing.T, startsDir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(startsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func toolCallFor(aiTool *aipb.Tool) *aipb.ToolCall {
+	return &aipb.ToolCall{Id: "1", Name: aiTool.GetName(), Annotations: aiTool.GetAnnotations()}
+}
+
+func TestBuildTool(t *testing.T) {
+	mcpServer := &sgptpb.McpServer{Name: "//:docs", ToolPrefix: "docs"}
+	readOnly := true
+	aiTool, err := buildTool(mcpServer, &gomcp.Tool{Name: "search", Title: "Search", Annotations: &gomcp.ToolAnnotations{ReadOnlyHint: &readOnly}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aiTool.GetName() != "docs_search" || aiTool.GetDescription() != "Search" {
+		t.Errorf("tool = %v, want docs_search described by its title", aiTool)
+	}
+	annotations := aiTool.GetAnnotations()
+	if annotations[aitool.AnnotationKeyNoSideEffect] != "true" || annotations[tool.ToolHandlerIDAnnotation] != tool.HandlerIDMCP ||
+		annotations[ServerAnnotation] != "//:docs" || annotations[ToolAnnotation] != "search" {
+		t.Errorf("annotations = %v", annotations)
+	}
+	// Without an input schema, the tool takes an object.
+	schemaBytes, err := pbutil.JSONMarshal(aiTool.GetJsonSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := map[string]any{}
+	if err := json.Unmarshal(schemaBytes, &schema); err != nil || len(schema) != 1 || schema["type"] != "object" {
+		t.Errorf("schema = %s, want the default object schema", schemaBytes)
+	}
+
+	aiTool, err = buildTool(mcpServer, &gomcp.Tool{Name: "edit", Description: "Edits.", InputSchema: json.RawMessage(`{"type":"object","required":["path"]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := aiTool.GetAnnotations()[aitool.AnnotationKeyNoSideEffect]; ok {
+		t.Error("a tool without the read-only hint is side-effect free")
+	}
+}
+
+func TestManagerReview(t *testing.T) {
+	manager, _ := newStubManager(t)
+	ctx := context.Background()
+	tools, err := manager.EnsureServer(ctx, "//:stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 3 {
+		t.Fatalf("got %d tools, want 3", len(tools))
+	}
+	for _, aiTool := range tools {
+		metadata, err := manager.Review(ctx, toolCallFor(aiTool))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The read-only hint is what auto-executes a call.
+		if want := aiTool.GetName() == "stub_read"; metadata.GetAutoExecute() != want {
+			t.Errorf("%s: AutoExecute = %t, want %t", aiTool.GetName(), metadata.GetAutoExecute(), want)
+		}
+	}
+}
+
+func TestManagerConnectsOnce(t *testing.T) {
+	manager, startsDir := newStubManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = manager.EnsureServer(ctx, "//:stub")
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if got := starts(t, startsDir); got != 1 {
+		t.Errorf("server started %d times, want once", got)
+	}
+	if _, err := manager.EnsureServer(ctx, "//:unknown"); err == nil {
+		t.Error("EnsureServer accepted an unknown server")
+	}
+}
+
+func TestManagerRestartsExitedServer(t *testing.T) {
+	manager, startsDir := newStubManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tools, err := manager.EnsureServer(ctx, "//:stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolNameToTool := map[string]*aipb.Tool{}
+	for _, aiTool := range tools {
+		toolNameToTool[aiTool.GetName()] = aiTool
+	}
+
+	// Dying mid-call is the connection failing, not the call.
+	toolResult, err := manager.Execute(ctx, toolCallFor(toolNameToTool["stub_exit"]))
+	if !tool.IsTransient(err) {
+		t.Fatalf("exit: result = %v, err = %v; want a transient error", toolResult, err)
+	}
+	// The next call restarts the server rather than failing with its exit.
+	toolResult, err = manager.Execute(ctx, toolCallFor(toolNameToTool["stub_read"]))
+	if err != nil || toolResult.GetError() != nil {
+		t.Fatalf("read after exit: result = %v, err = %v", toolResult, err)
+	}
+	if got := starts(t, startsDir); got != 2 {
+		t.Errorf("server started %d times, want twice", got)
+	}
+}
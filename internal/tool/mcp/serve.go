@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sync/atomic"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"github.com/malonaz/core/go/pbutil"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// Handler serves registry tools to MCP clients. MCP clients run tool calls
+// without sgpt's review prompt, so a call Review would not auto-execute is
+// refused unless its tool is auto-approved.
+type Handler struct {
+	registry                *tool.Registry
+	tools                   []*aipb.Tool
+	nameToTool              map[string]*aipb.Tool
+	autoApprovedToolNameSet map[string]bool
+	nextID                  atomic.Int64
+}
+
+// NewHandler exposes tools, whose handlers are registered in registry.
+func NewHandler(registry *tool.Registry, tools []*aipb.Tool, autoApprovedToolNames []string) *Handler {
+	nameToTool := map[string]*aipb.Tool{}
+	for _, aiTool := range tools {
+		nameToTool[aiTool.GetName()] = aiTool
+	}
+	autoApprovedToolNameSet := map[string]bool{}
+	for _, name := range autoApprovedToolNames {
+		autoApprovedToolNameSet[name] = true
+	}
+	return &Handler{
+		registry:                registry,
+		tools:                   tools,
+		nameToTool:              nameToTool,
+		autoApprovedToolNameSet: autoApprovedToolNameSet,
+	}
+}
+
+// ListTools implements gomcp.ToolHandler.
+func (h *Handler) ListTools(context.Context) ([]*gomcp.Tool, error) {
+	mcpTools := make([]*gomcp.Tool, 0, len(h.tools))
+	for _, aiTool := range h.tools {
+		inputSchema, err := pbutil.JSONMarshal(aiTool.GetJsonSchema())
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s input schema: %w", aiTool.GetName(), err)
+		}
+		readOnly := aiTool.GetAnnotations()[aitool.AnnotationKeyNoSideEffect] == "true"
+		mcpTools = append(mcpTools, &gomcp.Tool{
+			Name:        aiTool.GetName(),
+			Description: aiTool.GetDescription(),
+			InputSchema: inputSchema,
+			Annotations: &gomcp.ToolAnnotations{ReadOnlyHint: &readOnly},
+		})
+	}
+	return mcpTools, nil
+}
+
+// CallTool implements gomcp.ToolHandler. Everything the model could react
+// to, from bad arguments to refusals, is an error result.
+func (h *Handler) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*gomcp.CallToolResult, error) {
+	aiTool, ok := h.nameToTool[name]
+	if !ok {
+		return nil, &gomcp.Error{Code: gomcp.CodeInvalidParams, Message: "unknown tool " + name}
+	}
+	argumentsStruct := &structpb.Struct{}
+	if len(arguments) > 0 {
+		if err := argumentsStruct.UnmarshalJSON(arguments); err != nil {
+			return errorResult(fmt.Sprintf("parsing arguments: %v", err)), nil
+		}
+	}
+	toolCall := &aipb.ToolCall{
+		Id:          fmt.Sprintf("mcp-%d", h.nextID.Add(1)),
+		Name:        name,
+		Arguments:   argumentsStruct,
+		Annotations: maps.Clone(aiTool.GetAnnotations()),
+	}
+
+	metadata, err := h.registry.Review(ctx, toolCall)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	if !metadata.GetAutoExecute() && !h.autoApprovedToolNameSet[name] {
+		return errorResult(fmt.Sprintf("%s calls need the user's approval, which sgpt cannot ask for here: "+
+			"the user may auto-approve the tool (mcp_serve.auto_approved_tools)", name)), nil
+	}
+	toolResult, err := h.registry.Execute(ctx, toolCall)
+	if err != nil {
+		return errorResult(err.Error()), nil
+	}
+	return callToolResult(toolResult)
+}
+
+func errorResult(message string) *gomcp.CallToolResult {
+	return &gomcp.CallToolResult{Content: []*gomcp.Content{{Type: "text", Text: message}}, IsError: true}
+}
+
+// callToolResult converts a tool result. Structured content is sent both
+// as text, for older clients, and as structured content when an object, as
+// MCP requires of it.
+func callToolResult(toolResult *aipb.ToolResult) (*gomcp.CallToolResult, error) {
+	if toolResult.GetError() != nil {
+		return errorResult(toolResult.GetError().GetMessage()), nil
+	}
+	structured := toolResult.GetStructuredContent()
+	if structured == nil {
+		return &gomcp.CallToolResult{Content: []*gomcp.Content{{Type: "text", Text: toolResult.GetContent()}}}, nil
+	}
+	structuredBytes, err := pbutil.JSONMarshal(structured)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling structured content: %w", err)
+	}
+	result := &gomcp.CallToolResult{Content: []*gomcp.Content{{Type: "text", Text: string(structuredBytes)}}}
+	if bytes.HasPrefix(bytes.TrimSpace(structuredBytes), []byte("{")) {
+		result.StructuredContent = structuredBytes
+	}
+	return result, nil
+}
+
+var _ gomcp.ToolHandler = (*Handler)(nil)
@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// fakeTool echoes its "text" argument; Review auto-executes it when
+// autoExecute is set.
+type fakeTool struct {
+	autoExecute bool
+	executed    bool
+}
+
+func (t *fakeTool) Review(context.Context, *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	return &sgptpb.ToolCallMetadata{AutoExecute: t.autoExecute}, nil
+}
+
+func (t *fakeTool) Execute(_ context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	t.executed = true
+	return ai.NewToolResult(toolCall.Name, toolCall.Id, toolCall.GetArguments().GetFields()["text"].GetStringValue()), nil
+}
+
+func TestHandlerPolicy(t *testing.T) {
+	look, touch, edit := &fakeTool{autoExecute: true}, &fakeTool{}, &fakeTool{}
+	registry := tool.NewRegistry()
+	registry.Register("look", look)
+	registry.Register("touch", touch)
+	registry.Register("edit", edit)
+	var tools []*aipb.Tool
+	for _, name := range []string{"look", "touch", "edit"} {
+		tools = append(tools, &aipb.Tool{Name: name, Annotations: map[string]string{tool.ToolHandlerIDAnnotation: name}})
+	}
+	handler := NewHandler(registry, tools, []string{"edit"})
+	ctx := context.Background()
+	arguments := json.RawMessage(`{"text": "hi"}`)
+
+	result, err := handler.CallTool(ctx, "look", arguments)
+	if err != nil || result.IsError || result.Text() != "hi" || !look.executed {
+		t.Errorf("auto-executed call: result = %+v, err = %v", result, err)
+	}
+	result, err = handler.CallTool(ctx, "touch", arguments)
+	if err != nil || !result.IsError || touch.executed {
+		t.Errorf("call needing review: result = %+v, err = %v, executed = %v", result, err, touch.executed)
+	}
+	result, err = handler.CallTool(ctx, "edit", arguments)
+	if err != nil || result.IsError || !edit.executed {
+		t.Errorf("auto-approved call: result = %+v, err = %v", result, err)
+	}
+	if _, err := handler.CallTool(ctx, "missing", nil); err == nil {
+		t.Error("calling an unserved tool succeeded")
+	}
+	result, err = handler.CallTool(ctx, "look", json.RawMessage(`[1]`))
+	if err != nil || !result.IsError {
+		t.Errorf("invalid arguments: result = %+v, err = %v", result, err)
+	}
+}
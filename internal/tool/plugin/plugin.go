@@ -0,0 +1,329 @@
+// Package plugin exposes the tools of local plugins (`.sgpt/{title}.plugin`
+// files) through the tool registry: executables a repo ships, speaking a
+// small JSON-RPC protocol over stdio (see protocol.go).
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"github.com/malonaz/core/go/pbutil"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+const (
+	// PluginAnnotation names the plugin (its selector) a tool belongs to.
+	PluginAnnotation = "sgpt.com/plugin"
+	// ToolAnnotation is the tool's name within its plugin, before prefixing.
+	ToolAnnotation = "sgpt.com/plugin-tool"
+)
+
+// Manager runs plugins and implements tool.Tool for the tools they
+// describe.
+type Manager struct {
+	// pluginNameToConfiguration indexes the discovered plugin files
+	// (selector-named) this manager can run.
+	pluginNameToConfiguration map[string]*sgptpb.Plugin
+
+	mu sync.Mutex
+	// pluginNameToClient records running plugins: plugins are started
+	// lazily, on the first EnsurePlugin call for their name.
+	pluginNameToClient map[string]*client
+	pluginNameToTools  map[string][]*aipb.Tool
+	// pluginNameToConnection holds the plugins being started: callers asking
+	// for a plugin being started wait for it instead of starting another.
+	pluginNameToConnection map[string]*connection
+	closed                 bool
+}
+
+// connection is a plugin start in progress; done is closed once it
+// settles.
+type connection struct {
+	done   chan struct{}
+	client *client
+	tools  []*aipb.Tool
+	err    error
+}
+
+// NewManager creates a lazy manager: no plugin is started until
+// EnsurePlugin is called for it.
+func NewManager(plugins []*sgptpb.Plugin) *Manager {
+	pluginNameToConfiguration := map[string]*sgptpb.Plugin{}
+	for _, plugin := range plugins {
+		pluginNameToConfiguration[plugin.GetName()] = plugin
+	}
+	return &Manager{
+		pluginNameToConfiguration: pluginNameToConfiguration,
+		pluginNameToClient:        map[string]*client{},
+		pluginNameToTools:         map[string][]*aipb.Tool{},
+		pluginNameToConnection:    map[string]*connection{},
+	}
+}
+
+// Has reports whether name is a known plugin.
+func (m *Manager) Has(name string) bool {
+	_, ok := m.pluginNameToConfiguration[name]
+	return ok
+}
+
+// EnsurePlugin starts the named plugin on first use and returns its tools;
+// later calls are served from memory, unless the plugin exited.
+func (m *Manager) EnsurePlugin(ctx context.Context, pluginName string) ([]*aipb.Tool, error) {
+	_, tools, err := m.connect(ctx, pluginName)
+	return tools, err
+}
+
+// connect returns the named plugin's client, starting the plugin unless it
+// is running. Starting runs outside the lock: a slow plugin must not hold up
+// the calls to the others.
+func (m *Manager) connect(ctx context.Context, pluginName string) (*client, []*aipb.Tool, error) {
+	plugin, ok := m.pluginNameToConfiguration[pluginName]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown plugin %q", pluginName)
+	}
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, nil, fmt.Errorf("plugin %s: manager closed", pluginName)
+	}
+	if pluginClient, ok := m.pluginNameToClient[pluginName]; ok {
+		if !gomcp.TransportExited(pluginClient.transport) {
+			tools := m.pluginNameToTools[pluginName]
+			m.mu.Unlock()
+			return pluginClient, tools, nil
+		}
+		// A crashed plugin would fail every call with its exit: drop it, and
+		// start it anew.
+		delete(m.pluginNameToClient, pluginName)
+		delete(m.pluginNameToTools, pluginName)
+		pluginClient.close()
+	}
+	if pending, ok := m.pluginNameToConnection[pluginName]; ok {
+		m.mu.Unlock()
+		select {
+		case <-pending.done:
+			return pending.client, pending.tools, pending.err
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	pending := &connection{done: make(chan struct{})}
+	m.pluginNameToConnection[pluginName] = pending
+	m.mu.Unlock()
+
+	pending.client, pending.tools, pending.err = start(ctx, plugin)
+
+	m.mu.Lock()
+	delete(m.pluginNameToConnection, pluginName)
+	if pending.err == nil && m.closed {
+		pending.client.close()
+		pending.client, pending.tools, pending.err = nil, nil, fmt.Errorf("plugin %s: manager closed", pluginName)
+	}
+	if pending.err == nil {
+		m.pluginNameToClient[pluginName] = pending.client
+		m.pluginNameToTools[pluginName] = pending.tools
+	}
+	m.mu.Unlock()
+	close(pending.done)
+	return pending.client, pending.tools, pending.err
+}
+
+// start runs a plugin and describes its tools.
+func start(ctx context.Context, plugin *sgptpb.Plugin) (*client, []*aipb.Tool, error) {
+	pluginName := plugin.GetName()
+	transport, err := gomcp.NewStdioTransport(plugin.GetCommand(), plugin.GetArgs(), plugin.GetEnv(), plugin.GetDir())
+	if err != nil {
+		return nil, nil, fmt.Errorf("plugin %s: %w", pluginName, err)
+	}
+	pluginClient := &client{transport: transport}
+	toolDefinitions, err := pluginClient.describe(ctx)
+	if err != nil {
+		pluginClient.close()
+		return nil, nil, fmt.Errorf("plugin %s: %w", pluginName, err)
+	}
+	tools := make([]*aipb.Tool, 0, len(toolDefinitions))
+	for _, toolDefinition := range toolDefinitions {
+		aiTool, err := buildTool(plugin, toolDefinition)
+		if err != nil {
+			pluginClient.close()
+			return nil, nil, fmt.Errorf("plugin %s: %w", pluginName, err)
+		}
+		tools = append(tools, aiTool)
+	}
+	return pluginClient, tools, nil
+}
+
+// buildTool maps a plugin's tool definition onto the tool advertised to the
+// model, like MCP tools: the input schema is carried over through its JSON
+// form.
+func buildTool(plugin *sgptpb.Plugin, toolDefinition *ToolDefinition) (*aipb.Tool, error) {
+	annotations := map[string]string{
+		tool.ToolHandlerIDAnnotation: tool.HandlerIDPlugin,
+		PluginAnnotation:             plugin.GetName(),
+		ToolAnnotation:               toolDefinition.Name,
+	}
+	if toolDefinition.ReadOnly {
+		annotations[aitool.AnnotationKeyNoSideEffect] = "true"
+	}
+	inputSchema := toolDefinition.InputSchema
+	if len(inputSchema) == 0 {
+		inputSchema = json.RawMessage(`{"type":"object"}`)
+	}
+	bytes, err := json.Marshal(map[string]any{
+		"name":        tool.PrefixedName(plugin.GetToolPrefix(), toolDefinition.Name),
+		"description": toolDefinition.Description,
+		"jsonSchema":  inputSchema,
+		"annotations": annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling tool %q: %w", toolDefinition.Name, err)
+	}
+	aiTool := &aipb.Tool{}
+	if err := pbutil.JSONUnmarshal(bytes, aiTool); err != nil {
+		return nil, fmt.Errorf("converting tool %q: %w", toolDefinition.Name, err)
+	}
+	return aiTool, nil
+}
+
+// clientFor returns the client of the plugin a tool call is for, restarting
+// the plugin if it exited since it was started.
+func (m *Manager) clientFor(ctx context.Context, toolCall *aipb.ToolCall) (*client, string, error) {
+	pluginName := tool.GetToolCallAnnotation(toolCall, PluginAnnotation)
+	toolName := tool.GetToolCallAnnotation(toolCall, ToolAnnotation)
+	m.mu.Lock()
+	pluginClient, ok := m.pluginNameToClient[pluginName]
+	m.mu.Unlock()
+	if !ok {
+		return nil, "", fmt.Errorf("plugin %q is not running", pluginName)
+	}
+	if gomcp.TransportExited(pluginClient.transport) {
+		var err error
+		if pluginClient, _, err = m.connect(ctx, pluginName); err != nil {
+			return nil, "", tool.Transient(err)
+		}
+	}
+	return pluginClient, toolName, nil
+}
+
+// Review implements tool.Tool. The plugin's rendering is computed here and
+// kept in the call's metadata: renderers run on the UI loop, and must not
+// wait on a subprocess.
+func (m *Manager) Review(ctx context.Context, toolCall *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	pluginClient, toolName, err := m.clientFor(ctx, toolCall)
+	if err != nil {
+		return nil, err
+	}
+	arguments, err := tool.ArgumentsJSON(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	reviewResult, err := pluginClient.review(ctx, toolName, arguments)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &sgptpb.ToolCallMetadata{
+		DisplayMessage: &sgptpb.DisplayMessage{},
+		AutoExecute:    tool.NoSideEffects(toolCall),
+	}
+	if reviewResult != nil {
+		if reviewResult.AutoExecute != nil {
+			metadata.AutoExecute = *reviewResult.AutoExecute
+		}
+		metadata.Header = reviewResult.Header
+		metadata.RequestMarkdown = reviewResult.RequestMarkdown
+	}
+	return metadata, nil
+}
+
+// Execute implements tool.Tool. Tool failures reported by the plugin are
+// results for the model to read; a failing connection is a transient error,
+// for the execution policy to retry.
+func (m *Manager) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	pluginClient, toolName, err := m.clientFor(ctx, toolCall)
+	if err != nil {
+		return nil, err
+	}
+	arguments, err := tool.ArgumentsJSON(toolCall)
+	if err != nil {
+		return nil, err
+	}
+	result, err := pluginClient.execute(ctx, toolName, arguments)
+	if err != nil {
+		var rpcError *gomcp.Error
+		if errors.As(err, &rpcError) || ctx.Err() != nil {
+			return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, err), nil
+		}
+		return nil, tool.Transient(err)
+	}
+	if result.Error != "" {
+		return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, errors.New(result.Error)), nil
+	}
+	value := structpb.NewStringValue(result.Content)
+	if len(result.StructuredContent) > 0 {
+		value = &structpb.Value{}
+		if err := value.UnmarshalJSON(result.StructuredContent); err != nil {
+			return nil, fmt.Errorf("unmarshaling structured content into structpb.Value: %w", err)
+		}
+	}
+	return ai.NewStructuredToolResult(toolCall.Name, toolCall.Id, value), nil
+}
+
+// ExecutionPolicy returns the policy of the call's plugin file.
+func (m *Manager) ExecutionPolicy(toolCall *aipb.ToolCall) *sgptpb.ExecutionPolicy {
+	pluginName := tool.GetToolCallAnnotation(toolCall, PluginAnnotation)
+	return m.pluginNameToConfiguration[pluginName].GetExecutionPolicy()
+}
+
+// RenderHeader shows the header the plugin computed at review time, or
+// {plugin}/{tool} instead of the prefixed tool name.
+func (m *Manager) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
+	if metadata, err := tool.ParseToolCallMetadata(toolCall); err == nil && metadata.GetHeader() != "" {
+		return metadata.GetHeader(), true
+	}
+	pluginName := tool.GetToolCallAnnotation(toolCall, PluginAnnotation)
+	toolName := tool.GetToolCallAnnotation(toolCall, ToolAnnotation)
+	if pluginName == "" || toolName == "" {
+		return "", false
+	}
+	return fmt.Sprintf("🧩 `%s/%s`", pluginName, toolName), true
+}
+
+// RenderRequest shows the request markdown the plugin computed at review
+// time, if any.
+func (m *Manager) RenderRequest(toolCall *aipb.ToolCall) (string, bool) {
+	metadata, err := tool.ParseToolCallMetadata(toolCall)
+	if err != nil || metadata.GetRequestMarkdown() == "" {
+		return "", false
+	}
+	return metadata.GetRequestMarkdown(), true
+}
+
+// Close stops every running plugin; plugins still starting are stopped
+// once started.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	for pluginName, pluginClient := range m.pluginNameToClient {
+		pluginClient.close()
+		delete(m.pluginNameToClient, pluginName)
+		delete(m.pluginNameToTools, pluginName)
+	}
+}
+
+var (
+	_ tool.Tool            = (*Manager)(nil)
+	_ tool.HeaderRenderer  = (*Manager)(nil)
+	_ tool.RequestRenderer = (*Manager)(nil)
+	_ tool.PolicyProvider  = (*Manager)(nil)
+)
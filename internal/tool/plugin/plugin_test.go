@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+// newStubManager returns a manager for one stub plugin, "//:stub", and the
+// directory its starts are recorded in.
+func newStubManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	startsDir := t.TempDir()
+	manager := NewManager([]*sgptpb.Plugin{{
+		Name:       "//:stub",
+		Command:    executable,
+		Env:        map[string]string{stubPluginEnv: "plain", stubStartsEnv: startsDir},
+		Dir:        t.TempDir(),
+		ToolPrefix: "stub",
+	}})
+	t.Cleanup(manager.Close)
+	return manager, startsDir
+}
+
+func starts(t *testing.T, startsDir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(startsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func toolCallFor(aiTool *aipb.Tool) *aipb.ToolCall {
+	return &aipb.ToolCall{Id: "1", Name: aiTool.GetName(), Annotations: aiTool.GetAnnotations()}
+}
+
+func TestManagerStartsOnce(t *testing.T) {
+	manager, startsDir := newStubManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = manager.EnsurePlugin(ctx, "//:stub")
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if got := starts(t, startsDir); got != 1 {
+		t.Errorf("plugin started %d times, want once", got)
+	}
+	if _, err := manager.EnsurePlugin(ctx, "//:unknown"); err == nil {
+		t.Error("EnsurePlugin accepted an unknown plugin")
+	}
+}
+
+func TestManagerRestartsExitedPlugin(t *testing.T) {
+	manager, startsDir := newStubManager(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tools, err := manager.EnsurePlugin(ctx, "//:stub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	toolNameToTool := map[string]*aipb.Tool{}
+	for _, aiTool := range tools {
+		toolNameToTool[aiTool.GetName()] = aiTool
+	}
+
+	// Dying mid-call is the plugin failing, not the call.
+	toolResult, err := manager.Execute(ctx, toolCallFor(toolNameToTool["stub_exit"]))
+	if !tool.IsTransient(err) {
+		t.Fatalf("exit: result = %v, err = %v; want a transient error", toolResult, err)
+	}
+	// The next call restarts the plugin rather than failing with its exit.
+	toolResult, err = manager.Execute(ctx, toolCallFor(toolNameToTool["stub_echo"]))
+	if err != nil || toolResult.GetError() != nil {
+		t.Fatalf("echo after exit: result = %v, err = %v", toolResult, err)
+	}
+	if got := starts(t, startsDir); got != 2 {
+		t.Errorf("plugin started %d times, want twice", got)
+	}
+}
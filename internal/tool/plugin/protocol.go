@@ -0,0 +1,135 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+)
+
+// Methods of the plugin protocol: JSON-RPC 2.0 requests, one per line, on
+// the plugin's stdin; responses, one per line, on its stdout. Requests may
+// be in flight concurrently.
+const (
+	// MethodDescribe lists the plugin's tools. Called once, on startup.
+	MethodDescribe = "describe"
+	// MethodReview is called before a call is shown to the user, to decide
+	// whether it runs without approval and how it renders. Optional: without
+	// it, calls to read-only tools run unreviewed.
+	MethodReview = "review"
+	// MethodExecute runs a call.
+	MethodExecute = "execute"
+)
+
+// ToolDefinition is a tool a plugin describes.
+type ToolDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON schema of the tool's arguments; defaults to an
+	// object with no declared properties.
+	InputSchema json.RawMessage `json:"input_schema,omitempty"`
+	// ReadOnly declares the tool free of side effects.
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+type describeResult struct {
+	Tools []*ToolDefinition `json:"tools"`
+}
+
+// callParams are the params of review and execute requests.
+type callParams struct {
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ReviewResult is a plugin's verdict on a call.
+type ReviewResult struct {
+	// AutoExecute runs the call without approval; defaults to the tool's
+	// read-only declaration.
+	AutoExecute *bool `json:"auto_execute,omitempty"`
+	// Header and RequestMarkdown replace the default rendering of the call
+	// in the timeline.
+	Header          string `json:"header,omitempty"`
+	RequestMarkdown string `json:"request_markdown,omitempty"`
+}
+
+// ExecuteResult is the outcome of a call: Error is set when it failed,
+// StructuredContent (any JSON value) takes precedence over Content
+// otherwise.
+type ExecuteResult struct {
+	Content           string          `json:"content,omitempty"`
+	StructuredContent json.RawMessage `json:"structured_content,omitempty"`
+	Error             string          `json:"error,omitempty"`
+}
+
+// client speaks the plugin protocol to a running plugin.
+type client struct {
+	transport gomcp.Transport
+	nextID    atomic.Int64
+}
+
+// describe lists the plugin's tools.
+func (c *client) describe(ctx context.Context) ([]*ToolDefinition, error) {
+	result := &describeResult{}
+	if err := c.request(ctx, MethodDescribe, struct{}{}, result); err != nil {
+		return nil, err
+	}
+	for _, toolDefinition := range result.Tools {
+		if toolDefinition.Name == "" {
+			return nil, fmt.Errorf("%s: tool without a name", MethodDescribe)
+		}
+	}
+	return result.Tools, nil
+}
+
+// review returns the plugin's verdict on a call, or nil when the plugin does
+// not implement review.
+func (c *client) review(ctx context.Context, toolName string, arguments json.RawMessage) (*ReviewResult, error) {
+	result := &ReviewResult{}
+	err := c.request(ctx, MethodReview, &callParams{Tool: toolName, Arguments: arguments}, result)
+	if rpcErr := (*gomcp.Error)(nil); errors.As(err, &rpcErr) && rpcErr.Code == gomcp.CodeMethodNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// execute runs a call.
+func (c *client) execute(ctx context.Context, toolName string, arguments json.RawMessage) (*ExecuteResult, error) {
+	result := &ExecuteResult{}
+	if err := c.request(ctx, MethodExecute, &callParams{Tool: toolName, Arguments: arguments}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// request sends one request and decodes its result into result. JSON-RPC
+// errors are returned as *gomcp.Error.
+func (c *client) request(ctx context.Context, method string, params, result any) error {
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("marshaling %s params: %w", method, err)
+	}
+	id := json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+	response, err := c.transport.Call(ctx, &gomcp.Message{JSONRPC: "2.0", ID: id, Method: method, Params: paramsBytes})
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("parsing %s result: %w", method, err)
+	}
+	return nil
+}
+
+func (c *client) close() error {
+	return c.transport.Close()
+}
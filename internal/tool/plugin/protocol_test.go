@@ -0,0 +1,132 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	gomcp "github.com/malonaz/sgpt/internal/mcp"
+)
+
+const (
+	// stubPluginEnv turns the test binary into a stub plugin; its value says
+	// whether the stub implements review.
+	stubPluginEnv = "SGPT_STUB_PLUGIN"
+	// stubStartsEnv names a directory the stub plugin records each of its
+	// starts in.
+	stubStartsEnv = "SGPT_STUB_PLUGIN_STARTS"
+)
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(stubPluginEnv); mode != "" {
+		if file, err := os.CreateTemp(os.Getenv(stubStartsEnv), "start"); err == nil {
+			file.Close()
+		}
+		serveStubPlugin(mode == "review")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// serveStubPlugin answers requests like a plugin with a read-only "echo"
+// tool, a "fail" tool, and an "exit" tool killing the plugin.
+func serveStubPlugin(withReview bool) {
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		request := &gomcp.Message{}
+		if err := json.Unmarshal(scanner.Bytes(), request); err != nil {
+			continue
+		}
+		response := &gomcp.Message{JSONRPC: "2.0", ID: request.ID}
+		params := &callParams{}
+		json.Unmarshal(request.Params, params)
+		var result any
+		switch {
+		case request.Method == MethodDescribe:
+			result = map[string]any{"tools": []map[string]any{
+				{"name": "echo", "description": "Echoes its arguments.", "read_only": true},
+				{"name": "fail", "input_schema": map[string]any{"type": "object"}},
+				{"name": "exit"},
+			}}
+		case request.Method == MethodReview && withReview:
+			result = map[string]any{"auto_execute": params.Tool == "fail", "header": "checking " + params.Tool}
+		case request.Method == MethodExecute && params.Tool == "exit":
+			os.Exit(1)
+		case request.Method == MethodExecute && params.Tool == "echo":
+			result = map[string]any{"structured_content": params.Arguments}
+		case request.Method == MethodExecute:
+			result = map[string]any{"error": "it failed"}
+		default:
+			response.Error = &gomcp.Error{Code: gomcp.CodeMethodNotFound, Message: "method not found: " + request.Method}
+		}
+		if result != nil {
+			response.Result, _ = json.Marshal(result)
+		}
+		encoder.Encode(response)
+	}
+}
+
+func startStubPlugin(t *testing.T, mode string) *client {
+	t.Helper()
+	transport, err := gomcp.NewStdioTransport(os.Args[0], nil, map[string]string{stubPluginEnv: mode}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pluginClient := &client{transport: transport}
+	t.Cleanup(func() { pluginClient.close() })
+	return pluginClient
+}
+
+func TestClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pluginClient := startStubPlugin(t, "review")
+
+	toolDefinitions, err := pluginClient.describe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toolDefinitions) != 3 || toolDefinitions[0].Name != "echo" || !toolDefinitions[0].ReadOnly || toolDefinitions[1].ReadOnly {
+		t.Fatalf("describe = %+v", toolDefinitions)
+	}
+
+	reviewResult, err := pluginClient.review(ctx, "fail", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reviewResult == nil || reviewResult.AutoExecute == nil || !*reviewResult.AutoExecute || reviewResult.Header != "checking fail" {
+		t.Errorf("review = %+v", reviewResult)
+	}
+
+	executeResult, err := pluginClient.execute(ctx, "echo", json.RawMessage(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(executeResult.StructuredContent) != `{"text":"hi"}` || executeResult.Error != "" {
+		t.Errorf("execute echo = %+v", executeResult)
+	}
+	executeResult, err = pluginClient.execute(ctx, "fail", json.RawMessage(`{}`))
+	if err != nil || executeResult.Error != "it failed" {
+		t.Errorf("execute fail = %+v, %v", executeResult, err)
+	}
+}
+
+func TestClientWithoutReview(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	pluginClient := startStubPlugin(t, "plain")
+
+	reviewResult, err := pluginClient.review(ctx, "echo", json.RawMessage(`{}`))
+	if err != nil || reviewResult != nil {
+		t.Errorf("review without a review method = %+v, %v; want nil, nil", reviewResult, err)
+	}
+	err = pluginClient.request(ctx, "bogus", struct{}{}, &struct{}{})
+	if err == nil || !strings.Contains(err.Error(), "method not found") {
+		t.Errorf("unknown method: err = %v", err)
+	}
+}
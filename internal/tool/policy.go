@@ -0,0 +1,229 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// defaultRetryBackoff is the wait before the first retry when a policy
+// retries without setting retry_backoff.
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// PolicyProvider is implemented by tools whose calls run under the
+// execution policy of their artifact (a tool set's, a plugin's).
+type PolicyProvider interface {
+	Tool
+	// ExecutionPolicy returns the policy of the call's tool; nil falls back
+	// to the registry's default policy.
+	ExecutionPolicy(toolCall *aipb.ToolCall) *sgptpb.ExecutionPolicy
+}
+
+// SetDefaultExecutionPolicy sets the policy of tools that declare none.
+func (r *Registry) SetDefaultExecutionPolicy(policy *sgptpb.ExecutionPolicy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.defaultExecutionPolicy = policy
+}
+
+func (r *Registry) executionPolicy(tool Tool, toolCall *aipb.ToolCall) *sgptpb.ExecutionPolicy {
+	if provider, ok := tool.(PolicyProvider); ok {
+		if policy := provider.ExecutionPolicy(toolCall); policy != nil {
+			return policy
+		}
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.defaultExecutionPolicy
+}
+
+// Idempotent reports whether a reviewed call can be retried: its tool is
+// declared side-effect free, or its review found the call to be.
+func Idempotent(toolCall *aipb.ToolCall) bool {
+	if NoSideEffects(toolCall) {
+		return true
+	}
+	metadata, err := ParseToolCallMetadata(toolCall)
+	return err == nil && metadata.GetIdempotent()
+}
+
+// transientError marks a failure of a tool's infrastructure; see Transient.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// Transient marks err as a failure of the tool's infrastructure (a lost
+// connection, an unavailable engine) rather than of the call: execution
+// policies retry such failures and count them toward the circuit breaker.
+// Anything else a tool fails with, error results included, is the call's
+// own doing.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// IsTransient reports whether err was marked Transient.
+func IsTransient(err error) bool {
+	var transient *transientError
+	return errors.As(err, &transient)
+}
+
+// executeWithPolicy runs a call under its policy: each attempt is bounded
+// by the timeout, idempotent calls failing transiently are retried with
+// exponential backoff, and a tool failing transiently too many calls in a
+// row is disabled for the rest of the session. Policy-triggered failures
+// are recorded in the result's metadata.
+func (r *Registry) executeWithPolicy(ctx context.Context, tool Tool, toolCall *aipb.ToolCall, policy *sgptpb.ExecutionPolicy) (*aipb.ToolResult, error) {
+	toolName := toolCall.GetName()
+	threshold := policy.GetCircuitBreakerThreshold()
+	circuitBreakers := circuitBreakersFrom(ctx)
+	if threshold > 0 && circuitBreakers.consecutiveFailures(toolName) >= threshold {
+		err := fmt.Errorf("%s is disabled for the rest of this session after %d consecutive failures", toolName, threshold)
+		toolResult := ai.NewErrorToolResult(toolName, toolCall.GetId(), err)
+		return toolResult, setPolicyOutcome(toolResult, sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN, 0)
+	}
+
+	maxAttempts := int32(1)
+	if Idempotent(toolCall) {
+		maxAttempts += policy.GetMaxRetries()
+	}
+	backoff := defaultRetryBackoff
+	if policy.GetRetryBackoff() != nil {
+		backoff = policy.GetRetryBackoff().AsDuration()
+	}
+	var (
+		toolResult *aipb.ToolResult
+		timedOut   bool
+		failed     bool
+		err        error
+		attempts   int32
+	)
+	for attempts = 1; ; attempts++ {
+		toolResult, timedOut, err = executeAttempt(ctx, tool, toolCall, policy.GetTimeout().AsDuration())
+		failed = timedOut || IsTransient(err)
+		if !failed || attempts >= maxAttempts || !sleep(ctx, backoff) {
+			break
+		}
+		backoff *= 2
+	}
+	// A cancelled turn says nothing about the tool's health.
+	if ctx.Err() == nil {
+		circuitBreakers.recordOutcome(toolName, !failed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case timedOut:
+		return toolResult, setPolicyOutcome(toolResult, sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_TIMEOUT, attempts)
+	case attempts > 1:
+		return toolResult, setPolicyOutcome(toolResult, sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_UNSPECIFIED, attempts)
+	default:
+		return toolResult, nil
+	}
+}
+
+// executeAttempt runs one attempt, bounded by timeout when positive. A tool
+// that ignores the cancellation is abandoned: the turn must not hang on it.
+func executeAttempt(ctx context.Context, tool Tool, toolCall *aipb.ToolCall, timeout time.Duration) (*aipb.ToolResult, bool, error) {
+	if timeout <= 0 {
+		toolResult, err := tool.Execute(ctx, toolCall)
+		return toolResult, false, err
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type outcome struct {
+		toolResult *aipb.ToolResult
+		err        error
+	}
+	outcomeCh := make(chan outcome, 1)
+	go func() {
+		toolResult, err := tool.Execute(attemptCtx, toolCall)
+		outcomeCh <- outcome{toolResult: toolResult, err: err}
+	}()
+	select {
+	case result := <-outcomeCh:
+		failed := result.err != nil || result.toolResult.GetError() != nil
+		// A tool honoring the deadline fails with an error of its own.
+		if !failed || ctx.Err() != nil || !errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+			return result.toolResult, false, result.err
+		}
+	case <-attemptCtx.Done():
+		if ctx.Err() != nil {
+			return nil, false, ctx.Err()
+		}
+	}
+	err := fmt.Errorf("timed out after %s", timeout)
+	return ai.NewErrorToolResult(toolCall.GetName(), toolCall.GetId(), err), true, nil
+}
+
+// sleep waits for d, reporting false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// setPolicyOutcome records what the policy did to a call in its result's
+// metadata, keeping whatever the tool put there.
+func setPolicyOutcome(toolResult *aipb.ToolResult, failure sgptpb.ExecutionPolicyFailure, attempts int32) error {
+	metadata, err := ParseToolResultMetadata(toolResult)
+	if err != nil {
+		metadata = &sgptpb.ToolCallResultMetadata{}
+	}
+	metadata.PolicyFailure = failure
+	metadata.Attempts = attempts
+	return SetToolResultMetadata(toolResult, metadata)
+}
+
+// CircuitBreakers counts each tool's consecutive transient failures within a
+// session, feeding the circuit breakers of its execution policies. A nil
+// CircuitBreakers (executing outside a session) never opens.
+type CircuitBreakers struct {
+	mutex                         sync.Mutex
+	toolNameToConsecutiveFailures map[string]int32
+}
+
+// NewCircuitBreakers instantiates closed circuit breakers.
+func NewCircuitBreakers() *CircuitBreakers {
+	return &CircuitBreakers{toolNameToConsecutiveFailures: map[string]int32{}}
+}
+
+func (c *CircuitBreakers) consecutiveFailures(toolName string) int32 {
+	if c == nil {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.toolNameToConsecutiveFailures[toolName]
+}
+
+func (c *CircuitBreakers) recordOutcome(toolName string, succeeded bool) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if succeeded {
+		delete(c.toolNameToConsecutiveFailures, toolName)
+		return
+	}
+	c.toolNameToConsecutiveFailures[toolName]++
+}
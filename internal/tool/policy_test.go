@@ -0,0 +1,180 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	"github.com/malonaz/core/go/ai"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
+)
+
+// flakyTool fails its first failures calls transiently, then succeeds; each
+// call takes delay, ignoring cancellation. A rejecting flakyTool fails every
+// call as the call's own fault instead.
+type flakyTool struct {
+	failures  int
+	delay     time.Duration
+	policy    *sgptpb.ExecutionPolicy
+	rejection func(toolCall *aipb.ToolCall) (*aipb.ToolResult, error)
+	calls     int
+}
+
+func (t *flakyTool) Review(context.Context, *aipb.ToolCall) (*sgptpb.ToolCallMetadata, error) {
+	return &sgptpb.ToolCallMetadata{}, nil
+}
+
+func (t *flakyTool) Execute(_ context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+	t.calls++
+	time.Sleep(t.delay)
+	if t.rejection != nil {
+		return t.rejection(toolCall)
+	}
+	if t.calls <= t.failures {
+		return nil, Transient(errors.New("flaked"))
+	}
+	return ai.NewToolResult(toolCall.Name, toolCall.Id, "ok"), nil
+}
+
+func (t *flakyTool) ExecutionPolicy(*aipb.ToolCall) *sgptpb.ExecutionPolicy {
+	return t.policy
+}
+
+func newToolCall(noSideEffects bool) *aipb.ToolCall {
+	annotations := map[string]string{ToolHandlerIDAnnotation: "flaky"}
+	if noSideEffects {
+		annotations[aitool.AnnotationKeyNoSideEffect] = "true"
+	}
+	return &aipb.ToolCall{Id: "1", Name: "flaky", Annotations: annotations}
+}
+
+func TestExecutionPolicyRetries(t *testing.T) {
+	ctx := context.Background()
+	policy := &sgptpb.ExecutionPolicy{MaxRetries: 2, RetryBackoff: durationpb.New(time.Millisecond)}
+
+	flaky := &flakyTool{failures: 2, policy: policy}
+	registry := NewRegistry()
+	registry.Register("flaky", flaky)
+	toolResult, err := registry.Execute(ctx, newToolCall(true))
+	if err != nil || toolResult.GetError() != nil || flaky.calls != 3 {
+		t.Fatalf("idempotent call: result = %v, err = %v, calls = %d", toolResult, err, flaky.calls)
+	}
+	if metadata, err := ParseToolResultMetadata(toolResult); err != nil || metadata.GetAttempts() != 3 {
+		t.Errorf("attempts = %v (%v), want 3", metadata.GetAttempts(), err)
+	}
+
+	flaky = &flakyTool{failures: 2, policy: policy}
+	registry.Register("flaky", flaky)
+	toolResult, err = registry.Execute(ctx, newToolCall(false))
+	if !IsTransient(err) || flaky.calls != 1 {
+		t.Errorf("call with side effects: result = %v, err = %v, calls = %d; want one failed attempt", toolResult, err, flaky.calls)
+	}
+}
+
+func TestExecutionPolicyTimeout(t *testing.T) {
+	flaky := &flakyTool{delay: time.Second, policy: &sgptpb.ExecutionPolicy{Timeout: durationpb.New(10 * time.Millisecond)}}
+	registry := NewRegistry()
+	registry.Register("flaky", flaky)
+	toolResult, err := registry.Execute(context.Background(), newToolCall(false))
+	if err != nil || toolResult.GetError() == nil {
+		t.Fatalf("result = %v, err = %v; want a timeout error result", toolResult, err)
+	}
+	metadata, err := ParseToolResultMetadata(toolResult)
+	if err != nil || metadata.GetPolicyFailure() != sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_TIMEOUT {
+		t.Errorf("metadata = %v (%v), want a timeout", metadata, err)
+	}
+}
+
+func TestExecutionPolicyCircuitBreaker(t *testing.T) {
+	ctx := WithCircuitBreakers(context.Background(), NewCircuitBreakers())
+	flaky := &flakyTool{failures: 2}
+	registry := NewRegistry()
+	registry.SetDefaultExecutionPolicy(&sgptpb.ExecutionPolicy{CircuitBreakerThreshold: 2})
+	registry.Register("flaky", flaky)
+	for range 2 {
+		registry.Execute(ctx, newToolCall(false))
+	}
+	toolResult, err := registry.Execute(ctx, newToolCall(false))
+	if err != nil || toolResult.GetError() == nil || flaky.calls != 2 {
+		t.Fatalf("call after the threshold: result = %v, err = %v, calls = %d; want a refused call", toolResult, err, flaky.calls)
+	}
+	metadata, err := ParseToolResultMetadata(toolResult)
+	if err != nil || metadata.GetPolicyFailure() != sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN {
+		t.Errorf("metadata = %v (%v), want an open circuit", metadata, err)
+	}
+
+	// Another session (a sub-agent, a tab) keeps the tool.
+	otherCtx := WithCircuitBreakers(context.Background(), NewCircuitBreakers())
+	toolResult, err = registry.Execute(otherCtx, newToolCall(false))
+	if err != nil || toolResult.GetError() != nil || flaky.calls != 3 {
+		t.Errorf("call from another session: result = %v, err = %v, calls = %d; want it run", toolResult, err, flaky.calls)
+	}
+}
+
+func TestExecutionPolicyCircuitBreakerResets(t *testing.T) {
+	ctx := WithCircuitBreakers(context.Background(), NewCircuitBreakers())
+	flaky := &flakyTool{failures: 1}
+	registry := NewRegistry()
+	registry.SetDefaultExecutionPolicy(&sgptpb.ExecutionPolicy{CircuitBreakerThreshold: 2})
+	registry.Register("flaky", flaky)
+	// Fail, succeed, then fail again: never two failures in a row.
+	registry.Execute(ctx, newToolCall(false))
+	registry.Execute(ctx, newToolCall(false))
+	flaky.failures = 3
+	registry.Execute(ctx, newToolCall(false))
+	toolResult, err := registry.Execute(ctx, newToolCall(false))
+	if err != nil || flaky.calls != 4 {
+		t.Fatalf("result = %v, err = %v, calls = %d; want the fourth call run", toolResult, err, flaky.calls)
+	}
+	if metadata, _ := ParseToolResultMetadata(toolResult); metadata.GetPolicyFailure() == sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN {
+		t.Error("circuit opened across a success")
+	}
+}
+
+func TestExecutionPolicyCircuitBreakerOutsideSession(t *testing.T) {
+	flaky := &flakyTool{failures: 3}
+	registry := NewRegistry()
+	registry.SetDefaultExecutionPolicy(&sgptpb.ExecutionPolicy{CircuitBreakerThreshold: 1})
+	registry.Register("flaky", flaky)
+	for range 3 {
+		registry.Execute(context.Background(), newToolCall(false))
+	}
+	if flaky.calls != 3 {
+		t.Errorf("calls = %d, want 3: no session, no circuit breaker", flaky.calls)
+	}
+}
+
+func TestExecutionPolicyToolErrors(t *testing.T) {
+	policy := &sgptpb.ExecutionPolicy{MaxRetries: 2, RetryBackoff: durationpb.New(time.Millisecond), CircuitBreakerThreshold: 1}
+	for name, rejection := range map[string]func(toolCall *aipb.ToolCall) (*aipb.ToolResult, error){
+		// A bad path, a failing command: the tool answered.
+		"error result": func(toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
+			return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, errors.New("no such file")), nil
+		},
+		// A patch that doesn't apply, a bad argument.
+		"error": func(*aipb.ToolCall) (*aipb.ToolResult, error) {
+			return nil, errors.New("patch does not apply")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := WithCircuitBreakers(context.Background(), NewCircuitBreakers())
+			flaky := &flakyTool{policy: policy, rejection: rejection}
+			registry := NewRegistry()
+			registry.Register("flaky", flaky)
+			for range 3 {
+				toolResult, err := registry.Execute(ctx, newToolCall(true))
+				if metadata, _ := ParseToolResultMetadata(toolResult); metadata.GetPolicyFailure() == sgptpb.ExecutionPolicyFailure_EXECUTION_POLICY_FAILURE_CIRCUIT_OPEN {
+					t.Fatalf("result = %v, err = %v; want the breaker closed", toolResult, err)
+				}
+			}
+			if flaky.calls != 3 {
+				t.Errorf("calls = %d, want 3: rejected calls are neither retried nor disabled", flaky.calls)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"sync"
 
 	aipb "github.com/malonaz/core/genproto/ai/v1"
@@ -21,7 +22,12 @@ const (
 	HandlerIDDiff        = "diff"
 	HandlerIDReplace     = "replace"
 	HandlerIDAgent       = "agent"
+	HandlerIDAgentBatch  = "agent_batch"
 	HandlerIDSearchLores = "search_lores"
+	HandlerIDWriteLore   = "write_lore"
+	HandlerIDDeleteLore  = "delete_lore"
+	HandlerIDMCP         = "mcp"
+	HandlerIDPlugin      = "plugin"
 )
 
 // Tool reviews and executes tool calls.
@@ -42,11 +48,16 @@ type Registry struct {
 	handlerIDToTool map[string]Tool
 	tools           []*aipb.Tool
 	toolSets        []*aipb.ToolSet
+	// defaultExecutionPolicy applies to tools without a policy of their
+	// own.
+	defaultExecutionPolicy *sgptpb.ExecutionPolicy
 }
 
 // NewRegistry instantiates an empty registry.
 func NewRegistry() *Registry {
-	return &Registry{handlerIDToTool: map[string]Tool{}}
+	return &Registry{
+		handlerIDToTool: map[string]Tool{},
+	}
 }
 
 // Register binds a handler ID to a tool implementation.
@@ -128,13 +139,14 @@ func (r *Registry) Review(ctx context.Context, toolCall *aipb.ToolCall) (*sgptpb
 	return metadata, nil
 }
 
-// Execute dispatches to the tool's Execute.
+// Execute dispatches to the tool's Execute, under the tool's execution
+// policy.
 func (r *Registry) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.ToolResult, error) {
 	tool, err := r.lookup(toolCall)
 	if err != nil {
 		return nil, err
 	}
-	toolResult, err := tool.Execute(ctx, toolCall)
+	toolResult, err := r.executeWithPolicy(ctx, tool, toolCall, r.executionPolicy(tool, toolCall))
 	if err != nil {
 		return nil, fmt.Errorf("executing tool call %q: %w", toolCall.GetName(), err)
 	}
@@ -150,6 +162,22 @@ func ArgumentsJSON(toolCall *aipb.ToolCall) ([]byte, error) {
 	return bytes, nil
 }
 
+// maxToolNameLength is the longest tool name model providers accept.
+const maxToolNameLength = 64
+
+var invalidToolNameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// PrefixedName returns the advertised name of a tool defined outside sgpt
+// (MCP servers, plugins): "{prefix}_{name}", restricted to the characters
+// and length providers accept.
+func PrefixedName(prefix, name string) string {
+	toolName := invalidToolNameCharacters.ReplaceAllString(prefix+"_"+name, "_")
+	if len(toolName) > maxToolNameLength {
+		toolName = toolName[:maxToolNameLength]
+	}
+	return toolName
+}
+
 // RequestRenderer is implemented by tools that dictate how their request
 // renders in the timeline (e.g. the diff tool renders a diff).
 type RequestRenderer interface {
@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/proto"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// reflectionStream issues requests on one reflection stream; the server
+// only sends each file descriptor once per stream.
+type reflectionStream struct {
+	stream reflectionpb.ServerReflection_ServerReflectionInfoClient
+}
+
+func newReflectionStream(ctx context.Context, reflectionClient reflectionpb.ServerReflectionClient) (*reflectionStream, error) {
+	stream, err := reflectionClient.ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &reflectionStream{stream: stream}, nil
+}
+
+func (s *reflectionStream) request(request *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := s.stream.Send(request); err != nil {
+		return nil, err
+	}
+	response, err := s.stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if errorResponse := response.GetErrorResponse(); errorResponse != nil {
+		return nil, fmt.Errorf("reflection: %s", errorResponse.GetErrorMessage())
+	}
+	return response, nil
+}
+
+// listServices returns the sorted names of the services the server exposes.
+func (s *reflectionStream) listServices() ([]string, error) {
+	response, err := s.request(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var serviceNames []string
+	for _, service := range response.GetListServicesResponse().GetService() {
+		serviceNames = append(serviceNames, service.GetName())
+	}
+	sort.Strings(serviceNames)
+	return serviceNames, nil
+}
+
+func (s *reflectionStream) close() {
+	s.stream.CloseSend()
+}
+
+// pingEngine checks an engine answers reflection requests, the one service
+// every tool engine must expose.
+func pingEngine(ctx context.Context, reflectionClient reflectionpb.ServerReflectionClient) error {
+	stream, err := newReflectionStream(ctx, reflectionClient)
+	if err != nil {
+		return err
+	}
+	defer stream.close()
+	_, err = stream.listServices()
+	return err
+}
+
+// schemaFingerprint hashes the file descriptors of every service an engine
+// exposes: it changes whenever the engine's schema does, so caches derived
+// from the schema can be keyed by it.
+func schemaFingerprint(ctx context.Context, reflectionClient reflectionpb.ServerReflectionClient) (string, error) {
+	stream, err := newReflectionStream(ctx, reflectionClient)
+	if err != nil {
+		return "", err
+	}
+	defer stream.close()
+	serviceNames, err := stream.listServices()
+	if err != nil {
+		return "", err
+	}
+	fileNameToDescriptorBytes := map[string][]byte{}
+	for _, serviceName := range serviceNames {
+		response, err := stream.request(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: serviceName},
+		})
+		if err != nil {
+			return "", fmt.Errorf("resolving %s: %w", serviceName, err)
+		}
+		for _, descriptorBytes := range response.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fileDescriptor := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(descriptorBytes, fileDescriptor); err != nil {
+				return "", fmt.Errorf("parsing file descriptor of %s: %w", serviceName, err)
+			}
+			fileNameToDescriptorBytes[fileDescriptor.GetName()] = descriptorBytes
+		}
+	}
+	fileNames := make([]string, 0, len(fileNameToDescriptorBytes))
+	for fileName := range fileNameToDescriptorBytes {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+	hash := sha256.New()
+	for _, fileName := range fileNames {
+		fmt.Fprintf(hash, "%s\x00%d\x00", fileName, len(fileNameToDescriptorBytes[fileName]))
+		hash.Write(fileNameToDescriptorBytes[fileName])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16], nil
+}
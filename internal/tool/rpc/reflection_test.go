@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// serveEngine starts a server exposing reflection, plus the health service
+// when withHealth is set, and returns a reflection client to it.
+func serveEngine(t *testing.T, withHealth bool) (reflectionpb.ServerReflectionClient, func()) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	if withHealth {
+		healthpb.RegisterHealthServer(server, health.NewServer())
+	}
+	reflection.Register(server)
+	go server.Serve(listener)
+	connection, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+	return reflectionpb.NewServerReflectionClient(connection), server.Stop
+}
+
+func TestSchemaFingerprint(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	healthClient, _ := serveEngine(t, true)
+	otherHealthClient, _ := serveEngine(t, true)
+	bareClient, stopBare := serveEngine(t, false)
+
+	fingerprint, err := schemaFingerprint(ctx, healthClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint == "" {
+		t.Fatal("empty fingerprint")
+	}
+	if otherFingerprint, err := schemaFingerprint(ctx, otherHealthClient); err != nil || otherFingerprint != fingerprint {
+		t.Errorf("same schema: fingerprint = %q (%v), want %q", otherFingerprint, err, fingerprint)
+	}
+	if bareFingerprint, err := schemaFingerprint(ctx, bareClient); err != nil || bareFingerprint == fingerprint {
+		t.Errorf("different schema: fingerprint = %q (%v), want a different one", bareFingerprint, err)
+	}
+
+	if err := pingEngine(ctx, bareClient); err != nil {
+		t.Errorf("pinging a live engine: %v", err)
+	}
+	stopBare()
+	if err := pingEngine(ctx, bareClient); grpcstatus.Code(err) != codes.Unavailable {
+		t.Errorf("pinging a stopped engine: err = %v, want Unavailable", err)
+	}
+}
@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -18,11 +19,15 @@ import (
 	"github.com/malonaz/core/go/pbutil/pbfieldmask"
 	"github.com/malonaz/core/go/pbutil/pbjson"
 	"github.com/malonaz/core/go/pbutil/pbreflection"
+	"google.golang.org/grpc/codes"
 	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
 	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	sgptpb "github.com/malonaz/sgpt/genproto/sgpt/v1"
 	"github.com/malonaz/sgpt/internal/cache"
@@ -35,9 +40,22 @@ const (
 	toolSetCacheKeyPrefix = "toolset_"
 	toolSetCacheMaxAge    = 24 * time.Hour
 	schemaCacheMaxAge     = 24 * time.Hour
+	// The fingerprint is checked against the engine on every
+	// initialization; its age only says since when the schema is unchanged.
+	fingerprintCacheKeyPrefix = "schema_fingerprint_"
+	fingerprintCacheMaxAge    = 365 * 24 * time.Hour
+
+	// reconnectTimeout bounds how long a call waits for an unavailable
+	// engine to come back, polling every reconnectInterval.
+	reconnectTimeout  = 10 * time.Second
+	reconnectInterval = 500 * time.Millisecond
 )
 
 type engineConnection struct {
+	name string
+	// fingerprint is the schema fingerprint the engine's tool sets were
+	// built against.
+	fingerprint      string
 	client           aienginepb.AiEngineClient
 	methodInvoker    *pbreflection.MethodInvoker
 	reflectionClient reflectionpb.ServerReflectionClient
@@ -63,11 +81,18 @@ type Manager struct {
 	closers              []func()
 }
 
+// cacheName flattens an engine name, a selector ("//dir:title",
+// "@import//dir:title"), into a safe file name.
+func cacheName(engineName string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(engineName)
+}
+
 func toolSetCacheKey(engineName string, index int) string {
-	// Engine names are selectors ("//dir:title", "@import//dir:title");
-	// flatten them into a safe file name.
-	sanitized := strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(engineName)
-	return fmt.Sprintf("%s%s_%d.pb", toolSetCacheKeyPrefix, sanitized, index)
+	return fmt.Sprintf("%s%s_%d.pb", toolSetCacheKeyPrefix, cacheName(engineName), index)
+}
+
+func fingerprintCacheKey(engineName string) string {
+	return fmt.Sprintf("%s%s.pb", fingerprintCacheKeyPrefix, cacheName(engineName))
 }
 
 // NewManager creates a lazy manager: no engine is contacted until
@@ -99,26 +124,68 @@ func (m *Manager) EnsureEngine(ctx context.Context, engineName string) ([]*aipb.
 	if toolSets, ok := m.engineNameToToolSets[engineName]; ok {
 		return toolSets, nil
 	}
+	return m.initializeEngine(ctx, engineName, false)
+}
+
+// RefreshEngine re-initializes the named engine, recreating its tool sets
+// instead of serving them from the cache.
+func (m *Manager) RefreshEngine(ctx context.Context, engineName string) ([]*aipb.ToolSet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.initializeEngine(ctx, engineName, true)
+}
+
+// reflectionClient returns a reflection client to the named engine.
+func (m *Manager) reflectionClient(engineName string) (*sgptpb.ToolSet, *sgptpb.GrpcClient, reflectionpb.ServerReflectionClient, error) {
 	toolEngine, ok := m.engineNameToConfiguration[engineName]
 	if !ok {
-		return nil, fmt.Errorf("unknown tool engine %q", engineName)
+		return nil, nil, nil, fmt.Errorf("unknown tool engine %q", engineName)
 	}
-
 	engineService, err := configuration.GrpcClient(m.configuration, toolEngine.GetEngineService())
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	connection := m.clientNameToGRPCConnection[engineService.GetName()]
-	reflectionClient := reflectionpb.NewServerReflectionClient(connection.Get())
-	// Resolve and cache the schema for this engine.
+	return toolEngine, engineService, reflectionpb.NewServerReflectionClient(connection.Get()), nil
+}
+
+// initializeEngine connects to an engine and builds its tool sets. The
+// caller holds m.mu.
+func (m *Manager) initializeEngine(ctx context.Context, engineName string, refresh bool) ([]*aipb.ToolSet, error) {
+	toolEngine, engineService, reflectionClient, err := m.reflectionClient(engineName)
+	if err != nil {
+		return nil, err
+	}
+	// Fingerprinting doubles as the health check: an unreachable engine
+	// fails here, before any cache is trusted.
+	fingerprint, err := schemaFingerprint(ctx, reflectionClient)
+	if err != nil {
+		return nil, fmt.Errorf("reaching tool engine %s: %w", engineName, err)
+	}
+	cachedFingerprint, ok := cache.Get(fingerprintCacheKey(engineName), fingerprintCacheMaxAge, &wrapperspb.StringValue{})
+	if refresh || !ok || cachedFingerprint.GetValue() != fingerprint {
+		for i := range toolEngine.GetToolSets() {
+			if err := cache.Delete(toolSetCacheKey(engineName, i)); err != nil {
+				return nil, fmt.Errorf("invalidating tool set cache: %w", err)
+			}
+		}
+		if err := cache.Store(fingerprintCacheKey(engineName), wrapperspb.String(fingerprint)); err != nil {
+			return nil, fmt.Errorf("caching schema fingerprint: %w", err)
+		}
+	}
+	// Resolve and cache the schema for this engine. Keyed by fingerprint,
+	// so a changed schema is never served from the disk cache.
 	schema, err := pbreflection.ResolveSchema(ctx, reflectionClient,
-		pbreflection.WithDiskCache(engineService.GetBaseUrl(), cache.Dir(), schemaCacheMaxAge),
+		pbreflection.WithDiskCache(engineService.GetBaseUrl()+"#"+fingerprint, cache.Dir(), schemaCacheMaxAge),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("resolving schema for %s: %w", toolEngine.GetName(), err)
 	}
 
+	connection := m.clientNameToGRPCConnection[engineService.GetName()]
 	engine := &engineConnection{
+		name:             engineName,
+		fingerprint:      fingerprint,
 		client:           aienginepb.NewAiEngineClient(connection.Get()),
 		methodInvoker:    pbreflection.NewMethodInvoker(connection.Get()),
 		reflectionClient: reflectionClient,
@@ -131,7 +198,6 @@ func (m *Manager) EnsureEngine(ctx context.Context, engineName string) ([]*aipb.
 
 		cachedToolSet, ok := cache.Get(cacheKey, toolSetCacheMaxAge, &aipb.ToolSet{})
 		if ok && cachedToolSet.GetName() != "" {
-			m.toolSetNameToEngine[cachedToolSet.GetName()] = engine
 			toolSets = append(toolSets, cachedToolSet)
 			continue
 		}
@@ -145,14 +211,117 @@ func (m *Manager) EnsureEngine(ctx context.Context, engineName string) ([]*aipb.
 			aip.SetAnnotation(engineTool, tool.ToolHandlerIDAnnotation, tool.HandlerIDEngine)
 		}
 		cache.Store(cacheKey, toolSet)
-		m.toolSetNameToEngine[toolSet.GetName()] = engine
 		toolSets = append(toolSets, toolSet)
 	}
+
+	// Replace a previous initialization's tool sets, if any.
+	previousToolSets := m.engineNameToToolSets[engineName]
+	m.toolSets = slices.DeleteFunc(m.toolSets, func(toolSet *aipb.ToolSet) bool {
+		return slices.Contains(previousToolSets, toolSet)
+	})
+	for _, toolSet := range previousToolSets {
+		delete(m.toolSetNameToEngine, toolSet.GetName())
+	}
+	for _, toolSet := range toolSets {
+		m.toolSetNameToEngine[toolSet.GetName()] = engine
+	}
 	m.engineNameToToolSets[engineName] = toolSets
 	m.toolSets = append(m.toolSets, toolSets...)
 	return toolSets, nil
 }
 
+// PingEngine checks the named engine is reachable and returns the round
+// trip time. It does not initialize the engine.
+func (m *Manager) PingEngine(ctx context.Context, engineName string) (time.Duration, error) {
+	_, _, reflectionClient, err := m.reflectionClient(engineName)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	if err := pingEngine(ctx, reflectionClient); err != nil {
+		return 0, fmt.Errorf("pinging tool engine %s: %w", engineName, err)
+	}
+	return time.Since(start), nil
+}
+
+// CacheStatus describes what is cached about an engine.
+type CacheStatus struct {
+	// Fingerprint is the engine's schema fingerprint when last initialized;
+	// empty when never.
+	Fingerprint string
+	// SchemaAge is how long the schema has been cached unchanged.
+	SchemaAge time.Duration
+	// CachedToolSetCount counts the tool sets the next initialization
+	// serves from the cache, if the schema is unchanged.
+	CachedToolSetCount int
+}
+
+// CacheStatus reports the named engine's cache, without contacting it.
+func (m *Manager) CacheStatus(engineName string) (*CacheStatus, error) {
+	toolEngine, ok := m.engineNameToConfiguration[engineName]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool engine %q", engineName)
+	}
+	cacheStatus := &CacheStatus{}
+	if fingerprint, ok := cache.Get(fingerprintCacheKey(engineName), fingerprintCacheMaxAge, &wrapperspb.StringValue{}); ok {
+		cacheStatus.Fingerprint = fingerprint.GetValue()
+		cacheStatus.SchemaAge, _ = cache.Age(fingerprintCacheKey(engineName))
+	}
+	for i := range toolEngine.GetToolSets() {
+		if _, ok := cache.Get(toolSetCacheKey(engineName, i), toolSetCacheMaxAge, &aipb.ToolSet{}); ok {
+			cacheStatus.CachedToolSetCount++
+		}
+	}
+	return cacheStatus, nil
+}
+
+// reconnect waits for an unavailable engine to answer again, checking it
+// still serves the schema its tool sets were built from.
+func (m *Manager) reconnect(ctx context.Context, engine *engineConnection) error {
+	ctx, cancel := context.WithTimeout(ctx, reconnectTimeout)
+	defer cancel()
+	for {
+		fingerprint, err := schemaFingerprint(ctx, engine.reflectionClient)
+		switch {
+		case err == nil && fingerprint != engine.fingerprint:
+			return fmt.Errorf("tool engine %s came back with a different schema: restart the chat to pick it up", engine.name)
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return fmt.Errorf("tool engine %s is unavailable: %w", engine.name, err)
+		case grpcstatus.Code(err) != codes.Unavailable:
+			return fmt.Errorf("reconnecting to tool engine %s: %w", engine.name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("tool engine %s is unavailable: %w", engine.name, err)
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+// invoke runs a call's RPC through call. An engine going away mid-call
+// (restart, redeploy) is waited for, then an idempotent call is run again;
+// any other call may or may not have run before the engine went, and fails
+// saying so rather than risk running twice.
+func (m *Manager) invoke(ctx context.Context, engine *engineConnection, toolCall *aipb.ToolCall, call func() error) error {
+	err := call()
+	if grpcstatus.Code(err) != codes.Unavailable {
+		return err
+	}
+	if err := m.reconnect(ctx, engine); err != nil {
+		return tool.Transient(err)
+	}
+	if !tool.Idempotent(toolCall) {
+		return tool.Transient(fmt.Errorf("tool engine %s restarted during the call, which may or may not have run: %w", engine.name, err))
+	}
+	err = call()
+	if grpcstatus.Code(err) == codes.Unavailable {
+		return tool.Transient(err)
+	}
+	return err
+}
+
 // GetToolSets returns the tool sets of every initialized engine.
 func (m *Manager) GetToolSets() []*aipb.ToolSet {
 	if m == nil {
@@ -225,8 +394,10 @@ func (m *Manager) Review(ctx context.Context, toolCall *aipb.ToolCall) (*sgptpb.
 		if !ok {
 			return nil, fmt.Errorf("expected method options for %q, got %T", rpc.MethodFullName, methodDescriptor.Options())
 		}
-		// Side-effect-free RPCs are safe to run without user confirmation.
+		// Side-effect-free RPCs are safe to run without user confirmation,
+		// and to retry.
 		toolCallMetadata.AutoExecute = methodOptions.GetIdempotencyLevel() == descriptorpb.MethodOptions_NO_SIDE_EFFECTS
+		toolCallMetadata.Idempotent = toolCallMetadata.AutoExecute
 	default:
 		return nil, fmt.Errorf("unknown tool type: %s", toolType)
 	}
@@ -274,7 +445,15 @@ func (m *Manager) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.T
 	if rpc.GetReadMask() != nil {
 		ctxInvoke = middleware.WithReadMaskStrict(ctxInvoke, pbfieldmask.New(rpc.GetReadMask()).String())
 	}
-	response, err := engine.methodInvoker.Invoke(ctxInvoke, methodDescriptor, request)
+	var response proto.Message
+	err = m.invoke(ctx, engine, toolCall, func() error {
+		var err error
+		response, err = engine.methodInvoker.Invoke(ctxInvoke, methodDescriptor, request)
+		return err
+	})
+	if tool.IsTransient(err) {
+		return nil, err
+	}
 	if err != nil {
 		return ai.NewErrorToolResult(toolCall.Name, toolCall.Id, err), nil
 	}
@@ -290,6 +469,15 @@ func (m *Manager) Execute(ctx context.Context, toolCall *aipb.ToolCall) (*aipb.T
 	return ai.NewStructuredToolResult(toolCall.Name, toolCall.Id, value), nil
 }
 
+// ExecutionPolicy returns the policy of the call's tool set file.
+func (m *Manager) ExecutionPolicy(toolCall *aipb.ToolCall) *sgptpb.ExecutionPolicy {
+	engine, err := m.engineFor(toolCall)
+	if err != nil {
+		return nil
+	}
+	return m.engineNameToConfiguration[engine.name].GetExecutionPolicy()
+}
+
 // RenderHeader shows {Service}/{Method} for RPC calls and a discrete label
 // for discovery calls, instead of the generated tool names.
 func (m *Manager) RenderHeader(toolCall *aipb.ToolCall) (string, bool) {
@@ -355,4 +543,5 @@ func (m *Manager) Close() {
 var (
 	_ tool.Tool           = (*Manager)(nil)
 	_ tool.HeaderRenderer = (*Manager)(nil)
+	_ tool.PolicyProvider = (*Manager)(nil)
 )
@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	aipb "github.com/malonaz/core/genproto/ai/v1"
+	aitool "github.com/malonaz/core/go/ai/tool"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/malonaz/sgpt/internal/tool"
+)
+
+func TestInvokeAcrossRestart(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reflectionClient, _ := serveEngine(t, true)
+	fingerprint, err := schemaFingerprint(ctx, reflectionClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := &engineConnection{name: "//:engine", fingerprint: fingerprint, reflectionClient: reflectionClient}
+	manager := &Manager{}
+
+	for _, tc := range []struct {
+		name      string
+		toolCall  *aipb.ToolCall
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "idempotent",
+			toolCall:  &aipb.ToolCall{Name: "get", Annotations: map[string]string{aitool.AnnotationKeyNoSideEffect: "true"}},
+			wantCalls: 2,
+		},
+		{
+			// The engine may have run the call before going: running it again
+			// could apply its side effects twice.
+			name:      "side effects",
+			toolCall:  &aipb.ToolCall{Name: "create"},
+			wantCalls: 1,
+			wantErr:   "may or may not have run",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := manager.invoke(ctx, engine, tc.toolCall, func() error {
+				calls++
+				if calls == 1 {
+					return grpcstatus.Error(codes.Unavailable, "engine restarting")
+				}
+				return nil
+			})
+			if calls != tc.wantCalls {
+				t.Errorf("called %d times, want %d", calls, tc.wantCalls)
+			}
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("invoke: %v", err)
+				}
+				return
+			}
+			if !tool.IsTransient(err) || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("invoke: err = %v, want a transient error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}